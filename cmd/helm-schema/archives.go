@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"helm-schema/pkg/helm"
+)
+
+// archiveIndexEntry records the outcome of generating a schema for one packaged chart
+type archiveIndexEntry struct {
+	Archive string `json:"archive"`
+	Name    string `json:"name,omitempty"`
+	Version string `json:"version,omitempty"`
+	Schema  string `json:"schema,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// archiveIndex lists every schema generated from an archive directory
+type archiveIndex struct {
+	Charts []archiveIndexEntry `json:"charts"`
+}
+
+// archivesToSchemas generates a schema for every *.tgz in archiveDir, writing
+// <name>-<version>.schema.json files plus an index.json into outDir
+func archivesToSchemas(archiveDir, outDir string, cfg generateConfig) error {
+	archives, err := helm.FindChartArchives(archiveDir)
+	if err != nil {
+		return err
+	}
+
+	if len(archives) == 0 {
+		return fmt.Errorf("no chart archives (*.tgz) found in %s", archiveDir)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	var index archiveIndex
+	failures := 0
+	for _, archive := range archives {
+		entry := archiveToSchema(archive, outDir, cfg)
+		if entry.Error != "" {
+			fmt.Fprintf(os.Stderr, "Error: %s: %s\n", filepath.Base(archive), entry.Error)
+			failures++
+		}
+		index.Charts = append(index.Charts, entry)
+	}
+
+	output, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("generating index JSON: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(outDir, "index.json"), append(output, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing index: %w", err)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d chart archives failed", failures, len(archives))
+	}
+
+	return nil
+}
+
+// archiveToSchema extracts a single packaged chart and writes its schema into outDir
+func archiveToSchema(archive, outDir string, cfg generateConfig) archiveIndexEntry {
+	entry := archiveIndexEntry{Archive: filepath.Base(archive)}
+
+	tempDir, err := os.MkdirTemp("", "helm-schema-archive-")
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	defer os.RemoveAll(tempDir)
+
+	chartPath, err := helm.ExtractChartArchive(archive, tempDir)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	metadata, err := helm.ParseChartMetadata(chartPath)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+	entry.Name = metadata.Name
+	entry.Version = metadata.Version
+
+	schemaJSON, err := chartToSchema(chartPath, cfg)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry
+	}
+
+	entry.Schema = fmt.Sprintf("%s-%s.schema.json", metadata.Name, metadata.Version)
+	if err := os.WriteFile(filepath.Join(outDir, entry.Schema), []byte(schemaJSON+"\n"), 0644); err != nil {
+		entry.Error = err.Error()
+		entry.Schema = ""
+	}
+
+	return entry
+}
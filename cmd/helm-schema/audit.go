@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"helm-schema/pkg/release"
+	"helm-schema/pkg/validate"
+)
+
+// auditResult records the outcome of validating one release
+type auditResult struct {
+	Release      string               `json:"release"`
+	Namespace    string               `json:"namespace"`
+	Revision     int                  `json:"revision"`
+	Chart        string               `json:"chart"`
+	ChartVersion string               `json:"chartVersion"`
+	Violations   []validate.Violation `json:"violations,omitempty"`
+	Error        string               `json:"error,omitempty"`
+}
+
+// runAudit validates the user-supplied values of installed releases against schemas generated
+// from the chart versions they were deployed with
+func runAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file")
+	kubeContext := fs.String("context", os.Getenv("HELM_KUBECONTEXT"), "Kubeconfig context to use (defaults to helm's --kube-context when run as a plugin)")
+	namespace := fs.String("namespace", "", "Only audit releases in this namespace")
+	all := fs.Bool("all", false, "Audit every deployed release")
+	asJSON := fs.Bool("json", false, "Print results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s audit [flags] --all | <release>...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *all == (fs.NArg() > 0) {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	client := &release.Client{Kubeconfig: *kubeconfig, Context: *kubeContext}
+	releases, err := client.Deployed(*namespace)
+	if err != nil {
+		return err
+	}
+
+	if !*all {
+		wanted := make(map[string]bool)
+		for _, name := range fs.Args() {
+			wanted[name] = true
+		}
+		var selected []*release.Release
+		for _, rel := range releases {
+			if wanted[rel.Name] {
+				selected = append(selected, rel)
+				delete(wanted, rel.Name)
+			}
+		}
+		for name := range wanted {
+			return fmt.Errorf("no deployed release named %s", name)
+		}
+		releases = selected
+	}
+
+	var results []auditResult
+	failed := 0
+	for _, rel := range releases {
+		result := auditRelease(rel)
+		if result.Error != "" || len(result.Violations) > 0 {
+			failed++
+		}
+		results = append(results, result)
+	}
+
+	if *asJSON {
+		if results == nil {
+			results = []auditResult{}
+		}
+		output, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		for _, result := range results {
+			status := "ok"
+			if result.Error != "" {
+				status = "error: " + result.Error
+			} else if len(result.Violations) > 0 {
+				status = fmt.Sprintf("%d invalid value(s)", len(result.Violations))
+			}
+			fmt.Printf("%s/%s (%s-%s, revision %d): %s\n", result.Namespace, result.Release, result.Chart, result.ChartVersion, result.Revision, status)
+			for _, violation := range result.Violations {
+				fmt.Printf("  %s\n", violation)
+			}
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d release(s) failed the audit", failed, len(results))
+	}
+	return nil
+}
+
+// auditRelease regenerates the schema of a release's chart and validates its values against it
+func auditRelease(rel *release.Release) auditResult {
+	result := auditResult{
+		Release:      rel.Name,
+		Namespace:    rel.Namespace,
+		Revision:     rel.Version,
+		Chart:        rel.ChartName(),
+		ChartVersion: rel.ChartVersion(),
+	}
+
+	dir, err := os.MkdirTemp("", "helm-schema-audit-")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer os.RemoveAll(dir)
+
+	chartPath, err := rel.WriteChart(dir)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// Helm storage does not keep dependencies, so their values cannot be checked
+	cfg, err := chartGenerateConfig(chartPath)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	cfg.IncludeSubcharts = false
+	generated, err := generateSchema(chartPath, cfg)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	allowOpaqueKeys(generated, append(rel.Dependencies(), "global"))
+
+	validator, err := validate.New(generated)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Violations, err = validator.Validate(rel.Config)
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// allowOpaqueKeys permits top-level keys whose contents the schema cannot describe
+func allowOpaqueKeys(generated map[string]any, keys []string) {
+	properties, ok := generated["properties"].(map[string]any)
+	if !ok {
+		return
+	}
+	for _, key := range keys {
+		if _, exists := properties[key]; !exists {
+			properties[key] = map[string]any{}
+		}
+	}
+}
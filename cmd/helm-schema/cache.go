@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"helm-schema/pkg/cache"
+)
+
+// runCache inspects and maintains the on-disk cache: cache ls|prune|clear
+func runCache(args []string) error {
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	dir := fs.String("dir", "", "Cache directory (defaults to $HELM_SCHEMA_CACHE_DIR or the user cache directory)")
+	maxSize := fs.String("max-size", "", "Size limit for prune, e.g. 256MB (defaults to the built-in limit)")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s cache [flags] ls|prune|clear\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	if *dir == "" {
+		defaultDir, err := cache.DefaultDir()
+		if err != nil {
+			return err
+		}
+		*dir = defaultDir
+	}
+
+	c, err := cache.Open(*dir)
+	if err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "ls":
+		entries, err := c.Entries()
+		if err != nil {
+			return err
+		}
+		var total int64
+		for _, entry := range entries {
+			fmt.Printf("%-10s %s %10d %s\n", entry.Bucket, entry.Key, entry.Size, entry.ModTime.Format("2006-01-02 15:04:05"))
+			total += entry.Size
+		}
+		fmt.Printf("%d entries, %d bytes in %s\n", len(entries), total, c.Root())
+	case "prune":
+		limit := cache.DefaultMaxBytes
+		if *maxSize != "" {
+			if limit, err = cache.ParseSize(*maxSize); err != nil {
+				return err
+			}
+		}
+		removed, err := c.Prune(limit)
+		if err != nil {
+			return err
+		}
+		var freed int64
+		for _, entry := range removed {
+			freed += entry.Size
+		}
+		fmt.Printf("Pruned %d entries (%d bytes)\n", len(removed), freed)
+	case "clear":
+		if err := c.Clear(); err != nil {
+			return err
+		}
+		fmt.Printf("Cleared %s\n", c.Root())
+	default:
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	return nil
+}
+
+// openCache opens the cache at its default location for generation runs
+func openCache() (*cache.Cache, error) {
+	dir, err := cache.DefaultDir()
+	if err != nil {
+		return nil, err
+	}
+	return cache.Open(dir)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/schema"
+)
+
+// chartDestination is where the schemas of several charts go
+type chartDestination struct {
+	OutDir         string // Directory receiving <name>-<version>.schema.json files instead of the charts
+	WriteSubcharts bool   // Also write the schemas of the local subcharts of each chart
+	Check          bool   // Compare the schemas with the files instead of writing them
+}
+
+// chartOutcome records where the schema of one of several charts went, or why it was not
+// generated
+type chartOutcome struct {
+	Chart   string              // Chart directory, as given
+	Path    string              // File the schema was written to or compared with
+	Changed bool                // Whether the file was written, rather than already up to date
+	Drift   []schema.Difference // How the file differs from the schema, when checking
+	Err     error
+}
+
+// chartsToSchemas generates the schemas of several chart directories in parallel, each on its
+// own: written to the chart's values.schema.json, or to <name>-<version>.schema.json in the
+// output directory when set, or compared with those files when checking. A failing chart does
+// not stop the others; outcomes are reported in the order the charts were given, and the error
+// counts the charts that failed or drifted.
+func chartsToSchemas(chartPaths []string, dest chartDestination, cfg generateConfig) error {
+	outcomes := make([]chartOutcome, len(chartPaths))
+	targets := make([]string, len(chartPaths))
+	for i, chartPath := range chartPaths {
+		outcomes[i].Chart = chartPath
+		targets[i] = filepath.Join(chartPath, helm.SchemaFile)
+	}
+	if dest.OutDir != "" {
+		if err := outDirTargets(chartPaths, dest.OutDir, targets, outcomes); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i := range chartPaths {
+		if outcomes[i].Err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(outcome *chartOutcome, target string) {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+
+			outcome.Path = target
+			schemaJSON, err := chartToSchema(outcome.Chart, cfg)
+			switch {
+			case err != nil:
+			case dest.Check:
+				outcome.Drift, err = schemaDrift(target, []byte(schemaJSON+"\n"))
+			default:
+				outcome.Changed, err = writeSchemaIfChanged(target, []byte(schemaJSON+"\n"))
+			}
+			if err == nil && dest.WriteSubcharts {
+				err = writeSubchartSchemas(outcome.Chart, cfg)
+			}
+			outcome.Err = err
+		}(&outcomes[i], targets[i])
+	}
+	wg.Wait()
+
+	failures, drifted := 0, 0
+	for _, outcome := range outcomes {
+		switch {
+		case outcome.Err != nil:
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", outcome.Chart, outcome.Err)
+			failures++
+		case len(outcome.Drift) > 0:
+			reportDrift(outcome.Path, outcome.Drift)
+			drifted++
+		default:
+			reportWrite(outcome.Path, outcome.Changed)
+		}
+	}
+	switch {
+	case failures > 0 && drifted > 0:
+		return fmt.Errorf("%d of %d charts failed and %d are out of date", failures, len(chartPaths), drifted)
+	case failures > 0:
+		return fmt.Errorf("%d of %d charts failed", failures, len(chartPaths))
+	case drifted > 0:
+		return fmt.Errorf("%d of %d schemas are out of date, regenerate them with --write", drifted, len(chartPaths))
+	}
+	return nil
+}
+
+// recursiveToSchemas generates the schema of every chart found below root, as in a monorepo,
+// writing each to the chart's values.schema.json or to the output directory when set. Subcharts
+// vendored in a chart are only written when asked to, like for a chart given by itself.
+func recursiveToSchemas(root string, dest chartDestination, cfg generateConfig) error {
+	chartPaths, err := helm.FindCharts(root)
+	if err != nil {
+		return err
+	}
+	if len(chartPaths) == 0 {
+		return fmt.Errorf("no charts (Chart.yaml) found in %s", root)
+	}
+	return chartsToSchemas(chartPaths, dest, cfg)
+}
+
+// outDirTargets names the schema of each chart after its name and version in outDir, failing
+// the charts whose Chart.yaml cannot be read. Two charts of the same name and version would
+// overwrite each other's schema, so they are rejected before anything is generated.
+func outDirTargets(chartPaths []string, outDir string, targets []string, outcomes []chartOutcome) error {
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+	named := make(map[string]string)
+	for i, chartPath := range chartPaths {
+		metadata, err := helm.ParseChartMetadata(chartPath)
+		if err != nil {
+			outcomes[i].Err = err
+			continue
+		}
+		name := fmt.Sprintf("%s-%s.schema.json", metadata.Name, metadata.Version)
+		if other, exists := named[name]; exists {
+			return fmt.Errorf("%s and %s would both be written to %s", other, chartPath, name)
+		}
+		named[name] = chartPath
+		targets[i] = filepath.Join(outDir, name)
+	}
+	return nil
+}
+
+// reportWrite tells whether a schema was written or already up to date
+func reportWrite(path string, changed bool) {
+	if changed {
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	} else {
+		fmt.Fprintf(os.Stderr, "%s is up to date\n", path)
+	}
+}
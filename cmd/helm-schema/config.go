@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is the file configuring how the schemas of the charts of its directory and the
+// directories below it are generated, at the root of a chart or of a repository of charts
+const configFile = ".helm-schema.yaml"
+
+// chartConfig is the content of the config file of a chart
+type chartConfig struct {
+	// Flags are defaults of command line flags, by name without dashes, e.g. schema-draft:
+	// draft-07 or nullable: true; flags given on the command line take precedence
+	Flags map[string]any `yaml:"flags"`
+	// ExcludePaths removes the values matching these value path globs, e.g. internal.* or
+	// *.experimental, from the schema
+	ExcludePaths []string `yaml:"excludePaths"`
+	// Overrides are schema fragments keyed by value path, applied like those of
+	// helm-schema.overrides.yaml, which are applied after them
+	Overrides map[string]any `yaml:"overrides"`
+	// AdditionalProperties lets every object accept values besides those the templates read
+	// when true, rather than rejecting them
+	AdditionalProperties *bool `yaml:"additionalProperties"`
+	// Output is the file the schema is written to when neither --output nor --write is given,
+	// relative to the config file
+	Output string `yaml:"output"`
+}
+
+// loadChartConfig reads the config files applying to a chart, empty when there are none: those
+// of the directories from the root of its repository, the nearest one holding .git, down to the
+// chart, nearer files taking precedence. Unknown keys are rejected so misspelled settings do not
+// go unnoticed.
+func loadChartConfig(chartPath string) (chartConfig, error) {
+	var config chartConfig
+	dir, err := filepath.Abs(chartPath)
+	if err != nil {
+		return config, fmt.Errorf("resolving path: %w", err)
+	}
+
+	var dirs []string
+	for {
+		dirs = append(dirs, dir)
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		layer, err := loadConfigFile(filepath.Join(dirs[i], configFile))
+		if err != nil {
+			return config, err
+		}
+		config.merge(layer)
+	}
+	return config, nil
+}
+
+// loadConfigFile reads a single config file, empty when it does not exist
+func loadConfigFile(path string) (chartConfig, error) {
+	var config chartConfig
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+	if config.Output != "" && !filepath.IsAbs(config.Output) {
+		config.Output = filepath.Join(filepath.Dir(path), config.Output)
+	}
+	return config, nil
+}
+
+// merge applies the settings of a config file nearer to the chart: flags and overrides replace
+// those of the same name, excluded paths add up
+func (c *chartConfig) merge(nearer chartConfig) {
+	if len(nearer.Flags) > 0 && c.Flags == nil {
+		c.Flags = make(map[string]any)
+	}
+	for name, value := range nearer.Flags {
+		c.Flags[name] = value
+	}
+	c.ExcludePaths = append(c.ExcludePaths, nearer.ExcludePaths...)
+	if len(nearer.Overrides) > 0 && c.Overrides == nil {
+		c.Overrides = make(map[string]any)
+	}
+	for path, override := range nearer.Overrides {
+		c.Overrides[path] = override
+	}
+	if nearer.AdditionalProperties != nil {
+		c.AdditionalProperties = nearer.AdditionalProperties
+	}
+	if nearer.Output != "" {
+		c.Output = nearer.Output
+	}
+}
+
+// flagAliases maps the shorthands of flags to the flags they stand for
+var flagAliases = map[string]string{
+	"o":  "output",
+	"w":  "write",
+	"f":  "values",
+	"vv": "debug",
+}
+
+// canonicalFlag returns the name of the flag a name stands for, resolving shorthands
+func canonicalFlag(name string) string {
+	if canonical, ok := flagAliases[name]; ok {
+		return canonical
+	}
+	return name
+}
+
+// applyConfigFlags sets the flags of a config file not given on the command line, under their
+// name or a shorthand. Lists set flags that can be repeated once per item.
+func applyConfigFlags(flags *flag.FlagSet, values map[string]any) error {
+	given := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) {
+		given[canonicalFlag(f.Name)] = true
+	})
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if flags.Lookup(name) == nil {
+			return fmt.Errorf("%s: unknown flag %s", configFile, name)
+		}
+		if given[canonicalFlag(name)] {
+			continue
+		}
+		items, isList := values[name].([]any)
+		if !isList {
+			items = []any{values[name]}
+		}
+		for _, item := range items {
+			if err := flags.Set(name, fmt.Sprint(item)); err != nil {
+				return fmt.Errorf("%s: flag %s: %w", configFile, name, err)
+			}
+		}
+	}
+	return nil
+}
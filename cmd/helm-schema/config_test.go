@@ -0,0 +1,41 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestApplyConfigFlagsKeepsShorthands(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		config map[string]any
+	}{
+		{name: "shorthand given", args: []string{"-o", "cli.json"}, config: map[string]any{"output": "config.json"}},
+		{name: "shorthand configured", args: []string{"--output", "cli.json"}, config: map[string]any{"o": "config.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			var output string
+			fs.StringVar(&output, "output", "", "")
+			fs.StringVar(&output, "o", "", "")
+			nullable := fs.Bool("nullable", false, "")
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatalf("Failed to parse flags: %v", err)
+			}
+
+			tt.config["nullable"] = true
+			if err := applyConfigFlags(fs, tt.config); err != nil {
+				t.Fatalf("Failed to apply config flags: %v", err)
+			}
+			if output != "cli.json" {
+				t.Errorf("Expected the command line to take precedence, got output %q", output)
+			}
+			if !*nullable {
+				t.Error("Expected the config to set flags not given on the command line")
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm-schema/pkg/crosscheck"
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+)
+
+// Kinds of coverage gaps --fail-on selects
+const (
+	coverageUnused     = "unused"
+	coverageUndeclared = "undeclared"
+	coverageNone       = "none"
+)
+
+// runCoverage reports the values a chart's values.yaml sets that no template reads and the
+// values its templates read that values.yaml does not set
+func runCoverage(args []string) error {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	failOn := fs.String("fail-on", coverageUnused+","+coverageUndeclared, "Comma separated gaps failing the command: unused, undeclared, or none to only report them")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s coverage [flags] <helm-chart-path>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	failing := make(map[string]bool)
+	for _, kind := range strings.Split(*failOn, ",") {
+		switch kind = strings.TrimSpace(kind); kind {
+		case coverageUnused, coverageUndeclared:
+			failing[kind] = true
+		case coverageNone:
+		default:
+			return fmt.Errorf("invalid --fail-on %q: expected %s, %s or %s", kind, coverageUnused, coverageUndeclared, coverageNone)
+		}
+	}
+
+	absPath, err := filepath.Abs(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+	if err := helm.ValidateChartDirectory(absPath); err != nil {
+		return err
+	}
+
+	// Values set for subcharts are read by their templates
+	p := parser.New()
+	if err := p.ParseChart(absPath); err != nil {
+		return fmt.Errorf("parsing chart: %w", err)
+	}
+	coverage, err := crosscheck.CheckCoverage(absPath, p)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		output, err := json.MarshalIndent(coverage, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		if len(coverage.Unused) > 0 {
+			fmt.Println("Set in values.yaml but never read by the templates:")
+			for _, path := range coverage.Unused {
+				fmt.Printf("  %s\n", path)
+			}
+		}
+		if len(coverage.Undeclared) > 0 {
+			if len(coverage.Unused) > 0 {
+				fmt.Println()
+			}
+			fmt.Println("Read by the templates but not set in values.yaml:")
+			for _, path := range coverage.Undeclared {
+				fmt.Printf("  %s\n", path)
+			}
+		}
+	}
+
+	var gaps []string
+	if failing[coverageUnused] && len(coverage.Unused) > 0 {
+		gaps = append(gaps, fmt.Sprintf("%d unused value(s)", len(coverage.Unused)))
+	}
+	if failing[coverageUndeclared] && len(coverage.Undeclared) > 0 {
+		gaps = append(gaps, fmt.Sprintf("%d undeclared value(s)", len(coverage.Undeclared)))
+	}
+	if len(gaps) > 0 {
+		return fmt.Errorf("%s", strings.Join(gaps, " and "))
+	}
+	return nil
+}
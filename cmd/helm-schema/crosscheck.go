@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"helm-schema/pkg/crosscheck"
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+)
+
+// Ways of reconciling the values found in the templates with helm template rendering
+const (
+	crossCheckReport = "report" // Warn about values the rendered chart consumes but no template reference was found for
+	crossCheckMerge  = "merge"  // Also add them to the schema, typed from values.yaml
+)
+
+// crossCheck renders the chart with helm template and reports the values it consumes that were
+// not found in the templates, adding them to the parsed model when merge is set
+func crossCheck(chartPath string, p *parser.TemplateParser, merge bool) error {
+	report, err := crosscheck.Check(chartPath, p.GetAllValues(), helm.RenderChart)
+	if err != nil {
+		return fmt.Errorf("cross-checking with helm template: %w", err)
+	}
+
+	for _, discrepancy := range report.Discrepancies {
+		fmt.Fprintf(os.Stderr, "Warning: %s: not found in the templates but %s\n", discrepancy.Path, discrepancy.Reason)
+		if merge {
+			p.AddRenderedValue(discrepancy.Path, discrepancy.Default)
+		}
+	}
+	for _, path := range report.Unverified {
+		fmt.Fprintf(os.Stderr, "Warning: %s: not found in the templates and could not be cross-checked since rendering is not deterministic\n", path)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"helm-schema/pkg/doctor"
+	"helm-schema/pkg/helm"
+)
+
+// runDoctor reports how amenable a chart is to accurate schema generation
+func runDoctor(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s doctor [flags] <helm-chart-path>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	absPath, err := filepath.Abs(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+
+	if err := helm.ValidateChartDirectory(absPath); err != nil {
+		return err
+	}
+
+	report, err := doctor.Diagnose(absPath)
+	if err != nil {
+		return fmt.Errorf("diagnosing chart: %w", err)
+	}
+
+	if *asJSON {
+		output, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	fmt.Printf("Chart: %s\n", report.Chart)
+	fmt.Printf("Templates scanned: %d (%d helper files)\n", report.Templates, report.Helpers)
+	fmt.Printf(".Values references: %d\n", report.ValueReferences)
+	fmt.Printf("Dynamic accesses: %d\n", report.Count(doctor.KindDynamicAccess))
+	fmt.Printf("tpl usages: %d\n", report.Count(doctor.KindTpl))
+	fmt.Printf("Helper indirections: %d\n", report.Count(doctor.KindHelperIndirection)+report.Count(doctor.KindHelperValues))
+
+	if len(report.Findings) == 0 {
+		fmt.Println("\nNo issues found - the chart is fully visible to static analysis.")
+		return nil
+	}
+
+	fmt.Println("\nSuggested refactors:")
+	for _, finding := range report.Findings {
+		fmt.Printf("  %s:%d [%s] %s\n", finding.File, finding.Line, finding.Kind, finding.Snippet)
+		fmt.Printf("    -> %s\n", finding.Suggestion)
+	}
+
+	return nil
+}
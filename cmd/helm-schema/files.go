@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+
+	"helm-schema/pkg/schema"
+)
+
+// writeFileAtomic replaces the file at path with data through a temporary file renamed over it,
+// so readers never see a partly written file and a failed run leaves the previous one intact.
+// The file keeps its permissions, new files are readable by everyone.
+func writeFileAtomic(path string, data []byte) error {
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-")
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// emit prints a result, or writes it to path when set
+func emit(path string, data []byte) error {
+	if path == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// writeSchemaIfChanged writes a schema to path unless the file holds it already, reporting whether
+// it did. Schemas differing only in when they were generated are the same, so regenerating an
+// unchanged chart leaves the file and its modification time alone.
+func writeSchemaIfChanged(path string, data []byte) (bool, error) {
+	existing, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return false, err
+	}
+	if err == nil && sameSchema(existing, data) {
+		return false, nil
+	}
+	return true, writeFileAtomic(path, data)
+}
+
+// sameSchema reports whether two schema documents are identical but for their generation time
+func sameSchema(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var first, second map[string]any
+	if json.Unmarshal(a, &first) != nil || json.Unmarshal(b, &second) != nil {
+		return false
+	}
+	withoutGenerationTime(first)
+	withoutGenerationTime(second)
+	return reflect.DeepEqual(first, second)
+}
+
+// schemaDrift compares the schema file at path with a freshly generated schema, listing how the
+// file would change if regenerated, nothing when they differ only in when they were generated
+func schemaDrift(path string, data []byte) ([]schema.Difference, error) {
+	existing, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s does not exist, generate it with --write", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var committed, generated map[string]any
+	if err := json.Unmarshal(existing, &committed); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, &generated); err != nil {
+		return nil, err
+	}
+	withoutGenerationTime(committed)
+	withoutGenerationTime(generated)
+	return schema.Diff(committed, generated)
+}
+
+// withoutGenerationTime removes the generation time from the metadata of a schema document
+func withoutGenerationTime(document map[string]any) {
+	if generation, ok := document["x-generation"].(map[string]any); ok {
+		delete(generation, "generatedAt")
+	}
+}
+
+// reportDrift prints how a schema file differs from the generated schema
+func reportDrift(path string, differences []schema.Difference) {
+	fmt.Printf("%s:\n", path)
+	for _, difference := range differences {
+		fmt.Printf("  %s\n", difference)
+	}
+}
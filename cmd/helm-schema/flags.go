@@ -0,0 +1,214 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+	"helm-schema/pkg/schema"
+)
+
+// generateFlags holds the flags shaping the generated schema, shared by the
+// main command and the subcommands generating a schema to check values against
+type generateFlags struct {
+	noSubcharts     *bool
+	markSensitive   *bool
+	provenance      *bool
+	emitUsage       *bool
+	minConfidence   *float64
+	unknown         *string
+	schemaDraft     *string
+	dedupe          *bool
+	nullable        *bool
+	noFormats       *bool
+	anyOf           *bool
+	conditionals    *bool
+	dependencies    *bool
+	oneOf           *bool
+	maxDepth        *int
+	examples        *bool
+	variantExamples *bool
+	constants       *bool
+	k8sRefs         *bool
+	k8sSchemas      *string
+	noRequired      *bool
+	strict          *bool
+	maxUnresolved   *int
+	keepMutated     *bool
+	crossCheckMode  *string
+	useCache        *bool
+	concurrency     *int
+	deriveSubcharts *bool
+	skipSubcharts   stringList
+	onlySubcharts   stringList
+	skipTests       *bool
+	exclude         stringList
+	stringMaps      stringList
+	valuesFiles     stringList
+	mergeExisting   *string
+	export          *bool
+	onEmpty         *string
+	selfCheck       *string
+	reproducible    *bool
+	noMetadata      *bool
+}
+
+// addGenerateFlags defines the generation flags on fs
+func addGenerateFlags(fs *flag.FlagSet) *generateFlags {
+	f := &generateFlags{}
+	f.noSubcharts = fs.Bool("no-subcharts", false, "Skip parsing subcharts")
+	f.markSensitive = fs.Bool("mark-sensitive", false, "Tag values piped through b64enc, sha256sum, htpasswd, ... with x-helm-sensitive")
+	f.provenance = fs.Bool("provenance", false, "Record where each value comes from: the template lines referencing it in x-helm-sources, the values it is only rendered when truthy in x-helm-condition and its subchart in x-helm-subchart")
+	f.emitUsage = fs.Bool("emit-usage", false, "List the template functions each value is passed through (quote, toYaml, tpl, ...) in x-helm-usage")
+	f.minConfidence = fs.Float64("min-confidence", 0, "Only emit inferred types at or above this confidence (0-1); default literals score 0.9, function hints 0.6")
+	f.unknown = fs.String("unknown", schema.UnknownAny, "Handling of values whose type cannot be inferred: any (property accepting any value), string, omit-property or strict-error (fail generation)")
+	f.schemaDraft = fs.String("schema-draft", schema.Draft202012, "JSON Schema draft to target: 2020-12 or draft-07, the draft Helm validates values against")
+	f.dedupe = fs.Bool("dedupe", false, "Hoist object schemas occurring more than once (image, resources, ... blocks repeated across subcharts) into $defs and reference them with $ref")
+	f.nullable = fs.Bool("nullable", false, "Accept null for every value the templates do not require, not only those set to null in values.yaml or marked nullable=true")
+	f.noFormats = fs.Bool("no-formats", false, "Omit the formats (uri, hostname, email) inferred from value names such as externalURL and functions such as urlParse")
+	f.anyOf = fs.Bool("any-of", false, "Render values accepting several types as anyOf with one subschema per type instead of a type list")
+	f.conditionals = fs.Bool("conditionals", false, "Require the values templates only read within if/with blocks when the values those test are truthy, with if/then (e.g. ingress.host when ingress.enabled is true)")
+	f.dependencies = fs.Bool("dependencies", false, "Make setting values templates only read when others of their object are truthy depend on those, with dependentRequired and dependentSchemas (e.g. tls.secretName on tls.enabled)")
+	f.oneOf = fs.Bool("one-of", false, "Accept at most one of the values testing the branches of an if/else if chain being set, with oneOf (e.g. persistence.existingClaim or persistence.hostPath)")
+	f.maxDepth = fs.Int("max-depth", 0, "Collapse values nested more than this many levels deep, subchart keys included, into permissive objects (0 keeps every level)")
+	f.examples = fs.Bool("examples", false, "List the values set in values.yaml for each value in examples, for editors to show on hover and offer on completion")
+	f.variantExamples = fs.Bool("variant-examples", false, "With --examples, also take examples from the values-*.yaml files next to values.yaml, such as values-production.yaml")
+	f.constants = fs.Bool("const", false, "Restrict values the templates only ever compare with one literal (e.g. eq .Values.mode \"standalone\") to it with const, warning about each as effectively hard-coded")
+	f.k8sRefs = fs.Bool("k8s-refs", false, "Reference the canonical schemas of the Kubernetes types well-known values hold (resources, affinity, podSecurityContext, tolerations, ...) with $ref instead of describing bare objects")
+	f.k8sSchemas = fs.String("k8s-schemas", "", "With --k8s-refs, the kubernetes-json-schema release to reference, an http(s) URL, or a vendored copy to embed for validating offline, a directory holding _definitions.json or the file itself (defaults to "+schema.DefaultKubernetesSchemasURL+")")
+	f.noRequired = fs.Bool("no-required", false, "Omit the required lists naming the values the templates pass to required")
+	f.strict = fs.Bool("strict", false, "Fail on constructs touching values that cannot be resolved (unknown variables, computed lookup keys, merge/pluck/deepCopy, ...)")
+	f.maxUnresolved = fs.Int("max-unresolved", -1, "Fail when more than this many constructs touching values cannot be resolved (-1 disables the limit)")
+	f.keepMutated = fs.Bool("keep-mutated", false, "Keep values the templates write with set/unset, which are excluded from the schema as outputs of the chart by default")
+	f.crossCheckMode = fs.String("cross-check", "", "Render the chart with helm template and reconcile the values it consumes with those found in the templates: report (warn about values only rendering reveals) or merge (also add them to the schema)")
+	f.useCache = fs.Bool("cache", false, "Reuse the values extracted from templates unchanged since the last run, kept in $HELM_SCHEMA_CACHE_DIR or the user cache directory")
+	f.concurrency = fs.Int("concurrency", 0, "Parse at most this many template files at once (0 uses every CPU)")
+	f.deriveSubcharts = fs.Bool("derive-subcharts", false, "Parse the templates of subcharts shipping a values.schema.json instead of embedding the schema they ship")
+	fs.Var(&f.skipSubcharts, "skip-subchart", "Skip parsing the subchart with this name or alias at any depth, accepting any values under its key (can be repeated)")
+	fs.Var(&f.onlySubcharts, "only-subchart", "Only parse the direct subchart with this name or alias, skipping the others as --skip-subchart does (can be repeated)")
+	f.skipTests = fs.Bool("skip-tests", false, "Skip Helm test hooks under templates/tests/")
+	fs.Var(&f.exclude, "exclude", "Skip templates matching a glob relative to the chart, e.g. templates/legacy/* (can be repeated)")
+	fs.Var(&f.valuesFiles, "f", "Values file merged over the chart's values.yaml as helm install -f does, for the defaults and the types inferred from them (can be repeated, later files take precedence)")
+	fs.Var(&f.valuesFiles, "values", "Alias for -f")
+	fs.Var(&f.stringMaps, "string-map", "Describe the values under this key as a map from strings to strings, like labels, annotations and nodeSelector, e.g. extraSelectors (can be repeated)")
+	f.mergeExisting = fs.String("merge-existing", "", "Merge the chart's existing values.schema.json into the result, keeping hand-written descriptions, enums and constraints; the precedence of keywords both set: existing or generated")
+	f.export = fs.Bool("export", false, "Emit a schema fragment for embedding under a parent chart's values key: no $schema, references relative to the fragment")
+	f.onEmpty = fs.String("on-empty", emptyClosed, "Handling of charts that reference no values: closed (schema accepting no values), open (schema accepting any values) or error")
+	f.selfCheck = fs.String("self-check", selfCheckError, "Validate the generated schema against the meta-schema of its draft before printing it: error (fail on invalid constructs), warn or off")
+	f.reproducible = fs.Bool("reproducible", false, "Omit the generation timestamp from the metadata, so regenerating an unchanged chart produces an identical schema")
+	f.noMetadata = fs.Bool("no-metadata", false, "Omit the generation metadata ($comment and x-generation) from the schema")
+	return f
+}
+
+// config validates the flags and builds the generation config they describe,
+// recording the flags set on fs in its metadata
+func (f *generateFlags) config(fs *flag.FlagSet) (generateConfig, error) {
+	cfg := generateConfig{
+		IncludeSubcharts: !*f.noSubcharts,
+		Parser: parser.Options{
+			Strict:          *f.strict,
+			Templates:       helm.TemplateOptions{SkipTests: *f.skipTests, Exclude: f.exclude},
+			KeepMutated:     *f.keepMutated,
+			Concurrency:     *f.concurrency,
+			DeriveSubcharts: *f.deriveSubcharts,
+			ValuesVariants:  *f.variantExamples,
+			ValuesFiles:     f.valuesFiles,
+			SkipSubcharts:   f.skipSubcharts,
+			OnlySubcharts:   f.onlySubcharts,
+		},
+		Schema: schema.Options{
+			MarkSensitive: *f.markSensitive,
+			EmitUsage:     *f.emitUsage,
+			Provenance:    *f.provenance,
+			MinConfidence: *f.minConfidence,
+			Unknown:       *f.unknown,
+			OmitRequired:  *f.noRequired,
+			Draft:         *f.schemaDraft,
+			StringMaps:    f.stringMaps,
+			AnyOf:         *f.anyOf,
+			OmitFormats:   *f.noFormats,
+			Nullable:      *f.nullable,
+			Conditionals:  *f.conditionals,
+			Dependencies:  *f.dependencies,
+			OneOf:         *f.oneOf,
+			MaxDepth:      *f.maxDepth,
+			Examples:      *f.examples,
+			Constants:     *f.constants,
+		},
+		Metadata:      !*f.noMetadata,
+		Reproducible:  *f.reproducible,
+		Export:        *f.export,
+		Deduplicate:   *f.dedupe,
+		MergeExisting: *f.mergeExisting,
+		OnEmpty:       *f.onEmpty,
+		SelfCheck:     *f.selfCheck,
+		CrossCheck:    *f.crossCheckMode,
+		Flags:         make(map[string]string),
+	}
+	if *f.onEmpty != emptyClosed && *f.onEmpty != emptyOpen && *f.onEmpty != emptyError {
+		return generateConfig{}, fmt.Errorf("invalid --on-empty %q: expected %s, %s or %s", *f.onEmpty, emptyClosed, emptyOpen, emptyError)
+	}
+	if *f.selfCheck != selfCheckError && *f.selfCheck != selfCheckWarn && *f.selfCheck != selfCheckOff {
+		return generateConfig{}, fmt.Errorf("invalid --self-check %q: expected %s, %s or %s", *f.selfCheck, selfCheckError, selfCheckWarn, selfCheckOff)
+	}
+	switch *f.unknown {
+	case schema.UnknownAny, schema.UnknownString, schema.UnknownOmit, schema.UnknownStrictError:
+	default:
+		return generateConfig{}, fmt.Errorf("invalid --unknown %q: expected %s, %s, %s or %s", *f.unknown, schema.UnknownAny, schema.UnknownString, schema.UnknownOmit, schema.UnknownStrictError)
+	}
+	if *f.schemaDraft != schema.Draft202012 && *f.schemaDraft != schema.Draft07 {
+		return generateConfig{}, fmt.Errorf("invalid --schema-draft %q: expected %s or %s", *f.schemaDraft, schema.Draft202012, schema.Draft07)
+	}
+	if *f.mergeExisting != "" && *f.mergeExisting != schema.PreferExisting && *f.mergeExisting != schema.PreferGenerated {
+		return generateConfig{}, fmt.Errorf("invalid --merge-existing %q: expected %s or %s", *f.mergeExisting, schema.PreferExisting, schema.PreferGenerated)
+	}
+	if *f.crossCheckMode != "" && *f.crossCheckMode != crossCheckReport && *f.crossCheckMode != crossCheckMerge {
+		return generateConfig{}, fmt.Errorf("invalid --cross-check %q: expected %s or %s", *f.crossCheckMode, crossCheckReport, crossCheckMerge)
+	}
+	if *f.maxUnresolved >= 0 {
+		cfg.MaxUnresolved = f.maxUnresolved
+	}
+	if *f.k8sRefs {
+		source, err := kubernetesSchemas(*f.k8sSchemas)
+		if err != nil {
+			return generateConfig{}, err
+		}
+		cfg.Kubernetes = source
+	}
+	if *f.useCache {
+		c, err := openCache()
+		if err != nil {
+			return generateConfig{}, err
+		}
+		cfg.Parser.Cache = c
+	}
+	fs.Visit(func(set *flag.Flag) {
+		if !unrecordedFlags[set.Name] {
+			cfg.Flags[set.Name] = set.Value.String()
+		}
+	})
+	return cfg, nil
+}
+
+// chartGenerateConfig builds the config generating the schema of a chart as the
+// main command does with no flags given, applying the chart's .helm-schema.yaml
+func chartGenerateConfig(chartPath string) (generateConfig, error) {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	f := addGenerateFlags(fs)
+	chartConfig, err := loadChartConfig(chartPath)
+	if err != nil {
+		return generateConfig{}, err
+	}
+	// Only the generation flags matter here, the others configure the main command
+	flags := make(map[string]any)
+	for name, value := range chartConfig.Flags {
+		if fs.Lookup(name) != nil {
+			flags[name] = value
+		}
+	}
+	if err := applyConfigFlags(fs, flags); err != nil {
+		return generateConfig{}, err
+	}
+	return f.config(fs)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"helm-schema/pkg/globals"
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+)
+
+// runGlobals checks that global.* values are typed consistently across a set of charts
+func runGlobals(args []string) error {
+	fs := flag.NewFlagSet("globals", flag.ExitOnError)
+	noSubcharts := fs.Bool("no-subcharts", false, "Skip parsing subcharts")
+	asJSON := fs.Bool("json", false, "Print conflicts as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s globals [flags] <dir-or-chart>...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Directories are searched recursively for charts.\n")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	var chartPaths []string
+	for _, arg := range fs.Args() {
+		absPath, err := filepath.Abs(arg)
+		if err != nil {
+			return fmt.Errorf("resolving path: %w", err)
+		}
+		found, err := helm.FindCharts(absPath)
+		if err != nil {
+			return err
+		}
+		chartPaths = append(chartPaths, found...)
+	}
+
+	if len(chartPaths) == 0 {
+		return fmt.Errorf("no charts found")
+	}
+
+	checker := globals.New()
+	for _, chartPath := range chartPaths {
+		p := parser.New()
+		if err := p.ParseChartWithOptions(chartPath, !*noSubcharts); err != nil {
+			return fmt.Errorf("parsing chart %s: %w", chartPath, err)
+		}
+		checker.AddChart(chartName(chartPath), p)
+	}
+
+	conflicts := checker.Conflicts()
+
+	if *asJSON {
+		if conflicts == nil {
+			conflicts = []globals.Conflict{}
+		}
+		output, err := json.MarshalIndent(conflicts, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		fmt.Printf("Charts scanned: %d\n", len(chartPaths))
+		fmt.Printf("Global values: %d\n", len(checker.Paths()))
+		for _, conflict := range conflicts {
+			fmt.Printf("\n%s is used inconsistently:\n", conflict.Path)
+			for _, usage := range conflict.Usages {
+				fmt.Printf("  %-8s %s\n", usage.Type, usage.Chart)
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("%d inconsistent global value(s)", len(conflicts))
+	}
+	return nil
+}
+
+// chartName returns the name declared in Chart.yaml, falling back to the directory name
+func chartName(chartPath string) string {
+	metadata, err := helm.ParseChartMetadata(chartPath)
+	if err != nil || metadata.Name == "" {
+		return filepath.Base(chartPath)
+	}
+	return metadata.Name
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm-schema/pkg/schema"
+)
+
+// kubernetesSchemas returns where the schemas of Kubernetes types are taken from: a
+// kubernetes-json-schema release at an http(s) URL, referenced remotely, or a vendored copy of
+// one, a directory holding its _definitions.json or the file itself, embedded for validating
+// offline. An empty source references the default release.
+func kubernetesSchemas(source string) (*schema.KubernetesSchemas, error) {
+	if source == "" {
+		return &schema.KubernetesSchemas{BaseURL: schema.DefaultKubernetesSchemasURL}, nil
+	}
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return &schema.KubernetesSchemas{BaseURL: source}, nil
+	}
+
+	path := source
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, schema.KubernetesDefinitionsFile)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Kubernetes schemas: %w", err)
+	}
+	var bundle struct {
+		Definitions map[string]any `json:"definitions"`
+	}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("failed to parse Kubernetes schemas %s: %w", path, err)
+	}
+	if len(bundle.Definitions) == 0 {
+		return nil, fmt.Errorf("no definitions in Kubernetes schemas %s", path)
+	}
+	return &schema.KubernetesSchemas{Definitions: bundle.Definitions}, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/lint"
+	"helm-schema/pkg/parser"
+)
+
+// runLint checks the value paths of a chart against naming conventions
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	maxDepth := fs.Int("max-depth", lint.DefaultMaxDepth, "Maximum nesting depth of value paths (0 disables the limit)")
+	abbreviations := fs.String("abbreviations", "", "Comma separated abbreviation=replacement pairs replacing the default list, e.g. cfg=config,svc=service")
+	disable := fs.String("disable", "", "Comma separated rules to skip: camel-case, abbreviations, max-depth")
+	asJSON := fs.Bool("json", false, "Print issues as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s lint [flags] <helm-chart-path>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	cfg := lint.DefaultConfig()
+	cfg.MaxDepth = *maxDepth
+	if *abbreviations != "" {
+		cfg.Abbreviations = make(map[string]string)
+		for _, pair := range strings.Split(*abbreviations, ",") {
+			abbreviation, replacement, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if !ok || abbreviation == "" || replacement == "" {
+				return fmt.Errorf("invalid abbreviation %q, expected abbreviation=replacement", pair)
+			}
+			cfg.Abbreviations[strings.ToLower(abbreviation)] = replacement
+		}
+	}
+	if *disable != "" {
+		for _, rule := range strings.Split(*disable, ",") {
+			cfg.Disabled[strings.TrimSpace(rule)] = true
+		}
+	}
+
+	absPath, err := filepath.Abs(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+
+	if err := helm.ValidateChartDirectory(absPath); err != nil {
+		return err
+	}
+
+	// Subcharts define their own values API and are linted separately
+	p := parser.New()
+	if err := p.ParseChartWithOptions(absPath, false); err != nil {
+		return fmt.Errorf("parsing chart: %w", err)
+	}
+
+	issues := lint.Lint(p.GetValues(), lint.Rules(cfg))
+
+	if *asJSON {
+		if issues == nil {
+			issues = []lint.Issue{}
+		}
+		output, err := json.MarshalIndent(issues, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		for _, issue := range issues {
+			fmt.Printf("%s [%s] %s\n", issue.Path, issue.Rule, issue.Message)
+			if issue.Suggestion != "" {
+				fmt.Printf("    -> rename to %s\n", issue.Suggestion)
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("%d naming issue(s) found", len(issues))
+	}
+	return nil
+}
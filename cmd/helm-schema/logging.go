@@ -0,0 +1,51 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// verbosity counts the -v flags given, each raising how much is logged
+type verbosity int
+
+func (v *verbosity) String() string {
+	return strconv.Itoa(int(*v))
+}
+
+func (v *verbosity) Set(value string) error {
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		*v++
+	}
+	return nil
+}
+
+// IsBoolFlag lets -v be given without a value
+func (v *verbosity) IsBoolFlag() bool {
+	return true
+}
+
+// helmDebug reports whether helm runs the tool as a plugin with --debug, which it passes on as
+// HELM_DEBUG
+func helmDebug() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("HELM_DEBUG"))
+	return enabled
+}
+
+// setupLogging logs to stderr, keeping stdout for the schema: warnings only by default, what the
+// tool does, such as the charts parsed or subcharts skipped, from -v, and details, such as the
+// values found per template or the output of helm, from -vv
+func setupLogging(level verbosity) {
+	threshold := slog.LevelWarn
+	switch {
+	case level >= 2:
+		threshold = slog.LevelDebug
+	case level == 1:
+		threshold = slog.LevelInfo
+	}
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: threshold})))
+}
@@ -1,90 +1,696 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
 	"helm-schema/pkg/helm"
 	"helm-schema/pkg/parser"
 	"helm-schema/pkg/schema"
+	"helm-schema/pkg/validate"
 )
 
+// version is the generator version injected with -ldflags "-X main.version=...". Read it
+// through currentBuild, which falls back to the version the Go toolchain recorded.
+var version = "dev"
+
+// commands maps subcommand names to their entry points
+var commands = map[string]func(args []string) error{
+	"audit":     runAudit,
+	"cache":     runCache,
+	"coverage":  runCoverage,
+	"doctor":    runDoctor,
+	"globals":   runGlobals,
+	"lint":      runLint,
+	"preflight": runPreflight,
+	"report":    runReport,
+	"validate":  runValidate,
+	"version":   runVersion,
+}
+
+// Ways of handling charts that reference no values
+const (
+	emptyClosed = "closed" // Schema accepting no values
+	emptyOpen   = "open"   // Schema accepting any values
+	emptyError  = "error"  // Fail generation
+)
+
+// Ways of handling generated schemas breaking the meta-schema of their draft
+const (
+	selfCheckError = "error" // Fail generation
+	selfCheckWarn  = "warn"  // Report the violations and keep the schema
+	selfCheckOff   = "off"   // Skip the check
+)
+
+// unrecordedFlags only choose where the chart comes from, how fast it is parsed, where the
+// schema goes, how it is encoded or what is logged, not what the schema holds, and are left out
+// of the flags recorded in its metadata. Flags of that kind belong here when they are added.
+var unrecordedFlags = map[string]bool{
+	"output":          true,
+	"o":               true,
+	"write":           true,
+	"w":               true,
+	"check":           true,
+	"watch":           true,
+	"out-dir":         true,
+	"recursive":       true,
+	"format":          true,
+	"v":               true,
+	"vv":              true,
+	"debug":           true,
+	"archive-dir":     true,
+	"versions":        true,
+	"template":        true,
+	"cache":           true,
+	"concurrency":     true,
+	"write-subcharts": true,
+	"version":         true,
+	"repo":            true,
+	"chart":           true,
+}
+
+// generateConfig collects the settings controlling schema generation for a chart
+type generateConfig struct {
+	IncludeSubcharts bool
+	Parser           parser.Options
+	Schema           schema.Options
+	Metadata         bool
+	Reproducible     bool                      // Omit the generation timestamp from the metadata
+	Export           bool                      // Emit a fragment for embedding under a parent chart's values key
+	Deduplicate      bool                      // Hoist repeated object schemas into $defs
+	MergeExisting    string                    // Precedence when merging with the chart's values.schema.json; empty disables merging
+	OnEmpty          string                    // Handling of charts referencing no values; empty means emptyClosed
+	SelfCheck        string                    // Handling of schemas breaking their draft's meta-schema; empty means selfCheckError
+	MaxUnresolved    *int                      // Fail when more constructs cannot be resolved; nil disables the limit
+	CrossCheck       string                    // Reconciliation with helm template rendering; empty disables it
+	Kubernetes       *schema.KubernetesSchemas // Source of the Kubernetes types well-known values reference; nil disables references
+	Flags            map[string]string         // Explicitly set flags, recorded in metadata
+}
+
+// stringList collects the values of a repeatable flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
 func usage() {
-	fmt.Fprintf(os.Stderr, "Usage: %s [flags] <helm-chart-path>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "Usage: %s [gen] [flags] <helm-chart-path>...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s check [flags] <helm-chart-path>...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s --recursive <dir> [--out-dir <dir>] [flags]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s --archive-dir <dir> [--out-dir <dir>] [flags]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s --repo <url> --chart <name> [--version <range>] [flags]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s --versions <range> [--out-dir <dir>] [flags] <repo/chart|oci://...>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s --versions <range> [--out-dir <dir>] [flags] --repo <url> --chart <name>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s audit [flags] --all | <release>...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s cache [flags] ls|prune|clear\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s coverage [flags] <helm-chart-path>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s doctor [flags] <helm-chart-path>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s globals [flags] <dir-or-chart>...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s lint [flags] <helm-chart-path>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s preflight [flags] <release> --to-chart <ref> [-f values.yaml]...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s report [flags] <helm-chart-path>\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s validate [flags] <helm-chart-path> [-f values.yaml]...\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "       %s version [flags] | --version\n", os.Args[0])
 	flag.PrintDefaults()
 }
 
 func main() {
-	var noSubcharts = flag.Bool("no-subcharts", false, "Skip parsing subcharts")
+	var level verbosity
+	if helmDebug() {
+		level = 2
+	}
+	setupLogging(level)
+	// Installed as a Helm plugin, the tool runs as helm schema gen|check|validate|...: gen is the
+	// default command and check is gen --check
+	// --version chooses the version of pulled charts, given alone it prints the build like the
+	// version command
+	if len(os.Args) == 2 && (os.Args[1] == "--version" || os.Args[1] == "-version") {
+		os.Args[1] = "version"
+	}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "gen":
+			os.Args = slices.Delete(os.Args, 1, 2)
+		case "check":
+			os.Args[1] = "--check"
+		}
+	}
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	gen := addGenerateFlags(flag.CommandLine)
+	var output string
+	flag.StringVar(&output, "output", "", "Write the schema, or the result of --template, to this file instead of printing it, replacing the file atomically")
+	flag.StringVar(&output, "o", "", "Shorthand for --output")
+	var write bool
+	flag.BoolVar(&write, "write", false, "Write the schema to the chart's values.schema.json, only replacing the file when the schema changed")
+	flag.BoolVar(&write, "w", false, "Shorthand for --write")
+	var format = flag.String("format", schema.FormatJSON, "Representation of the schema printed or written with --output: json, yaml (the JSON schema as YAML) or openapi (an OpenAPI 3.0 document)")
+	var outputTemplate = flag.String("template", "", "Render the result with a Go template instead of printing the schema, e.g. '{{range required .Values}}{{println .Path}}{{end}}'")
+	var writeSubcharts = flag.Bool("write-subcharts", false, "Also write the schema of every local subchart, generated as a chart of its own, to its values.schema.json")
+	var archiveDir = flag.String("archive-dir", "", "Generate schemas for every packaged chart (*.tgz) in this directory")
+	var chartVersion = flag.String("version", "", "Version of a chart pulled from an OCI registry or downloaded with --repo, e.g. 1.2.3 or a range such as ^1.2 or 18.x (defaults to the latest); given alone, prints the version of helm-schema")
+	var repo = flag.String("repo", "", "URL of an HTTP chart repository to download the chart named with --chart from, e.g. https://charts.bitnami.com/bitnami")
+	var repoChart = flag.String("chart", "", "Name of the chart to download from --repo")
+	var versions = flag.String("versions", "", "Generate schemas and diffs for every published version of a chart reference in this range, e.g. '>=1.0.0 <2.0.0'")
+	var watch = flag.Bool("watch", false, "Keep running, regenerating the schema written with --write or --output whenever a file of the chart changes; templates that did not change are not parsed again")
+	var check = flag.Bool("check", false, "Compare the generated schema with the chart's values.schema.json, or the file given with --output or found in --out-dir, printing how they differ and failing when they do")
+	var recursive = flag.String("recursive", "", "Find every chart below this directory and write its schema to its values.schema.json, or to --out-dir")
+	var outDir = flag.String("out-dir", "", "Directory receiving per-archive, per-version or per-chart schemas, and index.json (defaults to --archive-dir, or the current directory with --versions)")
+	var verbose verbosity
+	flag.Var(&verbose, "v", "Log what the tool does to stderr, such as the charts parsed and subcharts skipped; repeat or use -vv for details")
+	var veryVerbose = flag.Bool("vv", false, "Log details to stderr, such as the values found per template and the output of helm")
+	var debug = flag.Bool("debug", false, "Alias for -vv")
 	flag.Usage = usage
 	flag.Parse()
 
-	if flag.NArg() != 1 {
+	// Flags default to the config of the chart, or of the directory holding the charts
+	configDir := "."
+	switch {
+	case *recursive != "":
+		configDir = *recursive
+	case flag.NArg() == 1 && *versions == "" && !isOCIReference(flag.Arg(0)):
+		configDir = flag.Arg(0)
+	}
+	runConfig, err := loadChartConfig(configDir)
+	if err == nil {
+		err = applyConfigFlags(flag.CommandLine, runConfig.Flags)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *veryVerbose || *debug || helmDebug() {
+		verbose = max(verbose, 2)
+	}
+	setupLogging(verbose)
+
+	cfg, err := gen.config(flag.CommandLine)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch *format {
+	case schema.FormatJSON:
+	case schema.FormatYAML, schema.FormatOpenAPI:
+		if write || *check || *outputTemplate != "" || *recursive != "" || *archiveDir != "" || *versions != "" || flag.NArg() > 1 {
+			fmt.Fprintf(os.Stderr, "Error: --format %s only applies to the schema of a single chart printed or written with --output, values.schema.json files are JSON\n", *format)
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid --format %q: expected %s, %s or %s\n", *format, schema.FormatJSON, schema.FormatYAML, schema.FormatOpenAPI)
+		os.Exit(1)
+	}
+	if *check && (write || *writeSubcharts || *outputTemplate != "" || *archiveDir != "" || *versions != "") {
+		fmt.Fprintf(os.Stderr, "Error: --check only compares the schema, it cannot be combined with --write, --write-subcharts, --template, --archive-dir or --versions\n")
+		os.Exit(1)
+	}
+	if *watch && (*check || *outputTemplate != "" || *archiveDir != "" || *recursive != "" || *versions != "" || *repo != "" || flag.NArg() != 1 || isOCIReference(flag.Arg(0))) {
+		fmt.Fprintf(os.Stderr, "Error: --watch regenerates the schema of a single chart directory, it cannot be combined with --check, --template, --archive-dir, --recursive, --versions, --repo or charts pulled from registries\n")
+		os.Exit(1)
+	}
+	if len(gen.valuesFiles) > 0 && (*recursive != "" || *archiveDir != "" || *versions != "" || flag.NArg() > 1) {
+		fmt.Fprintf(os.Stderr, "Error: -f/--values overlay the values of a single chart, they cannot be combined with several charts, --recursive, --archive-dir or --versions\n")
+		os.Exit(1)
+	}
+	if *gen.noSubcharts && (len(gen.skipSubcharts) > 0 || len(gen.onlySubcharts) > 0) {
+		fmt.Fprintf(os.Stderr, "Error: --no-subcharts already skips every subchart, it cannot be combined with --skip-subchart or --only-subchart\n")
+		os.Exit(1)
+	}
+	dest := chartDestination{OutDir: *outDir, WriteSubcharts: *writeSubcharts, Check: *check}
+
+	if *archiveDir != "" {
+		if flag.NArg() != 0 || *repo != "" {
+			usage()
+			os.Exit(1)
+		}
+		if *outDir == "" {
+			*outDir = *archiveDir
+		}
+		if err := archivesToSchemas(*archiveDir, *outDir, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *recursive != "" {
+		if flag.NArg() != 0 || *versions != "" || *repo != "" {
+			usage()
+			os.Exit(1)
+		}
+		if output != "" || *outputTemplate != "" {
+			fmt.Fprintf(os.Stderr, "Error: --output and --template take a single chart, --recursive writes each chart's schema to the chart or --out-dir\n")
+			os.Exit(1)
+		}
+		if err := recursiveToSchemas(*recursive, dest, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *repo != "" || *repoChart != "" {
+		if *repo == "" || *repoChart == "" || flag.NArg() != 0 {
+			usage()
+			os.Exit(1)
+		}
+	} else if flag.NArg() == 0 || flag.NArg() > 1 && *versions != "" {
 		usage()
 		os.Exit(1)
 	}
 
+	if *versions != "" {
+		if *outDir == "" {
+			*outDir = "."
+		}
+		ref := flag.Arg(0)
+		if *repo != "" {
+			ref = *repoChart
+		}
+		if err := versionsToSchemas(*repo, ref, *versions, *outDir, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if flag.NArg() > 1 {
+		switch {
+		case slices.ContainsFunc(flag.Args(), isOCIReference) || *chartVersion != "":
+			fmt.Fprintf(os.Stderr, "Error: charts are pulled from OCI registries one at a time, with --version choosing the version\n")
+			os.Exit(1)
+		case output != "" || *outputTemplate != "":
+			fmt.Fprintf(os.Stderr, "Error: --output and --template take a single chart, write the schemas of several charts with --write or --out-dir\n")
+			os.Exit(1)
+		case write == (*outDir != "") && !*check:
+			fmt.Fprintf(os.Stderr, "Error: several charts need either --write or --out-dir to receive their schemas\n")
+			os.Exit(1)
+		}
+		if err := chartsToSchemas(flag.Args(), dest, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	chartPath := flag.Arg(0)
-	includeSubcharts := !*noSubcharts
+	if *repo != "" {
+		chartPath = *repoChart
+	}
+	if *repo != "" || isOCIReference(chartPath) {
+		if write || *writeSubcharts {
+			fmt.Fprintf(os.Stderr, "Error: %s is downloaded into a temporary directory, write its schema with --output instead of --write or --write-subcharts\n", chartPath)
+			os.Exit(1)
+		}
+		defer cleanup()
+		var pulled string
+		var err error
+		if *repo != "" {
+			pulled, err = downloadChart(*repo, *repoChart, *chartVersion)
+		} else {
+			pulled, err = pullChart(chartPath, *chartVersion)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		chartPath = pulled
+	} else if *chartVersion != "" {
+		fmt.Fprintf(os.Stderr, "Error: --version selects the version of a chart pulled from an OCI registry or downloaded with --repo, %s is a chart directory\n", chartPath)
+		exit(1)
+	}
+	if output == "" && !write {
+		output = runConfig.Output
+	}
+	if write {
+		if output != "" || *outputTemplate != "" {
+			fmt.Fprintf(os.Stderr, "Error: --write writes the schema to the chart, it cannot be combined with --output or --template\n")
+			exit(1)
+		}
+	}
+
+	if *watch {
+		if !write && output == "" {
+			fmt.Fprintf(os.Stderr, "Error: --watch needs --write or --output to receive the schema\n")
+			exit(1)
+		}
+		if err := watchSchema(chartPath, output, *format, *writeSubcharts, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
+
+	if *outputTemplate != "" {
+		tmpl, err := parseOutputTemplate(*outputTemplate)
+		if err == nil {
+			var result *generation
+			if result, err = generate(chartPath, cfg); err == nil {
+				var rendered bytes.Buffer
+				if err = renderGeneration(&rendered, tmpl, result); err == nil {
+					err = emit(output, rendered.Bytes())
+				}
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		return
+	}
 
-	schemaJSON, err := chartToSchema(chartPath, includeSubcharts)
+	result, err := generate(chartPath, cfg)
+	var rendered []byte
+	if err == nil {
+		rendered, err = renderSchema(result, *format)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		exit(1)
+	}
+
+	if *check {
+		path := output
+		if path == "" {
+			path = filepath.Join(chartPath, helm.SchemaFile)
+		}
+		differences, err := schemaDrift(path, rendered)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		if len(differences) > 0 {
+			reportDrift(path, differences)
+			fmt.Fprintf(os.Stderr, "Error: %s is out of date, regenerate it with --write\n", path)
+			exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "%s is up to date\n", path)
+		return
+	}
+
+	if write {
+		path := filepath.Join(chartPath, helm.SchemaFile)
+		changed, err := writeSchemaIfChanged(path, rendered)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+		reportWrite(path, changed)
+	} else if err := emit(output, rendered); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		exit(1)
 	}
 
-	fmt.Println(schemaJSON)
+	if *writeSubcharts {
+		if err := writeSubchartSchemas(chartPath, cfg); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit(1)
+		}
+	}
 }
 
 // chartToSchema converts a Helm chart directory to a JSON schema string
-func chartToSchema(chartPath string, includeSubcharts bool) (string, error) {
+func chartToSchema(chartPath string, cfg generateConfig) (string, error) {
+	finalSchema, err := generateSchema(chartPath, cfg)
+	if err != nil {
+		return "", err
+	}
+
+	output, err := schema.MarshalIndent(finalSchema, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("generating JSON: %w", err)
+	}
+
+	return string(output), nil
+}
+
+// generateSchema builds the merged JSON schema of a Helm chart directory
+func generateSchema(chartPath string, cfg generateConfig) (map[string]any, error) {
+	result, err := generate(chartPath, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return result.Schema, nil
+}
+
+// generate parses a Helm chart directory and builds its merged JSON schema
+func generate(chartPath string, cfg generateConfig) (*generation, error) {
 	// Convert to absolute path
 	absPath, err := filepath.Abs(chartPath)
 	if err != nil {
-		return "", fmt.Errorf("resolving path: %w", err)
+		return nil, fmt.Errorf("resolving path: %w", err)
 	}
 
 	// Validate chart directory
 	if err := helm.ValidateChartDirectory(absPath); err != nil {
-		return "", err
+		return nil, err
 	}
 
 	// Parse chart including subcharts (if enabled)
-	p := parser.New()
-	if err := p.ParseChartWithOptions(absPath, includeSubcharts); err != nil {
-		return "", fmt.Errorf("parsing chart: %w", err)
+	p := parser.NewWithOptions(cfg.Parser)
+	if err := p.ParseChartWithOptions(absPath, cfg.IncludeSubcharts); err != nil {
+		return nil, fmt.Errorf("parsing chart: %w", err)
+	}
+	slog.Info("parsed chart", "chart", absPath, "values", len(p.GetValues()), "subcharts", len(p.GetSubcharts()), "unresolved", len(p.Unresolved()))
+
+	// Report constructs that degrade the schema without failing generation
+	for _, warning := range p.Warnings() {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", warning.Path, warning.Message)
+	}
+
+	// Constructs the parser cannot see through leave the schema incomplete
+	unresolved := p.Unresolved()
+	for _, construct := range unresolved {
+		fmt.Fprintf(os.Stderr, "Warning: unresolved %s\n", construct)
+	}
+	if cfg.MaxUnresolved != nil && len(unresolved) > *cfg.MaxUnresolved {
+		return nil, fmt.Errorf("%d constructs could not be resolved, more than the %d allowed by --max-unresolved", len(unresolved), *cfg.MaxUnresolved)
+	}
+
+	// Values only rendering reveals, e.g. behind tpl or computed keys
+	if cfg.CrossCheck != "" {
+		if err := crossCheck(absPath, p, cfg.CrossCheck == crossCheckMerge); err != nil {
+			return nil, err
+		}
+	}
+
+	// Values of unknown type fail generation under the strict-error policy
+	if err := schema.CheckUnknown(p.GetAllValues(), cfg.Schema); err != nil {
+		return nil, err
+	}
+
+	// Values only ever compared with one literal are knobs the chart effectively hard-codes
+	if cfg.Schema.Constants {
+		constants := schema.Constants(p.GetAllValues())
+		paths := make([]string, 0, len(constants))
+		for path := range constants {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			fmt.Fprintf(os.Stderr, "Warning: %s: only compared with %#v, effectively hard-coded\n", path, constants[path])
+		}
 	}
 
 	// Step 1: Generate individual schemas for main chart and each subchart
-	mainSchema, subchartSchemas := schema.GenerateChartSchemas(p)
+	mainSchema, subchartSchemas := schema.GenerateChartSchemasWithOptions(p, cfg.Schema)
 
 	// Validate we have schemas to work with
-	totalValues := 0
-	if mainProps, ok := mainSchema.Schema["properties"].(map[string]any); ok {
-		totalValues = len(mainProps)
+	totalValues := len(mainSchema.Schema.Properties)
+	for _, subchart := range subchartSchemas {
+		totalValues += len(subchart.Schema.Properties)
 	}
 
-	for _, subchart := range subchartSchemas {
-		if props, ok := subchart.Schema["properties"].(map[string]any); ok {
-			totalValues += len(props)
+	// Static manifests packaged as a chart are valid, they just accept no values
+	if totalValues == 0 {
+		if cfg.OnEmpty == emptyError {
+			return nil, fmt.Errorf("no value paths found in chart %s - ensure templates use .Values references", absPath)
 		}
+		fmt.Fprintf(os.Stderr, "Warning: no value paths found in chart %s\n", absPath)
 	}
 
-	if totalValues == 0 {
-		return "", fmt.Errorf("no value paths found in chart %s - ensure templates use .Values references", absPath)
+	chart, err := helm.ParseChartMetadata(absPath)
+	if err != nil {
+		return nil, err
 	}
 
 	// Step 2: Aggregate individual schemas into final schema
-	finalSchema := schema.MergeSchemas(mainSchema, subchartSchemas)
+	// The passes below work on the schema as a map
+	finalSchema := schema.MergeSchemas(mainSchema, subchartSchemas).ToMap()
+	// Helm reads the conditions and tags enabling dependencies, which the templates rarely do
+	schema.AddSwitches(finalSchema, dependencySwitches(chart))
+	// Charts sharing a global value should agree on its type, the merged schema accepts them all
+	for _, conflict := range schema.GlobalConflicts(mainSchema, subchartSchemas) {
+		var usages []string
+		for _, usage := range conflict.Usages {
+			usages = append(usages, fmt.Sprintf("%s (%s)", usage.Type, usage.Chart))
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %s is read as different types across charts: %s\n", conflict.Path, strings.Join(usages, ", "))
+	}
+	// Subchart values sit a level deeper once nested under the subchart's key
+	schema.LimitDepth(finalSchema, cfg.Schema.MaxDepth)
+	// Kubernetes types are referenced on the merged schema, which holds the definitions embedded
+	if cfg.Kubernetes != nil {
+		count, err := schema.ReferenceKubernetes(finalSchema, *cfg.Kubernetes)
+		if err != nil {
+			return nil, err
+		}
+		slog.Debug("referenced kubernetes types", "values", count)
+	}
+	if totalValues == 0 && cfg.OnEmpty == emptyOpen {
+		finalSchema["additionalProperties"] = true
+	}
+
+	// Hand-written schemas the chart ships are kept up to date rather than replaced
+	if cfg.MergeExisting != "" {
+		existing, err := helm.LoadChartSchema(absPath)
+		switch {
+		case err == nil:
+			finalSchema = schema.MergeExisting(finalSchema, existing, cfg.MergeExisting)
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		}
+	}
 
-	// Step 3: Convert to JSON string
-	output, err := json.MarshalIndent(finalSchema, "", "  ")
+	// Corrections the chart author declares take precedence over inference
+	config, err := loadChartConfig(absPath)
 	if err != nil {
-		return "", fmt.Errorf("generating JSON: %w", err)
+		return nil, err
+	}
+	if err := schema.ApplyOverrides(finalSchema, config.Overrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	overrides, err := helm.LoadValuesFile(filepath.Join(absPath, schema.OverridesFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	if err := schema.ApplyOverrides(finalSchema, overrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", schema.OverridesFile, err)
 	}
 
-	return string(output), nil
+	// Values the chart keeps out of its published contract, such as internal tuning knobs
+	excluded, err := schema.ExcludePaths(finalSchema, config.ExcludePaths)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", configFile, err)
+	}
+	if excluded > 0 {
+		slog.Debug("excluded values", "config", configFile, "values", excluded)
+	}
+	// Charts passing values through to configuration they do not template accept any others
+	if config.AdditionalProperties != nil && *config.AdditionalProperties {
+		slog.Debug("opened objects", "config", configFile, "objects", schema.OpenObjects(finalSchema))
+	}
+
+	if cfg.Deduplicate {
+		schema.Deduplicate(finalSchema)
+	}
+
+	// Step 3: Record how the schema was produced
+	if cfg.Metadata {
+		chartDigest, err := helm.ChartDigest(absPath)
+		if err != nil {
+			return nil, err
+		}
+		metadata := schema.Metadata{
+			GeneratorVersion: currentBuild().Version,
+			RulesetDigest:    parser.RulesetDigest(),
+			ChartDigest:      chartDigest,
+			ChartVersion:     chart.Version,
+			Flags:            cfg.Flags,
+			Unresolved:       len(unresolved),
+		}
+		// Timestamps would make every regeneration differ
+		if !cfg.Reproducible {
+			metadata.GeneratedAt = time.Now()
+		}
+		schema.AddMetadata(finalSchema, metadata)
+	}
+
+	// Step 4: Catch constructs the generator, overrides or merged schemas got wrong before
+	// anything consumes the schema, while it still declares its draft
+	if err := checkSchema(finalSchema, cfg.SelfCheck); err != nil {
+		return nil, err
+	}
+
+	// Step 5: Make the schema embeddable under a parent chart
+	if cfg.Export {
+		finalSchema = schema.Fragment(finalSchema, chart.Name)
+	}
+
+	return newGeneration(absPath, p, finalSchema)
+}
+
+// dependencySwitches returns the values enabling the dependencies of a chart: the paths of their
+// conditions and the tags they are enabled by under tags
+func dependencySwitches(chart *helm.ChartMetadata) []schema.Switch {
+	var switches []schema.Switch
+	seen := make(map[string]bool)
+	add := func(path, description string) {
+		if !seen[path] {
+			seen[path] = true
+			switches = append(switches, schema.Switch{Path: path, Description: description})
+		}
+	}
+	for _, dep := range chart.Dependencies {
+		for _, path := range dep.ConditionPaths() {
+			add(path, fmt.Sprintf("Enables the %s dependency", dep.ValuesKey()))
+		}
+	}
+	for _, dep := range chart.Dependencies {
+		for _, tag := range dep.Tags {
+			add("tags."+tag, fmt.Sprintf("Enables the dependencies tagged %s", tag))
+		}
+	}
+	return switches
+}
+
+// checkSchema validates a generated schema against the meta-schema of its draft, failing or
+// warning on violations as mode says
+func checkSchema(generated map[string]any, mode string) error {
+	if mode == selfCheckOff {
+		return nil
+	}
+	violations, err := validate.CheckSchema(generated)
+	if err != nil {
+		return fmt.Errorf("checking the generated schema: %w", err)
+	}
+	if len(violations) == 0 {
+		return nil
+	}
+	if mode == selfCheckWarn {
+		for _, violation := range violations {
+			fmt.Fprintf(os.Stderr, "Warning: generated schema is invalid: %s\n", violation)
+		}
+		return nil
+	}
+	problems := make([]string, len(violations))
+	for i, violation := range violations {
+		problems[i] = "  " + violation.String()
+	}
+	return fmt.Errorf("generated schema is invalid against the %s meta-schema:\n%s", schema.DraftOf(generated), strings.Join(problems, "\n"))
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/template"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+	"helm-schema/pkg/schema"
+)
+
+// generation is the result model of generating a chart's schema, exposed to --template
+type generation struct {
+	Chart      *helm.ChartMetadata // Chart.yaml of the chart
+	Path       string              // Absolute chart directory
+	Schema     map[string]any      // Generated JSON schema
+	Values     []*parser.ValuePath // Values referenced by the chart and its subcharts, in path order
+	Warnings   []parser.Warning    // Constructs degrading the schema
+	Unresolved []parser.Unresolved // Constructs touching values that could not be resolved
+}
+
+// newGeneration collects the result model of a parsed chart
+func newGeneration(chartPath string, p *parser.TemplateParser, chartSchema map[string]any) (*generation, error) {
+	metadata, err := helm.ParseChartMetadata(chartPath)
+	if err != nil {
+		return nil, err
+	}
+
+	all := p.GetAllValues()
+	var values []*parser.ValuePath
+	for _, valuePath := range all {
+		values = append(values, valuePath)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Path < values[j].Path
+	})
+
+	return &generation{
+		Chart:      metadata,
+		Path:       chartPath,
+		Schema:     chartSchema,
+		Values:     values,
+		Warnings:   p.Warnings(),
+		Unresolved: p.Unresolved(),
+	}, nil
+}
+
+// templateFuncs are the functions available to --template besides the text/template builtins
+var templateFuncs = template.FuncMap{
+	"toJson": func(value any) (string, error) {
+		output, err := json.MarshalIndent(value, "", "  ")
+		return string(output), err
+	},
+	"join":      strings.Join,
+	"hasPrefix": strings.HasPrefix,
+	"required": func(values []*parser.ValuePath) []*parser.ValuePath {
+		var required []*parser.ValuePath
+		for _, valuePath := range values {
+			if valuePath.Required {
+				required = append(required, valuePath)
+			}
+		}
+		return required
+	},
+}
+
+// parseOutputTemplate parses a --template argument
+func parseOutputTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("output").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing --template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// renderGeneration executes an output template against the result model
+func renderGeneration(w io.Writer, tmpl *template.Template, result *generation) error {
+	if err := tmpl.Execute(w, result); err != nil {
+		return fmt.Errorf("executing --template: %w", err)
+	}
+	return nil
+}
+
+// renderSchema encodes a generated schema in an output format, ending with a newline
+func renderSchema(result *generation, format string) ([]byte, error) {
+	var rendered []byte
+	var err error
+	switch format {
+	case schema.FormatYAML:
+		return schema.MarshalYAML(result.Schema)
+	case schema.FormatOpenAPI:
+		rendered, err = schema.MarshalOpenAPI(result.Schema, result.Chart.Name, result.Chart.Version)
+	default:
+		rendered, err = schema.MarshalIndent(result.Schema, "", "  ")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("generating %s: %w", format, err)
+	}
+	return append(rendered, '\n'), nil
+}
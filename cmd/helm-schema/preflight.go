@@ -0,0 +1,183 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/release"
+	"helm-schema/pkg/validate"
+)
+
+// preflightResult records the outcome of checking an upgrade of one release
+type preflightResult struct {
+	Release     string               `json:"release"`
+	Namespace   string               `json:"namespace"`
+	FromChart   string               `json:"fromChart"`
+	FromVersion string               `json:"fromVersion"`
+	ToChart     string               `json:"toChart"`
+	ToVersion   string               `json:"toVersion"`
+	Violations  []validate.Violation `json:"violations,omitempty"`
+}
+
+// runPreflight validates the values a release would be upgraded with, its current values merged
+// with new values files, against the schema of the target chart version
+func runPreflight(args []string) error {
+	fs := flag.NewFlagSet("preflight", flag.ExitOnError)
+	toChart := fs.String("to-chart", "", "Target chart: a directory, a packaged chart (*.tgz) or a reference passed to helm pull")
+	chartVersion := fs.String("version", "", "Target chart version when pulling a chart reference")
+	var valuesFiles stringList
+	fs.Var(&valuesFiles, "f", "Values file applied on top of the release's current values (can be repeated)")
+	fs.Var(&valuesFiles, "values", "Alias for -f")
+	kubeconfig := fs.String("kubeconfig", "", "Path to the kubeconfig file")
+	kubeContext := fs.String("context", os.Getenv("HELM_KUBECONTEXT"), "Kubeconfig context to use (defaults to helm's --kube-context when run as a plugin)")
+	namespace := fs.String("namespace", "", "Namespace of the release")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s preflight [flags] <release> --to-chart <ref> [-f values.yaml]...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Flags may also follow the release name, as in helm upgrade
+	name := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+	if name == "" || fs.NArg() != 0 || *toChart == "" {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	client := &release.Client{Kubeconfig: *kubeconfig, Context: *kubeContext}
+	rel, err := findRelease(client, name, *namespace)
+	if err != nil {
+		return err
+	}
+
+	values := rel.Config
+	for _, file := range valuesFiles {
+		overlay, err := helm.LoadValuesFile(file)
+		if err != nil {
+			return err
+		}
+		values = helm.MergeValues(values, overlay)
+	}
+
+	dir, err := os.MkdirTemp("", "helm-schema-preflight-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	chartPath, err := resolveChart(*toChart, *chartVersion, dir)
+	if err != nil {
+		return err
+	}
+	metadata, err := helm.ParseChartMetadata(chartPath)
+	if err != nil {
+		return err
+	}
+
+	// Dependencies are checked by their own charts, so only their keys are admitted
+	cfg, err := chartGenerateConfig(chartPath)
+	if err != nil {
+		return err
+	}
+	cfg.IncludeSubcharts = false
+	generated, err := generateSchema(chartPath, cfg)
+	if err != nil {
+		return err
+	}
+	opaque := []string{"global"}
+	for _, dep := range metadata.Dependencies {
+		opaque = append(opaque, dep.ValuesKey())
+	}
+	allowOpaqueKeys(generated, opaque)
+
+	validator, err := validate.New(generated)
+	if err != nil {
+		return err
+	}
+
+	result := preflightResult{
+		Release:     rel.Name,
+		Namespace:   rel.Namespace,
+		FromChart:   rel.ChartName(),
+		FromVersion: rel.ChartVersion(),
+		ToChart:     metadata.Name,
+		ToVersion:   metadata.Version,
+	}
+	result.Violations, err = validator.Validate(values)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		status := "ok"
+		if len(result.Violations) > 0 {
+			status = fmt.Sprintf("%d invalid value(s)", len(result.Violations))
+		}
+		fmt.Printf("%s/%s (%s-%s -> %s-%s): %s\n", result.Namespace, result.Release, result.FromChart, result.FromVersion, result.ToChart, result.ToVersion, status)
+		for _, violation := range result.Violations {
+			fmt.Printf("  %s\n", violation)
+		}
+	}
+
+	if len(result.Violations) > 0 {
+		return fmt.Errorf("upgrade of %s to %s-%s would be rejected", rel.Name, result.ToChart, result.ToVersion)
+	}
+	return nil
+}
+
+// findRelease returns the deployed release with the given name, which must be unique across
+// namespaces unless a namespace is given
+func findRelease(client *release.Client, name, namespace string) (*release.Release, error) {
+	releases, err := client.Deployed(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *release.Release
+	for _, rel := range releases {
+		if rel.Name != name {
+			continue
+		}
+		if found != nil {
+			return nil, fmt.Errorf("release %s exists in namespaces %s and %s, use --namespace", name, found.Namespace, rel.Namespace)
+		}
+		found = rel
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no deployed release named %s", name)
+	}
+	return found, nil
+}
+
+// resolveChart returns a chart directory for a chart directory, packaged chart or chart
+// reference, unpacking into dir as needed
+func resolveChart(ref, version, dir string) (string, error) {
+	if info, err := os.Stat(ref); err == nil {
+		if info.IsDir() {
+			return ref, nil
+		}
+		if strings.HasSuffix(ref, ".tgz") {
+			return helm.ExtractChartArchive(ref, dir)
+		}
+		return "", fmt.Errorf("%s is neither a chart directory nor a packaged chart", ref)
+	}
+
+	if err := helm.EnsureHelmAvailable(); err != nil {
+		return "", err
+	}
+	return helm.PullChart(ref, version, dir)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"helm-schema/pkg/helm"
+)
+
+// cleanups run before the process ends, removing temporary directories such as those holding
+// pulled charts
+var cleanups []func()
+
+// exit runs the cleanups and ends the process with code
+func exit(code int) {
+	cleanup()
+	os.Exit(code)
+}
+
+// cleanup runs the cleanups registered so far, once
+func cleanup() {
+	for _, fn := range cleanups {
+		fn()
+	}
+	cleanups = nil
+}
+
+// isOCIReference reports whether a chart argument names a chart in an OCI registry rather than
+// a chart directory
+func isOCIReference(ref string) bool {
+	return strings.HasPrefix(ref, "oci://")
+}
+
+// pullChart pulls and unpacks a chart reference into a temporary directory removed by cleanup,
+// returning the chart directory. The latest version is pulled when version is empty.
+func pullChart(ref, version string) (string, error) {
+	if err := helm.EnsureHelmAvailable(); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp("", "helm-schema-pull-")
+	if err != nil {
+		return "", err
+	}
+	cleanups = append(cleanups, func() { os.RemoveAll(dir) })
+
+	chartPath, err := helm.PullChart(ref, version, dir)
+	if err != nil {
+		return "", fmt.Errorf("pulling %s: %w", ref, err)
+	}
+	return chartPath, nil
+}
+
+// downloadChart downloads and unpacks a chart of an HTTP chart repository into a temporary
+// directory removed by cleanup, returning the chart directory. The version is a constraint
+// resolved against the index of the repository, the latest stable version when empty.
+func downloadChart(repoURL, chart, version string) (string, error) {
+	dir, err := os.MkdirTemp("", "helm-schema-pull-")
+	if err != nil {
+		return "", err
+	}
+	cleanups = append(cleanups, func() { os.RemoveAll(dir) })
+
+	chartPath, _, err := helm.DownloadRepoChart(repoURL, chart, version, dir)
+	if err != nil {
+		return "", fmt.Errorf("downloading %s from %s: %w", chart, repoURL, err)
+	}
+	return chartPath, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+)
+
+// reportEntry is a value path found in a chart, as the report lists it
+type reportEntry struct {
+	Path       string   `json:"path"`
+	Subchart   string   `json:"subchart,omitempty"` // Subchart whose templates read the value, nested ones joined with /
+	Type       string   `json:"type"`
+	Types      []string `json:"types,omitempty"`
+	Confidence float64  `json:"confidence"`
+	Required   bool     `json:"required"`
+	Default    any      `json:"default,omitempty"`
+	Files      []string `json:"files"` // Relative to the chart or the subchart
+}
+
+// runReport lists every value path found in a chart with what was inferred about it, to explain
+// the schema generated from them
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	var valuesFiles stringList
+	fs.Var(&valuesFiles, "f", "Values file merged over the chart's values.yaml for the defaults (can be repeated)")
+	fs.Var(&valuesFiles, "values", "Alias for -f")
+	noSubcharts := fs.Bool("no-subcharts", false, "Skip parsing subcharts")
+	asJSON := fs.Bool("json", false, "Print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s report [flags] <helm-chart-path>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	absPath, err := filepath.Abs(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+	if err := helm.ValidateChartDirectory(absPath); err != nil {
+		return err
+	}
+
+	p := parser.NewWithOptions(parser.Options{ValuesFiles: valuesFiles})
+	if err := p.ParseChartWithOptions(absPath, !*noSubcharts); err != nil {
+		return fmt.Errorf("parsing chart: %w", err)
+	}
+
+	found := make(map[string]reportEntry)
+	collectReport(p, "", "", found)
+	entries := []reportEntry{}
+	for _, entry := range found {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Path < entries[j].Path
+	})
+
+	if *asJSON {
+		output, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PATH\tTYPE\tREQUIRED\tDEFAULT\tFILES")
+	for _, entry := range entries {
+		typ := entry.Type
+		if len(entry.Types) > 0 {
+			typ = strings.Join(entry.Types, "|")
+		}
+		required := "no"
+		if entry.Required {
+			required = "yes"
+		}
+		files := entry.Files
+		if entry.Subchart != "" {
+			files = nil
+			for _, file := range entry.Files {
+				files = append(files, entry.Subchart+":"+file)
+			}
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", entry.Path, typ, required, reportDefault(entry.Default), strings.Join(files, ","))
+	}
+	return w.Flush()
+}
+
+// collectReport adds the values of a parsed chart and its subcharts to the report, by path.
+// Subchart values are prefixed with the subchart key and replace those the parent reads at the
+// same path, as in the schema.
+func collectReport(p *parser.TemplateParser, prefix, subchart string, found map[string]reportEntry) {
+	for _, valuePath := range p.GetValues() {
+		entry := newReportEntry(prefix+valuePath.Path, valuePath)
+		entry.Subchart = subchart
+		found[entry.Path] = entry
+	}
+	for name, subchartParser := range p.GetSubcharts() {
+		nested := name
+		if subchart != "" {
+			nested = subchart + "/" + name
+		}
+		collectReport(subchartParser, prefix+name+".", nested, found)
+	}
+}
+
+// newReportEntry summarizes a value path, listing the files referencing it once each
+func newReportEntry(path string, valuePath *parser.ValuePath) reportEntry {
+	entry := reportEntry{
+		Path:       path,
+		Type:       valuePath.Type,
+		Types:      valuePath.Types,
+		Confidence: valuePath.Confidence,
+		Required:   valuePath.Required,
+		Default:    valuePath.Default,
+		Files:      []string{},
+	}
+	seen := make(map[string]bool)
+	for _, site := range valuePath.Sources {
+		if site.File != "" && !seen[site.File] {
+			seen[site.File] = true
+			entry.Files = append(entry.Files, site.File)
+		}
+	}
+	sort.Strings(entry.Files)
+	return entry
+}
+
+// reportDefault renders a default on a single line, - when there is none
+func reportDefault(value any) string {
+	if value == nil {
+		return "-"
+	}
+	output, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(output)
+}
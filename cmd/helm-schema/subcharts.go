@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"helm-schema/pkg/helm"
+)
+
+// writeSubchartSchemas generates the schema of every local subchart of a chart as a chart of its
+// own, writing it to the values.schema.json of the subchart directory so Helm validates each
+// chart of a monorepo installed by itself; nested local subcharts are written too. Hand-written
+// schemas are left alone unless merged with --merge-existing.
+func writeSubchartSchemas(chartPath string, cfg generateConfig) error {
+	deps, err := helm.FindLocalSubcharts(chartPath)
+	if err != nil {
+		return err
+	}
+
+	// The schema of the subchart itself, recording how it was produced so later runs tell it
+	// from a hand-written one
+	cfg.Export = false
+	cfg.Metadata = true
+	for _, dep := range deps {
+		subchartPath := dep.GetLocalSubchartPath(chartPath)
+		if helm.ValidateChartDirectory(subchartPath) != nil {
+			continue
+		}
+
+		existing, err := helm.LoadChartSchema(subchartPath)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return err
+		case existing["x-generation"] == nil && cfg.MergeExisting == "":
+			fmt.Fprintf(os.Stderr, "Warning: %s: keeping the hand-written %s, pass --merge-existing to update it\n", dep.Name, helm.SchemaFile)
+			continue
+		}
+
+		schemaJSON, err := chartToSchema(subchartPath, cfg)
+		if err != nil {
+			return fmt.Errorf("subchart %s: %w", dep.Name, err)
+		}
+		path := filepath.Join(subchartPath, helm.SchemaFile)
+		if err := os.WriteFile(path, []byte(schemaJSON+"\n"), 0644); err != nil {
+			return fmt.Errorf("writing schema of subchart %s: %w", dep.Name, err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+
+		if err := writeSubchartSchemas(subchartPath, cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
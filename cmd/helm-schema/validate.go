@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/validate"
+)
+
+// validateResult records the outcome of checking the values of a chart against its schema
+type validateResult struct {
+	Chart      string               `json:"chart"`
+	Schema     string               `json:"schema"`
+	Values     []string             `json:"values"`
+	Violations []validate.Violation `json:"violations,omitempty"`
+}
+
+// runValidate checks the values a chart would be installed with, its values.yaml merged with
+// values files the way helm install -f does, against the generated schema of the chart or a
+// given schema file
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	var valuesFiles stringList
+	fs.Var(&valuesFiles, "f", "Values file applied on top of the chart's values.yaml (can be repeated)")
+	fs.Var(&valuesFiles, "values", "Alias for -f")
+	schemaFile := fs.String("schema", "", "Validate against this schema file, e.g. the chart's values.schema.json, instead of generating the schema")
+	noSubcharts := fs.Bool("no-subcharts", false, "Generate the schema without parsing subcharts")
+	asJSON := fs.Bool("json", false, "Print the result as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s validate [flags] <helm-chart-path> [-f values.yaml]...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	// Flags may also follow the chart, as in helm install
+	chartPath := fs.Arg(0)
+	if fs.NArg() > 0 {
+		fs.Parse(fs.Args()[1:])
+	}
+	if chartPath == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+	if err := helm.ValidateChartDirectory(chartPath); err != nil {
+		return err
+	}
+
+	result := validateResult{Chart: chartPath, Schema: *schemaFile, Values: []string{}}
+	var generated map[string]any
+	if *schemaFile != "" {
+		data, err := os.ReadFile(*schemaFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(data, &generated); err != nil {
+			return fmt.Errorf("parsing %s: %w", *schemaFile, err)
+		}
+	} else {
+		cfg, err := chartGenerateConfig(chartPath)
+		if err != nil {
+			return err
+		}
+		if *noSubcharts {
+			cfg.IncludeSubcharts = false
+		}
+		if generated, err = generateSchema(chartPath, cfg); err != nil {
+			return err
+		}
+		result.Schema = "generated"
+	}
+	validator, err := validate.New(generated)
+	if err != nil {
+		return err
+	}
+
+	values, err := helm.LoadValuesFile(filepath.Join(chartPath, "values.yaml"))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		values = map[string]any{}
+	case err != nil:
+		return err
+	default:
+		result.Values = append(result.Values, filepath.Join(chartPath, "values.yaml"))
+	}
+	for _, file := range valuesFiles {
+		overlay, err := helm.LoadValuesFile(file)
+		if err != nil {
+			return err
+		}
+		values = helm.MergeValues(values, overlay)
+		result.Values = append(result.Values, file)
+	}
+
+	result.Violations, err = validator.Validate(values)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		output, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+	} else {
+		for _, violation := range result.Violations {
+			if violation.Pointer == "" {
+				fmt.Println(violation.Message)
+			} else {
+				fmt.Printf("%s: %s\n", violation.Pointer, violation.Message)
+			}
+		}
+	}
+
+	if len(result.Violations) > 0 {
+		return fmt.Errorf("%d invalid value(s)", len(result.Violations))
+	}
+	return nil
+}
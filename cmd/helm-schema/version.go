@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+
+	"helm-schema/pkg/schema"
+)
+
+// Build details injected with -ldflags "-X main.commit=... -X main.date=...", see the Makefile.
+// Builds without them, such as go install, fall back to what the Go toolchain recorded.
+var (
+	commit = ""
+	date   = ""
+)
+
+// buildInfo describes the running build, for bug reports and scripts asserting tool versions
+type buildInfo struct {
+	Version   string   `json:"version"`
+	Commit    string   `json:"commit"`
+	Date      string   `json:"date"`
+	GoVersion string   `json:"goVersion"`
+	Drafts    []string `json:"drafts"` // JSON Schema drafts --schema-draft targets
+}
+
+// currentBuild collects the build details, unknown when neither ldflags nor the toolchain set them
+func currentBuild() buildInfo {
+	info := buildInfo{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+		Drafts:    []string{schema.Draft202012, schema.Draft07},
+	}
+	if recorded, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "dev" && recorded.Main.Version != "" && recorded.Main.Version != "(devel)" {
+			info.Version = recorded.Main.Version
+		}
+		for _, setting := range recorded.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// runVersion prints the version, commit, build date and supported drafts of the build
+func runVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the build information as JSON")
+	short := fs.Bool("short", false, "Print the version only")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s version [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	info := currentBuild()
+	switch {
+	case *asJSON:
+		output, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return fmt.Errorf("generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+	case *short:
+		fmt.Println(info.Version)
+	default:
+		fmt.Printf("helm-schema %s\n", info.Version)
+		fmt.Printf("Commit: %s\n", info.Commit)
+		fmt.Printf("Built: %s\n", info.Date)
+		fmt.Printf("Go: %s\n", info.GoVersion)
+		fmt.Printf("JSON Schema drafts: %s, %s\n", info.Drafts[0], info.Drafts[1])
+	}
+	return nil
+}
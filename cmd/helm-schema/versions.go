@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/schema"
+)
+
+// versionIndexEntry records the schema generated for one published chart version and how it
+// differs from the previous matching version
+type versionIndexEntry struct {
+	Version  string `json:"version"`
+	Schema   string `json:"schema,omitempty"`
+	Previous string `json:"previous,omitempty"`
+	Diff     string `json:"diff,omitempty"`
+	Changes  int    `json:"changes"`
+	Breaking bool   `json:"breaking"`
+	Error    string `json:"error,omitempty"`
+}
+
+// versionIndex lists the schemas generated for the versions of a chart matching a range
+type versionIndex struct {
+	Chart      string              `json:"chart"`
+	Repo       string              `json:"repo,omitempty"`
+	Constraint string              `json:"constraint"`
+	Versions   []versionIndexEntry `json:"versions"`
+}
+
+// versionDiff is the content of a per-version diff file
+type versionDiff struct {
+	From    string          `json:"from"`
+	To      string          `json:"to"`
+	Changes []schema.Change `json:"changes"`
+}
+
+// versionsToSchemas generates a schema for every published version of a chart reference matching
+// the range, or of the chart named ref in the HTTP chart repository at repoURL when given, writing
+// <name>-<version>.schema.json files, <name>-<version>.diff.json files against the previous
+// matching version and an index.json into outDir
+func versionsToSchemas(repoURL, ref, versionRange, outDir string, cfg generateConfig) error {
+	constraint, err := helm.ParseConstraint(versionRange)
+	if err != nil {
+		return err
+	}
+
+	published, err := publishedVersions(repoURL, ref)
+	if err != nil {
+		return err
+	}
+	versions := helm.MatchingVersions(published, constraint)
+	if len(versions) == 0 {
+		return fmt.Errorf("no published version of %s matches %s", ref, versionRange)
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	index := versionIndex{Chart: ref, Repo: repoURL, Constraint: versionRange}
+	var previousVersion string
+	var previousSchema map[string]any
+	failures := 0
+	for _, version := range versions {
+		entry, chartSchema, name := versionToSchema(repoURL, ref, version.String(), outDir, cfg)
+		if entry.Error != "" {
+			fmt.Fprintf(os.Stderr, "Error: %s %s: %s\n", ref, entry.Version, entry.Error)
+			failures++
+			index.Versions = append(index.Versions, entry)
+			continue
+		}
+
+		if previousSchema != nil {
+			changes := schema.Compare(previousSchema, chartSchema)
+			entry.Previous = previousVersion
+			entry.Changes = len(changes)
+			entry.Breaking = schema.HasBreakingChanges(changes)
+			entry.Diff = fmt.Sprintf("%s-%s.diff.json", name, entry.Version)
+			diff := versionDiff{From: previousVersion, To: entry.Version, Changes: changes}
+			if err := writeJSON(filepath.Join(outDir, entry.Diff), diff); err != nil {
+				return err
+			}
+		}
+
+		index.Versions = append(index.Versions, entry)
+		previousVersion = entry.Version
+		previousSchema = chartSchema
+	}
+
+	if err := writeJSON(filepath.Join(outDir, "index.json"), index); err != nil {
+		return err
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("%d of %d chart versions failed", failures, len(versions))
+	}
+	return nil
+}
+
+// publishedVersions lists the versions of a chart reference, or of a chart of the HTTP chart
+// repository at repoURL when given
+func publishedVersions(repoURL, ref string) ([]string, error) {
+	if repoURL == "" {
+		return helm.ListChartVersions(ref)
+	}
+	index, err := helm.FetchRepoIndex(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	entries, ok := index.Entries[ref]
+	if !ok {
+		return nil, fmt.Errorf("chart %s not found in %s", ref, repoURL)
+	}
+	var versions []string
+	for _, entry := range entries {
+		versions = append(versions, entry.Version)
+	}
+	return versions, nil
+}
+
+// versionToSchema pulls a single chart version, or downloads it from the HTTP chart repository
+// at repoURL when given, and writes its schema into outDir, returning the schema and the chart
+// name for diffing
+func versionToSchema(repoURL, ref, version, outDir string, cfg generateConfig) (versionIndexEntry, map[string]any, string) {
+	entry := versionIndexEntry{Version: version}
+
+	tempDir, err := os.MkdirTemp("", "helm-schema-version-")
+	if err != nil {
+		entry.Error = err.Error()
+		return entry, nil, ""
+	}
+	defer os.RemoveAll(tempDir)
+
+	var chartPath string
+	if repoURL != "" {
+		chartPath, _, err = helm.DownloadRepoChart(repoURL, ref, version, tempDir)
+	} else {
+		chartPath, err = helm.PullChart(ref, version, tempDir)
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		return entry, nil, ""
+	}
+
+	metadata, err := helm.ParseChartMetadata(chartPath)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry, nil, ""
+	}
+
+	chartSchema, err := generateSchema(chartPath, cfg)
+	if err != nil {
+		entry.Error = err.Error()
+		return entry, nil, ""
+	}
+
+	entry.Schema = fmt.Sprintf("%s-%s.schema.json", metadata.Name, version)
+	if err := writeSchema(filepath.Join(outDir, entry.Schema), chartSchema); err != nil {
+		entry.Error = err.Error()
+		entry.Schema = ""
+		return entry, nil, ""
+	}
+
+	return entry, chartSchema, metadata.Name
+}
+
+// writeSchema writes a schema as indented JSON in keyword order followed by a newline
+func writeSchema(path string, chartSchema map[string]any) error {
+	output, err := schema.MarshalIndent(chartSchema, "", "  ")
+	if err != nil {
+		return fmt.Errorf("generating JSON: %w", err)
+	}
+	if err := os.WriteFile(path, append(output, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeJSON writes value as indented JSON followed by a newline
+func writeJSON(path string, value any) error {
+	output, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return fmt.Errorf("generating JSON: %w", err)
+	}
+	if err := os.WriteFile(path, append(output, '\n'), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return nil
+}
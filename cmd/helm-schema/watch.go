@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"helm-schema/pkg/helm"
+)
+
+// watchDebounce is how long watching waits for changes to settle before regenerating, so an
+// editor saving several files, or writing a file in several steps, triggers a single run
+const watchDebounce = 200 * time.Millisecond
+
+// watchChart runs regenerate once, then again whenever a file of the chart changes, until
+// interrupted. Failed runs are reported and watching goes on, so a template saved half-edited
+// does not end the session. Changes to the files regenerate writes, for which ignored reports
+// true, and to the temporary files they are written through, do not trigger a run.
+func watchChart(chartPath string, ignored func(path string) bool, regenerate func() error) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("watching %s: %w", chartPath, err)
+	}
+	defer watcher.Close()
+	if err := watchTree(watcher, chartPath); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	run := func() {
+		if err := regenerate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Watching %s for changes (press Ctrl+C to stop)\n", chartPath)
+	}
+	run()
+
+	debounce := time.NewTimer(watchDebounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("watching chart", "chart", chartPath, "error", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if ignored(event.Name) || isTemporaryWrite(event.Name) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			slog.Debug("chart changed", "file", event.Name, "op", event.Op.String())
+			// Directories created later, such as a new subchart, are watched too
+			if event.Op.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := watchTree(watcher, event.Name); err != nil {
+						slog.Warn("watching directory", "dir", event.Name, "error", err)
+					}
+				}
+			}
+			debounce.Reset(watchDebounce)
+		case <-debounce.C:
+			run()
+		}
+	}
+}
+
+// watchTree watches a directory and every directory below it, version control metadata aside,
+// since watches do not extend to subdirectories
+func watchTree(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			// Directories removed while walking are not watched
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		if entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("watching %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+// isTemporaryWrite reports whether a file is one writeFileAtomic writes before renaming it over
+// its target
+func isTemporaryWrite(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, ".") && strings.Contains(name, ".tmp-")
+}
+
+// watchSchema regenerates the schema of a chart on every change, writing it to output, or to the
+// chart's values.schema.json when output is empty, and to those of its subcharts with
+// writeSubcharts. Templates are cached, so only those that changed are parsed again.
+func watchSchema(chartPath, output, format string, writeSubcharts bool, cfg generateConfig) error {
+	if cfg.Parser.Cache == nil {
+		c, err := openCache()
+		if err != nil {
+			return err
+		}
+		cfg.Parser.Cache = c
+	}
+
+	path := output
+	if path == "" {
+		path = filepath.Join(chartPath, helm.SchemaFile)
+	}
+	target, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("resolving path: %w", err)
+	}
+	ignored := func(changed string) bool {
+		if writeSubcharts && filepath.Base(changed) == helm.SchemaFile {
+			return true
+		}
+		abs, err := filepath.Abs(changed)
+		return err == nil && abs == target
+	}
+
+	return watchChart(chartPath, ignored, func() error {
+		result, err := generate(chartPath, cfg)
+		if err != nil {
+			return err
+		}
+		rendered, err := renderSchema(result, format)
+		if err != nil {
+			return err
+		}
+		changed, err := writeSchemaIfChanged(path, rendered)
+		if err != nil {
+			return err
+		}
+		reportWrite(path, changed)
+		if writeSubcharts {
+			return writeSubchartSchemas(chartPath, cfg)
+		}
+		return nil
+	})
+}
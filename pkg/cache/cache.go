@@ -0,0 +1,341 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FormatVersion identifies the on-disk layout; entries written by other versions are discarded
+const FormatVersion = 1
+
+// Buckets group cache entries by the kind of data they hold. Only the values extracted from
+// templates, subcharts' included, are cached; other kinds of data get a bucket once cached.
+const (
+	BucketTemplates = "templates" // Value paths extracted from single template files
+)
+
+// DefaultMaxBytes bounds the cache size before least recently used entries are collected
+const DefaultMaxBytes int64 = 512 << 20
+
+// versionFile holds the format header at the cache root
+const versionFile = "VERSION"
+
+// versionDirRe matches per-version entry directories
+var versionDirRe = regexp.MustCompile(`^v\d+$`)
+
+// Cache is a versioned on-disk store laid out as <root>/v<version>/<bucket>/<key[:2]>/<key>
+type Cache struct {
+	root     string
+	MaxBytes int64
+
+	// size is the running total of the entries' sizes, counted on the first Put, so writes only
+	// walk the cache again when it grew beyond MaxBytes
+	mu    sync.Mutex
+	size  int64
+	sized bool
+}
+
+// Entry describes a single cached item
+type Entry struct {
+	Bucket  string
+	Key     string
+	Size    int64
+	ModTime time.Time
+	path    string
+}
+
+// DefaultDir returns the cache location, honoring HELM_SCHEMA_CACHE_DIR and, when running as a
+// Helm plugin, helm's HELM_CACHE_HOME
+func DefaultDir() (string, error) {
+	if dir := os.Getenv("HELM_SCHEMA_CACHE_DIR"); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("HELM_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "helm-schema"), nil
+	}
+
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("locating user cache directory: %w", err)
+	}
+
+	return filepath.Join(userCache, "helm-schema"), nil
+}
+
+// Open prepares a cache rooted at dir, discarding data written in another format version
+func Open(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating cache directory: %w", err)
+	}
+
+	c := &Cache{root: dir, MaxBytes: DefaultMaxBytes}
+	header := fmt.Sprintf("helm-schema-cache v%d\n", FormatVersion)
+
+	existing, err := os.ReadFile(filepath.Join(dir, versionFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("reading cache version: %w", err)
+	}
+
+	if string(existing) != header {
+		if err := c.removeStaleVersions(); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, versionFile), []byte(header), 0644); err != nil {
+			return nil, fmt.Errorf("writing cache version: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// Key derives a stable cache key from its parts
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Root returns the cache root directory
+func (c *Cache) Root() string {
+	return c.root
+}
+
+// Get returns the cached data for a key, refreshing its access time
+func (c *Cache) Get(bucket, key string) ([]byte, bool) {
+	path := c.entryPath(bucket, key)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	return data, true
+}
+
+// Put stores data under a key and collects old entries if the cache grew beyond MaxBytes
+func (c *Cache) Put(bucket, key string, data []byte) error {
+	path := c.entryPath(bucket, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("creating cache bucket: %w", err)
+	}
+
+	var replaced int64
+	if info, err := os.Stat(path); err == nil {
+		replaced = info.Size()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+
+	if c.MaxBytes <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.sized {
+		entries, err := c.Entries()
+		if err != nil {
+			return err
+		}
+		c.size = totalSize(entries)
+		c.sized = true
+	} else {
+		c.size += int64(len(data)) - replaced
+	}
+	if c.size <= c.MaxBytes {
+		return nil
+	}
+	_, err = c.prune(c.MaxBytes)
+	return err
+}
+
+// Entries lists all cached items, most recently used first
+func (c *Cache) Entries() ([]Entry, error) {
+	var entries []Entry
+	versionDir := c.versionDir()
+
+	err := filepath.WalkDir(versionDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == versionDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".tmp-") {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(versionDir, path)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, Entry{
+			Bucket:  strings.SplitN(filepath.ToSlash(rel), "/", 2)[0],
+			Key:     d.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+			path:    path,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing cache: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ModTime.After(entries[j].ModTime)
+	})
+
+	return entries, nil
+}
+
+// Prune removes least recently used entries until the cache holds at most maxBytes
+func (c *Cache) Prune(maxBytes int64) ([]Entry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.prune(maxBytes)
+}
+
+// prune implements Prune, recording the size left for Put; callers hold mu
+func (c *Cache) prune(maxBytes int64) ([]Entry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	total := totalSize(entries)
+	var removed []Entry
+	for i := len(entries) - 1; i >= 0 && total > maxBytes; i-- {
+		if err := os.Remove(entries[i].path); err != nil && !os.IsNotExist(err) {
+			c.sized = false
+			return removed, fmt.Errorf("pruning cache: %w", err)
+		}
+		total -= entries[i].Size
+		removed = append(removed, entries[i])
+	}
+
+	c.size = total
+	c.sized = true
+	return removed, nil
+}
+
+// Clear removes every cached entry while keeping the format header
+func (c *Cache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.RemoveAll(c.versionDir()); err != nil {
+		c.sized = false
+		return fmt.Errorf("clearing cache: %w", err)
+	}
+	c.size = 0
+	c.sized = true
+	return nil
+}
+
+// ParseSize converts sizes like 512MB, 2G or 1048576 into bytes
+func ParseSize(size string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"GB", 1 << 30}, {"G", 1 << 30},
+		{"MB", 1 << 20}, {"M", 1 << 20},
+		{"KB", 1 << 10}, {"K", 1 << 10},
+		{"B", 1},
+	}
+
+	trimmed := strings.ToUpper(strings.TrimSpace(size))
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(trimmed, unit.suffix) {
+			trimmed = strings.TrimSpace(strings.TrimSuffix(trimmed, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	value, err := strconv.ParseInt(trimmed, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+
+	return value * multiplier, nil
+}
+
+// totalSize sums the sizes of entries
+func totalSize(entries []Entry) int64 {
+	var total int64
+	for _, entry := range entries {
+		total += entry.Size
+	}
+	return total
+}
+
+// entryPath returns the file holding a cache entry
+func (c *Cache) entryPath(bucket, key string) string {
+	prefix := key
+	if len(prefix) > 2 {
+		prefix = prefix[:2]
+	}
+	return filepath.Join(c.versionDir(), bucket, prefix, key)
+}
+
+// versionDir returns the directory holding entries of the current format version
+func (c *Cache) versionDir() string {
+	return filepath.Join(c.root, fmt.Sprintf("v%d", FormatVersion))
+}
+
+// removeStaleVersions deletes data written by other format versions. Only v<N> directories are
+// touched so pointing the cache at a shared directory never removes unrelated files.
+func (c *Cache) removeStaleVersions() error {
+	entries, err := os.ReadDir(c.root)
+	if err != nil {
+		return fmt.Errorf("reading cache directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() || !versionDirRe.MatchString(entry.Name()) {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(c.root, entry.Name())); err != nil {
+			return fmt.Errorf("removing stale cache data: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPutGet(t *testing.T) {
+	c, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+
+	key := Key("chart", "digest")
+	if _, ok := c.Get(BucketTemplates, key); ok {
+		t.Error("Expected cache miss for unknown key")
+	}
+
+	if err := c.Put(BucketTemplates, key, []byte(`{"type":"object"}`)); err != nil {
+		t.Fatalf("Failed to put entry: %v", err)
+	}
+
+	data, ok := c.Get(BucketTemplates, key)
+	if !ok {
+		t.Fatal("Expected cache hit after put")
+	}
+	if string(data) != `{"type":"object"}` {
+		t.Errorf("Unexpected cached data: %s", data)
+	}
+
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("Failed to list entries: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Bucket != BucketTemplates || entries[0].Key != key {
+		t.Errorf("Unexpected entries: %+v", entries)
+	}
+}
+
+func TestOpenDiscardsOtherVersions(t *testing.T) {
+	dir := t.TempDir()
+
+	// Simulate data written by an older layout next to an unrelated file
+	if err := os.MkdirAll(filepath.Join(dir, "v0", "templates"), 0755); err != nil {
+		t.Fatalf("Failed to create stale version: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, versionFile), []byte("helm-schema-cache v0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write stale header: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0644); err != nil {
+		t.Fatalf("Failed to write unrelated file: %v", err)
+	}
+
+	if _, err := Open(dir); err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "v0")); !os.IsNotExist(err) {
+		t.Error("Expected stale version directory to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("Unrelated files must not be removed")
+	}
+
+	header, err := os.ReadFile(filepath.Join(dir, versionFile))
+	if err != nil || string(header) != "helm-schema-cache v1\n" {
+		t.Errorf("Expected current version header, got %q (%v)", header, err)
+	}
+}
+
+func TestPruneRemovesLeastRecentlyUsed(t *testing.T) {
+	c, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	c.MaxBytes = 0
+
+	for i, key := range []string{"old", "mid", "new"} {
+		if err := c.Put(BucketTemplates, key, make([]byte, 100)); err != nil {
+			t.Fatalf("Failed to put %s: %v", key, err)
+		}
+		stamp := time.Now().Add(time.Duration(i-3) * time.Hour)
+		os.Chtimes(c.entryPath(BucketTemplates, key), stamp, stamp)
+	}
+
+	removed, err := c.Prune(250)
+	if err != nil {
+		t.Fatalf("Failed to prune: %v", err)
+	}
+
+	if len(removed) != 1 || removed[0].Key != "old" {
+		t.Errorf("Expected only the oldest entry to be pruned, got %+v", removed)
+	}
+
+	if err := c.Clear(); err != nil {
+		t.Fatalf("Failed to clear: %v", err)
+	}
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("Failed to list entries after clear: %v", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected empty cache after clear, got %d entries", len(entries))
+	}
+}
+
+func TestPutCollectsBeyondMaxBytes(t *testing.T) {
+	c, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+	c.MaxBytes = 250
+
+	for i, key := range []string{"a", "b", "c", "d", "e"} {
+		if err := c.Put(BucketTemplates, key, make([]byte, 100)); err != nil {
+			t.Fatalf("Failed to put %s: %v", key, err)
+		}
+		stamp := time.Now().Add(time.Duration(i-5) * time.Hour)
+		os.Chtimes(c.entryPath(BucketTemplates, key), stamp, stamp)
+	}
+	// Replacing an entry does not count its old size twice
+	if err := c.Put(BucketTemplates, "e", make([]byte, 100)); err != nil {
+		t.Fatalf("Failed to replace e: %v", err)
+	}
+
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("Failed to list entries: %v", err)
+	}
+	var keys []string
+	for _, entry := range entries {
+		keys = append(keys, entry.Key)
+	}
+	if len(keys) != 2 || keys[0] != "e" || keys[1] != "d" {
+		t.Errorf("Expected the two most recent entries to be kept, got %v", keys)
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := map[string]int64{
+		"1024":  1024,
+		"2K":    2 << 10,
+		"512MB": 512 << 20,
+		"1g":    1 << 30,
+	}
+
+	for input, expected := range tests {
+		result, err := ParseSize(input)
+		if err != nil {
+			t.Errorf("ParseSize(%s) returned error: %v", input, err)
+		} else if result != expected {
+			t.Errorf("ParseSize(%s) = %d, expected %d", input, result, expected)
+		}
+	}
+
+	if _, err := ParseSize("lots"); err == nil {
+		t.Error("Expected error for invalid size")
+	}
+}
@@ -0,0 +1,86 @@
+package crosscheck
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+)
+
+// Coverage compares the values a chart's values.yaml sets with the values its templates read
+type Coverage struct {
+	Unused     []string `json:"unused"`     // Set in values.yaml, read by no template of the chart or its subcharts, in path order
+	Undeclared []string `json:"undeclared"` // Read by the chart's templates, not set in values.yaml, in path order
+}
+
+// CheckCoverage reports the dead configuration of a parsed chart, values its values.yaml sets that
+// no template reads, and its undocumented knobs, values its templates read that values.yaml does
+// not set. Values set for subcharts count as read when the subchart reads them, and global values
+// when any chart does; values only subcharts read are declared in their own values.yaml and not
+// checked here. Like Check, it cannot see values reached through tpl or computed keys.
+func CheckCoverage(chartPath string, p *parser.TemplateParser) (*Coverage, error) {
+	defaults, err := helm.LoadValuesFile(filepath.Join(chartPath, "values.yaml"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	found := p.GetAllValues()
+	addSubchartGlobals(p, found)
+	patterns := foundPatterns(found)
+	coverage := &Coverage{Unused: []string{}, Undeclared: []string{}}
+	for _, l := range leaves(defaults, nil) {
+		if !covered(patterns, parser.SplitPath(l.path)) {
+			coverage.Unused = append(coverage.Unused, l.path)
+		}
+	}
+
+	for path, valuePath := range p.GetValues() {
+		// Objects only holding the values read below them are not references themselves
+		if len(valuePath.Sources) == 0 {
+			continue
+		}
+		if !declared(defaults, parser.SplitPath(path)) {
+			coverage.Undeclared = append(coverage.Undeclared, path)
+		}
+	}
+	sort.Strings(coverage.Undeclared)
+	return coverage, nil
+}
+
+// addSubchartGlobals adds the global values subcharts read at their unprefixed paths, where the
+// values.yaml of the parent sets them
+func addSubchartGlobals(p *parser.TemplateParser, found map[string]*parser.ValuePath) {
+	for _, subchart := range p.GetSubcharts() {
+		for path, valuePath := range subchart.GetValues() {
+			if path == "global" || strings.HasPrefix(path, "global.") {
+				found[path] = valuePath
+			}
+		}
+		addSubchartGlobals(subchart, found)
+	}
+}
+
+// declared reports whether values.yaml sets the value at a path or a value holding it: the
+// items of lists and the entries of maps read with any key are not described key by key
+func declared(values map[string]any, segments []string) bool {
+	current := values
+	for _, segment := range segments {
+		if segment == parser.AnyKey {
+			return true
+		}
+		value, ok := current[parser.UnescapeKey(segment)]
+		if !ok {
+			return false
+		}
+		object, isObject := value.(map[string]any)
+		if strings.HasSuffix(segment, "[]") || !isObject {
+			return true
+		}
+		current = object
+	}
+	return true
+}
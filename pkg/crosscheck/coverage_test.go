@@ -0,0 +1,54 @@
+package crosscheck
+
+import (
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/parser"
+	"helm-schema/pkg/testutil"
+)
+
+func TestCheckCoverage(t *testing.T) {
+	chart := testutil.NewChart(t, "app").
+		Values(`image:
+  repository: nginx
+  tag: "1.25"
+resources:
+  limits:
+    cpu: 100m
+legacy:
+  enabled: false
+labels: {}
+global:
+  region: eu
+cache:
+  port: 6379
+  unknown: true
+`).
+		Template("deployment.yaml", `image: {{ .Values.image.repository }}:{{ .Values.image.tag }}
+resources: {{ toYaml .Values.resources }}
+{{- range $key, $value := .Values.labels }}
+{{ $key }}: {{ $value }}
+{{- end }}
+replicas: {{ .Values.replicas }}
+debug: {{ .Values.image.debug }}
+`)
+	chart.Subchart("cache").
+		Template("service.yaml", "port: {{ .Values.port }}\nregion: {{ .Values.global.region }}\n")
+
+	p := parser.New()
+	if err := p.ParseChart(chart.Dir()); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	coverage, err := CheckCoverage(chart.Dir(), p)
+	if err != nil {
+		t.Fatalf("Failed to check coverage: %v", err)
+	}
+	expected := &Coverage{
+		Unused:     []string{"cache.unknown", "legacy.enabled"},
+		Undeclared: []string{"image.debug", "replicas"},
+	}
+	if !reflect.DeepEqual(coverage, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, coverage)
+	}
+}
@@ -0,0 +1,283 @@
+package crosscheck
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+)
+
+// Renderer renders the manifests of a chart with overrides coalesced onto its defaults, as
+// helm.RenderChart does
+type Renderer func(chartPath string, overrides map[string]any) (string, error)
+
+// Ways a value was found to be consumed by the rendered chart
+const (
+	ReasonRendered = "appears in the rendered manifests"
+	ReasonChanged  = "changes the rendered manifests"
+	ReasonFailed   = "fails rendering when changed"
+)
+
+// Discrepancy is a value of the chart's values.yaml that the rendered manifests depend on,
+// though no template reference to it was found, typically because it is reached through tpl or
+// a computed key
+type Discrepancy struct {
+	Path    string // Value path, with keys escaped as in parser paths
+	Default any    // Value in values.yaml
+	Reason  string
+}
+
+// Report is the outcome of reconciling the values found in the templates with rendering
+type Report struct {
+	Discrepancies []Discrepancy // In path order
+	Unverified    []string      // Paths that could not be probed because rendering is not deterministic
+}
+
+// leaf is a value of values.yaml that is not a non-empty map
+type leaf struct {
+	path  string
+	keys  []string
+	value any
+}
+
+// probePrefix starts the marker values substituted for defaults while probing
+const probePrefix = "helm-schema-probe-"
+
+// Check renders a chart with its defaults and with the values of its values.yaml that no
+// template reference was found for replaced one at a time, reporting those the manifests depend
+// on. Strings, numbers, nulls, lists and empty maps are replaced with markers looked for in the
+// output, batched into a single render when the chart accepts them. Booleans are negated and the
+// output compared with the defaults, which requires rendering to be deterministic.
+func Check(chartPath string, found map[string]*parser.ValuePath, render Renderer) (*Report, error) {
+	defaults, err := helm.LoadValuesFile(filepath.Join(chartPath, "values.yaml"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &Report{}, nil
+		}
+		return nil, err
+	}
+
+	patterns := foundPatterns(found)
+	var unfound []leaf
+	for _, l := range leaves(defaults, nil) {
+		if !covered(patterns, parser.SplitPath(l.path)) {
+			unfound = append(unfound, l)
+		}
+	}
+	report := &Report{}
+	if len(unfound) == 0 {
+		return report, nil
+	}
+
+	baseline, err := render(chartPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("rendering chart defaults: %w", err)
+	}
+	again, err := render(chartPath, nil)
+	if err != nil {
+		return nil, fmt.Errorf("rendering chart defaults: %w", err)
+	}
+	deterministic := again == baseline
+
+	// Markers are looked for in a single render first, falling back to a render per value when
+	// the chart rejects the batch. Markers share their width, so none is a prefix of another.
+	width := len(fmt.Sprint(len(unfound)))
+	var marked []leaf
+	markers := make(map[string]any)
+	batch := make(map[string]any)
+	for i, l := range unfound {
+		if _, ok := l.value.(bool); ok {
+			continue
+		}
+		marker := markerFor(l.value, fmt.Sprintf("%s%0*d", probePrefix, width, i))
+		marked = append(marked, l)
+		markers[l.path] = marker
+		setValue(batch, l.keys, marker)
+	}
+	if len(marked) > 0 {
+		output, err := render(chartPath, batch)
+		for _, l := range marked {
+			reason := ""
+			if err == nil {
+				if strings.Contains(output, markerText(markers[l.path])) {
+					reason = ReasonRendered
+				}
+			} else {
+				reason = probe(chartPath, render, l, markers[l.path], "")
+			}
+			if reason != "" {
+				report.Discrepancies = append(report.Discrepancies, Discrepancy{Path: l.path, Default: l.value, Reason: reason})
+			}
+		}
+	}
+
+	for _, l := range unfound {
+		flag, ok := l.value.(bool)
+		if !ok {
+			continue
+		}
+		if !deterministic {
+			report.Unverified = append(report.Unverified, l.path)
+			continue
+		}
+		if reason := probe(chartPath, render, l, !flag, baseline); reason != "" {
+			report.Discrepancies = append(report.Discrepancies, Discrepancy{Path: l.path, Default: l.value, Reason: reason})
+		}
+	}
+
+	sort.Slice(report.Discrepancies, func(i, j int) bool {
+		return report.Discrepancies[i].Path < report.Discrepancies[j].Path
+	})
+	sort.Strings(report.Unverified)
+	return report, nil
+}
+
+// probe renders the chart with a single value replaced. Markers are looked for in the output;
+// other replacements are compared with the baseline output of the defaults.
+func probe(chartPath string, render Renderer, l leaf, replacement any, baseline string) string {
+	overrides := make(map[string]any)
+	setValue(overrides, l.keys, replacement)
+	output, err := render(chartPath, overrides)
+	switch {
+	case err != nil:
+		return ReasonFailed
+	case markerText(replacement) != "":
+		if strings.Contains(output, markerText(replacement)) {
+			return ReasonRendered
+		}
+	case output != baseline:
+		return ReasonChanged
+	}
+	return ""
+}
+
+// markerFor returns the replacement carrying marker for a value, keeping lists and maps of their
+// kind so templates ranging over them still render
+func markerFor(value any, marker string) any {
+	switch value.(type) {
+	case []any:
+		return []any{marker}
+	case map[string]any:
+		return map[string]any{marker: marker}
+	default:
+		return marker
+	}
+}
+
+// markerText returns the marker a replacement carries, or "" for other replacements
+func markerText(replacement any) string {
+	switch replacement := replacement.(type) {
+	case string:
+		if strings.HasPrefix(replacement, probePrefix) {
+			return replacement
+		}
+	case []any:
+		if len(replacement) == 1 {
+			return markerText(replacement[0])
+		}
+	case map[string]any:
+		for key := range replacement {
+			return markerText(key)
+		}
+	}
+	return ""
+}
+
+// leaves flattens values into the values that are not non-empty maps, in path order
+func leaves(values map[string]any, keys []string) []leaf {
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var flattened []leaf
+	for _, name := range names {
+		path := append(append([]string(nil), keys...), name)
+		if object, ok := values[name].(map[string]any); ok && len(object) > 0 {
+			flattened = append(flattened, leaves(object, path)...)
+			continue
+		}
+		escaped := make([]string, len(path))
+		for i, key := range path {
+			escaped[i] = parser.EscapeKey(key)
+		}
+		flattened = append(flattened, leaf{path: strings.Join(escaped, "."), keys: path, value: values[name]})
+	}
+	return flattened
+}
+
+// foundPatterns splits the paths found in the templates into segments without array markers,
+// since values.yaml leaves never descend into lists
+func foundPatterns(found map[string]*parser.ValuePath) [][]string {
+	patterns := make([][]string, 0, len(found))
+	for path := range found {
+		segments := parser.SplitPath(path)
+		for i, segment := range segments {
+			segments[i] = strings.TrimSuffix(segment, "[]")
+		}
+		patterns = append(patterns, segments)
+	}
+	return patterns
+}
+
+// covered reports whether a leaf is accounted for by the paths found in the templates: it is
+// found itself, a field or item of it is found, or an ancestor is found with none of its fields,
+// in which case the templates consume the ancestor as a whole, e.g. with toYaml
+func covered(patterns [][]string, segments []string) bool {
+	wholes := make(map[int]bool)
+	for _, pattern := range patterns {
+		n := min(len(pattern), len(segments))
+		if !matches(pattern[:n], segments[:n]) {
+			continue
+		}
+		if len(pattern) >= len(segments) {
+			return true
+		}
+		wholes[len(pattern)] = true
+	}
+
+	// An ancestor found with none of its fields
+	for depth := range wholes {
+		extended := false
+		for _, pattern := range patterns {
+			if len(pattern) > depth && matches(pattern[:depth], segments[:depth]) {
+				extended = true
+				break
+			}
+		}
+		if !extended {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether path segments match the segments of a found path, where * matches any
+// key
+func matches(pattern, segments []string) bool {
+	for i := range pattern {
+		if pattern[i] != parser.AnyKey && pattern[i] != segments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// setValue sets the value at a key path of nested maps, creating the maps on the way
+func setValue(values map[string]any, keys []string, value any) {
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := values[key].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+			values[key] = child
+		}
+		values = child
+	}
+	values[keys[len(keys)-1]] = value
+}
@@ -0,0 +1,128 @@
+package crosscheck
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+
+	"helm-schema/pkg/helm"
+	"helm-schema/pkg/parser"
+	"helm-schema/pkg/testutil"
+)
+
+// templateRenderer renders a single template against the chart defaults coalesced with the
+// overrides, standing in for helm template
+func templateRenderer(t *testing.T, text string) Renderer {
+	t.Helper()
+	tmpl := template.Must(template.New("manifest").Funcs(template.FuncMap{
+		"double": func(n int) int { return 2 * n },
+	}).Option("missingkey=zero").Parse(text))
+
+	return func(chartPath string, overrides map[string]any) (string, error) {
+		defaults, err := helm.LoadValuesFile(filepath.Join(chartPath, "values.yaml"))
+		if err != nil {
+			return "", err
+		}
+		var output strings.Builder
+		if err := tmpl.Execute(&output, map[string]any{"Values": helm.MergeValues(defaults, overrides)}); err != nil {
+			return "", err
+		}
+		return output.String(), nil
+	}
+}
+
+// foundPaths builds the values found in the templates from their paths
+func foundPaths(paths ...string) map[string]*parser.ValuePath {
+	found := make(map[string]*parser.ValuePath)
+	for _, path := range paths {
+		found[path] = &parser.ValuePath{Path: path}
+	}
+	return found
+}
+
+const crosscheckValues = `image:
+  repository: nginx
+  tag: "1.25"
+  pullPolicy: IfNotPresent
+debug: false
+ports:
+  http: 8080
+resources:
+  limits:
+    cpu: 100m
+replicas: 2
+unused: value
+`
+
+func TestCheckReportsValuesConsumedWhenRendered(t *testing.T) {
+	chart := testutil.NewChart(t, "app").Values(crosscheckValues)
+	render := templateRenderer(t, `image: {{ .Values.image.repository }}:{{ .Values.image.tag }}
+{{- if .Values.debug }}
+debug: true
+{{- end }}
+port: {{ index .Values.ports "http" }}
+replicas: {{ double .Values.replicas }}
+resources: {{ .Values.resources }}
+`)
+	found := foundPaths("image", "image.repository", "image.tag", "resources")
+
+	report, err := Check(chart.Dir(), found, render)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	expected := []Discrepancy{
+		{Path: "debug", Default: false, Reason: ReasonChanged},
+		{Path: "ports.http", Default: 8080, Reason: ReasonRendered},
+		{Path: "replicas", Default: 2, Reason: ReasonFailed},
+	}
+	if !reflect.DeepEqual(report.Discrepancies, expected) {
+		t.Errorf("Expected discrepancies %+v, got %+v", expected, report.Discrepancies)
+	}
+	if len(report.Unverified) != 0 {
+		t.Errorf("Expected every value to be verified, got %v", report.Unverified)
+	}
+}
+
+func TestCheckSkipsDiffsOfNondeterministicCharts(t *testing.T) {
+	chart := testutil.NewChart(t, "app").Values(crosscheckValues)
+	base := templateRenderer(t, `{{ .Values.unused }}{{ if .Values.debug }}debug{{ end }}`)
+	renders := 0
+	render := func(chartPath string, overrides map[string]any) (string, error) {
+		renders++
+		output, err := base(chartPath, overrides)
+		return fmt.Sprintf("%s\nnonce: %d", output, renders), err
+	}
+
+	report, err := Check(chart.Dir(), foundPaths("image", "ports", "resources", "replicas"), render)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+
+	expected := []Discrepancy{{Path: "unused", Default: "value", Reason: ReasonRendered}}
+	if !reflect.DeepEqual(report.Discrepancies, expected) {
+		t.Errorf("Expected discrepancies %+v, got %+v", expected, report.Discrepancies)
+	}
+	if !reflect.DeepEqual(report.Unverified, []string{"debug"}) {
+		t.Errorf("Expected debug to be unverified, got %v", report.Unverified)
+	}
+}
+
+func TestCheckWithoutUnfoundValuesSkipsRendering(t *testing.T) {
+	chart := testutil.NewChart(t, "app").Values("replicas: 1\n")
+	render := func(string, map[string]any) (string, error) {
+		t.Fatal("Expected no render when every value is found")
+		return "", nil
+	}
+
+	report, err := Check(chart.Dir(), foundPaths("replicas"), render)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(report.Discrepancies) != 0 {
+		t.Errorf("Expected no discrepancies, got %+v", report.Discrepancies)
+	}
+}
@@ -0,0 +1,167 @@
+package doctor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/helm"
+)
+
+// Finding kinds reported by the doctor
+const (
+	KindDynamicAccess     = "dynamic-access"
+	KindTpl               = "tpl"
+	KindHelperIndirection = "helper-indirection"
+	KindHelperValues      = "helper-values"
+)
+
+// Finding describes a single construct that limits schema accuracy
+type Finding struct {
+	Kind       string `json:"kind"`
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Snippet    string `json:"snippet"`
+	Suggestion string `json:"suggestion"`
+}
+
+// Report summarizes how amenable a chart is to accurate schema generation
+type Report struct {
+	Chart           string    `json:"chart"`
+	Templates       int       `json:"templates"`
+	Helpers         int       `json:"helpers"`
+	ValueReferences int       `json:"valueReferences"`
+	Findings        []Finding `json:"findings"`
+}
+
+var (
+	// Match: {{ ... }} actions, possibly spanning lines
+	actionRe = regexp.MustCompile(`(?s)\{\{.*?\}\}`)
+	// Match: .Values.path
+	valueRefRe = regexp.MustCompile(`\.Values\.[a-zA-Z]`)
+	// Match: .Values used as a whole, e.g. toYaml .Values
+	wholeValuesRe = regexp.MustCompile(`\.Values(?:[^.\w]|$)`)
+	// Match: index/get with a non-literal key, e.g. index .Values.config $key
+	dynamicKeyRe = regexp.MustCompile(`\b(?:index|get)\s+\$?\.Values(?:\.[\w.]+)?\s+[$(.]`)
+	// Match: tpl function calls
+	tplRe = regexp.MustCompile(`\btpl\s`)
+	// Match: include "name" . / template "name" $
+	rootContextRe = regexp.MustCompile(`\b(?:include|template)\s+"([^"]+)"\s+[.$]\s*[|)}]`)
+)
+
+// Diagnose analyzes the templates of a chart for constructs that static analysis cannot resolve
+func Diagnose(chartPath string) (*Report, error) {
+	templateFiles, err := helm.FindTemplates(chartPath)
+	if err != nil {
+		return nil, err
+	}
+
+	helperFiles, err := helm.FindHelpers(chartPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{
+		Chart:     chartPath,
+		Templates: len(templateFiles),
+		Helpers:   len(helperFiles),
+	}
+
+	for _, file := range templateFiles {
+		if err := report.scanFile(chartPath, file, false); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, file := range helperFiles {
+		if err := report.scanFile(chartPath, file, true); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		if report.Findings[i].File != report.Findings[j].File {
+			return report.Findings[i].File < report.Findings[j].File
+		}
+		return report.Findings[i].Line < report.Findings[j].Line
+	})
+
+	return report, nil
+}
+
+// Count returns the number of findings of the given kind
+func (r *Report) Count(kind string) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// scanFile records value references and findings for a single template file
+func (r *Report) scanFile(chartPath, filePath string, helper bool) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read template file %s: %w", filePath, err)
+	}
+
+	relPath, err := filepath.Rel(chartPath, filePath)
+	if err != nil {
+		relPath = filePath
+	}
+
+	contentStr := string(content)
+	for _, loc := range actionRe.FindAllStringIndex(contentStr, -1) {
+		action := contentStr[loc[0]:loc[1]]
+
+		// Template comments cannot reference values
+		if strings.HasPrefix(strings.TrimLeft(action, "{- "), "/*") {
+			continue
+		}
+
+		line := strings.Count(contentStr[:loc[0]], "\n") + 1
+		refs := len(valueRefRe.FindAllStringIndex(action, -1))
+		r.ValueReferences += refs
+
+		if helper && refs > 0 {
+			r.add(KindHelperValues, relPath, line, action,
+				"helper bodies are resolved only through their callers; pass the needed subtree explicitly, e.g. (dict \"cfg\" .Values.<key>)")
+		}
+
+		if dynamicKeyRe.MatchString(action) {
+			r.add(KindDynamicAccess, relPath, line, action,
+				"lookup uses a computed key; reference concrete keys (.Values.a.b) or iterate with range so the key set is visible")
+		} else if wholeValuesRe.MatchString(action) {
+			r.add(KindDynamicAccess, relPath, line, action,
+				".Values is consumed as a whole; select the specific subtree (e.g. toYaml .Values.<key>) instead")
+		}
+
+		if tplRe.MatchString(action) {
+			r.add(KindTpl, relPath, line, action,
+				"tpl renders value strings at runtime; document the expected keys in values.yaml or replace tpl with direct references")
+		}
+
+		if match := rootContextRe.FindStringSubmatch(action); match != nil {
+			r.add(KindHelperIndirection, relPath, line, action,
+				fmt.Sprintf("helper %q receives the whole root context; pass only the values it needs, e.g. (dict \"cfg\" .Values.<key>)", match[1]))
+		}
+	}
+
+	return nil
+}
+
+// add appends a finding with a condensed snippet
+func (r *Report) add(kind, file string, line int, action, suggestion string) {
+	r.Findings = append(r.Findings, Finding{
+		Kind:       kind,
+		File:       file,
+		Line:       line,
+		Snippet:    strings.Join(strings.Fields(action), " "),
+		Suggestion: suggestion,
+	})
+}
@@ -0,0 +1,84 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiagnoseCleanChart(t *testing.T) {
+	report, err := Diagnose("../../test-charts/basic")
+	if err != nil {
+		t.Fatalf("Failed to diagnose basic chart: %v", err)
+	}
+
+	if report.Templates != 3 {
+		t.Errorf("Expected 3 templates, got %d", report.Templates)
+	}
+
+	if report.ValueReferences == 0 {
+		t.Error("Expected to count .Values references in basic chart")
+	}
+
+	if len(report.Findings) != 0 {
+		t.Errorf("Expected no findings for basic chart, got %d", len(report.Findings))
+		for _, finding := range report.Findings {
+			t.Logf("Found: %+v", finding)
+		}
+	}
+}
+
+func TestDiagnoseOpaqueConstructs(t *testing.T) {
+	chartDir := t.TempDir()
+	templatesDir := filepath.Join(chartDir, "templates")
+	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+		t.Fatalf("Failed to create templates directory: %v", err)
+	}
+
+	template := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ include "app.fullname" . }}
+data:
+  {{- range $key := .Values.keys }}
+  {{ $key }}: {{ index .Values.config $key | quote }}
+  {{- end }}
+  rendered: {{ tpl .Values.template . | quote }}
+  all: {{ toYaml .Values | nindent 4 }}
+`
+	helpers := `{{- define "app.fullname" -}}
+{{ .Values.nameOverride | default .Chart.Name }}
+{{- end }}
+`
+
+	if err := os.WriteFile(filepath.Join(templatesDir, "configmap.yaml"), []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templatesDir, "_helpers.tpl"), []byte(helpers), 0644); err != nil {
+		t.Fatalf("Failed to write helpers: %v", err)
+	}
+
+	report, err := Diagnose(chartDir)
+	if err != nil {
+		t.Fatalf("Failed to diagnose chart: %v", err)
+	}
+
+	expected := map[string]int{
+		KindDynamicAccess:     2,
+		KindTpl:               1,
+		KindHelperIndirection: 1,
+		KindHelperValues:      1,
+	}
+
+	for kind, count := range expected {
+		if got := report.Count(kind); got != count {
+			t.Errorf("Expected %d %s findings, got %d", count, kind, got)
+		}
+	}
+
+	for _, finding := range report.Findings {
+		if finding.Suggestion == "" {
+			t.Errorf("Finding %s at %s:%d has no suggestion", finding.Kind, finding.File, finding.Line)
+		}
+	}
+}
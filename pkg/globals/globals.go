@@ -0,0 +1,89 @@
+package globals
+
+import (
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/parser"
+)
+
+// globalPrefix is the root key Helm shares between a chart and all of its subcharts
+const globalPrefix = "global"
+
+// Usage records how a single chart treats a global value
+type Usage struct {
+	Chart string `json:"chart"`
+	Type  string `json:"type"`
+}
+
+// Conflict describes a global value that charts treat as incompatible types
+type Conflict struct {
+	Path   string  `json:"path"`
+	Usages []Usage `json:"usages"`
+}
+
+// Checker collects global value usages across charts and reports type conflicts
+type Checker struct {
+	usages map[string][]Usage
+}
+
+// New creates an empty global consistency checker
+func New() *Checker {
+	return &Checker{usages: make(map[string][]Usage)}
+}
+
+// AddChart records the global values referenced by a parsed chart and its subcharts.
+// Subcharts are recorded as <chart>/<subchart> since they read the same globals.
+func (c *Checker) AddChart(name string, p *parser.TemplateParser) {
+	for path, valuePath := range p.GetValues() {
+		if path != globalPrefix && !strings.HasPrefix(path, globalPrefix+".") {
+			continue
+		}
+		c.usages[path] = append(c.usages[path], Usage{Chart: name, Type: valuePath.Type})
+	}
+
+	for subchartName, subchartParser := range p.GetSubcharts() {
+		c.AddChart(name+"/"+subchartName, subchartParser)
+	}
+}
+
+// Paths returns every global value path seen so far
+func (c *Checker) Paths() []string {
+	var paths []string
+	for path := range c.usages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// Conflicts returns the global values whose known types disagree between charts.
+// Usages of unknown type are compatible with anything and are omitted.
+func (c *Checker) Conflicts() []Conflict {
+	var conflicts []Conflict
+	for _, path := range c.Paths() {
+		var typed []Usage
+		types := make(map[string]bool)
+		for _, usage := range c.usages[path] {
+			if usage.Type == "unknown" {
+				continue
+			}
+			typed = append(typed, usage)
+			types[usage.Type] = true
+		}
+
+		if len(types) < 2 {
+			continue
+		}
+
+		sort.Slice(typed, func(i, j int) bool {
+			if typed[i].Type != typed[j].Type {
+				return typed[i].Type < typed[j].Type
+			}
+			return typed[i].Chart < typed[j].Chart
+		})
+		conflicts = append(conflicts, Conflict{Path: path, Usages: typed})
+	}
+
+	return conflicts
+}
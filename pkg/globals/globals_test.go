@@ -0,0 +1,66 @@
+package globals
+
+import (
+	"testing"
+
+	"helm-schema/pkg/parser"
+	"helm-schema/pkg/testutil"
+)
+
+func TestConflictingGlobals(t *testing.T) {
+	charts := map[string]string{
+		"api":    `image: {{ .Values.global.registry | b64enc }}/api`,
+		"worker": `image: {{ .Values.global.registry.url }}/worker {{ .Values.global.pullPolicy }}`,
+		"web":    `image: {{ .Values.global.registry }}/web {{ .Values.global.pullPolicy }}`,
+	}
+
+	checker := New()
+	for name, template := range charts {
+		p := parser.New()
+		if err := p.ParseChartWithOptions(testutil.NewChart(t, name).Template("deployment.yaml", template).Dir(), false); err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		checker.AddChart(name, p)
+	}
+
+	conflicts := checker.Conflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %d: %+v", len(conflicts), conflicts)
+	}
+
+	conflict := conflicts[0]
+	if conflict.Path != "global.registry" {
+		t.Errorf("Expected conflict on global.registry, got %s", conflict.Path)
+	}
+
+	expected := []Usage{{Chart: "worker", Type: "object"}, {Chart: "api", Type: "string"}}
+	if len(conflict.Usages) != len(expected) {
+		t.Fatalf("Expected usages %+v, got %+v", expected, conflict.Usages)
+	}
+	for i, usage := range expected {
+		if conflict.Usages[i] != usage {
+			t.Errorf("Usage %d: expected %+v, got %+v", i, usage, conflict.Usages[i])
+		}
+	}
+
+	paths := checker.Paths()
+	if len(paths) != 4 {
+		t.Errorf("Expected 4 global paths, got %v", paths)
+	}
+}
+
+func TestConsistentGlobals(t *testing.T) {
+	checker := New()
+	for _, name := range []string{"a", "b"} {
+		p := parser.New()
+		chartDir := testutil.NewChart(t, name).Template("deployment.yaml", `{{ .Values.global.registry.url }} {{ .Values.name }}`).Dir()
+		if err := p.ParseChartWithOptions(chartDir, false); err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+		checker.AddChart(name, p)
+	}
+
+	if conflicts := checker.Conflicts(); len(conflicts) != 0 {
+		t.Errorf("Expected no conflicts, got %+v", conflicts)
+	}
+}
@@ -0,0 +1,144 @@
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FindChartArchives discovers all packaged charts (*.tgz) directly inside a directory
+func FindChartArchives(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive directory %s: %w", dir, err)
+	}
+
+	var archives []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".tgz") {
+			archives = append(archives, filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	sort.Strings(archives)
+	return archives, nil
+}
+
+// ExtractChartArchive unpacks a packaged chart into destDir and returns the chart directory.
+// Dependencies vendored as charts/*.tgz are unpacked in place so they can be parsed as subcharts.
+func ExtractChartArchive(archivePath, destDir string) (string, error) {
+	roots, err := extractTarGz(archivePath, destDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s: %w", archivePath, err)
+	}
+
+	if len(roots) != 1 {
+		return "", fmt.Errorf("expected a single chart directory in %s, found %d", archivePath, len(roots))
+	}
+
+	chartPath := filepath.Join(destDir, roots[0])
+	if err := extractVendoredDependencies(chartPath); err != nil {
+		return "", err
+	}
+
+	return chartPath, nil
+}
+
+// extractVendoredDependencies unpacks charts/*.tgz of a chart and its subcharts recursively
+func extractVendoredDependencies(chartPath string) error {
+	chartsDir := filepath.Join(chartPath, "charts")
+	archives, err := FindChartArchives(chartsDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	for _, archive := range archives {
+		roots, err := extractTarGz(archive, chartsDir)
+		if err != nil {
+			return fmt.Errorf("failed to extract dependency %s: %w", archive, err)
+		}
+		if err := os.Remove(archive); err != nil {
+			return err
+		}
+		for _, root := range roots {
+			if err := extractVendoredDependencies(filepath.Join(chartsDir, root)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractTarGz unpacks a gzipped tarball into destDir and returns its top-level directory names
+func extractTarGz(archivePath, destDir string) ([]string, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	roots := make(map[string]bool)
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("illegal path in archive: %s", header.Name)
+		}
+		roots[strings.SplitN(name, string(filepath.Separator), 2)[0]] = true
+
+		target := filepath.Join(destDir, name)
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := io.Copy(out, reader); err != nil {
+				out.Close()
+				return nil, err
+			}
+			if err := out.Close(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var names []string
+	for root := range roots {
+		names = append(names, root)
+	}
+	sort.Strings(names)
+	return names, nil
+}
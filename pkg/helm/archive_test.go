@@ -0,0 +1,106 @@
+package helm
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTarGz creates a gzipped tarball containing the given files
+func writeTarGz(t *testing.T, archivePath string, files map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to create archive: %v", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		header := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write content: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+}
+
+func TestExtractChartArchive(t *testing.T) {
+	workDir := t.TempDir()
+
+	// Dependency packaged inside the parent chart as charts/cache-1.0.0.tgz
+	depArchive := filepath.Join(workDir, "cache-1.0.0.tgz")
+	writeTarGz(t, depArchive, map[string]string{
+		"cache/Chart.yaml":               "apiVersion: v2\nname: cache\nversion: 1.0.0\n",
+		"cache/templates/configmap.yaml": "port: {{ .Values.port }}\n",
+	})
+	depContent, err := os.ReadFile(depArchive)
+	if err != nil {
+		t.Fatalf("Failed to read dependency archive: %v", err)
+	}
+
+	archive := filepath.Join(workDir, "app-0.1.0.tgz")
+	writeTarGz(t, archive, map[string]string{
+		"app/Chart.yaml":                "apiVersion: v2\nname: app\nversion: 0.1.0\ndependencies:\n  - name: cache\n    version: 1.0.0\n    repository: https://example.com/charts\n",
+		"app/templates/deployment.yaml": "name: {{ .Values.name }}\n",
+		"app/charts/cache-1.0.0.tgz":    string(depContent),
+	})
+
+	archives, err := FindChartArchives(workDir)
+	if err != nil {
+		t.Fatalf("Failed to find archives: %v", err)
+	}
+	if len(archives) != 2 {
+		t.Errorf("Expected 2 archives, got %d", len(archives))
+	}
+
+	destDir := t.TempDir()
+	chartPath, err := ExtractChartArchive(archive, destDir)
+	if err != nil {
+		t.Fatalf("Failed to extract archive: %v", err)
+	}
+
+	if chartPath != filepath.Join(destDir, "app") {
+		t.Errorf("Expected chart path %s, got %s", filepath.Join(destDir, "app"), chartPath)
+	}
+
+	if err := ValidateChartDirectory(chartPath); err != nil {
+		t.Errorf("Extracted chart is invalid: %v", err)
+	}
+
+	if err := ValidateChartDirectory(filepath.Join(chartPath, "charts", "cache")); err != nil {
+		t.Errorf("Vendored dependency was not unpacked: %v", err)
+	}
+
+	vendored, err := RemoteDependenciesVendored(chartPath)
+	if err != nil {
+		t.Fatalf("Failed to check vendored dependencies: %v", err)
+	}
+	if !vendored {
+		t.Error("Expected remote dependency to be satisfied by the vendored chart")
+	}
+}
+
+func TestExtractChartArchiveRejectsTraversal(t *testing.T) {
+	workDir := t.TempDir()
+	archive := filepath.Join(workDir, "evil.tgz")
+	writeTarGz(t, archive, map[string]string{
+		"../escape.yaml": "oops",
+	})
+
+	if _, err := ExtractChartArchive(archive, t.TempDir()); err == nil {
+		t.Error("Expected error for archive entry escaping the destination")
+	}
+}
@@ -1,12 +1,18 @@
 package helm
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"io/fs"
+	"log/slog"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 )
 
@@ -23,8 +29,56 @@ type Dependency struct {
 	Name       string   `yaml:"name"`
 	Version    string   `yaml:"version"`
 	Repository string   `yaml:"repository"`
+	Alias      string   `yaml:"alias,omitempty"`
 	Condition  string   `yaml:"condition,omitempty"`
 	Tags       []string `yaml:"tags,omitempty"`
+	// ImportValues copies values of the dependency into the parent chart's values
+	ImportValues []ImportValue `yaml:"import-values,omitempty"`
+}
+
+// ImportValue maps a path of a dependency's values to the path of the parent's values it is
+// copied to. Both are dot separated keys; a parent of "." or "" is the parent's root.
+type ImportValue struct {
+	Child  string `yaml:"child"`
+	Parent string `yaml:"parent"`
+}
+
+// UnmarshalYAML accepts both the child/parent form and the short form naming an entry of the
+// dependency's exports, whose contents are copied to the parent's root
+func (iv *ImportValue) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		iv.Child = "exports." + node.Value
+		iv.Parent = "."
+		return nil
+	}
+
+	type mapping ImportValue
+	return node.Decode((*mapping)(iv))
+}
+
+// ParentPath returns the parent path without the root marker, empty for the root
+func (iv ImportValue) ParentPath() string {
+	return strings.Trim(iv.Parent, ".")
+}
+
+// ValuesKey returns the top-level values key configuring the dependency
+func (d *Dependency) ValuesKey() string {
+	if d.Alias != "" {
+		return d.Alias
+	}
+	return d.Name
+}
+
+// ConditionPaths returns the values paths of the dependency's condition, a comma separated list
+// of which the first one set enables or disables the dependency
+func (d *Dependency) ConditionPaths() []string {
+	var paths []string
+	for _, path := range strings.Split(d.Condition, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
 }
 
 // ValidateChartDirectory ensures the provided path contains a valid Helm chart structure
@@ -42,18 +96,47 @@ func ValidateChartDirectory(chartPath string) error {
 	return nil
 }
 
+// TemplateOptions selects which template files are discovered
+type TemplateOptions struct {
+	// SkipTests leaves out Helm test hooks under templates/tests/
+	SkipTests bool
+	// Exclude lists glob patterns (path.Match syntax) matched against paths relative to the
+	// chart, e.g. templates/legacy/* or templates/*-debug.yaml; a matching directory is skipped
+	// entirely
+	Exclude []string
+}
+
 // FindTemplates discovers all YAML template files in the chart's templates directory
 func FindTemplates(chartPath string) ([]string, error) {
+	return FindTemplatesWithOptions(chartPath, TemplateOptions{})
+}
+
+// FindTemplatesWithOptions discovers the YAML template files in the chart's templates directory
+// that are not excluded
+func FindTemplatesWithOptions(chartPath string, opts TemplateOptions) ([]string, error) {
+	for _, pattern := range opts.Exclude {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+
 	var templateFiles []string
 	templatesDir := filepath.Join(chartPath, "templates")
 
-	err := filepath.WalkDir(templatesDir, func(path string, d fs.DirEntry, err error) error {
+	err := filepath.WalkDir(templatesDir, func(filePath string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		if !d.IsDir() && (strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml")) {
-			templateFiles = append(templateFiles, path)
+		if opts.excludes(chartPath, filePath) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() && (strings.HasSuffix(filePath, ".yaml") || strings.HasSuffix(filePath, ".yml")) {
+			templateFiles = append(templateFiles, filePath)
 		}
 		return nil
 	})
@@ -61,6 +144,123 @@ func FindTemplates(chartPath string) ([]string, error) {
 	return templateFiles, err
 }
 
+// excludes reports whether a file or directory of the chart is left out
+func (o TemplateOptions) excludes(chartPath, filePath string) bool {
+	rel, err := filepath.Rel(chartPath, filePath)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	if o.SkipTests && rel == "templates/tests" {
+		return true
+	}
+	for _, pattern := range o.Exclude {
+		if matched, _ := path.Match(pattern, rel); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// FindHelpers discovers all helper template files (*.tpl) in the chart's templates directory
+func FindHelpers(chartPath string) ([]string, error) {
+	var helperFiles []string
+	templatesDir := filepath.Join(chartPath, "templates")
+
+	err := filepath.WalkDir(templatesDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() && strings.HasSuffix(path, ".tpl") {
+			helperFiles = append(helperFiles, path)
+		}
+		return nil
+	})
+
+	return helperFiles, err
+}
+
+// FindCharts discovers chart directories below root, as found in monorepos. The search does
+// not descend into a chart once found, so vendored subcharts are not reported separately.
+func FindCharts(root string) ([]string, error) {
+	var charts []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if ValidateChartDirectory(path) == nil {
+			slog.Debug("found chart", "path", path)
+			charts = append(charts, path)
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for charts in %s: %w", root, err)
+	}
+
+	sort.Strings(charts)
+	return charts, nil
+}
+
+// SchemaFile is the schema Helm validates the values of a chart against
+const SchemaFile = "values.schema.json"
+
+// generatedFiles are schema outputs that must not influence the chart digest
+var generatedFiles = map[string]bool{
+	SchemaFile:                 true,
+	".helm-schema.values.json": true,
+}
+
+// ChartDigest returns a sha256 digest over the relative paths and contents of all chart files,
+// excluding generated schema files, so identical inputs always produce the same digest
+func ChartDigest(chartPath string) (string, error) {
+	var files []string
+	err := filepath.WalkDir(chartPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !generatedFiles[d.Name()] {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to walk chart %s: %w", chartPath, err)
+	}
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		rel, err := filepath.Rel(chartPath, file)
+		if err != nil {
+			return "", err
+		}
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		fmt.Fprintf(hash, "%s\x00%d\x00", filepath.ToSlash(rel), len(content))
+		hash.Write(content)
+	}
+
+	return "sha256:" + hex.EncodeToString(hash.Sum(nil)), nil
+}
+
 // ParseChartMetadata reads and parses the Chart.yaml file
 func ParseChartMetadata(chartPath string) (*ChartMetadata, error) {
 	chartFile := filepath.Join(chartPath, "Chart.yaml")
@@ -78,6 +278,21 @@ func ParseChartMetadata(chartPath string) (*ChartMetadata, error) {
 	return &metadata, nil
 }
 
+// LoadChartSchema reads the values.schema.json a chart ships
+func LoadChartSchema(chartPath string) (map[string]any, error) {
+	data, err := os.ReadFile(filepath.Join(chartPath, SchemaFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", SchemaFile, err)
+	}
+
+	var schema map[string]any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return nil, fmt.Errorf("failed to parse %s of %s: %w", SchemaFile, chartPath, err)
+	}
+
+	return schema, nil
+}
+
 // IsLocalDependency checks if a dependency is a local subchart
 func (d *Dependency) IsLocalDependency() bool {
 	// Local dependencies have file:// repository or are relative paths
@@ -125,16 +340,26 @@ func FindLocalSubcharts(chartPath string) ([]*Dependency, error) {
 
 // EnsureHelmAvailable checks if helm is available in PATH
 func EnsureHelmAvailable() error {
-	_, err := exec.LookPath("helm")
+	_, err := exec.LookPath(Binary())
 	if err != nil {
 		return fmt.Errorf("helm not found in PATH: %w", err)
 	}
 	return nil
 }
 
+// Binary returns the helm executable to run: the one running the tool as a plugin, which helm
+// passes as HELM_BIN, or helm from PATH. Helm also passes its settings, such as the repository
+// and registry config, through the environment, which the commands run inherit.
+func Binary() string {
+	if bin := os.Getenv("HELM_BIN"); bin != "" {
+		return bin
+	}
+	return "helm"
+}
+
 // BuildDependencies runs 'helm dependency build' to download remote dependencies
 func BuildDependencies(chartPath string) error {
-	cmd := exec.Command("helm", "dependency", "build")
+	cmd := exec.Command(Binary(), "dependency", "build")
 	cmd.Dir = chartPath
 
 	// Capture output for error reporting
@@ -142,10 +367,43 @@ func BuildDependencies(chartPath string) error {
 	if err != nil {
 		return fmt.Errorf("helm dependency build failed: %w\nOutput: %s", err, string(output))
 	}
+	slog.Debug("helm dependency build", "chart", chartPath, "output", string(output))
 
 	return nil
 }
 
+// PullChart downloads and unpacks a chart reference (repo/chart, oci:// or URL) into destDir
+// using 'helm pull' and returns the chart directory
+func PullChart(ref, version, destDir string) (string, error) {
+	args := []string{"pull", ref, "--untar", "--untardir", destDir}
+	if version != "" {
+		args = append(args, "--version", version)
+	}
+
+	cmd := exec.Command(Binary(), args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("helm pull failed: %w\nOutput: %s", err, string(output))
+	}
+	slog.Debug("helm pull", "chart", ref, "version", version, "output", string(output))
+
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		return "", err
+	}
+	var charts []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			charts = append(charts, filepath.Join(destDir, entry.Name()))
+		}
+	}
+	if len(charts) != 1 {
+		return "", fmt.Errorf("expected a single chart directory from %s, found %d", ref, len(charts))
+	}
+
+	return charts[0], nil
+}
+
 // HasRemoteDependencies checks if the chart has any remote dependencies
 func HasRemoteDependencies(chartPath string) (bool, error) {
 	metadata, err := ParseChartMetadata(chartPath)
@@ -162,6 +420,26 @@ func HasRemoteDependencies(chartPath string) (bool, error) {
 	return false, nil
 }
 
+// RemoteDependenciesVendored checks if every remote dependency is already unpacked under charts/,
+// as is the case for extracted chart packages
+func RemoteDependenciesVendored(chartPath string) (bool, error) {
+	metadata, err := ParseChartMetadata(chartPath)
+	if err != nil {
+		return false, err
+	}
+
+	for _, dep := range metadata.Dependencies {
+		if dep.IsLocalDependency() {
+			continue
+		}
+		if err := ValidateChartDirectory(dep.GetSubchartPath(chartPath)); err != nil {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
 // FindAllSubcharts discovers all subchart dependencies (local and remote after build)
 func FindAllSubcharts(chartPath string) ([]*Dependency, error) {
 	metadata, err := ParseChartMetadata(chartPath)
@@ -1,9 +1,15 @@
 package helm
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"testing"
+
+	"gopkg.in/yaml.v3"
 )
 
 func TestValidateChartDirectory(t *testing.T) {
@@ -105,6 +111,56 @@ func TestFindTemplatesEmptyDirectory(t *testing.T) {
 	}
 }
 
+func TestFindTemplatesWithOptions(t *testing.T) {
+	chartDir := t.TempDir()
+	files := []string{
+		"templates/deployment.yaml",
+		"templates/debug-pod.yaml",
+		"templates/tests/test-connection.yaml",
+		"templates/legacy/service.yaml",
+	}
+	for _, file := range files {
+		filePath := filepath.Join(chartDir, filepath.FromSlash(file))
+		os.MkdirAll(filepath.Dir(filePath), 0755)
+		if err := os.WriteFile(filePath, []byte("kind: Test\n"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", file, err)
+		}
+	}
+
+	relative := func(templates []string) []string {
+		var rel []string
+		for _, template := range templates {
+			r, _ := filepath.Rel(chartDir, template)
+			rel = append(rel, filepath.ToSlash(r))
+		}
+		sort.Strings(rel)
+		return rel
+	}
+
+	all, err := FindTemplates(chartDir)
+	if err != nil {
+		t.Fatalf("Should not error finding templates: %v", err)
+	}
+	if len(all) != len(files) {
+		t.Errorf("Expected all %d templates without options, got %v", len(files), relative(all))
+	}
+
+	templates, err := FindTemplatesWithOptions(chartDir, TemplateOptions{
+		SkipTests: true,
+		Exclude:   []string{"templates/legacy", "templates/debug-*.yaml"},
+	})
+	if err != nil {
+		t.Fatalf("Should not error finding templates: %v", err)
+	}
+	if got := relative(templates); !reflect.DeepEqual(got, []string{"templates/deployment.yaml"}) {
+		t.Errorf("Expected only templates/deployment.yaml, got %v", got)
+	}
+
+	if _, err := FindTemplatesWithOptions(chartDir, TemplateOptions{Exclude: []string{"templates/["}}); err == nil {
+		t.Error("Should reject malformed exclude patterns")
+	}
+}
+
 func hasYAMLExtension(filename string) bool {
 	ext := filepath.Ext(filename)
 	return ext == ".yaml" || ext == ".yml"
@@ -267,3 +323,126 @@ func TestFindAllSubcharts(t *testing.T) {
 
 	t.Logf("Found %d local and %d remote dependencies", localCount, remoteCount)
 }
+
+func TestChartDigest(t *testing.T) {
+	chartDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(chartDir, "templates"), 0755); err != nil {
+		t.Fatalf("Failed to create chart directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(chartDir, "Chart.yaml"), []byte("name: digest\n"), 0644); err != nil {
+		t.Fatalf("Failed to write Chart.yaml: %v", err)
+	}
+
+	first, err := ChartDigest(chartDir)
+	if err != nil {
+		t.Fatalf("Failed to compute digest: %v", err)
+	}
+
+	// Generated schema files must not change the digest
+	if err := os.WriteFile(filepath.Join(chartDir, "values.schema.json"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	second, err := ChartDigest(chartDir)
+	if err != nil {
+		t.Fatalf("Failed to compute digest: %v", err)
+	}
+	if first != second {
+		t.Error("Digest changed after writing values.schema.json")
+	}
+
+	if err := os.WriteFile(filepath.Join(chartDir, "templates", "cm.yaml"), []byte("a: {{ .Values.a }}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	third, err := ChartDigest(chartDir)
+	if err != nil {
+		t.Fatalf("Failed to compute digest: %v", err)
+	}
+	if third == second {
+		t.Error("Digest should change when a template is added")
+	}
+}
+
+func TestFindCharts(t *testing.T) {
+	charts, err := FindCharts("../../test-charts")
+	if err != nil {
+		t.Fatalf("Should not error finding charts: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, chart := range charts {
+		found[filepath.Base(chart)] = true
+	}
+
+	if !found["basic"] {
+		t.Errorf("Expected basic chart to be discovered, got %v", charts)
+	}
+
+	// Subcharts are reached through their parent chart
+	for _, chart := range charts {
+		if filepath.Base(filepath.Dir(chart)) == "charts" {
+			t.Errorf("Subchart %s should not be reported as a standalone chart", chart)
+		}
+	}
+}
+
+func TestImportValuesForms(t *testing.T) {
+	data := `name: parent
+dependencies:
+- name: metrics
+  import-values:
+  - defaults
+  - child: service
+    parent: metricsService
+`
+	var metadata ChartMetadata
+	if err := yaml.Unmarshal([]byte(data), &metadata); err != nil {
+		t.Fatalf("Failed to parse Chart.yaml: %v", err)
+	}
+
+	expected := []ImportValue{
+		{Child: "exports.defaults", Parent: "."},
+		{Child: "service", Parent: "metricsService"},
+	}
+	if got := metadata.Dependencies[0].ImportValues; !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected import values %v, got %v", expected, got)
+	}
+	if parent := expected[0].ParentPath(); parent != "" {
+		t.Errorf("The root should have an empty parent path, got %q", parent)
+	}
+}
+
+func TestLoadChartSchema(t *testing.T) {
+	chartDir := t.TempDir()
+
+	if _, err := LoadChartSchema(chartDir); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a missing schema to be reported as not existing, got %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(chartDir, SchemaFile), []byte(`{"type": "object", "required": ["host"]}`), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	schema, err := LoadChartSchema(chartDir)
+	if err != nil {
+		t.Fatalf("Failed to load schema: %v", err)
+	}
+	if schema["type"] != "object" || !reflect.DeepEqual(schema["required"], []any{"host"}) {
+		t.Errorf("Unexpected schema %v", schema)
+	}
+
+	if err := os.WriteFile(filepath.Join(chartDir, SchemaFile), []byte(`{`), 0644); err != nil {
+		t.Fatalf("Failed to write schema: %v", err)
+	}
+	if _, err := LoadChartSchema(chartDir); err == nil {
+		t.Error("Expected an invalid schema to fail loading")
+	}
+}
+
+func TestConditionPaths(t *testing.T) {
+	dep := Dependency{Name: "redis", Condition: "redis.enabled, global.redis.enabled,"}
+	if paths := dep.ConditionPaths(); !reflect.DeepEqual(paths, []string{"redis.enabled", "global.redis.enabled"}) {
+		t.Errorf("Expected both condition paths, got %v", paths)
+	}
+	if paths := (&Dependency{Name: "redis"}).ConditionPaths(); paths != nil {
+		t.Errorf("Expected no condition paths, got %v", paths)
+	}
+}
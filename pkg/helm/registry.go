@@ -0,0 +1,93 @@
+package helm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// registryLogin is the login helm registry login stored for a registry, empty when there is none
+type registryLogin struct {
+	Username string
+	Password string
+}
+
+// registryConfig is helm's registry config, in the format of docker's config.json
+type registryConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth"` // base64 of username:password
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+}
+
+// RegistryConfigPath returns the file helm registry login stores logins in, honoring
+// HELM_REGISTRY_CONFIG, HELM_CONFIG_HOME and XDG_CONFIG_HOME as helm does
+func RegistryConfigPath() (string, error) {
+	if path := os.Getenv("HELM_REGISTRY_CONFIG"); path != "" {
+		return path, nil
+	}
+	configHome := os.Getenv("HELM_CONFIG_HOME")
+	if configHome == "" {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			configHome = filepath.Join(xdg, "helm")
+		} else if runtime.GOOS == "darwin" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			configHome = filepath.Join(home, "Library", "Preferences", "helm")
+		} else {
+			userConfig, err := os.UserConfigDir()
+			if err != nil {
+				return "", err
+			}
+			configHome = filepath.Join(userConfig, "helm")
+		}
+	}
+	return filepath.Join(configHome, "registry", "config.json"), nil
+}
+
+// loadRegistryLogin returns the login stored for a registry host, empty when helm has none.
+// Logins kept by credential helpers are not read.
+func loadRegistryLogin(host string) (registryLogin, error) {
+	path, err := RegistryConfigPath()
+	if err != nil {
+		return registryLogin{}, fmt.Errorf("locating registry config: %w", err)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return registryLogin{}, nil
+	}
+	if err != nil {
+		return registryLogin{}, fmt.Errorf("reading registry config: %w", err)
+	}
+
+	var config registryConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return registryLogin{}, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for key, auth := range config.Auths {
+		// Keys may be written as URLs
+		key = strings.TrimPrefix(strings.TrimPrefix(key, "https://"), "http://")
+		if strings.TrimSuffix(key, "/") != host {
+			continue
+		}
+		if auth.Auth == "" {
+			return registryLogin{Username: auth.Username, Password: auth.Password}, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(auth.Auth)
+		if err != nil {
+			return registryLogin{}, fmt.Errorf("invalid login for %s in %s: %w", host, path, err)
+		}
+		username, password, _ := strings.Cut(string(decoded), ":")
+		return registryLogin{Username: username, Password: password}, nil
+	}
+	return registryLogin{}, nil
+}
@@ -0,0 +1,51 @@
+package helm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RenderChart renders the manifests of a chart directory with 'helm template', using the chart
+// defaults with overrides coalesced on top of them
+func RenderChart(chartPath string, overrides map[string]any) (string, error) {
+	if err := EnsureHelmAvailable(); err != nil {
+		return "", err
+	}
+
+	args := []string{"template", "helm-schema", chartPath}
+	if len(overrides) > 0 {
+		dir, err := os.MkdirTemp("", "helm-schema-render-")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(dir)
+
+		data, err := yaml.Marshal(overrides)
+		if err != nil {
+			return "", fmt.Errorf("encoding values: %w", err)
+		}
+		valuesFile := filepath.Join(dir, "values.yaml")
+		if err := os.WriteFile(valuesFile, data, 0o600); err != nil {
+			return "", err
+		}
+		args = append(args, "--values", valuesFile)
+	}
+
+	cmd := exec.Command(Binary(), args...)
+	output, err := cmd.Output()
+	if err != nil {
+		// Manifests go to stdout, so only stderr explains the failure
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("helm template failed: %w\nOutput: %s", err, string(exitErr.Stderr))
+		}
+		return "", fmt.Errorf("helm template failed: %w", err)
+	}
+
+	return string(output), nil
+}
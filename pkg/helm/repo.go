@@ -0,0 +1,157 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// httpClient sends the requests to chart repositories and registries. Its timeout fails a run
+// against a stalled server instead of letting it hang.
+var httpClient = &http.Client{Timeout: 2 * time.Minute}
+
+// RepoIndex is the index.yaml of an HTTP chart repository, listing the published versions of
+// its charts
+type RepoIndex struct {
+	Entries map[string][]RepoChartVersion `yaml:"entries"`
+}
+
+// RepoChartVersion is a published version of a chart in a repository index
+type RepoChartVersion struct {
+	Name    string   `yaml:"name"`
+	Version string   `yaml:"version"`
+	URLs    []string `yaml:"urls"`
+	Digest  string   `yaml:"digest"`
+}
+
+// Resolve returns the highest version of a chart satisfying the constraint, the latest stable
+// version when the constraint is empty
+func (idx *RepoIndex) Resolve(chart, constraint string) (RepoChartVersion, error) {
+	entries, ok := idx.Entries[chart]
+	if !ok {
+		return RepoChartVersion{}, fmt.Errorf("chart %s not found in repository", chart)
+	}
+	if constraint == "" {
+		constraint = "*"
+	}
+	parsed, err := ParseConstraint(constraint)
+	if err != nil {
+		return RepoChartVersion{}, err
+	}
+
+	var published []string
+	for _, entry := range entries {
+		published = append(published, entry.Version)
+	}
+	matching := MatchingVersions(published, parsed)
+	if len(matching) == 0 {
+		return RepoChartVersion{}, fmt.Errorf("no version of chart %s matches %s", chart, constraint)
+	}
+	highest := matching[len(matching)-1].Original
+	for _, entry := range entries {
+		if entry.Version == highest {
+			return entry, nil
+		}
+	}
+	return RepoChartVersion{}, fmt.Errorf("version %s of chart %s not found", highest, chart)
+}
+
+// FetchRepoIndex downloads the index.yaml of the HTTP chart repository at repoURL
+func FetchRepoIndex(repoURL string) (*RepoIndex, error) {
+	return fetchRepoIndex(httpClient, repoURL)
+}
+
+// DownloadRepoChart downloads the highest version of a chart of an HTTP chart repository
+// satisfying the constraint, the latest stable version when it is empty, and unpacks it into
+// destDir. It returns the chart directory and the version downloaded. Archives are checked
+// against the digest the index records for them.
+func DownloadRepoChart(repoURL, chart, constraint, destDir string) (string, string, error) {
+	return downloadRepoChart(httpClient, repoURL, chart, constraint, destDir)
+}
+
+// fetchRepoIndex downloads and parses the index.yaml of a repository
+func fetchRepoIndex(client *http.Client, repoURL string) (*RepoIndex, error) {
+	indexURL := strings.TrimSuffix(repoURL, "/") + "/index.yaml"
+	data, err := httpGet(client, indexURL)
+	if err != nil {
+		return nil, err
+	}
+	var index RepoIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", indexURL, err)
+	}
+	return &index, nil
+}
+
+// downloadRepoChart resolves, downloads and unpacks a chart of a repository, see DownloadRepoChart
+func downloadRepoChart(client *http.Client, repoURL, chart, constraint, destDir string) (string, string, error) {
+	index, err := fetchRepoIndex(client, repoURL)
+	if err != nil {
+		return "", "", err
+	}
+	entry, err := index.Resolve(chart, constraint)
+	if err != nil {
+		return "", "", err
+	}
+	if len(entry.URLs) == 0 {
+		return "", "", fmt.Errorf("repository lists no download URL for %s %s", chart, entry.Version)
+	}
+
+	// URLs of the index may be relative to the repository
+	base, err := url.Parse(strings.TrimSuffix(repoURL, "/") + "/")
+	if err != nil {
+		return "", "", fmt.Errorf("invalid repository URL %s: %w", repoURL, err)
+	}
+	archiveURL, err := base.Parse(entry.URLs[0])
+	if err != nil {
+		return "", "", fmt.Errorf("invalid download URL %s: %w", entry.URLs[0], err)
+	}
+	slog.Info("downloading chart", "chart", chart, "version", entry.Version, "url", archiveURL.String())
+	data, err := httpGet(client, archiveURL.String())
+	if err != nil {
+		return "", "", err
+	}
+	if entry.Digest != "" {
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != strings.ToLower(entry.Digest) {
+			return "", "", fmt.Errorf("digest of %s does not match the repository index", archiveURL)
+		}
+	}
+
+	archivePath := filepath.Join(destDir, path.Base(archiveURL.Path))
+	if !strings.HasSuffix(archivePath, ".tgz") {
+		archivePath = filepath.Join(destDir, fmt.Sprintf("%s-%s.tgz", chart, entry.Version))
+	}
+	if err := os.WriteFile(archivePath, data, 0644); err != nil {
+		return "", "", err
+	}
+	chartPath, err := ExtractChartArchive(archivePath, filepath.Join(destDir, "chart"))
+	if err != nil {
+		return "", "", err
+	}
+	return chartPath, entry.Version, nil
+}
+
+// httpGet reads the body of a successful GET request
+func httpGet(client *http.Client, target string) ([]byte, error) {
+	resp, err := client.Get(target)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s failed: %s", target, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
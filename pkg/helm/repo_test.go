@@ -0,0 +1,99 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDownloadRepoChart(t *testing.T) {
+	archivePath := filepath.Join(t.TempDir(), "app-1.2.0.tgz")
+	writeTarGz(t, archivePath, map[string]string{
+		"app/Chart.yaml":  "apiVersion: v2\nname: app\nversion: 1.2.0\n",
+		"app/values.yaml": "replicas: 1\n",
+	})
+	archive, err := os.ReadFile(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(archive)
+	digest := hex.EncodeToString(sum[:])
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/charts/index.yaml":
+			fmt.Fprintf(w, `apiVersion: v1
+entries:
+  app:
+    - name: app
+      version: 2.0.0
+      urls: [%s/charts/app-2.0.0.tgz]
+    - name: app
+      version: 1.2.0
+      digest: %s
+      urls: [app-1.2.0.tgz]
+    - name: app
+      version: 1.3.0-rc.1
+      urls: [app-1.3.0-rc.1.tgz]
+    - name: app
+      version: 1.1.0
+      digest: 0000
+      urls: [app-1.1.0.tgz]
+`, server.URL, digest)
+		case "/charts/app-1.2.0.tgz", "/charts/app-1.1.0.tgz":
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	destDir := t.TempDir()
+	chartPath, version, err := downloadRepoChart(server.Client(), server.URL+"/charts/", "app", "1.x", destDir)
+	if err != nil {
+		t.Fatalf("Failed to download chart: %v", err)
+	}
+	if version != "1.2.0" {
+		t.Errorf("Expected version 1.2.0, got %s", version)
+	}
+	if _, err := os.Stat(filepath.Join(chartPath, "Chart.yaml")); err != nil {
+		t.Errorf("Expected the chart to be unpacked: %v", err)
+	}
+
+	if _, _, err := downloadRepoChart(server.Client(), server.URL+"/charts", "app", "1.1.0", t.TempDir()); err == nil || !strings.Contains(err.Error(), "digest") {
+		t.Errorf("Expected a digest mismatch, got %v", err)
+	}
+	if _, _, err := downloadRepoChart(server.Client(), server.URL+"/charts", "app", "", t.TempDir()); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Expected the latest version 2.0.0 to be requested, got %v", err)
+	}
+	if _, _, err := downloadRepoChart(server.Client(), server.URL+"/charts", "other", "", t.TempDir()); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected an unknown chart error, got %v", err)
+	}
+}
+
+func TestFetchRepoIndexTimesOut(t *testing.T) {
+	stalled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-stalled:
+		}
+	}))
+	defer server.Close()
+	defer close(stalled)
+
+	defer func(client *http.Client) { httpClient = client }(httpClient)
+	httpClient = &http.Client{Timeout: 50 * time.Millisecond}
+
+	if _, err := FetchRepoIndex(server.URL); err == nil {
+		t.Fatal("Expected a stalled repository to fail the request")
+	}
+}
@@ -0,0 +1,252 @@
+package helm
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Version is a semantic version as used for chart versions
+type Version struct {
+	Major, Minor, Patch int
+	Prerelease          string
+	Original            string
+}
+
+// versionRe matches semantic versions, with an optional v prefix and optional minor and patch
+// numbers as accepted in constraints, e.g. v1.2.3-rc.1+build or 1.2
+var versionRe = regexp.MustCompile(`^v?(\d+)(?:\.(\d+))?(?:\.(\d+))?(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+
+// ParseVersion parses a semantic version; missing minor and patch numbers are zero
+func ParseVersion(version string) (Version, error) {
+	match := versionRe.FindStringSubmatch(strings.TrimSpace(version))
+	if match == nil {
+		return Version{}, fmt.Errorf("invalid semantic version %q", version)
+	}
+
+	parsed := Version{Prerelease: match[4], Original: version}
+	parsed.Major, _ = strconv.Atoi(match[1])
+	parsed.Minor, _ = strconv.Atoi(match[2])
+	parsed.Patch, _ = strconv.Atoi(match[3])
+	return parsed, nil
+}
+
+func (v Version) String() string {
+	if v.Original != "" {
+		return v.Original
+	}
+	version := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		version += "-" + v.Prerelease
+	}
+	return version
+}
+
+// Compare returns -1, 0 or 1 when v is lower than, equal to or higher than other, by
+// semantic version precedence
+func (v Version) Compare(other Version) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			return compareInts(pair[0], pair[1])
+		}
+	}
+
+	// A release has higher precedence than its prereleases
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	ours, theirs := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(ours) && i < len(theirs); i++ {
+		if ours[i] == theirs[i] {
+			continue
+		}
+		ourNumber, ourErr := strconv.Atoi(ours[i])
+		theirNumber, theirErr := strconv.Atoi(theirs[i])
+		switch {
+		case ourErr == nil && theirErr == nil:
+			return compareInts(ourNumber, theirNumber)
+		case ourErr == nil:
+			return -1
+		case theirErr == nil:
+			return 1
+		default:
+			return strings.Compare(ours[i], theirs[i])
+		}
+	}
+	return compareInts(len(ours), len(theirs))
+}
+
+// compareInts returns -1, 0 or 1 when a is lower than, equal to or higher than b
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// SortVersions orders versions by ascending precedence
+func SortVersions(versions []Version) {
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Compare(versions[j]) < 0
+	})
+}
+
+// comparator is a single version condition, e.g. >=1.2.0
+type comparator struct {
+	op      string
+	version Version
+}
+
+// Constraint is a version range: comparators separated by spaces or commas must all hold, and
+// groups separated by || are alternatives, e.g. ">=1.0.0 <2.0.0 || ^3.1"
+type Constraint struct {
+	groups [][]comparator
+	raw    string
+}
+
+// comparatorRe splits a comparator into its operator and version
+var comparatorRe = regexp.MustCompile(`^(>=|<=|!=|==|=|>|<|~|\^)?\s*(\S+)$`)
+
+// wildcardRe matches a version with wildcard minor or patch numbers, e.g. 1.x or 1.2.*
+var wildcardRe = regexp.MustCompile(`^=?v?(\d+)(?:\.(\d+))?\.[xX*](?:\.[xX*])?$`)
+
+// ParseConstraint parses a version range. Besides =, !=, >, >=, < and <=, it accepts ~1.2.3
+// (patch updates), ^1.2.3 (updates not changing the leftmost non-zero number), wildcards such
+// as 1.x or 1.2.* and * for any version.
+func ParseConstraint(constraint string) (*Constraint, error) {
+	parsed := &Constraint{raw: constraint}
+	for _, group := range strings.Split(constraint, "||") {
+		// Operators may be separated from their versions, as in ">= 1.0"
+		fields := strings.Fields(strings.ReplaceAll(group, ",", " "))
+		var terms []string
+		for i := 0; i < len(fields); i++ {
+			if strings.Trim(fields[i], "<>=!~^") == "" && i+1 < len(fields) {
+				terms = append(terms, fields[i]+fields[i+1])
+				i++
+				continue
+			}
+			terms = append(terms, fields[i])
+		}
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("invalid version constraint %q: empty range", constraint)
+		}
+
+		var comparators []comparator
+		for _, term := range terms {
+			expanded, err := parseComparator(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+			}
+			comparators = append(comparators, expanded...)
+		}
+		parsed.groups = append(parsed.groups, comparators)
+	}
+	return parsed, nil
+}
+
+// parseComparator expands a single term into plain comparators
+func parseComparator(term string) ([]comparator, error) {
+	if term == "*" || term == "x" {
+		return nil, nil
+	}
+	if match := wildcardRe.FindStringSubmatch(term); match != nil {
+		major, _ := strconv.Atoi(match[1])
+		if match[2] == "" {
+			return []comparator{{">=", Version{Major: major}}, {"<", Version{Major: major + 1}}}, nil
+		}
+		minor, _ := strconv.Atoi(match[2])
+		return []comparator{{">=", Version{Major: major, Minor: minor}}, {"<", Version{Major: major, Minor: minor + 1}}}, nil
+	}
+
+	match := comparatorRe.FindStringSubmatch(term)
+	if match == nil {
+		return nil, fmt.Errorf("invalid comparator %q", term)
+	}
+	version, err := ParseVersion(match[2])
+	if err != nil {
+		return nil, err
+	}
+	version.Original = ""
+
+	switch match[1] {
+	case "~":
+		upper := Version{Major: version.Major, Minor: version.Minor + 1}
+		return []comparator{{">=", version}, {"<", upper}}, nil
+	case "^":
+		upper := Version{Major: version.Major + 1}
+		if version.Major == 0 {
+			upper = Version{Minor: version.Minor + 1}
+		}
+		return []comparator{{">=", version}, {"<", upper}}, nil
+	case "", "==":
+		return []comparator{{"=", version}}, nil
+	default:
+		return []comparator{{match[1], version}}, nil
+	}
+}
+
+// Check reports whether the version satisfies the constraint. Prereleases only match groups
+// whose comparators name a prerelease, so ">=1.0.0" does not select 2.0.0-rc.1.
+func (c *Constraint) Check(version Version) bool {
+	for _, group := range c.groups {
+		if version.Prerelease != "" && !mentionsPrerelease(group) {
+			continue
+		}
+		satisfied := true
+		for _, cmp := range group {
+			if !cmp.check(version) {
+				satisfied = false
+				break
+			}
+		}
+		if satisfied {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// check reports whether the version satisfies a single comparator
+func (cmp comparator) check(version Version) bool {
+	order := version.Compare(cmp.version)
+	switch cmp.op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	}
+	return false
+}
+
+// mentionsPrerelease reports whether any comparator of a group names a prerelease
+func mentionsPrerelease(group []comparator) bool {
+	for _, cmp := range group {
+		if cmp.version.Prerelease != "" {
+			return true
+		}
+	}
+	return false
+}
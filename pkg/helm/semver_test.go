@@ -0,0 +1,71 @@
+package helm
+
+import (
+	"testing"
+)
+
+func TestVersionCompare(t *testing.T) {
+	ordered := []string{"0.9.0", "1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "v1.0.1", "1.2", "1.10.0"}
+	for i := 1; i < len(ordered); i++ {
+		lower, err := ParseVersion(ordered[i-1])
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", ordered[i-1], err)
+		}
+		higher, err := ParseVersion(ordered[i])
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", ordered[i], err)
+		}
+		if lower.Compare(higher) >= 0 || higher.Compare(lower) <= 0 {
+			t.Errorf("Expected %s < %s", ordered[i-1], ordered[i])
+		}
+	}
+
+	if _, err := ParseVersion("latest"); err == nil {
+		t.Error("Expected an error for a non-semantic version")
+	}
+}
+
+func TestConstraintCheck(t *testing.T) {
+	tests := []struct {
+		constraint string
+		matches    []string
+		rejects    []string
+	}{
+		{">=1.0.0 <2.0.0", []string{"1.0.0", "1.9.9"}, []string{"0.9.0", "2.0.0", "1.5.0-rc.1"}},
+		{">= 1.2, < 1.4", []string{"1.2.0", "1.3.7"}, []string{"1.4.0"}},
+		{"~1.2.3", []string{"1.2.3", "1.2.9"}, []string{"1.3.0", "1.2.2"}},
+		{"^1.2.3", []string{"1.2.3", "1.9.0"}, []string{"2.0.0"}},
+		{"^0.3.1", []string{"0.3.5"}, []string{"0.4.0"}},
+		{"1.0.0 || >=3.0.0", []string{"1.0.0", "3.1.0"}, []string{"2.0.0"}},
+		{"!=1.1.0", []string{"1.0.0"}, []string{"1.1.0"}},
+		{">=2.0.0-rc.1", []string{"2.0.0-rc.2", "2.0.0"}, []string{"2.0.0-beta.1"}},
+		{"18.x", []string{"18.0.0", "18.19.4"}, []string{"17.9.0", "19.0.0"}},
+		{"1.2.*", []string{"1.2.0", "1.2.7"}, []string{"1.3.0"}},
+		{"*", []string{"0.1.0", "5.0.0"}, []string{"5.0.0-rc.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint, func(t *testing.T) {
+			constraint, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("Failed to parse constraint: %v", err)
+			}
+			for _, version := range tt.matches {
+				parsed, _ := ParseVersion(version)
+				if !constraint.Check(parsed) {
+					t.Errorf("Expected %s to match", version)
+				}
+			}
+			for _, version := range tt.rejects {
+				parsed, _ := ParseVersion(version)
+				if constraint.Check(parsed) {
+					t.Errorf("Expected %s not to match", version)
+				}
+			}
+		})
+	}
+
+	if _, err := ParseConstraint(">=1.0.0 <"); err == nil {
+		t.Error("Expected an error for an incomplete comparator")
+	}
+}
@@ -0,0 +1,66 @@
+package helm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LoadValuesFile reads a values file as passed to helm with -f
+func LoadValuesFile(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+
+	values := make(map[string]any)
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+	}
+
+	return values, nil
+}
+
+// MergeValues coalesces overlay onto base the way helm combines values sources: nested maps are
+// merged, any other overlay value replaces the base value and a null overlay value removes the key.
+// Neither argument is modified.
+func MergeValues(base, overlay map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(overlay))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range overlay {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		baseMap, baseIsMap := merged[key].(map[string]any)
+		overlayMap, overlayIsMap := value.(map[string]any)
+		if baseIsMap && overlayIsMap {
+			merged[key] = MergeValues(baseMap, overlayMap)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// FindValuesVariants returns the values-*.yaml files next to the values.yaml of a chart, such as
+// values-production.yaml, in name order
+func FindValuesVariants(chartPath string) ([]string, error) {
+	var files []string
+	for _, pattern := range []string{"values-*.yaml", "values-*.yml"} {
+		matches, err := filepath.Glob(filepath.Join(chartPath, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,76 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMergeValues(t *testing.T) {
+	base := map[string]any{
+		"image":    map[string]any{"repository": "nginx", "tag": "1.25"},
+		"replicas": 2,
+		"legacy":   map[string]any{"enabled": true},
+	}
+	overlay := map[string]any{
+		"image":    map[string]any{"tag": "1.27"},
+		"replicas": 3,
+		"legacy":   nil,
+		"ingress":  map[string]any{"enabled": true},
+	}
+
+	merged := MergeValues(base, overlay)
+
+	expected := map[string]any{
+		"image":    map[string]any{"repository": "nginx", "tag": "1.27"},
+		"replicas": 3,
+		"ingress":  map[string]any{"enabled": true},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Unexpected merge result: %v", merged)
+	}
+
+	if base["image"].(map[string]any)["tag"] != "1.25" || base["legacy"] == nil {
+		t.Errorf("Base values should not be modified: %v", base)
+	}
+}
+
+func TestLoadValuesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "values.yaml")
+	if err := os.WriteFile(path, []byte("image:\n  tag: \"1.27\"\nreplicas: 3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write values file: %v", err)
+	}
+
+	values, err := LoadValuesFile(path)
+	if err != nil {
+		t.Fatalf("Failed to load values: %v", err)
+	}
+
+	expected := map[string]any{"image": map[string]any{"tag": "1.27"}, "replicas": 3}
+	if !reflect.DeepEqual(values, expected) {
+		t.Errorf("Unexpected values: %v", values)
+	}
+
+	if _, err := LoadValuesFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("Expected error for a missing values file")
+	}
+}
+
+func TestFindValuesVariants(t *testing.T) {
+	chartPath := t.TempDir()
+	for _, name := range []string{"values.yaml", "values-staging.yml", "values-production.yaml", "values.schema.json", "ci-values.yaml"} {
+		if err := os.WriteFile(filepath.Join(chartPath, name), nil, 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	files, err := FindValuesVariants(chartPath)
+	if err != nil {
+		t.Fatalf("Failed to find values variants: %v", err)
+	}
+	expected := []string{filepath.Join(chartPath, "values-production.yaml"), filepath.Join(chartPath, "values-staging.yml")}
+	if !reflect.DeepEqual(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+}
@@ -0,0 +1,230 @@
+package helm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// searchResult is an entry of 'helm search repo -o json'
+type searchResult struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// ListChartVersions returns every published version of a chart reference: repo/chart for a
+// repository added with 'helm repo add', or oci://registry/path/chart
+func ListChartVersions(ref string) ([]string, error) {
+	if strings.HasPrefix(ref, "oci://") {
+		host, repository, found := strings.Cut(strings.TrimPrefix(ref, "oci://"), "/")
+		if !found {
+			return nil, fmt.Errorf("invalid OCI reference %s: missing repository", ref)
+		}
+		login, err := loadRegistryLogin(host)
+		if err != nil {
+			return nil, err
+		}
+		return listOCITags(httpClient, "https://"+host, repository, login)
+	}
+
+	if err := EnsureHelmAvailable(); err != nil {
+		return nil, err
+	}
+	// The search term is a regular expression over chart names
+	cmd := exec.Command(Binary(), "search", "repo", "^"+regexp.QuoteMeta(ref)+"$", "--regexp", "--versions", "--devel", "-o", "json")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("helm search repo failed: %w", err)
+	}
+
+	var results []searchResult
+	if err := json.Unmarshal(output, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse helm search output: %w", err)
+	}
+
+	var versions []string
+	for _, result := range results {
+		if result.Name == ref {
+			versions = append(versions, result.Version)
+		}
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("chart %s not found in any repository (run 'helm repo add' and 'helm repo update' first)", ref)
+	}
+	return versions, nil
+}
+
+// MatchingVersions returns the versions satisfying the constraint in ascending order. Entries
+// that are not semantic versions, such as OCI tags like latest, are skipped.
+func MatchingVersions(versions []string, constraint *Constraint) []Version {
+	var matching []Version
+	for _, version := range versions {
+		parsed, err := ParseVersion(version)
+		if err != nil {
+			continue
+		}
+		if constraint.Check(parsed) {
+			matching = append(matching, parsed)
+		}
+	}
+	SortVersions(matching)
+	return matching
+}
+
+// ociTags is the response of the registry tag listing endpoint
+type ociTags struct {
+	Tags []string `json:"tags"`
+}
+
+// linkNextRe extracts the next page of a paginated registry response from its Link header
+var linkNextRe = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// listOCITags lists the tags of a repository through the OCI distribution API at baseURL.
+// Registries requiring a token, or a login, are handled, authenticating with the login of helm
+// registry login when there is one; Helm stores the + of versions with build metadata as _ in
+// tags, which is reverted.
+func listOCITags(client *http.Client, baseURL, repository string, login registryLogin) ([]string, error) {
+	var versions []string
+	authorization := ""
+	next := baseURL + "/v2/" + repository + "/tags/list"
+	for next != "" {
+		resp, err := ociGet(client, next, authorization)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && authorization == "" {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			resp.Body.Close()
+			if authorization, err = ociAuthorization(client, challenge, login); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("listing tags of %s failed: %s", repository, resp.Status)
+		}
+
+		var page ociTags
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, fmt.Errorf("failed to parse tags of %s: %w", repository, err)
+		}
+		for _, tag := range page.Tags {
+			versions = append(versions, strings.ReplaceAll(tag, "_", "+"))
+		}
+
+		next = ""
+		if link := linkNextRe.FindStringSubmatch(resp.Header.Get("Link")); link != nil {
+			next = link[1]
+			if strings.HasPrefix(next, "/") {
+				next = baseURL + next
+			}
+		}
+	}
+	return versions, nil
+}
+
+// ociGet requests a registry URL, with an Authorization header when one is given
+func ociGet(client *http.Client, url, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("registry request failed: %w", err)
+	}
+	return resp, nil
+}
+
+// ociAuthorization answers the WWW-Authenticate challenge of a registry with the Authorization
+// header to retry with: the login itself for Basic challenges, a token fetched with it, or
+// anonymously without one, for Bearer challenges
+func ociAuthorization(client *http.Client, challenge string, login registryLogin) (string, error) {
+	if strings.HasPrefix(challenge, "Basic ") {
+		if login.Username == "" {
+			return "", fmt.Errorf("registry requires a login (run 'helm registry login' first)")
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(login.Username + ":" + login.Password))
+		return "Basic " + credentials, nil
+	}
+	token, err := ociToken(client, challenge, login)
+	if err != nil {
+		return "", err
+	}
+	return "Bearer " + token, nil
+}
+
+// challengeParamRe matches the parameters of a WWW-Authenticate challenge, e.g. realm="..."
+var challengeParamRe = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// ociToken fetches a token as requested by a Bearer challenge, for the login when there is one
+// and anonymously otherwise
+func ociToken(client *http.Client, challenge string, login registryLogin) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("registry requires unsupported authentication %q", challenge)
+	}
+
+	params := make(map[string]string)
+	for _, param := range challengeParamRe.FindAllStringSubmatch(challenge, -1) {
+		params[param[1]] = param[2]
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("registry authentication challenge has no realm: %q", challenge)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, params["realm"], nil)
+	if err != nil {
+		return "", err
+	}
+	query := req.URL.Query()
+	for _, name := range []string{"service", "scope"} {
+		if params[name] != "" {
+			query.Set(name, params[name])
+		}
+	}
+	req.URL.RawQuery = query.Encode()
+	if login.Username != "" {
+		req.SetBasicAuth(login.Username, login.Password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("registry token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized && login.Username == "" {
+		return "", fmt.Errorf("registry token request failed: %s (run 'helm registry login' first)", resp.Status)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token request failed: %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("failed to parse registry token: %w", err)
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("registry returned no token")
+	}
+	return token.AccessToken, nil
+}
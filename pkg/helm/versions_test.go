@@ -0,0 +1,125 @@
+package helm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestListOCITags(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.URL.Query().Get("scope") != "repository:charts/app:pull" {
+				t.Errorf("Unexpected token scope %q", r.URL.Query().Get("scope"))
+			}
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+		case "/v2/charts/app/tags/list":
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:charts/app:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", `</v2/charts/app/tags/list?n=2&last=1.1.0>; rel="next"`)
+				fmt.Fprint(w, `{"name": "charts/app", "tags": ["1.0.0", "1.1.0"]}`)
+				return
+			}
+			fmt.Fprint(w, `{"name": "charts/app", "tags": ["2.0.0_build.1", "latest"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tags, err := listOCITags(server.Client(), server.URL, "charts/app", registryLogin{})
+	if err != nil {
+		t.Fatalf("Failed to list tags: %v", err)
+	}
+	expected := []string{"1.0.0", "1.1.0", "2.0.0+build.1", "latest"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected tags %v, got %v", expected, tags)
+	}
+
+	constraint, _ := ParseConstraint(">=1.1.0")
+	var matching []string
+	for _, version := range MatchingVersions(tags, constraint) {
+		matching = append(matching, version.String())
+	}
+	if !reflect.DeepEqual(matching, []string{"1.1.0", "2.0.0+build.1"}) {
+		t.Errorf("Unexpected matching versions %v", matching)
+	}
+}
+
+func TestListOCITagsWithLogin(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	config := `{"auths": {"https://registry.example.com": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("ci:secret")) + `"}}}`
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		t.Fatalf("Failed to write registry config: %v", err)
+	}
+	t.Setenv("HELM_REGISTRY_CONFIG", configPath)
+
+	login, err := loadRegistryLogin("registry.example.com")
+	if err != nil {
+		t.Fatalf("Failed to load registry login: %v", err)
+	}
+	if login != (registryLogin{Username: "ci", Password: "secret"}) {
+		t.Errorf("Unexpected login %+v", login)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			// Private repositories hand out no anonymous tokens
+			if username, password, ok := r.BasicAuth(); !ok || username != "ci" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"token": "private"}`)
+		case "/v2/private/app/tags/list":
+			if r.Header.Get("Authorization") != "Bearer private" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:private/app:pull"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{"name": "private/app", "tags": ["1.0.0"]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	if _, err := listOCITags(server.Client(), server.URL, "private/app", registryLogin{}); err == nil {
+		t.Error("Expected listing a private repository anonymously to fail")
+	}
+	tags, err := listOCITags(server.Client(), server.URL, "private/app", login)
+	if err != nil {
+		t.Fatalf("Failed to list tags: %v", err)
+	}
+	if !reflect.DeepEqual(tags, []string{"1.0.0"}) {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+}
+
+func TestLoadRegistryLoginUnlocatableConfig(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "plan9" {
+		t.Skip("the user config directory does not depend on HOME")
+	}
+	for _, name := range []string{"HELM_REGISTRY_CONFIG", "HELM_CONFIG_HOME", "XDG_CONFIG_HOME", "HOME"} {
+		t.Setenv(name, "")
+	}
+
+	// An unusable environment must not turn into an anonymous pull
+	if _, err := loadRegistryLogin("registry.example.com"); err == nil || !strings.Contains(err.Error(), "locating registry config") {
+		t.Errorf("Expected the registry config path error, got %v", err)
+	}
+}
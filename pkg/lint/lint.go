@@ -0,0 +1,239 @@
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"helm-schema/pkg/parser"
+)
+
+// Rule names, usable to disable individual rules
+const (
+	RuleCamelCase     = "camel-case"
+	RuleAbbreviations = "abbreviations"
+	RuleMaxDepth      = "max-depth"
+)
+
+// DefaultMaxDepth bounds how deeply value paths may nest
+const DefaultMaxDepth = 5
+
+// DefaultAbbreviations maps discouraged abbreviations to their spelled-out replacement
+var DefaultAbbreviations = map[string]string{
+	"cfg":  "config",
+	"conf": "config",
+	"ctx":  "context",
+	"img":  "image",
+	"ns":   "namespace",
+	"pwd":  "password",
+	"repo": "repository",
+	"svc":  "service",
+}
+
+// Issue describes a value path violating a naming rule
+type Issue struct {
+	Rule       string `json:"rule"`
+	Path       string `json:"path"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"` // Replacement path, when one can be derived
+}
+
+// Rule checks a single value path; rules are called once for every discovered path,
+// intermediate object paths included, and should only judge the path's last segment
+type Rule interface {
+	Name() string
+	Check(path string) []Issue
+}
+
+// Config selects and tunes the built-in rules
+type Config struct {
+	MaxDepth      int
+	Abbreviations map[string]string
+	Disabled      map[string]bool
+}
+
+// DefaultConfig returns the configuration applied when no options are given
+func DefaultConfig() Config {
+	return Config{
+		MaxDepth:      DefaultMaxDepth,
+		Abbreviations: DefaultAbbreviations,
+		Disabled:      make(map[string]bool),
+	}
+}
+
+// Rules returns the built-in rules enabled by the configuration
+func Rules(cfg Config) []Rule {
+	all := []Rule{
+		camelCaseRule{},
+		abbreviationRule{abbreviations: cfg.Abbreviations},
+		maxDepthRule{maxDepth: cfg.MaxDepth},
+	}
+
+	var enabled []Rule
+	for _, rule := range all {
+		if !cfg.Disabled[rule.Name()] {
+			enabled = append(enabled, rule)
+		}
+	}
+	return enabled
+}
+
+// Lint runs the rules over every value path and returns the issues ordered by path
+func Lint(values map[string]*parser.ValuePath, rules []Rule) []Issue {
+	var paths []string
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var issues []Issue
+	for _, path := range paths {
+		// Keys of iterated maps are chosen by users, not by the chart
+		if _, key := lastSegment(path); key == parser.AnyKey {
+			continue
+		}
+		for _, rule := range rules {
+			issues = append(issues, rule.Check(path)...)
+		}
+	}
+	return issues
+}
+
+// lastSegment splits a path into its parent and final key, without array markers or escapes
+func lastSegment(path string) (string, string) {
+	segments := parser.SplitPath(path)
+	last := len(segments) - 1
+	return strings.Join(segments[:last], "."), parser.UnescapeKey(segments[last])
+}
+
+// replaceLastSegment renames the final key of a path, keeping its array marker
+func replaceLastSegment(path, key string) string {
+	parent, _ := lastSegment(path)
+	key = parser.EscapeKey(key)
+	if strings.HasSuffix(path, "[]") {
+		key += "[]"
+	}
+	return joinPath(parent, key)
+}
+
+// joinPath appends a key to a parent path, which may be empty
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// camelCaseRe matches keys written in lower camelCase
+var camelCaseRe = regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`)
+
+// camelCaseRule requires keys to be lower camelCase, as in Helm's best practices
+type camelCaseRule struct{}
+
+func (camelCaseRule) Name() string { return RuleCamelCase }
+
+func (camelCaseRule) Check(path string) []Issue {
+	_, key := lastSegment(path)
+	if camelCaseRe.MatchString(key) {
+		return nil
+	}
+
+	return []Issue{{
+		Rule:       RuleCamelCase,
+		Path:       path,
+		Message:    fmt.Sprintf("key %q is not camelCase", key),
+		Suggestion: replaceLastSegment(path, toCamelCase(key)),
+	}}
+}
+
+// toCamelCase converts snake_case, kebab-case and capitalized keys to lower camelCase
+func toCamelCase(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool { return r == '_' || r == '-' || r == '.' })
+
+	var b strings.Builder
+	for i, word := range words {
+		if strings.ToUpper(word) == word {
+			word = strings.ToLower(word)
+		}
+		runes := []rune(word)
+		if i == 0 {
+			runes[0] = unicode.ToLower(runes[0])
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+// abbreviationRule rejects keys built from discouraged abbreviations
+type abbreviationRule struct {
+	abbreviations map[string]string
+}
+
+func (abbreviationRule) Name() string { return RuleAbbreviations }
+
+func (r abbreviationRule) Check(path string) []Issue {
+	_, key := lastSegment(path)
+	words := splitWords(key)
+
+	var found []string
+	for i, word := range words {
+		replacement, ok := r.abbreviations[strings.ToLower(word)]
+		if !ok {
+			continue
+		}
+		found = append(found, strings.ToLower(word))
+		if i > 0 {
+			replacement = strings.ToUpper(replacement[:1]) + replacement[1:]
+		}
+		words[i] = replacement
+	}
+
+	if len(found) == 0 {
+		return nil
+	}
+
+	return []Issue{{
+		Rule:       RuleAbbreviations,
+		Path:       path,
+		Message:    fmt.Sprintf("key %q uses abbreviation %s", key, strings.Join(found, ", ")),
+		Suggestion: replaceLastSegment(path, strings.Join(words, "")),
+	}}
+}
+
+// splitWords splits a camelCase key into its words, e.g. svcPort into svc and Port
+func splitWords(key string) []string {
+	var words []string
+	start := 0
+	for i := 1; i < len(key); i++ {
+		if unicode.IsUpper(rune(key[i])) && !unicode.IsUpper(rune(key[i-1])) {
+			words = append(words, key[start:i])
+			start = i
+		}
+	}
+	return append(words, key[start:])
+}
+
+// maxDepthRule limits nesting so values stay easy to override with --set
+type maxDepthRule struct {
+	maxDepth int
+}
+
+func (maxDepthRule) Name() string { return RuleMaxDepth }
+
+func (r maxDepthRule) Check(path string) []Issue {
+	// Only the first segment beyond the limit is reported, not every path below it
+	depth := len(parser.SplitPath(path))
+	if r.maxDepth <= 0 || depth != r.maxDepth+1 {
+		return nil
+	}
+
+	return []Issue{{
+		Rule:    RuleMaxDepth,
+		Path:    path,
+		Message: fmt.Sprintf("path nests %d levels deep, more than the allowed %d", depth, r.maxDepth),
+	}}
+}
@@ -0,0 +1,78 @@
+package lint
+
+import (
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+// valuesOf builds a value map from plain paths
+func valuesOf(paths ...string) map[string]*parser.ValuePath {
+	values := make(map[string]*parser.ValuePath)
+	for _, path := range paths {
+		values[path] = &parser.ValuePath{Path: path, Type: "unknown"}
+	}
+	return values
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		rule       string
+		suggestion string
+	}{
+		{name: "snake case", path: "image.pull_policy", rule: RuleCamelCase, suggestion: "image.pullPolicy"},
+		{name: "kebab case", path: "extra-env[]", rule: RuleCamelCase, suggestion: "extraEnv[]"},
+		{name: "capitalized", path: "Service", rule: RuleCamelCase, suggestion: "service"},
+		{name: "upper case word", path: "ingress.TLS", rule: RuleCamelCase, suggestion: "ingress.tls"},
+		{name: "dotted key", path: `config.nginx\.conf`, rule: RuleCamelCase, suggestion: "config.nginxConf"},
+		{name: "abbreviation", path: "app.cfg", rule: RuleAbbreviations, suggestion: "app.config"},
+		{name: "abbreviation inside key", path: "svcPort", rule: RuleAbbreviations, suggestion: "servicePort"},
+		{name: "too deep", path: "a.b.c.d.e.f", rule: RuleMaxDepth},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issues := Lint(valuesOf(tt.path), Rules(DefaultConfig()))
+			if len(issues) != 1 {
+				t.Fatalf("Expected 1 issue, got %+v", issues)
+			}
+			if issues[0].Rule != tt.rule {
+				t.Errorf("Expected rule %s, got %s", tt.rule, issues[0].Rule)
+			}
+			if issues[0].Suggestion != tt.suggestion {
+				t.Errorf("Expected suggestion %q, got %q", tt.suggestion, issues[0].Suggestion)
+			}
+		})
+	}
+}
+
+func TestCleanPaths(t *testing.T) {
+	values := valuesOf("image", "image.repository", "image.pullPolicy", "ingress.hosts[]", "ingress.hosts[].paths[]", "a.b.c.d.e")
+	if issues := Lint(values, Rules(DefaultConfig())); len(issues) != 0 {
+		t.Errorf("Expected no issues, got %+v", issues)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxDepth = 2
+	cfg.Abbreviations = map[string]string{"db": "database"}
+	cfg.Disabled[RuleCamelCase] = true
+
+	issues := Lint(valuesOf("db", "db.host", "app.Name", "a.b.c", "a.b.c.d"), Rules(cfg))
+
+	expected := []Issue{
+		{Rule: RuleMaxDepth, Path: "a.b.c"},
+		{Rule: RuleAbbreviations, Path: "db", Suggestion: "database"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, got %+v", len(expected), issues)
+	}
+	for i, issue := range expected {
+		if issues[i].Rule != issue.Rule || issues[i].Path != issue.Path || issues[i].Suggestion != issue.Suggestion {
+			t.Errorf("Issue %d: expected %+v, got %+v", i, issue, issues[i])
+		}
+	}
+}
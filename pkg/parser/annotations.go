@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Match: # @schema, opening or closing a block, or followed by inline settings
+	schemaAnnotationRe = regexp.MustCompile(`^#\s*@schema\b(.*)$`)
+)
+
+// valuesAnnotation is the @schema annotation of a values.yaml key
+type valuesAnnotation struct {
+	path      string
+	line      int
+	directive *directive // Nil when the annotation cannot be parsed
+	err       error
+}
+
+// loadValuesAnnotations reads the @schema annotations of a values file, none when the file does
+// not exist
+func loadValuesAnnotations(path string) ([]valuesAnnotation, error) {
+	content, err := readValuesFile(path)
+	if content == nil || err != nil {
+		return nil, err
+	}
+	annotations, err := valuesAnnotations(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+	}
+	return annotations, nil
+}
+
+// valuesAnnotations reads the @schema annotations of values.yaml content, in the style of
+// helm-values-schema-json: # @schema type:string;enum:[a, b];required:true in the comment above
+// a key, or a YAML block between two # @schema lines
+//
+//	# @schema
+//	# type: integer
+//	# minimum: 1
+//	# @schema
+//	replicas: 1
+//
+// Settings are the keywords directive comments accept, plus required and nullable.
+func valuesAnnotations(content []byte) ([]valuesAnnotation, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+	if len(document.Content) == 0 {
+		return nil, nil
+	}
+
+	var annotations []valuesAnnotation
+	walkKeys(document.Content[0], "", func(keyPath string, key, _ *yaml.Node) {
+		settings, found, err := schemaSettings(key.HeadComment)
+		if !found {
+			return
+		}
+		annotation := valuesAnnotation{path: keyPath, line: key.Line, err: err}
+		if err == nil {
+			annotation.directive, annotation.err = parseAnnotation(settings)
+		}
+		annotations = append(annotations, annotation)
+	})
+	return annotations, nil
+}
+
+// schemaSettings returns the settings of the @schema annotations in a comment, as YAML
+func schemaSettings(comment string) (map[string]any, bool, error) {
+	settings := make(map[string]any)
+	found := false
+	var block []string
+	inBlock := false
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		match := schemaAnnotationRe.FindStringSubmatch(line)
+		switch {
+		case match != nil && strings.TrimSpace(match[1]) == "":
+			found = true
+			if inBlock {
+				if err := yaml.Unmarshal([]byte(strings.Join(block, "\n")), &settings); err != nil {
+					return nil, true, fmt.Errorf("@schema block: %w", err)
+				}
+				block = nil
+			}
+			inBlock = !inBlock
+		case match != nil:
+			found = true
+			for _, setting := range splitSettings(match[1], ';') {
+				key, raw, ok := strings.Cut(setting, ":")
+				key, raw = strings.TrimSpace(key), strings.TrimSpace(raw)
+				if !ok || key == "" {
+					return nil, true, fmt.Errorf("@schema setting %q is not key:value", strings.TrimSpace(setting))
+				}
+				var value any
+				if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+					return nil, true, fmt.Errorf("@schema setting %s: %w", key, err)
+				}
+				settings[key] = value
+			}
+		case inBlock:
+			// The comment marker and the single space after it, keeping the YAML indentation
+			block = append(block, strings.TrimPrefix(strings.TrimPrefix(line, "#"), " "))
+		}
+	}
+	if inBlock {
+		return nil, true, fmt.Errorf("@schema block is not closed")
+	}
+	return settings, found, nil
+}
+
+// parseAnnotation builds the directive the settings of an annotation stand for
+func parseAnnotation(settings map[string]any) (*directive, error) {
+	d := &directive{constraints: make(map[string]any)}
+	for key, value := range settings {
+		// The annotated key is the value
+		if key == "path" {
+			return nil, fmt.Errorf("@schema setting %q is unknown", key)
+		}
+		if err := d.set(key, value); err != nil {
+			return nil, fmt.Errorf("@schema %w", err)
+		}
+	}
+	if d.empty() {
+		return nil, fmt.Errorf("@schema annotation sets nothing")
+	}
+	return d, nil
+}
+
+// addValuesAnnotations applies the @schema annotations of values.yaml keys, the paths of which
+// are relative to prefix, to the values found in the templates. Annotations of keys the
+// templates do not read are left out with them.
+func (tp *TemplateParser) addValuesAnnotations(annotations []valuesAnnotation, prefix string) {
+	for _, annotation := range annotations {
+		path := annotation.path
+		if prefix != "" {
+			if !strings.HasPrefix(path, prefix+".") {
+				continue
+			}
+			path = strings.TrimPrefix(path, prefix+".")
+		}
+		valuePath, exists := tp.values[path]
+		if !exists || annotation.directive == nil {
+			continue
+		}
+		annotation.directive.apply(valuePath)
+	}
+}
+
+// invalidAnnotations reports the annotations of a values file that cannot be parsed
+func invalidAnnotations(annotations []valuesAnnotation, file string) []Unresolved {
+	var invalid []Unresolved
+	for _, annotation := range annotations {
+		if annotation.err != nil {
+			invalid = append(invalid, Unresolved{
+				Kind:    UnresolvedDirective,
+				Site:    Site{File: file, Line: annotation.line},
+				Snippet: fmt.Sprintf("%s: %v", annotation.path, annotation.err),
+			})
+		}
+	}
+	return invalid
+}
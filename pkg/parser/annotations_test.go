@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const annotatedValues = `# @schema type:string;enum:[debug, info, "warn;error"];required:true
+logLevel: info
+
+image:
+  # @schema
+  # pattern: ^[a-z0-9./-]+$
+  # description: Image repository
+  # @schema
+  repository: nginx
+  # @schema type:[string, "null"]
+  tag: null
+
+# -- Pods to run
+# @schema minimum: 1 ; maximum: 10
+replicas: 1
+
+# @schema
+# minimum: [1
+# @schema
+broken: 1
+# @schema path:replicas
+pointed: 1
+# @schema nothing
+unknown: 1
+# @schema
+unclosed: 1
+`
+
+func TestValuesAnnotations(t *testing.T) {
+	annotations, err := valuesAnnotations([]byte(annotatedValues))
+	if err != nil {
+		t.Fatalf("valuesAnnotations failed: %v", err)
+	}
+
+	parser := New()
+	for _, path := range []string{"logLevel", "image", "image.repository", "image.tag", "replicas", "broken"} {
+		parser.valuePath(path)
+	}
+	parser.addValuesAnnotations(annotations, "")
+
+	expected := map[string]map[string]any{
+		"logLevel":         {"type": "string", "enum": []any{"debug", "info", "warn;error"}},
+		"image.repository": {"pattern": "^[a-z0-9./-]+$", "description": "Image repository"},
+		"image.tag":        {"type": []any{"string", "null"}},
+		"replicas":         {"minimum": 1, "maximum": 10},
+		"image":            nil,
+		"broken":           nil,
+	}
+	for path, constraints := range expected {
+		if got := parser.values[path].Constraints; !reflect.DeepEqual(got, constraints) {
+			t.Errorf("Path %s has constraints %v, expected %v", path, got, constraints)
+		}
+	}
+	if !parser.values["logLevel"].Required {
+		t.Error("Expected logLevel to be required")
+	}
+	if parser.values["replicas"].Required {
+		t.Error("Expected replicas to stay optional")
+	}
+}
+
+func TestInvalidValuesAnnotations(t *testing.T) {
+	annotations, err := valuesAnnotations([]byte(annotatedValues))
+	if err != nil {
+		t.Fatalf("valuesAnnotations failed: %v", err)
+	}
+
+	invalid := invalidAnnotations(annotations, "values.yaml")
+	expected := map[string]string{
+		"broken":   "@schema block",
+		"pointed":  `@schema setting "path" is unknown`,
+		"unknown":  `@schema setting "nothing" is not key:value`,
+		"unclosed": "@schema block is not closed",
+	}
+	if len(invalid) != len(expected) {
+		t.Fatalf("Expected %d invalid annotations, got %v", len(expected), invalid)
+	}
+	for _, construct := range invalid {
+		path, _, _ := strings.Cut(construct.Snippet, ":")
+		if construct.Kind != UnresolvedDirective || construct.Site.File != "values.yaml" || construct.Site.Line == 0 {
+			t.Errorf("Expected an invalid directive in values.yaml, got %+v", construct)
+		}
+		if want, ok := expected[path]; !ok || !strings.Contains(construct.Snippet, want) {
+			t.Errorf("Expected %s to be reported with %q, got %q", path, want, construct.Snippet)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// blockScalarRe matches a line opening a YAML block scalar, e.g. "data: |" or "- >-"
+var blockScalarRe = regexp.MustCompile(`(?:^|:|-)\s*[|>][-+]?[0-9]?\s*$`)
+
+// stripYAMLComments blanks out full-line YAML comments so references inside them are ignored.
+// Lines are replaced by spaces rather than removed to keep offsets and line numbers intact.
+// Content of block scalars (key: |) is rendered verbatim by Helm, so '#' lines there are kept.
+// Template comments ({{/* ... */}}) are not YAML comments and are left for directive handling,
+// and continuation lines of actions spanning several lines are never treated as YAML.
+func stripYAMLComments(content string) string {
+	lines := strings.Split(content, "\n")
+	actions := scanActions(content)
+	blockIndent := -1
+	offset := 0
+
+	for i, line := range lines {
+		lineStart := offset
+		offset += len(line) + 1
+
+		if insideAction(actions, lineStart) {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " \t")
+		indent := len(line) - len(trimmed)
+
+		if blockIndent >= 0 {
+			// Blank lines and template-only lines do not terminate a block scalar
+			if trimmed == "" || strings.HasPrefix(trimmed, "{{") || indent > blockIndent {
+				continue
+			}
+			blockIndent = -1
+		}
+
+		if strings.HasPrefix(trimmed, "#") {
+			lines[i] = strings.Repeat(" ", len(line))
+			continue
+		}
+
+		if blockScalarRe.MatchString(line) {
+			blockIndent = indent
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// insideAction reports whether offset lies within an action that started before it
+func insideAction(actions [][]int, offset int) bool {
+	for _, span := range actions {
+		if span[0] >= offset {
+			return false
+		}
+		if offset < span[1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStripYAMLComments(t *testing.T) {
+	content := `metadata:
+  name: {{ .Values.app.name }}
+  # name: {{ .Values.legacy.name }}
+# image: {{ .Values.legacy.image }}
+data:
+  script.sh: |
+    # export HOST={{ .Values.script.host }}
+    echo {{ .Values.script.port }}
+  after: {{ .Values.after }}
+  #{{ .Values.legacy.tag }}
+`
+
+	parser := New()
+	parser.parseDirectValueReferences(stripYAMLComments(content))
+
+	expected := []string{"app.name", "app", "script.host", "script.port", "script", "after"}
+	for _, path := range expected {
+		if _, found := parser.values[path]; !found {
+			t.Errorf("Expected path %s not found", path)
+		}
+	}
+
+	for _, path := range []string{"legacy", "legacy.name", "legacy.image", "legacy.tag"} {
+		if _, found := parser.values[path]; found {
+			t.Errorf("Commented-out path %s should not be discovered", path)
+		}
+	}
+
+	if len(parser.values) != len(expected) {
+		t.Errorf("Expected %d paths, found %d", len(expected), len(parser.values))
+	}
+}
+
+func TestStripYAMLCommentsKeepsOffsets(t *testing.T) {
+	content := "a: 1\n# {{ .Values.x }}\nb: 2"
+	stripped := stripYAMLComments(content)
+
+	if len(stripped) != len(content) {
+		t.Errorf("Expected length %d, got %d", len(content), len(stripped))
+	}
+	if stripped != "a: 1\n"+strings.Repeat(" ", len("# {{ .Values.x }}"))+"\nb: 2" {
+		t.Errorf("Unexpected stripped content: %q", stripped)
+	}
+}
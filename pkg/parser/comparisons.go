@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Match: eq or ne, followed by the compared operands
+	comparisonRe = regexp.MustCompile(`\b(?:eq|ne)\s+`)
+)
+
+// Comparison is a literal a value is compared with by eq or ne, and where
+type Comparison struct {
+	Literal any
+	Site    Site
+}
+
+// parseComparisons finds {{ if eq .Values.mode "standalone" }} comparisons of values with
+// scalar literals. Go templates compare the first operand of eq with each of the others.
+func (tp *TemplateParser) parseComparisons(content string) {
+	index := tp.indexOf(content)
+	masked := index.masked
+	for _, match := range comparisonRe.FindAllStringIndex(masked, -1) {
+		if !isWordStart(masked, match[0]) {
+			continue
+		}
+		args := comparisonArgs(masked, match[1])
+		if len(args) < 2 {
+			continue
+		}
+
+		// Literals are read from the original content since masking blanks string contents
+		operand, literals := args[0], args[1:]
+		if _, isLiteral := scalarLiteral(content[args[0][0]:args[0][1]]); isLiteral && len(args) == 2 {
+			operand, literals = args[1], args[:1]
+		}
+		var values []any
+		for _, span := range literals {
+			value, isLiteral := scalarLiteral(content[span[0]:span[1]])
+			if !isLiteral {
+				values = nil
+				break
+			}
+			values = append(values, value)
+		}
+		if values == nil {
+			continue
+		}
+
+		text := masked[operand[0]:operand[1]]
+		path, ok := tp.resolveOperandAt(text, operand[0])
+		if !ok || path == "" {
+			continue
+		}
+		valuePath, exists := tp.values[tp.normalizePath(path)]
+		if !exists {
+			continue
+		}
+		// References are located at .Values, after the $ of $.Values
+		offset := operand[0]
+		if strings.HasPrefix(text, "$.Values") {
+			offset++
+		}
+		site := tp.siteAt(index, offset)
+		for _, value := range values {
+			valuePath.addComparison(Comparison{Literal: value, Site: site})
+		}
+	}
+}
+
+// comparisonArgs returns the spans of the operands of a function call starting at pos, up to the
+// end of its pipeline or enclosing group
+func comparisonArgs(masked string, pos int) [][]int {
+	var args [][]int
+	for {
+		for pos < len(masked) && isSpace(masked[pos]) {
+			pos++
+		}
+		if pos >= len(masked) || strings.ContainsRune("|)}", rune(masked[pos])) {
+			return args
+		}
+		end := matchingArgEnd(masked, pos)
+		// String contents are blanked, so the next quote closes the literal
+		if quote := masked[pos]; quote == '"' || quote == '`' || quote == '\'' {
+			if close := strings.IndexByte(masked[pos+1:], quote); close >= 0 {
+				end = pos + close + 2
+			}
+		}
+		if end <= pos {
+			return args
+		}
+		args = append(args, []int{pos, end})
+		pos = end
+	}
+}
+
+// scalarLiteral returns the value of a string, boolean or number literal
+func scalarLiteral(literal string) (any, bool) {
+	literalType, value, ok := parseLiteral(literal)
+	if !ok || literalType == "object" || literalType == "array" {
+		return nil, false
+	}
+	return value, true
+}
+
+// addComparison records a comparison of the value with a literal, once
+func (vp *ValuePath) addComparison(comparison Comparison) {
+	for _, existing := range vp.Comparisons {
+		if existing == comparison {
+			return
+		}
+	}
+	vp.Comparisons = append(vp.Comparisons, comparison)
+}
+
+// ComparedLiteral returns the literal a value is only ever compared with: every reference to it
+// compares it with eq or ne, always with that literal. Such values are effectively hard-coded,
+// the templates telling apart that literal from anything else.
+func (vp *ValuePath) ComparedLiteral() (any, bool) {
+	if len(vp.Comparisons) == 0 || len(vp.Sources) == 0 {
+		return nil, false
+	}
+	literal := vp.Comparisons[0].Literal
+	compared := make(map[Site]bool)
+	for _, comparison := range vp.Comparisons {
+		if comparison.Literal != literal {
+			return nil, false
+		}
+		compared[comparison.Site] = true
+	}
+	for _, site := range vp.Sources {
+		if !compared[site] {
+			return nil, false
+		}
+	}
+	return literal, true
+}
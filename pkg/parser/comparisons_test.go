@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestComparedLiteral(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "deployment.yaml")
+	template := `{{- if eq .Values.mode "standalone" }}
+replicas: 1
+{{- end }}
+{{- if ne "standalone" $.Values.mode }}
+replicas: 3
+{{- end }}
+{{- if eq .Values.tier "frontend" }}{{ end }}
+{{- if eq .Values.tier "backend" }}{{ end }}
+{{- if eq .Values.arch "amd64" "arm64" }}{{ end }}
+{{- if (eq .Values.replicas 3) }}{{ end }}
+{{- if eq .Values.debug true }}{{ end }}
+debug: {{ .Values.debug }}
+{{- if eq .Values.channel .Values.release }}{{ end }}
+{{- if eq .Values.label "a b" }}{{ end }}
+{{- $config := .Values.config }}
+{{- if eq $config.kind "file" }}{{ end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	expected := map[string]any{
+		"mode":        "standalone",
+		"replicas":    3,
+		"label":       "a b",
+		"config.kind": "file",
+	}
+	for path, valuePath := range parser.GetValues() {
+		literal, ok := valuePath.ComparedLiteral()
+		want, constant := expected[path]
+		if ok != constant || literal != want {
+			t.Errorf("%s: expected %v (%v), got %v (%v)", path, want, constant, literal, ok)
+		}
+	}
+	// Compared with several literals, with another value or also rendered
+	for _, path := range []string{"tier", "arch", "debug", "channel", "release"} {
+		if _, exists := parser.GetValues()[path]; !exists {
+			t.Errorf("Expected %s to be found", path)
+		}
+	}
+}
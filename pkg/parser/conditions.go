@@ -0,0 +1,244 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// Match: the keyword opening, continuing or closing a block, and its pipeline
+	blockKeywordRe = regexp.MustCompile(`^\{\{-?\s*(if|else|range|with|define|block|end)\b(.*?)-?\}\}$`)
+	// Match: the block an else continues, as in {{ else if ... }}
+	elseKeywordRe = regexp.MustCompile(`^\s*(if|with)\b`)
+)
+
+// optionalFunctions lists functions handling their argument being unset
+var optionalFunctions = map[string]bool{
+	"default":  true,
+	"coalesce": true,
+	"empty":    true,
+	"ternary":  true,
+}
+
+// conditionBranch is the body of a block branch, such as the part of an if block up to its else
+type conditionBranch struct {
+	start, end int      // Offsets of the body
+	guards     []string // Values the branch is only rendered with when they are truthy
+	define     bool     // Body of a named template, rendered wherever it is included
+}
+
+// conditionBranches returns the branches of the blocks of a template, in the order they open
+func (tp *TemplateParser) conditionBranches(index *templateIndex) []conditionBranch {
+	if index.branches != nil {
+		return index.branches
+	}
+
+	branches := []conditionBranch{}
+	var open []int // Branches of the blocks enclosing the action being read
+	masked := index.masked
+	for _, span := range index.actions {
+		match := blockKeywordRe.FindStringSubmatch(masked[span[0]:span[1]])
+		if match == nil {
+			continue
+		}
+		keyword, pipeline := match[1], match[2]
+		switch keyword {
+		case "end", "else":
+			if len(open) == 0 {
+				continue
+			}
+			branches[open[len(open)-1]].end = span[0]
+			open = open[:len(open)-1]
+			if keyword == "end" {
+				continue
+			}
+			// Branches of an else test their own condition, not the negation of the previous
+			var guards []string
+			if continued := elseKeywordRe.FindStringSubmatchIndex(pipeline); continued != nil {
+				guards = tp.conditionGuards(pipeline[continued[1]:], span[0])
+			}
+			branches = append(branches, conditionBranch{start: span[1], end: len(masked), guards: guards})
+		default:
+			branch := conditionBranch{start: span[1], end: len(masked), define: keyword == "define" || keyword == "block"}
+			if !branch.define {
+				branch.guards = tp.conditionGuards(pipeline, span[0])
+			}
+			branches = append(branches, branch)
+		}
+		open = append(open, len(branches)-1)
+	}
+
+	index.branches = branches
+	return branches
+}
+
+// conditionGuards returns the values an if, with or range pipeline requires to be truthy for its
+// body to render: the value tested, ranged over or bound, or each operand of an and
+func (tp *TemplateParser) conditionGuards(pipeline string, offset int) []string {
+	pipeline = strings.TrimSpace(pipeline)
+	pipeline = strings.TrimSpace(pipeline[len(declarationRe.FindString(pipeline)):])
+
+	operands := []string{pipeline}
+	if spans := argSpans(pipeline); len(spans) > 1 && pipeline[spans[0][0]:spans[0][1]] == "and" {
+		operands = operands[:0]
+		for _, span := range spans[1:] {
+			operands = append(operands, pipeline[span[0]:span[1]])
+		}
+	}
+
+	var guards []string
+	for _, operand := range operands {
+		// Plain operands only: the value of a function call tells nothing of its arguments
+		if len(argSpans(operand)) != 1 || strings.Contains(operand, "|") {
+			continue
+		}
+		if path, ok := tp.resolveOperandAt(operand, offset); ok && path != "" {
+			guards = append(guards, tp.normalizePath(path))
+		}
+	}
+	return guards
+}
+
+// guardsAt returns the values the blocks enclosing offset are only rendered with when they are
+// truthy, sorted, leaving out the value at path itself and those holding it or held by it, whose
+// truthiness its presence already tells
+func (tp *TemplateParser) guardsAt(index *templateIndex, offset int, path string) []string {
+	var guards []string
+	for _, branch := range tp.conditionBranches(index) {
+		if offset < branch.start || offset >= branch.end {
+			continue
+		}
+		if branch.define {
+			guards = nil
+			continue
+		}
+		for _, guard := range branch.guards {
+			if !relatedPaths(guard, path) && !slices.Contains(guards, guard) {
+				guards = append(guards, guard)
+			}
+		}
+	}
+	slices.Sort(guards)
+	return guards
+}
+
+// branchChain is an if/else if chain being read
+type branchChain struct {
+	selectors []string // Value tested by each branch, in order
+	plain     bool     // Whether every branch tests a single value
+}
+
+// parseExclusiveBranches finds {{ if .Values.a }} ... {{ else if .Values.b }} chains, whose
+// branches the chart renders one of, selected by the first value tested that is truthy. Chains
+// of branches each testing a single value are recorded on every value they test.
+func (tp *TemplateParser) parseExclusiveBranches(content string) {
+	index := tp.indexOf(content)
+	masked := index.masked
+	var open []*branchChain // Nil for blocks other than if and with
+	for _, span := range index.actions {
+		match := blockKeywordRe.FindStringSubmatch(masked[span[0]:span[1]])
+		if match == nil {
+			continue
+		}
+		keyword, pipeline := match[1], match[2]
+		switch keyword {
+		case "if", "with":
+			chain := &branchChain{plain: true}
+			chain.addSelector(tp, pipeline, span[0])
+			open = append(open, chain)
+		case "else":
+			if len(open) == 0 || open[len(open)-1] == nil {
+				continue
+			}
+			if continued := elseKeywordRe.FindStringSubmatchIndex(pipeline); continued != nil {
+				open[len(open)-1].addSelector(tp, pipeline[continued[1]:], span[0])
+			}
+		case "end":
+			if len(open) == 0 {
+				continue
+			}
+			chain := open[len(open)-1]
+			open = open[:len(open)-1]
+			if chain == nil || !chain.plain || len(chain.selectors) < 2 {
+				continue
+			}
+			for _, path := range chain.selectors {
+				if valuePath, exists := tp.values[path]; exists {
+					valuePath.addExclusive(chain.selectors)
+				}
+			}
+		default:
+			open = append(open, nil)
+		}
+	}
+}
+
+// addSelector adds the value a branch of the chain tests, which must be a single value unrelated
+// to those the other branches test
+func (c *branchChain) addSelector(tp *TemplateParser, pipeline string, offset int) {
+	pipeline = strings.TrimSpace(pipeline)
+	pipeline = strings.TrimSpace(pipeline[len(declarationRe.FindString(pipeline)):])
+	if len(argSpans(pipeline)) != 1 || strings.Contains(pipeline, "|") {
+		c.plain = false
+		return
+	}
+	path, ok := tp.resolveOperandAt(pipeline, offset)
+	if !ok || path == "" {
+		c.plain = false
+		return
+	}
+	path = tp.normalizePath(path)
+	for _, selector := range c.selectors {
+		if relatedPaths(selector, path) {
+			c.plain = false
+			return
+		}
+	}
+	c.selectors = append(c.selectors, path)
+}
+
+// addExclusive records a chain of branches the value selects one of, once
+func (vp *ValuePath) addExclusive(chain []string) {
+	for _, existing := range vp.Exclusive {
+		if slices.Equal(existing, chain) {
+			return
+		}
+	}
+	vp.Exclusive = append(vp.Exclusive, slices.Clone(chain))
+}
+
+// inCondition reports whether offset lies within the pipeline of an if or with action, whose
+// values the template tests rather than renders
+func inCondition(index *templateIndex, offset int) bool {
+	i := index.actionAt(offset)
+	if i < 0 {
+		return false
+	}
+	span := index.actions[i]
+	match := blockKeywordRe.FindStringSubmatch(index.masked[span[0]:span[1]])
+	if match == nil {
+		return false
+	}
+	return match[1] == "if" || match[1] == "with" || (match[1] == "else" && elseKeywordRe.MatchString(match[2]))
+}
+
+// relatedPaths reports whether two value paths are equal or one holds the other
+func relatedPaths(a, b string) bool {
+	return a == b || strings.HasPrefix(b, a+".") || strings.HasPrefix(b, a+"[]") ||
+		strings.HasPrefix(a, b+".") || strings.HasPrefix(a, b+"[]")
+}
+
+// addGuards narrows the guards of a value to those shared with a new reference
+func (vp *ValuePath) addGuards(guards []string, first bool) {
+	if first {
+		vp.Guards = slices.Clone(guards)
+		return
+	}
+	vp.Guards = slices.DeleteFunc(vp.Guards, func(guard string) bool {
+		return !slices.Contains(guards, guard)
+	})
+	if len(vp.Guards) == 0 {
+		vp.Guards = nil
+	}
+}
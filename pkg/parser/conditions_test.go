@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestConditionGuards(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "ingress.yaml")
+	template := `{{- if .Values.ingress.enabled }}
+host: {{ .Values.ingress.host }}
+class: {{ .Values.ingress.className | default "nginx" }}
+{{- with .Values.ingress.annotations }}
+annotations: {{ toYaml . }}
+{{- end }}
+{{- if and .Values.tls.enabled .Values.tls.secretName }}
+secret: {{ .Values.tls.secretName }}
+cert: {{ .Values.tls.cert }}
+{{- end }}
+{{- else if .Values.service.enabled }}
+port: {{ .Values.service.port }}
+{{- else }}
+name: {{ .Values.name }}
+{{- end }}
+{{- range .Values.hosts }}
+paths: {{ $.Values.paths }}
+{{- end }}
+{{- if .Values.metrics.enabled }}
+metrics: {{ .Values.metrics.port }}
+{{- end }}
+port: {{ .Values.metrics.port }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	values := parser.GetValues()
+
+	expected := map[string][]string{
+		"ingress.host":        {"ingress.enabled"},
+		"ingress.className":   {"ingress.enabled"},
+		"ingress.annotations": {"ingress.enabled"},
+		// Also referenced in the condition of the block
+		"tls.secretName": {"ingress.enabled"},
+		"tls.cert":       {"ingress.enabled", "tls.enabled", "tls.secretName"},
+		// else branches test their own condition
+		"service.port": {"service.enabled"},
+		"name":         nil,
+		"paths":        {"hosts"},
+		// Also referenced outside the block
+		"metrics.port":    nil,
+		"ingress.enabled": nil,
+	}
+	for path, want := range expected {
+		valuePath, exists := values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if !reflect.DeepEqual(valuePath.Guards, want) {
+			t.Errorf("Path %s is guarded by %v, expected %v", path, valuePath.Guards, want)
+		}
+	}
+
+	optional := map[string]bool{
+		"ingress.host":        false,
+		"ingress.className":   true,
+		"ingress.annotations": true,
+		"tls.secretName":      true,
+		"tls.cert":            false,
+		"ingress.enabled":     true,
+	}
+	for path, want := range optional {
+		if got := values[path].Optional; got != want {
+			t.Errorf("Path %s optional is %v, expected %v", path, got, want)
+		}
+	}
+}
+
+func TestExclusiveBranches(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "pvc.yaml")
+	template := `{{- if .Values.persistence.existingClaim }}
+claimName: {{ .Values.persistence.existingClaim }}
+{{- else if .Values.persistence.size }}
+{{- range .Values.accessModes }}
+mode: {{ . }}
+{{- end }}
+size: {{ .Values.persistence.size }}
+{{- else if .Values.persistence.hostPath }}
+path: {{ .Values.persistence.hostPath }}
+{{- else }}
+emptyDir: {}
+{{- end }}
+{{- if .Values.tls.secretName }}
+secret: {{ .Values.tls.secretName }}
+{{- else if and .Values.tls.enabled .Values.tls.issuer }}
+issuer: {{ .Values.tls.issuer }}
+{{- end }}
+{{- if .Values.metrics.enabled }}
+port: {{ .Values.metrics.port }}
+{{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+	values := parser.GetValues()
+
+	chain := []string{"persistence.existingClaim", "persistence.size", "persistence.hostPath"}
+	expected := map[string][][]string{
+		"persistence.existingClaim": {chain},
+		"persistence.size":          {chain},
+		"persistence.hostPath":      {chain},
+		"accessModes":               nil,
+		// A branch testing several values cannot be told apart by one
+		"tls.secretName": nil,
+		"tls.enabled":    nil,
+		// A single branch selects nothing
+		"metrics.enabled": nil,
+	}
+	for path, want := range expected {
+		valuePath, exists := values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if !reflect.DeepEqual(valuePath.Exclusive, want) {
+			t.Errorf("Path %s selects %v, expected %v", path, valuePath.Exclusive, want)
+		}
+	}
+}
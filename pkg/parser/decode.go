@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"regexp"
+)
+
+// decodeFunctions parse a string value as a structured document, by the encoding they read
+var decodeFunctions = map[string]string{
+	"fromYaml":      "yaml",
+	"fromYamlArray": "yaml",
+	"fromJson":      "json",
+	"fromJsonArray": "json",
+}
+
+// decodedBinding records the value a variable holds the decoded document of
+type decodedBinding struct {
+	path     string
+	encoding string
+}
+
+var (
+	// Match: {{ $var := (declaration at the start of an action)
+	declarationActionRe = regexp.MustCompile(`^` + pipelineOpen + `\$(` + identifier + `)` + assign)
+)
+
+// parseDecodedAssignments finds {{ $cfg := .Values.raw | fromYaml }} and
+// {{ $cfg := fromJson .Values.raw }} patterns: the value is a string holding a document, and
+// fields read from the variable belong to the document rather than to the values
+func (tp *TemplateParser) parseDecodedAssignments(index *templateIndex) {
+	masked := index.masked
+	for _, span := range index.actions {
+		action := masked[span[0]:span[1]]
+		declaration := declarationActionRe.FindStringSubmatchIndex(action)
+		if declaration == nil {
+			continue
+		}
+
+		match := tp.re.FindStringSubmatchIndex(action[declaration[1]:])
+		if match == nil {
+			continue
+		}
+		offset := span[0] + declaration[1] + match[0]
+		for _, function := range index.functionsAt(offset) {
+			encoding, decodes := decodeFunctions[function]
+			if !decodes {
+				continue
+			}
+			name := action[declaration[2]:declaration[3]]
+			delete(tp.variables, name)
+			tp.decoded[name] = decodedBinding{
+				path:     tp.normalizePath(action[declaration[1]+match[2] : declaration[1]+match[3]]),
+				encoding: encoding,
+			}
+			break
+		}
+	}
+}
+
+// resolveDecodedGroup returns the value a parenthesized group decodes, as in
+// (.Values.raw | fromYaml) or (fromJson .Values.raw)
+func (tp *TemplateParser) resolveDecodedGroup(group string) (decodedBinding, bool) {
+	commands := splitPipeline(group)
+	args := splitArgs(commands[0])
+
+	var function, operand string
+	switch {
+	case len(commands) == 2 && len(args) == 1:
+		if decodeArgs := splitArgs(commands[1]); len(decodeArgs) == 1 {
+			function, operand = decodeArgs[0], args[0]
+		}
+	case len(commands) == 1 && len(args) == 2:
+		function, operand = args[0], args[1]
+	}
+
+	encoding, decodes := decodeFunctions[function]
+	if !decodes {
+		return decodedBinding{}, false
+	}
+	path, ok := tp.resolveOperand(operand)
+	if !ok || path == "" {
+		return decodedBinding{}, false
+	}
+	return decodedBinding{path: tp.normalizePath(path), encoding: encoding}, true
+}
+
+// addDecodedField records a field read from the document a string value decodes to
+func (tp *TemplateParser) addDecodedField(source decodedBinding, field string) {
+	valuePath := tp.valuePath(source.path)
+	valuePath.Encoding = source.encoding
+	valuePath.Decoded = mergeSorted(valuePath.Decoded, []string{field})
+}
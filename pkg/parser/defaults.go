@@ -0,0 +1,157 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// Match: integer literals, e.g. 80 or -1
+	integerLiteralRe = regexp.MustCompile(`^-?\d+$`)
+	// Match: floating point literals, e.g. 0.5 or 1e-3
+	numberLiteralRe = regexp.MustCompile(`^-?\d+(?:\.\d+)?(?:[eE][-+]?\d+)?$`)
+)
+
+// parseDefaultLiterals finds values given a literal fallback, as in {{ .Values.port | default 80 }}
+// or {{ default "nginx" .Values.image }}, and types them after the literal
+func (tp *TemplateParser) parseDefaultLiterals(content string) {
+	index := tp.indexOf(content)
+	masked := index.masked
+	for _, span := range index.actions {
+		start, end := enclosingGroup(masked[span[0]:span[1]], 0)
+		tp.parseDefaultPipeline(content[span[0]+start:span[0]+end], masked[span[0]+start:span[0]+end], tp.siteAt(index, span[0]))
+	}
+}
+
+// parseDefaultPipeline handles the default commands of a single pipeline and of the
+// parenthesized groups within it. Structure is read from the masked pipeline while literals
+// are read from the original text at the same offsets.
+func (tp *TemplateParser) parseDefaultPipeline(raw, masked string, site Site) {
+	var previous [][]string
+	offset := 0
+	for i, command := range splitPipeline(masked) {
+		var args [][]string // Pairs of masked and raw argument text
+		for _, span := range commandArgSpans(command) {
+			args = append(args, []string{command[span[0]:span[1]], raw[offset+span[0] : offset+span[1]]})
+		}
+		offset += len(command) + 1
+
+		for _, arg := range args {
+			if strings.HasPrefix(arg[0], "(") && strings.HasSuffix(arg[0], ")") {
+				tp.parseDefaultPipeline(arg[1][1:len(arg[1])-1], arg[0][1:len(arg[0])-1], site)
+			}
+		}
+
+		if len(args) > 0 && args[0][0] == "default" {
+			switch {
+			case len(args) == 3:
+				// default "nginx" .Values.image
+				tp.addDefaultLiteral(args[2][0], args[1][1], site)
+			case len(args) == 2 && i > 0 && len(previous) == 1:
+				// .Values.image | default "nginx"
+				tp.addDefaultLiteral(previous[0][0], args[1][1], site)
+			}
+		}
+		previous = args
+	}
+}
+
+// addDefaultLiteral types the value an operand refers to after its literal fallback
+func (tp *TemplateParser) addDefaultLiteral(operand, literal string, site Site) {
+	literalType, value, ok := parseLiteral(literal)
+	if !ok {
+		return
+	}
+
+	path, ok := tp.resolveOperand(operand)
+	if !ok || path == "" {
+		return
+	}
+
+	valuePath := tp.addTypeHint(path, TypeHint{Type: literalType, Reason: "defaults to " + literal, Site: site, Confidence: ConfidenceDefault})
+	if valuePath.Default == nil {
+		valuePath.Default = value
+	}
+}
+
+// parseLiteral returns the type and value of a template literal
+func parseLiteral(literal string) (string, any, bool) {
+	switch {
+	case strings.HasPrefix(literal, `"`):
+		value, err := strconv.Unquote(literal)
+		return "string", value, err == nil
+	case strings.HasPrefix(literal, "`") && strings.HasSuffix(literal, "`") && len(literal) > 1:
+		return "string", literal[1 : len(literal)-1], true
+	case literal == "true" || literal == "false":
+		return "boolean", literal == "true", true
+	case integerLiteralRe.MatchString(literal):
+		value, err := strconv.Atoi(literal)
+		return "integer", value, err == nil
+	case numberLiteralRe.MatchString(literal):
+		value, err := strconv.ParseFloat(literal, 64)
+		return "number", value, err == nil
+	case literal == "dict" || literal == "(dict)":
+		return "object", map[string]any{}, true
+	case literal == "list" || literal == "(list)":
+		return "array", []any{}, true
+	}
+	return "", nil, false
+}
+
+// commandArgSpans returns the argument offsets of a pipeline command, skipping leading action
+// keywords and variable declarations, e.g. the if and $x := of {{ if $x := .Values.a }}
+func commandArgSpans(command string) [][]int {
+	spans := argSpans(command)
+	for len(spans) > 0 && templateKeywords[command[spans[0][0]:spans[0][1]]] {
+		spans = spans[1:]
+	}
+	for i := 0; i < len(spans) && i < 3; i++ {
+		token := command[spans[i][0]:spans[i][1]]
+		if token == ":=" || token == "=" {
+			return spans[i+1:]
+		}
+	}
+	return spans
+}
+
+// addValuesDefaults takes the defaults of the values found in the templates, and the types
+// they imply, from the values file content of the chart, which Helm applies before any template
+// fallback. Non-empty maps are left to their fields, and paths through lists or map keys have no
+// single default. Values set to null, as charts do to disable a section, accept null.
+func (tp *TemplateParser) addValuesDefaults(defaults map[string]any) {
+	for path, valuePath := range tp.values {
+		current, set := valueAtPath(defaults, path)
+		if current == nil {
+			valuePath.Nullable = valuePath.Nullable || set
+			continue
+		}
+		if object, isObject := current.(map[string]any); isObject && len(object) > 0 {
+			continue
+		}
+		if valueType := valueTypeOf(current); valueType != "" {
+			tp.addTypeHint(path, TypeHint{Type: valueType, Reason: "set in the chart's values", Confidence: ConfidenceDefault})
+		}
+		valuePath.Default = current
+		valuePath.addExample(current)
+	}
+}
+
+// valueAtPath returns the value at a value path of values file content and whether it is set. Paths
+// through lists or map keys have no single value.
+func valueAtPath(values map[string]any, path string) (any, bool) {
+	if strings.Contains(path, "[]") {
+		return nil, false
+	}
+
+	var current any = values
+	set := true
+	for _, segment := range SplitPath(path) {
+		object, ok := current.(map[string]any)
+		if !ok || segment == AnyKey {
+			return nil, false
+		}
+		current, set = object[UnescapeKey(segment)]
+	}
+	return current, set
+}
@@ -0,0 +1,81 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Match: # @deprecated, optionally followed by a message, as in # @deprecated -- use image.repository
+	deprecatedRe = regexp.MustCompile(`^#\s*@deprecated\b\s*(?:--)?\s*(.*)$`)
+)
+
+// loadValuesDeprecations reads the # @deprecated markers of a values file, none when the file
+// does not exist
+func loadValuesDeprecations(path string) (map[string]string, error) {
+	content, err := readValuesFile(path)
+	if content == nil || err != nil {
+		return nil, err
+	}
+	deprecations, err := valuesDeprecations(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+	}
+	return deprecations, nil
+}
+
+// valuesDeprecations reads the # @deprecated markers in the comments above the keys of
+// values.yaml content, keyed by value path, with the message following the marker, if any
+//
+//	# -- Image to run
+//	# @deprecated -- use image.repository and image.tag
+//	imageName: nginx:1.25
+func valuesDeprecations(content []byte) (map[string]string, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	deprecations := make(map[string]string)
+	if len(document.Content) == 0 {
+		return deprecations, nil
+	}
+	root := document.Content[0]
+	walkKeys(root, "", func(keyPath string, key, _ *yaml.Node) {
+		addDeprecation(deprecations, key.HeadComment, keyPath)
+	})
+	// The document comment holds the comments of the first key
+	if len(root.Content) > 0 {
+		addDeprecation(deprecations, document.HeadComment, EscapeKey(root.Content[0].Value))
+	}
+	return deprecations, nil
+}
+
+// addDeprecation records the # @deprecated marker a comment block holds for path
+func addDeprecation(deprecations map[string]string, comment, path string) {
+	for _, line := range strings.Split(comment, "\n") {
+		if match := deprecatedRe.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			deprecations[path] = strings.TrimSpace(match[1])
+		}
+	}
+}
+
+// addValuesDeprecations marks the values found in the templates deprecated when their
+// values.yaml keys, the paths of which are relative to prefix, are
+func (tp *TemplateParser) addValuesDeprecations(deprecations map[string]string, prefix string) {
+	for path, message := range deprecations {
+		if prefix != "" {
+			if !strings.HasPrefix(path, prefix+".") {
+				continue
+			}
+			path = strings.TrimPrefix(path, prefix+".")
+		}
+		if valuePath, exists := tp.values[path]; exists {
+			valuePath.Deprecated = true
+			valuePath.Deprecation = message
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// Match: # -- description, the helm-docs comment above a key
+	descriptionRe = regexp.MustCompile(`^#\s*--\s?(.*)$`)
+	// Match: # path.to.key -- description, the older helm-docs form naming the key it documents
+	pathDescriptionRe = regexp.MustCompile(`^#\s*([^\s#]+)\s+--\s?(.*)$`)
+	// Match: # @default -- ..., # @section -- ... and other helm-docs annotations
+	annotationRe = regexp.MustCompile(`^#\s*@\w+`)
+)
+
+// loadValuesDescriptions reads the helm-docs descriptions of a values file, none when the file
+// does not exist
+func loadValuesDescriptions(path string) (map[string]string, error) {
+	content, err := readValuesFile(path)
+	if content == nil || err != nil {
+		return nil, err
+	}
+	descriptions, err := valuesDescriptions(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
+	}
+	return descriptions, nil
+}
+
+// readValuesFile reads a values file, returning nil content when it does not exist
+func readValuesFile(path string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read values file: %w", err)
+	}
+	return content, nil
+}
+
+// addValuesDescriptions describes the values found in the templates with the descriptions of
+// their values.yaml keys, the paths of which are relative to prefix
+func (tp *TemplateParser) addValuesDescriptions(descriptions map[string]string, prefix string) {
+	for path, description := range descriptions {
+		if prefix != "" {
+			if !strings.HasPrefix(path, prefix+".") {
+				continue
+			}
+			path = strings.TrimPrefix(path, prefix+".")
+		}
+		if valuePath, exists := tp.values[path]; exists {
+			valuePath.Description = description
+		}
+	}
+}
+
+// valuesDescriptions reads the helm-docs style comments of values.yaml content, keyed by value
+// path: a # -- comment documents the key below it, continuing over the comment lines that follow
+// up to a helm-docs annotation such as # @default --, and # path.to.key -- documents the key it
+// names wherever it is
+func valuesDescriptions(content []byte) (map[string]string, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, err
+	}
+
+	descriptions := make(map[string]string)
+	if len(document.Content) > 0 {
+		collectDescriptions(document.Content[0], "", descriptions)
+		// The document comment holds the comments of the first key
+		if len(document.Content[0].Content) > 0 {
+			addDescription(descriptions, document.HeadComment, joinPath("", EscapeKey(document.Content[0].Content[0].Value)))
+		}
+	}
+	return descriptions, nil
+}
+
+// collectDescriptions records the descriptions of the keys below a node found at path
+func collectDescriptions(node *yaml.Node, path string, descriptions map[string]string) {
+	walkKeys(node, path, func(keyPath string, key, value *yaml.Node) {
+		addDescription(descriptions, key.HeadComment, keyPath)
+		addDescription(descriptions, key.FootComment, "")
+		addDescription(descriptions, value.FootComment, "")
+	})
+}
+
+// walkKeys calls fn with every key of the mappings below a node found at path, parents first
+func walkKeys(node *yaml.Node, path string, fn func(keyPath string, key, value *yaml.Node)) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := joinPath(path, EscapeKey(key.Value))
+		fn(keyPath, key, value)
+		walkKeys(value, keyPath, fn)
+	}
+}
+
+// addDescription records the descriptions a comment block holds, the # -- one for path unless
+// path is empty
+func addDescription(descriptions map[string]string, comment, path string) {
+	var target string
+	var lines []string
+	flush := func() {
+		if target != "" && len(lines) > 0 {
+			descriptions[target] = strings.TrimSpace(strings.Join(lines, " "))
+		}
+		target, lines = "", nil
+	}
+
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case annotationRe.MatchString(line):
+			flush()
+		case descriptionRe.MatchString(line):
+			flush()
+			if path != "" {
+				target = path
+				lines = []string{descriptionRe.FindStringSubmatch(line)[1]}
+			}
+		case pathDescriptionRe.MatchString(line):
+			flush()
+			match := pathDescriptionRe.FindStringSubmatch(line)
+			target, lines = match[1], []string{match[2]}
+		case target != "" && strings.HasPrefix(line, "#"):
+			lines = append(lines, strings.TrimSpace(strings.TrimPrefix(line, "#")))
+		default:
+			flush()
+		}
+	}
+	flush()
+}
@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValuesDescriptions(t *testing.T) {
+	content := `# -- Number of pods
+replicas: 1
+
+# -- Image settings
+image:
+  # -- Repository
+  # of the image
+  repository: nginx
+  # -- Tag
+  # @default -- the chart's appVersion
+  tag: ""
+
+  # A plain comment
+  pullPolicy: Always
+
+# -- Escaped like template paths
+nginx.conf: ""
+
+# resources.limits -- Container limits, in the older form naming the key
+resources: {}
+`
+
+	descriptions, err := valuesDescriptions([]byte(content))
+	if err != nil {
+		t.Fatalf("valuesDescriptions failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"replicas":         "Number of pods",
+		"image":            "Image settings",
+		"image.repository": "Repository of the image",
+		"image.tag":        "Tag",
+		`nginx\.conf`:      "Escaped like template paths",
+		"resources.limits": "Container limits, in the older form naming the key",
+	}
+	if !reflect.DeepEqual(descriptions, expected) {
+		t.Errorf("Expected descriptions %v, got %v", expected, descriptions)
+	}
+}
+
+func TestAddValuesDescriptions(t *testing.T) {
+	parser := New()
+	parser.valuePath("port").Description = "Own description"
+	parser.valuePath("name")
+
+	parser.addValuesDescriptions(map[string]string{
+		"cache.port": "Port set by the parent",
+		"cache":      "Cache subchart",
+		"other.name": "Another dependency",
+	}, "cache")
+
+	if description := parser.values["port"].Description; description != "Port set by the parent" {
+		t.Errorf("Expected the parent's description to take precedence, got %q", description)
+	}
+	if description := parser.values["name"].Description; description != "" {
+		t.Errorf("Expected no description for name, got %q", description)
+	}
+}
+
+func TestValuesDeprecations(t *testing.T) {
+	content := `# @deprecated
+legacy: true
+
+image:
+  # -- Image to run
+  # @deprecated -- use image.repository and image.tag
+  name: nginx:1.25
+  repository: nginx
+
+# A plain comment mentioning @deprecated
+replicas: 1
+`
+
+	deprecations, err := valuesDeprecations([]byte(content))
+	if err != nil {
+		t.Fatalf("valuesDeprecations failed: %v", err)
+	}
+
+	expected := map[string]string{
+		"legacy":     "",
+		"image.name": "use image.repository and image.tag",
+	}
+	if !reflect.DeepEqual(deprecations, expected) {
+		t.Errorf("Expected deprecations %v, got %v", expected, deprecations)
+	}
+	// The marker ends the description above it
+	descriptions, _ := valuesDescriptions([]byte(content))
+	if description := descriptions["image.name"]; description != "Image to run" {
+		t.Errorf("Expected the description up to the marker, got %q", description)
+	}
+}
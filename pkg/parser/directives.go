@@ -0,0 +1,371 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// directiveKeywords are the schema keywords directives may set
+var directiveKeywords = map[string]bool{
+	"type":             true,
+	"enum":             true,
+	"const":            true,
+	"default":          true,
+	"title":            true,
+	"description":      true,
+	"examples":         true,
+	"deprecated":       true,
+	"format":           true,
+	"pattern":          true,
+	"minLength":        true,
+	"maxLength":        true,
+	"minimum":          true,
+	"maximum":          true,
+	"exclusiveMinimum": true,
+	"exclusiveMaximum": true,
+	"multipleOf":       true,
+	"minItems":         true,
+	"maxItems":         true,
+	"uniqueItems":      true,
+	"minProperties":    true,
+	"maxProperties":    true,
+}
+
+// schemaTypes are the type names of JSON Schema
+var schemaTypes = map[string]bool{
+	"string":  true,
+	"integer": true,
+	"number":  true,
+	"boolean": true,
+	"array":   true,
+	"object":  true,
+	"null":    true,
+}
+
+var (
+	// Match: {{/* helm-schema: type=integer, minimum=1 */}}, capturing the settings
+	directiveRe = regexp.MustCompile(`(?s)^\{\{-?\s*/\*\s*helm-schema:(.*?)\*/\s*-?\}\}$`)
+)
+
+// directive is a parsed directive comment
+type directive struct {
+	path        string         // Restricts the directive to this value, from path=...
+	required    bool           // From required=true
+	nullable    bool           // From nullable=true
+	constraints map[string]any // Schema keywords
+}
+
+// Ignore directives, as the settings of a directive comment
+const (
+	ignoreLine  = "ignore"       // Excludes the references next to the comment
+	ignoreStart = "ignore-start" // Excludes the references up to the matching ignore-end
+	ignoreEnd   = "ignore-end"
+)
+
+// lineRange is an inclusive range of 1-based lines
+type lineRange struct {
+	first, last int
+}
+
+// parseDirectives applies directive comments such as {{/* helm-schema: type=integer, minimum=1 */}}
+// to the values referenced on the lines the comment spans or, for a comment on lines of its own,
+// on the line right after it. Settings are comma separated key=value pairs whose values are
+// read as YAML, e.g. enum=[a, b] or description="Pods, at least 1"; path=<value path> picks a
+// single value when the line references several, and required=true marks the value required.
+//
+// {{/* helm-schema:ignore */}} excludes the references next to it the same way, and
+// {{/* helm-schema:ignore-start */}} ... {{/* helm-schema:ignore-end */}} every reference
+// between the two. Values only referenced on ignored lines are left out of the model, and
+// constructs found there are not reported as unresolved.
+//
+// Directives that cannot be parsed or apply to no value are returned as unresolved.
+func (tp *TemplateParser) parseDirectives(content string) []Unresolved {
+	index := tp.indexOf(content)
+
+	var found []Unresolved
+	report := func(offset int, format string, args ...any) {
+		found = append(found, Unresolved{Kind: UnresolvedDirective, Site: tp.siteAt(index, offset), Snippet: fmt.Sprintf(format, args...)})
+	}
+
+	// Ignored references are dropped first, so constraints never apply to them
+	var directives [][]int
+	start := -1 // Offset of the pending ignore-start
+	for _, span := range index.actions {
+		match := directiveRe.FindStringSubmatchIndex(content[span[0]:span[1]])
+		if match == nil {
+			continue
+		}
+		first, _ := index.position(span[0])
+		last, _ := index.position(span[1] - 1)
+
+		switch strings.TrimSpace(content[span[0]+match[2] : span[0]+match[3]]) {
+		case ignoreLine:
+			lines := lineRange{first, last}
+			if len(tp.referencedBetween(first, last, "")) == 0 {
+				lines = lineRange{last + 1, last + 1}
+			}
+			if len(tp.referencedBetween(lines.first, lines.last, "")) == 0 {
+				report(span[0], "no value referenced next to %s", strings.Join(strings.Fields(content[span[0]:span[1]]), " "))
+				continue
+			}
+			tp.ignored = append(tp.ignored, lines)
+		case ignoreStart:
+			if start >= 0 {
+				report(span[0], "%s within another %s", ignoreStart, ignoreStart)
+				continue
+			}
+			start = span[0]
+		case ignoreEnd:
+			if start < 0 {
+				report(span[0], "%s without %s", ignoreEnd, ignoreStart)
+				continue
+			}
+			startLine, _ := index.position(start)
+			tp.ignored = append(tp.ignored, lineRange{startLine, last})
+			start = -1
+		default:
+			directives = append(directives, []int{span[0], span[1], span[0] + match[2], span[0] + match[3]})
+		}
+	}
+	if start >= 0 {
+		report(start, "%s without %s", ignoreStart, ignoreEnd)
+		startLine, _ := index.position(start)
+		tp.ignored = append(tp.ignored, lineRange{startLine, len(index.lineStarts)})
+	}
+	tp.suppressIgnored()
+
+	for _, span := range directives {
+		d, err := parseDirective(content[span[2]:span[3]])
+		if err != nil {
+			report(span[0], "%v", err)
+			continue
+		}
+
+		first, _ := index.position(span[0])
+		last, _ := index.position(span[1] - 1)
+		targets := tp.referencedBetween(first, last, d.path)
+		if len(targets) == 0 {
+			targets = tp.referencedBetween(last+1, last+1, d.path)
+		}
+		if len(targets) == 0 {
+			report(span[0], "no value referenced next to %s", strings.Join(strings.Fields(content[span[0]:span[1]]), " "))
+			continue
+		}
+
+		for _, valuePath := range targets {
+			d.apply(valuePath)
+		}
+	}
+
+	tp.unresolved = append(tp.unresolved, found...)
+	return found
+}
+
+// suppressIgnored drops the sources and hints found on ignored lines of the template being
+// parsed, and the values left without any, unless other values are nested below them
+func (tp *TemplateParser) suppressIgnored() {
+	if len(tp.ignored) == 0 {
+		return
+	}
+
+	var suppressed []string
+	kept := make(map[string]bool)
+	for path, valuePath := range tp.values {
+		sources := slices.DeleteFunc(slices.Clone(valuePath.Sources), tp.ignoredSite)
+		hints := slices.DeleteFunc(slices.Clone(valuePath.Hints), func(hint TypeHint) bool {
+			return tp.ignoredSite(hint.Site)
+		})
+		switch {
+		case len(sources) == 0 && len(hints) == 0 && (len(valuePath.Sources) > 0 || len(valuePath.Hints) > 0):
+			suppressed = append(suppressed, path)
+		case len(sources) < len(valuePath.Sources) || len(hints) < len(valuePath.Hints):
+			valuePath.Sources, valuePath.Hints = sources, hints
+			valuePath.Type, valuePath.Types, valuePath.Confidence = resolveHints(hints)
+			kept[path] = true
+		default:
+			kept[path] = true
+		}
+	}
+
+	for _, path := range suppressed {
+		nested := false
+		for other := range kept {
+			if strings.HasPrefix(other, path+".") || strings.HasPrefix(other, path+"[]") {
+				nested = true
+				break
+			}
+		}
+		if !nested {
+			delete(tp.values, path)
+		}
+	}
+}
+
+// ignoredSite reports whether a site lies on an ignored line of the template being parsed
+func (tp *TemplateParser) ignoredSite(site Site) bool {
+	if site.File != tp.file {
+		return false
+	}
+	for _, lines := range tp.ignored {
+		if site.Line >= lines.first && site.Line <= lines.last {
+			return true
+		}
+	}
+	return false
+}
+
+// referencedBetween returns the values referenced in the template being parsed on lines first
+// through last, in path order, limited to path unless it is empty
+func (tp *TemplateParser) referencedBetween(first, last int, path string) []*ValuePath {
+	var referenced []*ValuePath
+	for _, valuePath := range tp.values {
+		if path != "" && valuePath.Path != path {
+			continue
+		}
+		for _, site := range valuePath.Sources {
+			if site.File == tp.file && site.Line >= first && site.Line <= last {
+				referenced = append(referenced, valuePath)
+				break
+			}
+		}
+	}
+	sort.Slice(referenced, func(i, j int) bool {
+		return referenced[i].Path < referenced[j].Path
+	})
+	return referenced
+}
+
+// parseDirective parses the settings of a directive comment
+func parseDirective(settings string) (*directive, error) {
+	d := &directive{constraints: make(map[string]any)}
+	for _, setting := range splitSettings(settings, ',') {
+		key, raw, ok := strings.Cut(setting, "=")
+		key, raw = strings.TrimSpace(key), strings.TrimSpace(raw)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("directive setting %q is not key=value", setting)
+		}
+
+		var value any
+		if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("directive setting %s: %w", key, err)
+		}
+		if err := d.set(key, value); err != nil {
+			return nil, fmt.Errorf("directive %w", err)
+		}
+	}
+	if d.empty() {
+		return nil, fmt.Errorf("directive sets nothing")
+	}
+	return d, nil
+}
+
+// empty reports whether a directive sets nothing
+func (d *directive) empty() bool {
+	return !d.required && !d.nullable && len(d.constraints) == 0
+}
+
+// apply sets what the directive declares on a value
+func (d *directive) apply(valuePath *ValuePath) {
+	valuePath.Required = valuePath.Required || d.required
+	valuePath.Nullable = valuePath.Nullable || d.nullable
+	if len(d.constraints) == 0 {
+		return
+	}
+	if valuePath.Constraints == nil {
+		valuePath.Constraints = make(map[string]any)
+	}
+	for keyword, value := range d.constraints {
+		valuePath.Constraints[keyword] = value
+	}
+}
+
+// set applies a single decoded setting
+func (d *directive) set(key string, value any) error {
+	switch {
+	case key == "path":
+		path, isString := value.(string)
+		if !isString || path == "" {
+			return fmt.Errorf("setting path must be a value path, got %v", value)
+		}
+		d.path = path
+	case key == "required":
+		required, isBool := value.(bool)
+		if !isBool {
+			return fmt.Errorf("setting required must be true or false, got %v", value)
+		}
+		d.required = required
+	case key == "nullable":
+		nullable, isBool := value.(bool)
+		if !isBool {
+			return fmt.Errorf("setting nullable must be true or false, got %v", value)
+		}
+		d.nullable = nullable
+	case key == "type":
+		if err := checkDirectiveType(value); err != nil {
+			return err
+		}
+		d.constraints[key] = value
+	case directiveKeywords[key]:
+		d.constraints[key] = value
+	default:
+		return fmt.Errorf("setting %q is unknown", key)
+	}
+	return nil
+}
+
+// checkDirectiveType accepts a JSON Schema type name or a list of them
+func checkDirectiveType(value any) error {
+	types, isList := value.([]any)
+	if !isList {
+		types = []any{value}
+	}
+	for _, valueType := range types {
+		name, isString := valueType.(string)
+		if !isString || !schemaTypes[name] {
+			return fmt.Errorf("setting type: unknown type %v", valueType)
+		}
+	}
+	return nil
+}
+
+// splitSettings splits settings at separators outside quotes, brackets and braces
+func splitSettings(settings string, separator byte) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(settings); i++ {
+		c := settings[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		case c == separator && depth == 0:
+			parts = append(parts, settings[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, settings[start:])
+
+	var settingsList []string
+	for _, part := range parts {
+		if strings.TrimSpace(part) != "" {
+			settingsList = append(settingsList, part)
+		}
+	}
+	return settingsList
+}
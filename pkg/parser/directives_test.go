@@ -0,0 +1,166 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDirectives(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: directives\nversion: 0.1.0\n",
+		"templates/deployment.yaml": `{{/* helm-schema: type=integer, minimum=1 */}}
+replicas: {{ .Values.replicas }}
+image: {{ .Values.image.tag }} {{/* helm-schema: pattern="^v[0-9]+", description="Tag, with a v prefix" */}}
+{{- /* helm-schema: enum=[IfNotPresent, Always], required=true, path=image.pullPolicy */}}
+pull: {{ .Values.image.pullPolicy | default .Values.global.pullPolicy }}
+port: {{ .Values.port }}
+{{- /* helm-schema: nullable=true */}}
+affinity: {{ toYaml .Values.affinity }}
+`,
+	}
+	writeChartFiles(t, chartPath, files)
+
+	parser := New()
+	if err := parser.ParseChartWithOptions(chartPath, false); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	values := parser.GetValues()
+
+	expected := map[string]map[string]any{
+		"replicas":          {"type": "integer", "minimum": 1},
+		"image.tag":         {"pattern": "^v[0-9]+", "description": "Tag, with a v prefix"},
+		"image.pullPolicy":  {"enum": []any{"IfNotPresent", "Always"}},
+		"global.pullPolicy": nil,
+		"port":              nil,
+		"affinity":          nil,
+	}
+	for path, constraints := range expected {
+		valuePath, exists := values[path]
+		if !exists {
+			t.Fatalf("Expected %s to be found", path)
+		}
+		if len(constraints) == 0 && len(valuePath.Constraints) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(valuePath.Constraints, constraints) {
+			t.Errorf("Expected %s to be constrained by %v, got %v", path, constraints, valuePath.Constraints)
+		}
+	}
+	if !values["image.pullPolicy"].Required || values["global.pullPolicy"].Required {
+		t.Error("Expected only image.pullPolicy to be marked required")
+	}
+	if !values["affinity"].Nullable || values["port"].Nullable {
+		t.Error("Expected only affinity to be marked nullable")
+	}
+	if len(parser.Unresolved()) != 0 {
+		t.Errorf("Expected every directive to apply, got %v", parser.Unresolved())
+	}
+}
+
+func TestInvalidDirectives(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+	}{
+		{"unknown setting", "{{/* helm-schema: colour=blue */}}\nx: {{ .Values.x }}\n"},
+		{"unknown type", "{{/* helm-schema: type=int */}}\nx: {{ .Values.x }}\n"},
+		{"not key=value", "{{/* helm-schema: integer */}}\nx: {{ .Values.x }}\n"},
+		{"no adjacent value", "{{/* helm-schema: type=integer */}}\n\nx: {{ .Values.x }}\n"},
+		{"path not on line", "{{/* helm-schema: type=integer, path=y */}}\nx: {{ .Values.x }}\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			templateFile := filepath.Join(t.TempDir(), "template.yaml")
+			if err := os.WriteFile(templateFile, []byte(tt.template), 0644); err != nil {
+				t.Fatalf("Failed to write template: %v", err)
+			}
+
+			parser := NewWithOptions(Options{Strict: true})
+			if err := parser.ParseTemplateFile(templateFile); err == nil {
+				t.Fatal("Expected strict parsing to fail on the directive")
+			}
+			unresolved := parser.Unresolved()
+			if len(unresolved) != 1 || unresolved[0].Kind != UnresolvedDirective {
+				t.Errorf("Expected a single invalid directive, got %v", unresolved)
+			}
+			if values := parser.GetValues(); values["x"] == nil || values["x"].Constraints != nil {
+				t.Errorf("Expected x to be found without constraints, got %+v", values["x"])
+			}
+		})
+	}
+}
+
+func TestIgnoreDirectives(t *testing.T) {
+	template := `name: {{ .Values.name }}
+{{/* helm-schema:ignore */}}
+checksum: {{ .Values.internal.checksum | int }}
+port: {{ .Values.port | int }} {{/* helm-schema:ignore */}}
+{{- /* helm-schema:ignore-start */}}
+{{- if .Values.computed.enabled }}
+rendered: {{ tpl .Values.computed.template . }}
+{{- end }}
+{{- /* helm-schema:ignore-end */}}
+port: {{ .Values.port | b64enc }}
+internal: {{ .Values.internal.name }}
+`
+	templateFile := filepath.Join(t.TempDir(), "template.yaml")
+	if err := os.WriteFile(templateFile, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := NewWithOptions(Options{Strict: true})
+	if err := parser.ParseTemplateFile(templateFile); err != nil {
+		t.Fatalf("Expected constructs on ignored lines not to fail strict parsing: %v", err)
+	}
+	values := parser.GetValues()
+
+	for _, path := range []string{"internal.checksum", "computed", "computed.enabled", "computed.template"} {
+		if _, exists := values[path]; exists {
+			t.Errorf("Expected ignored value %s to be excluded", path)
+		}
+	}
+	for _, path := range []string{"name", "internal", "internal.name"} {
+		if _, exists := values[path]; !exists {
+			t.Errorf("Expected value %s to be kept", path)
+		}
+	}
+
+	// Only the reference outside ignored lines types the value
+	port := values["port"]
+	if port == nil || port.Type != "string" || len(port.Sources) != 1 || port.Sources[0].Line != 10 {
+		t.Errorf("Expected port to be typed by its reference on line 10 only, got %+v", port)
+	}
+}
+
+func TestUnbalancedIgnoreDirectives(t *testing.T) {
+	template := `{{/* helm-schema:ignore-end */}}
+a: {{ .Values.a }}
+{{/* helm-schema:ignore-start */}}
+b: {{ .Values.b }}
+`
+	templateFile := filepath.Join(t.TempDir(), "template.yaml")
+	if err := os.WriteFile(templateFile, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templateFile); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	unresolved := parser.Unresolved()
+	if len(unresolved) != 2 || unresolved[0].Site.Line != 1 || unresolved[1].Site.Line != 3 {
+		t.Errorf("Expected the stray ignore-end and the unclosed ignore-start to be reported, got %v", unresolved)
+	}
+	values := parser.GetValues()
+	if _, exists := values["a"]; !exists {
+		t.Error("Expected a to be kept")
+	}
+	if _, exists := values["b"]; exists {
+		t.Error("Expected an unclosed ignore-start to exclude the rest of the template")
+	}
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	// Match: an action calling fail, as in {{ fail "image.tag must be set" }}
+	failActionRe = regexp.MustCompile(`^` + pipelineOpen + `fail\b`)
+)
+
+// emptyBranch is a branch of a block rendered exactly when a value is empty, or followed by one
+type emptyBranch struct {
+	empty     string // Value whose emptiness alone renders the branch
+	elseEmpty string // Value whose emptiness alone renders the else branch following it
+}
+
+// parseEmptyFailures finds values the chart fails to render without, as in
+// {{ if empty .Values.image.tag }}{{ fail "image.tag must be set" }}{{ end }} or the else branch
+// of {{ if not (empty .Values.host) }} or {{ with .Values.host }} calling fail. Like values passed
+// to required, they must not be empty.
+func (tp *TemplateParser) parseEmptyFailures(content string) {
+	index := tp.indexOf(content)
+	masked := index.masked
+	var open []emptyBranch
+	for _, span := range index.actions {
+		action := masked[span[0]:span[1]]
+		match := blockKeywordRe.FindStringSubmatch(action)
+		if match == nil {
+			if failActionRe.MatchString(action) && len(open) > 0 && open[len(open)-1].empty != "" {
+				if valuePath, exists := tp.values[open[len(open)-1].empty]; exists {
+					valuePath.NonEmpty = true
+				}
+			}
+			continue
+		}
+
+		keyword, pipeline := match[1], match[2]
+		switch keyword {
+		case "if", "with", "range":
+			var branch emptyBranch
+			if path, empty, ok := tp.emptinessTest(pipeline, span[0]); ok && empty && keyword == "if" {
+				branch.empty = path
+			} else if ok && !empty {
+				branch.elseEmpty = path
+			}
+			open = append(open, branch)
+		case "else":
+			if len(open) == 0 {
+				continue
+			}
+			// Branches of an else if also depend on their own condition
+			if elseKeywordRe.MatchString(pipeline) {
+				open[len(open)-1] = emptyBranch{}
+			} else {
+				open[len(open)-1] = emptyBranch{empty: open[len(open)-1].elseEmpty}
+			}
+		case "end":
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		default:
+			open = append(open, emptyBranch{})
+		}
+	}
+}
+
+// emptinessTest reads a condition testing whether a single value is empty: the value itself or
+// not (empty ...) render their branch when it is set, empty ... and not ... when it is empty
+func (tp *TemplateParser) emptinessTest(pipeline string, offset int) (string, bool, bool) {
+	pipeline = strings.TrimSpace(pipeline)
+	pipeline = strings.TrimSpace(pipeline[len(declarationRe.FindString(pipeline)):])
+	if strings.Contains(pipeline, "|") {
+		return "", false, false
+	}
+	spans := argSpans(pipeline)
+	switch {
+	case len(spans) == 1 && strings.HasPrefix(pipeline, "(") && strings.HasSuffix(pipeline, ")") && len(argSpans(pipeline[1:len(pipeline)-1])) > 1:
+		return tp.emptinessTest(pipeline[1:len(pipeline)-1], offset)
+	case len(spans) == 1:
+		path, ok := tp.resolveOperandAt(pipeline, offset)
+		if !ok || path == "" {
+			return "", false, false
+		}
+		return tp.normalizePath(path), false, true
+	case len(spans) == 2 && (pipeline[spans[0][0]:spans[0][1]] == "not" || pipeline[spans[0][0]:spans[0][1]] == "empty"):
+		path, empty, ok := tp.emptinessTest(pipeline[spans[1][0]:spans[1][1]], offset)
+		return path, !empty, ok
+	}
+	return "", false, false
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNonEmpty(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "deployment.yaml")
+	template := `image: {{ required "image.repository is required" .Values.image.repository }}
+tag: {{ .Values.image.tag | default "latest" | required "tag" }}
+{{- if empty .Values.host }}
+{{- fail "host must be set" }}
+{{- end }}
+{{- if not (empty .Values.domain) }}
+domain: {{ .Values.domain }}
+{{- else }}
+{{- fail "domain must be set" }}
+{{- end }}
+{{- with .Values.secret }}
+secret: {{ . }}
+{{- else }}
+{{- fail "secret must be set" }}
+{{- end }}
+{{- if .Values.legacy }}
+legacy: true
+{{- else if not .Values.token }}
+{{- fail "token must be set without legacy" }}
+{{- end }}
+{{- if .Values.ingress.enabled }}
+{{- if empty .Values.ingress.host }}
+{{- if .Values.strict }}
+{{- fail "ingress.host must be set" }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if not .Values.optional }}
+optional: false
+{{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	nonEmpty := map[string]bool{
+		"image.repository": true,
+		"host":             true,
+		"domain":           true,
+		"secret":           true,
+		// Rendering only fails without token when legacy is not set either
+	}
+	for path, valuePath := range parser.GetValues() {
+		if valuePath.NonEmpty != nonEmpty[path] {
+			t.Errorf("%s: expected non-empty %v, got %v", path, nonEmpty[path], valuePath.NonEmpty)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+
+	"helm-schema/pkg/helm"
+)
+
+// loadValuesVariants reads the values-*.yaml files of a chart, in name order
+func loadValuesVariants(chartPath string) ([]map[string]any, error) {
+	files, err := helm.FindValuesVariants(chartPath)
+	if err != nil {
+		return nil, err
+	}
+	var variants []map[string]any
+	for _, file := range files {
+		values, err := helm.LoadValuesFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load values variant %s: %w", file, err)
+		}
+		variants = append(variants, values)
+	}
+	return variants, nil
+}
+
+// addValuesExamples takes examples of the values found in the templates from values file
+// content, leaving out non-empty maps, which are described by their fields
+func (tp *TemplateParser) addValuesExamples(values map[string]any) {
+	for path, valuePath := range tp.values {
+		current, _ := valueAtPath(values, path)
+		if current == nil {
+			continue
+		}
+		if object, isObject := current.(map[string]any); isObject && len(object) > 0 {
+			continue
+		}
+		valuePath.addExample(current)
+	}
+}
+
+// addExample records a value set in a values file, once
+func (vp *ValuePath) addExample(value any) {
+	for _, existing := range vp.Examples {
+		if reflect.DeepEqual(existing, value) {
+			return
+		}
+	}
+	vp.Examples = append(vp.Examples, value)
+}
@@ -0,0 +1,23 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeChartFiles writes files, keyed by slash-separated paths relative to chartPath, creating
+// the directories holding them. Tests of this package cannot use testutil.NewChart, since
+// testutil imports the parser.
+func writeChartFiles(t testing.TB, chartPath string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(chartPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// formatFunctions lists, by JSON Schema format, the template functions only making sense of
+// strings in that format
+var formatFunctions = map[string]map[string]bool{
+	"uri":      {"urlParse": true},
+	"hostname": {"getHostByName": true},
+}
+
+// formatNames lists, by JSON Schema format, the value names implying it, matched ignoring case
+// against the whole key or its last camelCase word, e.g. url, externalURL or smtpHost
+var formatNames = map[string]map[string]bool{
+	"uri":      {"url": true, "uri": true},
+	"hostname": {"host": true, "hostname": true},
+	"email":    {"email": true},
+}
+
+// formats are the formats in order of precedence, functions being stronger evidence than names
+var formats = []string{"uri", "hostname", "email"}
+
+func init() {
+	for _, format := range formats {
+		hintRulesets[format+"-format-functions"] = formatFunctions[format]
+		hintRulesets[format+"-format-names"] = formatNames[format]
+	}
+}
+
+var (
+	// Match: RFC 1123 host names, e.g. db.example.com
+	hostnameRe = regexp.MustCompile(`^[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*\.?$`)
+)
+
+// InferredFormat returns the JSON Schema format of a value that is or may be a string, from the
+// functions it is passed to or from its name, or "" when none is implied. Values defaulting to a
+// string not in the format, such as "", get none, so the schema accepts the chart's own defaults.
+func (v *ValuePath) InferredFormat() string {
+	switch {
+	case v.Encoding != "":
+		return ""
+	case v.Type == "union" && !slices.Contains(v.Types, "string"):
+		return ""
+	case v.Type != "string" && v.Type != "union" && v.Type != "unknown":
+		return ""
+	}
+
+	format := ""
+	for _, candidate := range formats {
+		if slices.ContainsFunc(v.Functions, func(function string) bool { return formatFunctions[candidate][function] }) {
+			format = candidate
+			break
+		}
+	}
+	if format == "" {
+		format = nameFormat(v.Path)
+	}
+	if value, isString := v.Default.(string); format == "" || (isString && !inFormat(format, value)) {
+		return ""
+	}
+	return format
+}
+
+// nameFormat returns the format the key a path ends at implies
+func nameFormat(path string) string {
+	segments := SplitPath(path)
+	key := segments[len(segments)-1]
+	if key == AnyKey || strings.HasSuffix(key, "[]") {
+		return ""
+	}
+	key = UnescapeKey(key)
+
+	for _, format := range formats {
+		for name := range formatNames[format] {
+			if strings.EqualFold(key, name) {
+				return format
+			}
+			// The last camelCase word, e.g. the URL of externalURL but not the host of ghost
+			boundary := len(key) - len(name)
+			if boundary > 0 && strings.EqualFold(key[boundary:], name) &&
+				unicode.IsUpper(rune(key[boundary])) && !unicode.IsUpper(rune(key[boundary-1])) {
+				return format
+			}
+		}
+	}
+	return ""
+}
+
+// inFormat reports whether a string is in a format
+func inFormat(format, value string) bool {
+	switch format {
+	case "uri":
+		parsed, err := url.Parse(value)
+		return err == nil && parsed.Scheme != ""
+	case "hostname":
+		return len(value) <= 253 && hostnameRe.MatchString(value)
+	case "email":
+		address, err := mail.ParseAddress(value)
+		return err == nil && address.Address == value
+	}
+	return true
+}
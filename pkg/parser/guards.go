@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"regexp"
+)
+
+// guardKinds maps the kinds and types tested by kindIs and typeIs to value types
+var guardKinds = map[string]string{
+	"string":                  "string",
+	"bool":                    "boolean",
+	"int":                     "integer",
+	"int64":                   "integer",
+	"float64":                 "number",
+	"map":                     "map",
+	"map[string]interface {}": "map",
+	"slice":                   "array",
+	"[]interface {}":          "array",
+}
+
+var (
+	// Match: kindIs "string" or typeIs "bool", followed by the tested operand
+	guardRe = regexp.MustCompile(`\b(?:kindIs|typeIs)\s+"([^"]*)"\s+`)
+)
+
+// parseKindGuards finds {{ if kindIs "string" .Values.path }} checks. Each names a kind the
+// chart accepts for the value, so values checked for several kinds become unions.
+func (tp *TemplateParser) parseKindGuards(content string) {
+	index := tp.indexOf(content)
+	masked := index.masked
+	// Kinds are read from the original content since masking blanks string contents
+	for _, match := range guardRe.FindAllStringSubmatchIndex(masked, -1) {
+		kind, known := guardKinds[content[match[2]:match[3]]]
+		if !known {
+			continue
+		}
+
+		path, ok := tp.resolveOperand(masked[match[1]:matchingArgEnd(masked, match[1])])
+		if !ok || path == "" {
+			continue
+		}
+
+		site := tp.siteAt(index, match[0])
+		tp.addTypeHint(path, TypeHint{Type: kind, Reason: "checked with " + content[match[0]:match[1]-1], Site: site, Confidence: ConfidenceStructural, Guard: true})
+	}
+}
+
+// guardedUnion reports whether every type of a union is one the templates check the value for
+// with kindIs or typeIs, in which case accepting several types is deliberate and not a conflict
+func guardedUnion(vp *ValuePath) bool {
+	guarded := make(map[string]bool)
+	for _, hint := range vp.Hints {
+		if hint.Guard {
+			guarded[hint.Type] = true
+		}
+	}
+	// Guards for maps and integers also cover the wider types hints for them resolve to
+	if guarded["map"] {
+		guarded["object"] = true
+	}
+	if guarded["integer"] {
+		guarded["number"] = true
+	}
+	for _, hintType := range vp.Types {
+		if !guarded[hintType] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,320 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// stringFunctions lists template functions that only accept string input
+var stringFunctions = map[string]bool{
+	"b64enc":     true,
+	"b64dec":     true,
+	"b32enc":     true,
+	"b32dec":     true,
+	"sha1sum":    true,
+	"sha256sum":  true,
+	"adler32sum": true,
+	"htpasswd":   true,
+	"tpl":        true,
+	// Decoding functions parse a string holding a document
+	"fromYaml":      true,
+	"fromYamlArray": true,
+	"fromJson":      true,
+	"fromJsonArray": true,
+	// Network functions parse a URL or resolve a host name
+	"urlParse":      true,
+	"getHostByName": true,
+}
+
+// numberFunctions lists template functions doing floating point arithmetic, whose operands may
+// be fractional, e.g. autoscaling targets or ratios
+var numberFunctions = map[string]bool{
+	"float64": true,
+	"addf":    true,
+	"add1f":   true,
+	"subf":    true,
+	"mulf":    true,
+	"divf":    true,
+	"maxf":    true,
+	"minf":    true,
+	"floor":   true,
+	"ceil":    true,
+	"round":   true,
+}
+
+// listFunctions lists Sprig functions taking only lists as arguments
+var listFunctions = map[string]bool{
+	"first":       true,
+	"mustFirst":   true,
+	"last":        true,
+	"mustLast":    true,
+	"rest":        true,
+	"mustRest":    true,
+	"initial":     true,
+	"mustInitial": true,
+	"reverse":     true,
+	"mustReverse": true,
+	"uniq":        true,
+	"mustUniq":    true,
+	"compact":     true,
+	"mustCompact": true,
+	"sortAlpha":   true,
+	"concat":      true,
+}
+
+// leadingListFunctions lists Sprig functions taking a list as their first argument, followed by
+// elements or indexes
+var leadingListFunctions = map[string]bool{
+	"append":      true,
+	"mustAppend":  true,
+	"push":        true,
+	"mustPush":    true,
+	"prepend":     true,
+	"mustPrepend": true,
+	"without":     true,
+	"mustWithout": true,
+	"slice":       true,
+	"mustSlice":   true,
+}
+
+// trailingListFunctions lists Sprig functions taking a list as their last argument, e.g. has
+// "needle" .Values.list
+var trailingListFunctions = map[string]bool{
+	"has":       true,
+	"mustHas":   true,
+	"chunk":     true,
+	"mustChunk": true,
+}
+
+// sensitiveFunctions lists template functions typically applied to secret material
+var sensitiveFunctions = map[string]bool{
+	"b64enc":    true,
+	"b64dec":    true,
+	"sha256sum": true,
+	"htpasswd":  true,
+}
+
+// Confidence levels of type hints, by the kind of evidence they stem from
+const (
+	ConfidenceStructural = 1.0 // Nested field access, list indexing or key/value iteration
+	ConfidenceDefault    = 0.9 // Literal the value defaults to
+	ConfidencePipeline   = 0.6 // Function requiring a specific input type
+)
+
+// templateKeywords are action keywords that precede a pipeline but are not functions
+var templateKeywords = map[string]bool{
+	"if":    true,
+	"else":  true,
+	"range": true,
+	"with":  true,
+	"end":   true,
+}
+
+// hintRulesets names every heuristic table so RulesetDigest changes whenever a rule does
+var hintRulesets = map[string]map[string]bool{
+	"string-functions":        stringFunctions,
+	"number-functions":        numberFunctions,
+	"sensitive-functions":     sensitiveFunctions,
+	"template-keywords":       templateKeywords,
+	"passthrough-functions":   passthroughFunctions,
+	"list-functions":          listFunctions,
+	"leading-list-functions":  leadingListFunctions,
+	"trailing-list-functions": trailingListFunctions,
+}
+
+var (
+	// Match: leading variable declaration, e.g. $k, $v :=
+	declarationRe = regexp.MustCompile(`^\$[a-zA-Z0-9_]*(?:\s*,\s*\$[a-zA-Z0-9_]*)?\s*:?=\s*`)
+)
+
+// RulesetDigest returns a short hash identifying the heuristic rules used for type inference,
+// letting consumers tell whether schema differences stem from a change in the rules
+func RulesetDigest() string {
+	var names []string
+	for name := range hintRulesets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	hash := sha256.New()
+	for _, name := range names {
+		var entries []string
+		for entry := range hintRulesets[name] {
+			entries = append(entries, entry)
+		}
+		sort.Strings(entries)
+		fmt.Fprintf(hash, "%s=%s\n", name, strings.Join(entries, ","))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))[:12]
+}
+
+// pipelineFunctions returns the functions applied to the operand at offset within a single action
+func pipelineFunctions(action string, offset int) []string {
+	return tokenizeAction(action).functionsAt(offset)
+}
+
+// enclosingGroup returns the bounds of the innermost parenthesized group containing offset,
+// or the action body without its delimiters when the operand is not parenthesized
+func enclosingGroup(action string, offset int) (int, int) {
+	start, end, groups := parenGroups(action)
+	for _, group := range groups {
+		if group[0] <= offset && offset < group[1] {
+			return group[0], group[1]
+		}
+	}
+	return start, end
+}
+
+// splitPipeline splits an expression into its commands at top-level | separators
+func splitPipeline(expr string) []string {
+	var commands []string
+	var quote byte
+	depth, last := 0, 0
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '|' && depth == 0:
+			commands = append(commands, expr[last:i])
+			last = i + 1
+		}
+	}
+	return append(commands, expr[last:])
+}
+
+// commandFunction returns the function invoked by a pipeline command, if any
+func commandFunction(command string) string {
+	command = declarationRe.ReplaceAllString(strings.TrimSpace(command), "")
+	for _, field := range strings.Fields(command) {
+		if templateKeywords[field] {
+			continue
+		}
+		if identifierRe.MatchString(field) {
+			return field
+		}
+		return ""
+	}
+	return ""
+}
+
+// firstNumberFunction returns the first of the floating point functions, if any
+func firstNumberFunction(functions []string) string {
+	for _, function := range functions {
+		if numberFunctions[function] {
+			return function
+		}
+	}
+	return ""
+}
+
+// firstStringFunction returns the first of the functions requiring string input, if any
+func firstStringFunction(functions []string) string {
+	for _, function := range functions {
+		if stringFunctions[function] {
+			return function
+		}
+	}
+	return ""
+}
+
+// isListArgument reports whether a function takes its argument at position, of count arguments,
+// as a list
+func isListArgument(function string, position, count int) bool {
+	switch {
+	case listFunctions[function]:
+		return true
+	case leadingListFunctions[function]:
+		return position == 0
+	case trailingListFunctions[function]:
+		return position == count-1
+	}
+	return false
+}
+
+// resolveHints derives a type and its confidence from the collected hints. A map is an object
+// and an integer is a number, so those agree on the wider type; any other disagreement yields a "union" of the hinted types, as confident as
+// its least supported member. Kind guards alone leave the type unknown, since the else branch of
+// {{ if kindIs "string" .Values.x }} accepts some other kind.
+func resolveHints(hints []TypeHint) (string, []string, float64) {
+	confidence := make(map[string]float64)
+	guardsOnly := true
+	for _, hint := range hints {
+		confidence[hint.Type] = max(confidence[hint.Type], hint.Confidence)
+		guardsOnly = guardsOnly && hint.Guard
+	}
+	if guardsOnly && len(confidence) == 1 {
+		return "unknown", nil, 0
+	}
+	for narrow, wide := range map[string]string{"map": "object", "integer": "number"} {
+		if narrowConfidence, hasNarrow := confidence[narrow]; hasNarrow {
+			if wideConfidence, hasWide := confidence[wide]; hasWide {
+				confidence[wide] = max(wideConfidence, narrowConfidence)
+				delete(confidence, narrow)
+			}
+		}
+	}
+
+	var resolved []string
+	for hintType := range confidence {
+		resolved = append(resolved, hintType)
+	}
+	sort.Strings(resolved)
+
+	switch len(resolved) {
+	case 0:
+		return "unknown", nil, 0
+	case 1:
+		return resolved[0], nil, confidence[resolved[0]]
+	}
+
+	least := 1.0
+	for _, hintType := range resolved {
+		least = min(least, confidence[hintType])
+	}
+	return "union", resolved, least
+}
+
+// hasSensitiveFunction reports whether any of the functions handles secret material
+func hasSensitiveFunction(functions []string) bool {
+	for _, function := range functions {
+		if sensitiveFunctions[function] {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeSorted returns the sorted union of two lists, such as function names, without
+// modifying either
+func mergeSorted(existing, added []string) []string {
+	if len(added) == 0 {
+		return existing
+	}
+
+	seen := make(map[string]bool, len(existing)+len(added))
+	var merged []string
+	for _, entry := range append(append([]string{}, existing...), added...) {
+		if !seen[entry] {
+			seen[entry] = true
+			merged = append(merged, entry)
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
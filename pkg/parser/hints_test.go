@@ -0,0 +1,517 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestPipelineFunctions(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   string
+		operand  string
+		expected []string
+	}{
+		{
+			name:     "piped through functions",
+			action:   `{{ .Values.password | b64enc | quote }}`,
+			operand:  ".Values.password",
+			expected: []string{"b64enc", "quote"},
+		},
+		{
+			name:     "function argument",
+			action:   `{{ htpasswd .Values.user .Values.password }}`,
+			operand:  ".Values.password",
+			expected: []string{"htpasswd"},
+		},
+		{
+			name:     "conditional keyword is not a function",
+			action:   `{{- if .Values.enabled }}`,
+			operand:  ".Values.enabled",
+			expected: nil,
+		},
+		{
+			name:     "innermost parenthesized group",
+			action:   `{{ quote (sha256sum .Values.secret) }}`,
+			operand:  ".Values.secret",
+			expected: []string{"sha256sum"},
+		},
+		{
+			name:     "variable declaration",
+			action:   `{{- $token := .Values.token | b64dec -}}`,
+			operand:  ".Values.token",
+			expected: []string{"b64dec"},
+		},
+		{
+			name:     "pipe inside string literal",
+			action:   `{{ .Values.name | default "a|b" | upper }}`,
+			operand:  ".Values.name",
+			expected: []string{"default", "upper"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset := strings.Index(tt.action, tt.operand)
+			result := pipelineFunctions(tt.action, offset)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("pipelineFunctions(%s) = %v, expected %v", tt.action, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStringFunctionHints(t *testing.T) {
+	parser := New()
+
+	content := `apiVersion: v1
+kind: Secret
+data:
+  password: {{ .Values.auth.password | b64enc }}
+  checksum: {{ sha256sum .Values.auth.config }}
+  htpasswd: {{ htpasswd .Values.auth.user .Values.auth.secret | b64enc }}
+  name: {{ .Values.auth.name | quote }}
+`
+
+	parser.parseDirectValueReferences(content)
+
+	expected := map[string]struct {
+		Type      string
+		Sensitive bool
+	}{
+		"auth.password": {"string", true},
+		"auth.config":   {"string", true},
+		"auth.user":     {"string", true},
+		"auth.secret":   {"string", true},
+		"auth.name":     {"unknown", false},
+		"auth":          {"object", false},
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if valuePath.Type != want.Type {
+			t.Errorf("Path %s has type %s, expected %s", path, valuePath.Type, want.Type)
+		}
+		if valuePath.Sensitive != want.Sensitive {
+			t.Errorf("Path %s sensitive = %v, expected %v", path, valuePath.Sensitive, want.Sensitive)
+		}
+	}
+}
+
+func TestFunctionsRecorded(t *testing.T) {
+	parser := New()
+
+	content := `data:
+  name: {{ .Values.app.name | quote }}
+  upper: {{ .Values.app.name | upper | quote }}
+  config: {{ toYaml .Values.app.config | nindent 4 }}
+  rendered: {{ tpl .Values.app.template $ }}
+  {{- if .Values.app.enabled }}{{ end }}
+`
+
+	parser.parseDirectValueReferences(content)
+
+	expected := map[string][]string{
+		"app.name":     {"quote", "upper"},
+		"app.config":   {"nindent", "toYaml"},
+		"app.template": {"tpl"},
+		"app.enabled":  nil,
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if !reflect.DeepEqual(valuePath.Functions, want) {
+			t.Errorf("Path %s has functions %v, expected %v", path, valuePath.Functions, want)
+		}
+	}
+}
+
+func TestConflictingTypeHints(t *testing.T) {
+	chartDir := t.TempDir()
+	templatesDir := filepath.Join(chartDir, "templates")
+	if err := os.MkdirAll(templatesDir, 0755); err != nil {
+		t.Fatalf("Failed to create templates directory: %v", err)
+	}
+
+	templates := map[string]string{
+		"configmap.yaml": `data:
+  {{- range $key, $value := .Values.config.data }}
+  {{ $key }}: {{ $value | quote }}
+  {{- end }}
+`,
+		"secret.yaml": `data:
+  rendered: {{ tpl .Values.config.data . | b64enc }}
+  labels: {{ .Values.labels.app }}
+`,
+		"service.yaml": `metadata:
+  {{- range $key, $value := .Values.labels }}
+  {{ $key }}: {{ $value }}
+  {{- end }}
+`,
+	}
+	for name, content := range templates {
+		if err := os.WriteFile(filepath.Join(templatesDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	parser := New()
+	parser.chartRoot = chartDir
+	for _, name := range []string{"configmap.yaml", "secret.yaml", "service.yaml"} {
+		if err := parser.ParseTemplateFile(filepath.Join(templatesDir, name)); err != nil {
+			t.Fatalf("Failed to parse %s: %v", name, err)
+		}
+	}
+
+	data := parser.values["config.data"]
+	if data == nil {
+		t.Fatal("Expected path config.data not found")
+	}
+	if data.Type != "union" || !reflect.DeepEqual(data.Types, []string{"map", "string"}) {
+		t.Errorf("config.data should be a union of map and string, got %s %v", data.Type, data.Types)
+	}
+
+	// A map with known fields is still an object
+	if labels := parser.values["labels"]; labels == nil || labels.Type != "object" {
+		t.Errorf("labels should be object, got %+v", labels)
+	}
+
+	warnings := parser.Warnings()
+	if len(warnings) != 1 {
+		t.Fatalf("Expected 1 warning, got %+v", warnings)
+	}
+	if warnings[0].Path != "config.data" {
+		t.Errorf("Expected warning for config.data, got %s", warnings[0].Path)
+	}
+	for _, site := range []string{"templates/configmap.yaml:2", "templates/secret.yaml:2"} {
+		if !strings.Contains(warnings[0].Message, site) {
+			t.Errorf("Warning should list site %s: %s", site, warnings[0].Message)
+		}
+	}
+}
+
+func TestDefaultLiteralHints(t *testing.T) {
+	parser := New()
+	parser.variables = map[string]string{"cfg": "app.config"}
+
+	content := `spec:
+  replicas: {{ .Values.replicas | default 1 }}
+  image: {{ default "nginx" .Values.image.repository }}
+  ratio: {{ .Values.ratio | default 0.5 | quote }}
+  debug: {{ if (.Values.debug | default false) }}on{{ end }}
+  timeout: {{ $cfg.timeout | default "30s" }}
+  labels: {{ .Values.labels | default dict | toYaml }}
+  name: {{ .Values.name | default .Chart.Name }}
+  token: {{ .Values.token | default "changeme" | b64enc }}
+  {{- $hosts := .Values.hosts | default list }}
+`
+
+	parser.parseDirectValueReferences(content)
+	parser.parseVariableReferences(content)
+	parser.parseDefaultLiterals(content)
+
+	expected := map[string]struct {
+		Type       string
+		Default    any
+		Confidence float64
+	}{
+		"replicas":           {"integer", 1, ConfidenceDefault},
+		"image.repository":   {"string", "nginx", ConfidenceDefault},
+		"ratio":              {"number", 0.5, ConfidenceDefault},
+		"debug":              {"boolean", false, ConfidenceDefault},
+		"app.config.timeout": {"string", "30s", ConfidenceDefault},
+		"labels":             {"object", map[string]any{}, ConfidenceDefault},
+		"name":               {"unknown", nil, 0},
+		"token":              {"string", "changeme", ConfidenceDefault},
+		"hosts":              {"array", []any{}, ConfidenceDefault},
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if valuePath.Type != want.Type {
+			t.Errorf("Path %s has type %s, expected %s", path, valuePath.Type, want.Type)
+		}
+		if !reflect.DeepEqual(valuePath.Default, want.Default) {
+			t.Errorf("Path %s has default %#v, expected %#v", path, valuePath.Default, want.Default)
+		}
+		if valuePath.Confidence != want.Confidence {
+			t.Errorf("Path %s has confidence %v, expected %v", path, valuePath.Confidence, want.Confidence)
+		}
+	}
+}
+
+func TestDecodedValues(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "configmap.yaml")
+	template := `{{- $cfg := .Values.rawConfig | fromYaml }}
+{{- $policy := fromJson .Values.policy }}
+data:
+  port: {{ $cfg.port }}
+  tls: {{ $cfg.tls.enabled }}
+  effect: {{ $policy.effect }}
+  level: {{ (.Values.logging | fromYaml).level }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	expected := map[string]struct {
+		Encoding string
+		Decoded  []string
+	}{
+		"rawConfig": {"yaml", []string{"port", "tls.enabled"}},
+		"policy":    {"json", []string{"effect"}},
+		"logging":   {"yaml", []string{"level"}},
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if valuePath.Type != "string" {
+			t.Errorf("Path %s has type %s, expected string", path, valuePath.Type)
+		}
+		if valuePath.Encoding != want.Encoding {
+			t.Errorf("Path %s has encoding %q, expected %q", path, valuePath.Encoding, want.Encoding)
+		}
+		if !reflect.DeepEqual(valuePath.Decoded, want.Decoded) {
+			t.Errorf("Path %s has decoded fields %v, expected %v", path, valuePath.Decoded, want.Decoded)
+		}
+	}
+
+	if len(parser.values) != len(expected) {
+		t.Errorf("Decoded fields should not become values, got %v", parser.values)
+	}
+	if unresolved := parser.Unresolved(); len(unresolved) > 0 {
+		t.Errorf("Decoded values should be resolved, got %v", unresolved)
+	}
+}
+
+func TestKindGuards(t *testing.T) {
+	parser := New()
+
+	content := `{{- if kindIs "string" .Values.extraConfig }}
+{{ tpl .Values.extraConfig . }}
+{{- else if kindIs "map" .Values.extraConfig }}
+{{ toYaml .Values.extraConfig }}
+{{- end }}
+{{- if typeIs "[]interface {}" .Values.hosts }}{{ .Values.hosts | toYaml }}{{ end }}
+{{- if typeIs "string" .Values.hosts }}{{ .Values.hosts }}{{ end }}
+{{- if kindIs "map" .Values.resources }}{{ .Values.resources.limits }}{{ end }}
+{{- if kindIs "string" .Values.tolerations }}{{ .Values.tolerations }}{{ else }}{{ toYaml .Values.tolerations }}{{ end }}
+`
+
+	parser.parseDirectValueReferences(content)
+	parser.parseKindGuards(content)
+
+	expected := map[string]struct {
+		Type  string
+		Types []string
+	}{
+		"extraConfig": {"union", []string{"map", "string"}},
+		"hosts":       {"union", []string{"array", "string"}},
+		"resources":   {"object", nil},
+		// A single guard does not exclude the kinds of its else branch
+		"tolerations": {"unknown", nil},
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if valuePath.Type != want.Type || !reflect.DeepEqual(valuePath.Types, want.Types) {
+			t.Errorf("Path %s has type %s %v, expected %s %v", path, valuePath.Type, valuePath.Types, want.Type, want.Types)
+		}
+	}
+
+	// Unions of the kinds checked for are deliberate
+	if warnings := parser.Warnings(); len(warnings) > 0 {
+		t.Errorf("Expected no conflicting type hints for guarded values, got %v", warnings)
+	}
+}
+
+func TestNumberHints(t *testing.T) {
+	parser := New()
+
+	content := `spec:
+  target: {{ mulf .Values.autoscaling.targetCPU 100 }}
+  ratio: {{ .Values.ratio | float64 }}
+  memory: {{ divf .Values.memoryMi 1024 | ceil }}
+  replicas: {{ .Values.replicas | default 1 }}
+  half: {{ divf .Values.replicas 2 }}
+  count: {{ .Values.count | default 3 }}
+  epsilon: {{ .Values.epsilon | default 1e-3 }}
+`
+
+	parser.parseDirectValueReferences(content)
+	parser.parseDefaultLiterals(content)
+
+	expected := map[string]string{
+		"autoscaling.targetCPU": "number",
+		"ratio":                 "number",
+		"memoryMi":              "number",
+		// An integer default does not narrow a value used in floating point arithmetic
+		"replicas": "number",
+		"count":    "integer",
+		"epsilon":  "number",
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if valuePath.Type != want {
+			t.Errorf("Path %s has type %s %v, expected %s", path, valuePath.Type, valuePath.Types, want)
+		}
+	}
+}
+
+func TestListFunctionHints(t *testing.T) {
+	parser := New()
+
+	content := `spec:
+  primary: {{ first .Values.hosts }}
+  latest: {{ .Values.tags | last }}
+  names: {{ .Values.names | default list | uniq | join "," }}
+  {{- if has .Values.mode .Values.modes }}
+  merged: {{ concat .Values.base .Values.extra | toJson }}
+  kept: {{ without .Values.all .Values.excluded | toJson }}
+  {{- end }}
+  {{- $sorted := sortAlpha .Values.keys }}
+  pair: {{ slice .Values.items 0 2 | toJson }}
+  plain: {{ .Values.plain | upper }}
+`
+
+	parser.parseDirectValueReferences(content)
+
+	expected := map[string]string{
+		"hosts": "array",
+		"tags":  "array",
+		"names": "array",
+		"modes": "array",
+		"base":  "array",
+		"extra": "array",
+		"all":   "array",
+		"keys":  "array",
+		"items": "array",
+		// The needle of has and the elements given to without are not lists
+		"mode":     "unknown",
+		"excluded": "unknown",
+		"plain":    "unknown",
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if valuePath.Type != want {
+			t.Errorf("Path %s has type %s %v, expected %s", path, valuePath.Type, valuePath.Types, want)
+		}
+	}
+}
+
+func TestRequiredFunction(t *testing.T) {
+	parser := New()
+
+	content := `spec:
+  host: {{ required "host is required" .Values.host }}
+  image: {{ .Values.image.repository | required "image.repository is required" }}
+  tag: {{ .Values.image.tag | default "latest" | required "tag is required" }}
+  port: {{ required "port is required" (.Values.port | default 80) }}
+  name: {{ .Values.name | quote }}
+`
+
+	parser.parseDirectValueReferences(content)
+
+	expected := map[string]bool{
+		"host":             true,
+		"image.repository": true,
+		// A default fills the value in before required checks it
+		"image.tag": false,
+		"port":      false,
+		"name":      false,
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if valuePath.Required != want {
+			t.Errorf("Path %s has required %v, expected %v", path, valuePath.Required, want)
+		}
+	}
+}
+
+func TestInferredFormats(t *testing.T) {
+	parser := New()
+
+	content := `spec:
+  endpoint: {{ (urlParse .Values.endpoint).host }}
+  address: {{ getHostByName .Values.backend }}
+  external: {{ .Values.externalURL | quote }}
+  webhook: {{ .Values.alerts.webhookUrl | default "https://hooks.example.com" }}
+  smtp: {{ .Values.smtpHost | default "" }}
+  admin: {{ .Values.adminEmail | default "admin@example.com" | quote }}
+  ghost: {{ .Values.ghost | quote }}
+  hosts: {{ .Values.ingress.hosts | toJson }}
+  port: {{ .Values.host.port }}
+`
+
+	parser.parseDirectValueReferences(content)
+	parser.parseDefaultLiterals(content)
+
+	expected := map[string]string{
+		"endpoint":          "uri",
+		"backend":           "hostname",
+		"externalURL":       "uri",
+		"alerts.webhookUrl": "uri",
+		"adminEmail":        "email",
+		// The chart's own default must validate
+		"smtpHost": "",
+		// Only whole camelCase words count
+		"ghost": "",
+		// Formats apply to strings
+		"ingress.hosts": "",
+		"host":          "",
+	}
+
+	for path, want := range expected {
+		valuePath, exists := parser.values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if got := valuePath.InferredFormat(); got != want {
+			t.Errorf("Path %s has format %q, expected %q", path, got, want)
+		}
+	}
+}
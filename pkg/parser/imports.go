@@ -0,0 +1,119 @@
+package parser
+
+import (
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/helm"
+)
+
+// importValues records the values a dependency's import-values mappings copy into the parent, at
+// the parent paths Helm exposes them at. Paths come from the dependency's templates and, since
+// exports are rarely read by the dependency itself, from its values.yaml.
+func (tp *TemplateParser) importValues(dep *helm.Dependency, subchart *TemplateParser, subchartPath string) error {
+	if len(dep.ImportValues) == 0 {
+		return nil
+	}
+
+	defaults, err := helm.LoadValuesFile(filepath.Join(subchartPath, "values.yaml"))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	subchartValues := subchart.GetAllValues()
+	for _, imported := range dep.ImportValues {
+		child := strings.Trim(imported.Child, ".")
+		parent := imported.ParentPath()
+		if child == "" {
+			continue
+		}
+
+		// Values the dependency's templates read below the imported path
+		var paths []string
+		for path := range subchartValues {
+			if path == child || strings.HasPrefix(path, child+".") {
+				paths = append(paths, path)
+			}
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			target := joinPath(parent, strings.TrimPrefix(strings.TrimPrefix(path, child), "."))
+			if target == "" {
+				continue
+			}
+			if _, exists := tp.values[target]; !exists {
+				tp.values[target] = subchartValues[path].withPath(target)
+			}
+			tp.addIntermediatePaths(target, Site{})
+		}
+
+		// Values the dependency only declares
+		if value, ok := lookupValue(defaults, child); ok {
+			tp.importDefaults(parent, value, dep.ValuesKey())
+		}
+	}
+
+	return nil
+}
+
+// importDefaults types the paths below path from the values.yaml content copied there
+func (tp *TemplateParser) importDefaults(path string, value any, dependency string) {
+	if object, ok := value.(map[string]any); ok && len(object) > 0 {
+		for key, child := range object {
+			tp.importDefaults(joinPath(path, EscapeKey(key)), child, dependency)
+		}
+		return
+	}
+	if path == "" {
+		return
+	}
+
+	valueType := valueTypeOf(value)
+	if valueType == "" {
+		tp.valuePath(path)
+		tp.addIntermediatePaths(path, Site{})
+		return
+	}
+	valuePath := tp.addTypeHint(path, TypeHint{Type: valueType, Reason: "imported from " + dependency, Confidence: ConfidenceDefault})
+	if valuePath.Default == nil {
+		valuePath.Default = value
+	}
+}
+
+// lookupValue returns the value at a dot separated path of decoded values
+func lookupValue(values map[string]any, path string) (any, bool) {
+	var current any = values
+	for _, key := range strings.Split(path, ".") {
+		object, ok := current.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if current, ok = object[key]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// valueTypeOf returns the value type of decoded YAML, or "" for null
+func valueTypeOf(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case int, int64, uint64:
+		return "integer"
+	case float64:
+		return "number"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,256 @@
+package parser
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// maxIncludeDepth bounds how deeply include calls are followed into helpers, guarding
+// against recursive helpers
+const maxIncludeDepth = 8
+
+var (
+	// Match: {{ define "name" }}
+	defineRe = regexp.MustCompile(`^\{\{-?\s*define\s+"([^"]+)"`)
+	// Match: include "name" / template "name"
+	includeRe = regexp.MustCompile(`\b(?:include|template)\s+"([^"]*)"\s*`)
+	// Match: .field.path relative to the helper context, not preceded by an identifier
+	contextFieldRe = regexp.MustCompile(`\.` + capture(identifier) + capture(`(?:\.`+identifier+`|\[\d+\])*`) + valueBoundary)
+	// Match: the first word of an action body, used to track block nesting
+	actionKeywordRe = regexp.MustCompile(`^\{\{-?\s*(if|range|with|define|block|end)\b`)
+)
+
+// binding describes what a name inside a helper refers to
+type binding struct {
+	path string // Value path the name is bound to
+	root bool   // Bound to the root context, so .name.Values.x refers to x
+}
+
+// includeScope maps the context a helper was included with back to value paths
+type includeScope struct {
+	dot    *binding            // Whole context, e.g. include "x" .Values.image
+	fields map[string]*binding // Dict keys, e.g. include "x" (dict "cfg" .Values.worker)
+}
+
+// ParseHelperFile collects the named templates defined in a helper file (_helpers.tpl) so that
+// include calls passing .Values subtrees can be resolved into them, and the values the helpers
+// write with set or unset
+func (tp *TemplateParser) ParseHelperFile(filePath string) error {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read helper file %s: %w", filePath, err)
+	}
+
+	tp.collectDefines(string(content))
+
+	// Helpers commonly compute values for the templates including them with the root context
+	tp.file = tp.relativePath(filePath)
+	tp.parseMutations(stripYAMLComments(string(content)))
+	return nil
+}
+
+// collectDefines records the body of every {{ define "name" }} ... {{ end }} block in content
+func (tp *TemplateParser) collectDefines(content string) {
+	maps.Copy(tp.helpers, definedTemplates(content))
+}
+
+// definedTemplates returns the bodies of the named templates defined in content, by name
+func definedTemplates(content string) map[string]string {
+	defines := make(map[string]string)
+	actions := scanActions(content)
+	for i, span := range actions {
+		match := defineRe.FindStringSubmatch(content[span[0]:span[1]])
+		if match == nil {
+			continue
+		}
+
+		if end := matchingEnd(content, actions, i); end >= 0 {
+			defines[match[1]] = content[span[1]:actions[end][0]]
+		}
+	}
+	return defines
+}
+
+// matchingEnd returns the index of the {{ end }} action closing the block opened by the action
+// at index start, or -1 when the block is not closed
+func matchingEnd(content string, actions [][]int, start int) int {
+	depth := 1
+	for i := start + 1; i < len(actions); i++ {
+		keyword := actionKeywordRe.FindStringSubmatch(content[actions[i][0]:actions[i][1]])
+		if keyword == nil {
+			continue
+		}
+		if keyword[1] == "end" {
+			depth--
+		} else {
+			depth++
+		}
+		if depth == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseIncludes follows include calls whose context carries .Values subtrees into the included
+// helper and records the fields the helper reads, e.g. .cfg.image as worker.image
+func (tp *TemplateParser) parseIncludes(content string) {
+	tp.followIncludes(content, nil, nil, 0)
+}
+
+// followIncludes resolves the include calls in content, evaluated within scope (nil at the top
+// level of a template), and descends into the included helpers. Paths found in helpers are
+// attributed to the site of the outermost include call.
+func (tp *TemplateParser) followIncludes(content string, scope *includeScope, site *Site, depth int) {
+	if depth >= maxIncludeDepth {
+		return
+	}
+
+	index := tp.indexOf(content)
+	masked := index.masked
+	for _, match := range includeRe.FindAllStringSubmatchIndex(masked, -1) {
+		// Helper names are read from the original content since masking blanks string contents
+		body, exists := tp.helpers[content[match[2]:match[3]]]
+		if !exists {
+			continue
+		}
+
+		args := splitArgs(content[match[1]:matchingArgEnd(masked, match[1])])
+		if len(args) == 0 {
+			continue
+		}
+
+		callee := tp.includeContext(args[0], scope)
+		if callee == nil {
+			continue
+		}
+
+		callSite := site
+		if callSite == nil {
+			top := tp.siteAt(index, match[0])
+			callSite = &top
+		}
+
+		tp.parseContextFields(body, callee, *callSite)
+		tp.followIncludes(body, callee, callSite, depth+1)
+	}
+}
+
+// matchingArgEnd returns the end of the include argument starting at pos: a parenthesized
+// group or a single operand
+func matchingArgEnd(masked string, pos int) int {
+	if pos < len(masked) && masked[pos] == '(' {
+		if close := matchingParen(masked, pos); close >= 0 {
+			return close + 1
+		}
+		return pos
+	}
+
+	end := pos
+	for end < len(masked) && !isSpace(masked[end]) && !strings.ContainsRune("|)}", rune(masked[end])) {
+		end++
+	}
+	return end
+}
+
+// includeContext returns the scope a helper sees when included with arg from within scope
+func (tp *TemplateParser) includeContext(arg string, scope *includeScope) *includeScope {
+	if strings.HasPrefix(arg, "(") && strings.HasSuffix(arg, ")") {
+		inner := splitArgs(arg[1 : len(arg)-1])
+		if len(inner) > 0 && inner[0] == "dict" {
+			callee := &includeScope{fields: make(map[string]*binding)}
+			for i := 1; i+1 < len(inner); i += 2 {
+				key := strings.Trim(inner[i], `"`)
+				if value := tp.resolveBinding(inner[i+1], scope); value != nil {
+					callee.fields[key] = value
+				}
+			}
+			if len(callee.fields) == 0 {
+				return nil
+			}
+			return callee
+		}
+	}
+
+	// The current context is forwarded unchanged
+	if scope != nil && (arg == "." || arg == "$") {
+		return scope
+	}
+
+	if value := tp.resolveBinding(arg, scope); value != nil && !value.root {
+		return &includeScope{dot: value}
+	}
+	return nil
+}
+
+// resolveBinding returns what a value expression passed to a helper refers to
+func (tp *TemplateParser) resolveBinding(expr string, scope *includeScope) *binding {
+	if scope == nil {
+		if expr == "." || expr == "$" {
+			return &binding{root: true}
+		}
+		if path, ok := tp.resolveOperand(expr); ok && path != "" {
+			return &binding{path: path}
+		}
+		return nil
+	}
+
+	if match := contextFieldRe.FindStringSubmatch(expr + " "); match != nil && match[0] == expr+" " {
+		// A bound name forwarded as a whole keeps its binding, e.g. (dict "root" .root)
+		if value, exists := scope.fields[match[1]]; exists && match[2] == "" {
+			return value
+		}
+		if path, ok := scope.resolve(match[1], match[2]); ok {
+			return &binding{path: path}
+		}
+	}
+	return nil
+}
+
+// resolve maps a field access .name.rest inside a helper to a value path
+func (s *includeScope) resolve(name, rest string) (string, bool) {
+	rest = strings.TrimPrefix(rest, ".")
+
+	if s.dot != nil {
+		return joinPath(s.dot.path, joinPath(name, rest)), true
+	}
+
+	value, exists := s.fields[name]
+	if !exists {
+		return "", false
+	}
+
+	if value.root {
+		// .root.Values.x refers to x; other root fields (.root.Release, ...) are not values
+		if !strings.HasPrefix(rest, "Values.") {
+			return "", false
+		}
+		return strings.TrimPrefix(rest, "Values."), true
+	}
+
+	if rest == "" {
+		return "", false
+	}
+	return joinPath(value.path, rest), true
+}
+
+// parseContextFields records the fields a helper body reads from its context, attributed to the
+// site of the include call
+func (tp *TemplateParser) parseContextFields(body string, scope *includeScope, site Site) {
+	index := tp.indexOf(body)
+	masked := index.masked
+	for _, match := range contextFieldRe.FindAllStringSubmatchIndex(masked, -1) {
+		// Fields selected on a group or index, as in (...).x, belong to that expression
+		if !isWordStart(masked, match[0]) || (match[0] > 0 && strings.ContainsRune(")]", rune(masked[match[0]-1]))) {
+			continue
+		}
+
+		path, ok := scope.resolve(masked[match[2]:match[3]], masked[match[4]:match[5]])
+		if ok && path != "" {
+			tp.addValuePathWithHints(path, index, match[0], site)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"sync"
+
+	"helm-schema/pkg/cache"
+)
+
+// templateCacheFormat is bumped whenever what a template contributes to the model, or how it is
+// encoded, changes in a way the build identity does not capture
+const templateCacheFormat = 11
+
+func init() {
+	// Directive constraints hold decoded YAML
+	gob.Register([]any{})
+	gob.Register(map[string]any{})
+}
+
+// templateResult is what parsing a single template file contributes to the chart model
+type templateResult struct {
+	Values     map[string]*ValuePath
+	Mutations  map[string]mutation
+	Unresolved []Unresolved
+}
+
+// parseTemplates parses the template files of the chart on a bounded pool of workers and merges
+// what each contributes into the model in file order, so the model does not depend on scheduling
+func (tp *TemplateParser) parseTemplates(templateFiles []string) error {
+	contents := make([][]byte, len(templateFiles))
+	defines := make([]map[string]string, len(templateFiles))
+	readErrs := make([]error, len(templateFiles))
+	tp.forEachFile(len(templateFiles), func(i int) {
+		content, err := os.ReadFile(templateFiles[i])
+		if err != nil {
+			readErrs[i] = fmt.Errorf("failed to read template file %s: %w", templateFiles[i], err)
+			return
+		}
+		contents[i] = content
+		defines[i] = definedTemplates(stripYAMLComments(string(content)))
+	})
+	for i := range templateFiles {
+		if readErrs[i] != nil {
+			return readErrs[i]
+		}
+		// Named templates are global to the chart, wherever they are defined
+		maps.Copy(tp.helpers, defines[i])
+	}
+	if tp.opts.Cache != nil {
+		tp.helperDigest = tp.helpersDigest()
+	}
+
+	results := make([]templateResult, len(templateFiles))
+	tp.forEachFile(len(templateFiles), func(i int) {
+		results[i] = tp.extractTemplate(templateFiles[i], contents[i])
+	})
+	for i, templateFile := range templateFiles {
+		if err := tp.mergeTemplateResult(tp.relativePath(templateFile), results[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachFile calls fn with every index below count, running at most Options.Concurrency calls
+// at once
+func (tp *TemplateParser) forEachFile(count int, fn func(i int)) {
+	workers := tp.opts.Concurrency
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, count)
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := range count {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// extractTemplate parses a template file of the chart on its own, returning what it contributes
+// to the model. With a cache, the contribution is reused while the file, the named templates it
+// may include and the parser build are unchanged. The parser itself is only read, so templates
+// can be extracted concurrently.
+func (tp *TemplateParser) extractTemplate(filePath string, content []byte) templateResult {
+	var key string
+	if tp.opts.Cache != nil {
+		key = cache.Key(parserIdentity(), tp.relativePath(filePath), tp.helperDigest, string(content))
+		if data, ok := tp.opts.Cache.Get(cache.BucketTemplates, key); ok {
+			var result templateResult
+			if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&result); err == nil {
+				slog.Debug("reusing cached template", "file", tp.relativePath(filePath))
+				return result
+			}
+		}
+	}
+
+	// Variables are scoped to the template declaring them
+	opts := tp.opts
+	opts.Strict = false
+	fileParser := NewWithOptions(opts)
+	fileParser.chartRoot = tp.chartRoot
+	fileParser.helpers = tp.helpers
+
+	unresolved := fileParser.parseTemplateContent(filePath, string(content))
+	result := templateResult{
+		Values:     fileParser.values,
+		Mutations:  fileParser.mutations,
+		Unresolved: unresolved,
+	}
+
+	if tp.opts.Cache != nil {
+		var data bytes.Buffer
+		// The cache only saves work, so failing to store an entry does not fail parsing
+		if err := gob.NewEncoder(&data).Encode(result); err == nil {
+			tp.opts.Cache.Put(cache.BucketTemplates, key, data.Bytes())
+		}
+	}
+
+	return result
+}
+
+// mergeTemplateResult adds the contribution of a template file to the model
+func (tp *TemplateParser) mergeTemplateResult(file string, result templateResult) error {
+	var paths []string
+	for path := range result.Values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		tp.mergeValuePath(result.Values[path])
+	}
+
+	for path, written := range result.Mutations {
+		if _, exists := tp.mutations[path]; !exists {
+			tp.mutations[path] = written
+		}
+	}
+
+	tp.unresolved = append(tp.unresolved, result.Unresolved...)
+	tp.file = file
+	slog.Debug("parsed template", "file", file, "values", len(paths), "unresolved", len(result.Unresolved))
+	return tp.strictError(result.Unresolved)
+}
+
+// mergeValuePath combines the evidence about a value found in another template with the
+// evidence collected so far
+func (tp *TemplateParser) mergeValuePath(found *ValuePath) {
+	valuePath, exists := tp.values[found.Path]
+	if !exists {
+		tp.values[found.Path] = found
+		return
+	}
+
+	if len(found.Sources) > 0 {
+		valuePath.addGuards(found.Guards, len(valuePath.Sources) == 0)
+	}
+	for _, site := range found.Sources {
+		valuePath.addSource(site)
+	}
+	for _, hint := range found.Hints {
+		valuePath.addHint(hint)
+	}
+	valuePath.Required = valuePath.Required || found.Required
+	valuePath.Nullable = valuePath.Nullable || found.Nullable
+	valuePath.Optional = valuePath.Optional || found.Optional
+	valuePath.NonEmpty = valuePath.NonEmpty || found.NonEmpty
+	valuePath.Unique = valuePath.Unique || found.Unique
+	valuePath.IntOrPercent = valuePath.IntOrPercent || found.IntOrPercent
+	valuePath.Quantity = valuePath.Quantity || found.Quantity
+	if found.KeyPattern != "" {
+		valuePath.addKeyPattern(found.KeyPattern)
+	}
+	for _, chain := range found.Exclusive {
+		valuePath.addExclusive(chain)
+	}
+	for _, comparison := range found.Comparisons {
+		valuePath.addComparison(comparison)
+	}
+	if valuePath.Default == nil {
+		valuePath.Default = found.Default
+	}
+	valuePath.Sensitive = valuePath.Sensitive || found.Sensitive
+	valuePath.Functions = mergeSorted(valuePath.Functions, found.Functions)
+	if found.Encoding != "" {
+		valuePath.Encoding = found.Encoding
+	}
+	valuePath.Decoded = mergeSorted(valuePath.Decoded, found.Decoded)
+	if len(found.Constraints) > 0 {
+		if valuePath.Constraints == nil {
+			valuePath.Constraints = make(map[string]any)
+		}
+		maps.Copy(valuePath.Constraints, found.Constraints)
+	}
+}
+
+// helpersDigest identifies the named templates the templates of the chart can include
+func (tp *TemplateParser) helpersDigest() string {
+	var names []string
+	for name := range tp.helpers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		parts = append(parts, name, tp.helpers[name])
+	}
+	return cache.Key(parts...)
+}
+
+// parserIdentity distinguishes parser builds, so cached results never outlive the code that
+// produced them
+func parserIdentity() string {
+	identity := []string{fmt.Sprintf("format=%d", templateCacheFormat), "ruleset=" + RulesetDigest()}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		identity = append(identity, "version="+info.Main.Version)
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+				identity = append(identity, setting.Key+"="+setting.Value)
+			}
+		}
+	}
+	return strings.Join(identity, " ")
+}
@@ -0,0 +1,139 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/cache"
+)
+
+func TestTemplateCache(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: cached\nversion: 0.1.0\n",
+		"templates/_helpers.tpl": `{{- define "cached.image" -}}
+{{ .repository }}:{{ .tag | default "latest" }}
+{{- end -}}`,
+		"templates/deployment.yaml": `image: {{ include "cached.image" .Values.image }}
+replicas: {{ .Values.replicas | default 1 }}
+{{- range $k, $v := .Values.env }}
+- {{ $k }}: {{ $v.value | quote }}
+{{- end }}
+`,
+		"templates/service.yaml": `port: {{ .Values.service.port | int }}
+`,
+	}
+	writeChartFiles(t, chartPath, files)
+
+	c, err := cache.Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to open cache: %v", err)
+	}
+
+	parse := func(opts Options) map[string]*ValuePath {
+		t.Helper()
+		parser := NewWithOptions(opts)
+		if err := parser.ParseChartWithOptions(chartPath, false); err != nil {
+			t.Fatalf("Failed to parse chart: %v", err)
+		}
+		return parser.GetValues()
+	}
+
+	uncached := parse(Options{})
+	cold := parse(Options{Cache: c})
+	warm := parse(Options{Cache: c})
+	if !reflect.DeepEqual(cold, uncached) {
+		t.Errorf("Parsing with an empty cache differs from parsing without one")
+	}
+	if !reflect.DeepEqual(warm, uncached) {
+		t.Errorf("Parsing from the cache differs from parsing without one")
+	}
+
+	entries, err := c.Entries()
+	if err != nil {
+		t.Fatalf("Failed to list cache: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected one cached result per template, found %d", len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Bucket != cache.BucketTemplates {
+			t.Errorf("Template results should be cached in %s, found %s", cache.BucketTemplates, entry.Bucket)
+		}
+	}
+
+	// Changing a template invalidates its result only
+	files["templates/service.yaml"] = "port: {{ .Values.service.port | int }}\ntype: {{ .Values.service.type }}\n"
+	writeChartFiles(t, chartPath, files)
+	if _, exists := parse(Options{Cache: c})["service.type"]; !exists {
+		t.Error("Expected service.type from the changed template")
+	}
+
+	// Changing a helper invalidates the results of the templates that may include it
+	files["templates/_helpers.tpl"] = `{{- define "cached.image" -}}
+{{ .registry }}/{{ .repository }}:{{ .tag | default "latest" }}
+{{- end -}}`
+	writeChartFiles(t, chartPath, files)
+	if _, exists := parse(Options{Cache: c})["image.registry"]; !exists {
+		t.Error("Expected image.registry from the changed helper")
+	}
+
+	entries, err = c.Entries()
+	if err != nil {
+		t.Fatalf("Failed to list cache: %v", err)
+	}
+	if len(entries) != 5 {
+		t.Errorf("Expected a result per template version, found %d", len(entries))
+	}
+}
+
+func TestParallelParsing(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: parallel\nversion: 0.1.0\n",
+		"templates/_helpers.tpl": `{{- define "parallel.labels" -}}
+team: {{ .Values.labels.team }}
+{{- end -}}`,
+	}
+	for i := range 40 {
+		files[fmt.Sprintf("templates/service-%02d.yaml", i)] = fmt.Sprintf(`{{- with .Values.services.svc%[1]d }}
+labels: {{ include "parallel.labels" $ | nindent 2 }}
+port: {{ .port | default %[1]d }}
+{{- end }}
+replicas: {{ .Values.replicas | default 1 }}
+shared: {{ .Values.shared | quote }}
+{{- range $k, $v := .Values.env }}
+- {{ $k }}: {{ $v.value }}
+{{- end }}
+`, i)
+	}
+	// A named template defined in a later template is visible to earlier ones
+	files["templates/service-00.yaml"] += "sidecar: {{ include \"parallel.sidecar\" .Values.sidecar }}\n"
+	files["templates/service-39.yaml"] += "{{- define \"parallel.sidecar\" }}{{ .image }}{{ end }}\n"
+
+	writeChartFiles(t, chartPath, files)
+
+	parse := func(concurrency int) map[string]*ValuePath {
+		t.Helper()
+		parser := NewWithOptions(Options{Concurrency: concurrency})
+		if err := parser.ParseChartWithOptions(chartPath, false); err != nil {
+			t.Fatalf("Failed to parse chart: %v", err)
+		}
+		return parser.GetValues()
+	}
+
+	sequential := parse(1)
+	for _, concurrency := range []int{0, 4, 64} {
+		if parallel := parse(concurrency); !reflect.DeepEqual(parallel, sequential) {
+			t.Errorf("Parsing with concurrency %d differs from parsing sequentially", concurrency)
+		}
+	}
+
+	if replicas := sequential["replicas"]; replicas == nil || len(replicas.Sources) != 40 {
+		t.Errorf("Expected replicas referenced from every template, got %+v", replicas)
+	}
+	if _, exists := sequential["sidecar.image"]; !exists {
+		t.Error("Expected sidecar.image through a named template defined in a later template")
+	}
+}
@@ -0,0 +1,355 @@
+package parser
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// Match: a YAML key followed by nothing but the value rendered after it, capturing the key
+	renderedKeyRe = regexp.MustCompile(`^\s*(?:-\s+)?"?([\w.-]+)"?:\s*$`)
+)
+
+// templateIndex is a template lexed once: its masked text, its actions, where its lines start
+// and the pipelines of its actions, tokenized on first use. Passes look the functions applied
+// to a reference and its site up here rather than rescanning the content for each reference.
+type templateIndex struct {
+	source     string
+	masked     string
+	actions    [][]int
+	lineStarts []int
+	pipelines  []*actionPipelines // By action index, nil until tokenized
+	branches   []conditionBranch  // Block branches, nil until read
+}
+
+// actionPipelines is an action split into the pipelines of its parenthesized groups
+type actionPipelines struct {
+	groups []pipelineGroup // In the order groups close, so inner ones first, then the action body
+}
+
+// pipelineGroup is a single pipeline within an action and the function each command calls
+type pipelineGroup struct {
+	start, end  int      // Bounds within the action
+	commandEnds []int    // End of each command, relative to start
+	functions   []string // Function called by each command, "" for plain operands
+}
+
+// newTemplateIndex lexes content
+func newTemplateIndex(content string) *templateIndex {
+	masked, actions := maskTemplate(content)
+	lineStarts := []int{0}
+	for i := 0; i < len(content); i++ {
+		if content[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return &templateIndex{
+		source:     content,
+		masked:     masked,
+		actions:    actions,
+		lineStarts: lineStarts,
+		pipelines:  make([]*actionPipelines, len(actions)),
+	}
+}
+
+// indexOf returns the index of content, reusing the one of the template being parsed
+func (tp *TemplateParser) indexOf(content string) *templateIndex {
+	if tp.index != nil && tp.index.source == content {
+		return tp.index
+	}
+	return newTemplateIndex(content)
+}
+
+// actionAt returns the index of the action containing offset, or -1 outside actions
+func (ti *templateIndex) actionAt(offset int) int {
+	i := sort.Search(len(ti.actions), func(i int) bool {
+		return ti.actions[i][1] > offset
+	})
+	if i < len(ti.actions) && ti.actions[i][0] <= offset {
+		return i
+	}
+	return -1
+}
+
+// functionsAt returns the template functions applied to the reference at offset
+func (ti *templateIndex) functionsAt(offset int) []string {
+	i := ti.actionAt(offset)
+	if i < 0 {
+		return nil
+	}
+	if ti.pipelines[i] == nil {
+		ti.pipelines[i] = tokenizeAction(ti.masked[ti.actions[i][0]:ti.actions[i][1]])
+	}
+	return ti.pipelines[i].functionsAt(offset - ti.actions[i][0])
+}
+
+// listFunctionAt returns the Sprig list function taking the reference at offset as its list, if any
+func (ti *templateIndex) listFunctionAt(offset int) string {
+	i := ti.actionAt(offset)
+	if i < 0 {
+		return ""
+	}
+	if ti.pipelines[i] == nil {
+		ti.pipelines[i] = tokenizeAction(ti.masked[ti.actions[i][0]:ti.actions[i][1]])
+	}
+	return ti.pipelines[i].listFunctionAt(ti.masked[ti.actions[i][0]:ti.actions[i][1]], offset-ti.actions[i][0])
+}
+
+// position returns the 1-based line and byte column of offset
+func (ti *templateIndex) position(offset int) (int, int) {
+	line := sort.Search(len(ti.lineStarts), func(i int) bool {
+		return ti.lineStarts[i] > offset
+	})
+	return line, offset - ti.lineStarts[line-1] + 1
+}
+
+// renderedKey returns the YAML key the action at offset renders its value for, on the line of
+// the key, as in maxSurge: {{ .Values.maxSurge }}, or "" when the action is a block or renders
+// something else
+func (ti *templateIndex) renderedKey(offset int) string {
+	i := ti.actionAt(offset)
+	if i < 0 || blockKeywordRe.MatchString(ti.masked[ti.actions[i][0]:ti.actions[i][1]]) {
+		return ""
+	}
+	line, _ := ti.position(ti.actions[i][0])
+	match := renderedKeyRe.FindStringSubmatch(ti.literalText(ti.lineStarts[line-1], ti.actions[i][0]))
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// keyLine returns the 0-based line holding the YAML key the action at index i renders under and
+// its text outside actions: the line of the action when text precedes it there, otherwise the
+// last line above holding more than actions. The line is -1 when there is none.
+func (ti *templateIndex) keyLine(i int) (int, string) {
+	start := ti.actions[i][0]
+	line, _ := ti.position(start)
+	line-- // 0-based from here on
+	if key := ti.literalText(ti.lineStarts[line], start); strings.TrimSpace(key) != "" {
+		return line, key
+	}
+	for line--; line >= 0; line-- {
+		if key := ti.literalLine(line); strings.TrimSpace(key) != "" {
+			return line, key
+		}
+	}
+	return -1, ""
+}
+
+// parentKey returns the text outside actions of the line holding the YAML key a 0-based line is
+// nested under: the last line above it indented less and holding more than actions. Items of a
+// list may be indented as much as its key.
+func (ti *templateIndex) parentKey(line int) string {
+	indent, item := ti.indentation(line), ti.listItem(line)
+	for line--; line >= 0; line-- {
+		key := ti.literalLine(line)
+		if strings.TrimSpace(key) == "" {
+			continue
+		}
+		if other := ti.indentation(line); other < indent || (item && other == indent && !ti.listItem(line)) {
+			return key
+		}
+	}
+	return ""
+}
+
+// listItem reports whether a 0-based line of the template starts an item of a YAML list
+func (ti *templateIndex) listItem(line int) bool {
+	return strings.HasPrefix(strings.TrimSpace(ti.literalLine(line)), "- ")
+}
+
+// indentation returns how many spaces a 0-based line of the template starts with
+func (ti *templateIndex) indentation(line int) int {
+	start := ti.lineStarts[line]
+	end := start
+	for end < len(ti.source) && ti.source[end] == ' ' {
+		end++
+	}
+	return end - start
+}
+
+// literalLine returns the text of a 0-based line of the template outside actions
+func (ti *templateIndex) literalLine(line int) string {
+	end := len(ti.source)
+	if line+1 < len(ti.lineStarts) {
+		end = ti.lineStarts[line+1] - 1
+	}
+	return ti.literalText(ti.lineStarts[line], end)
+}
+
+// literalText returns the text of the template between two offsets with actions blanked
+func (ti *templateIndex) literalText(start, end int) string {
+	text := []byte(ti.source[start:end])
+	// Actions are in order, the first one ending after start may overlap
+	first := sort.Search(len(ti.actions), func(i int) bool { return ti.actions[i][1] > start })
+	for _, span := range ti.actions[first:] {
+		if span[0] >= end {
+			break
+		}
+		for i := max(span[0], start); i < min(span[1], end); i++ {
+			text[i-start] = ' '
+		}
+	}
+	return string(text)
+}
+
+// tokenizeAction splits an action into the pipelines of its groups and of its body
+func tokenizeAction(action string) *actionPipelines {
+	start, end, groups := parenGroups(action)
+	groups = append(groups, [2]int{start, end})
+
+	pipelines := &actionPipelines{}
+	for _, bounds := range groups {
+		group := pipelineGroup{start: bounds[0], end: bounds[1]}
+		commandStart := 0
+		for _, command := range splitPipeline(action[bounds[0]:bounds[1]]) {
+			commandEnd := commandStart + len(command)
+			group.commandEnds = append(group.commandEnds, commandEnd)
+			group.functions = append(group.functions, commandFunction(command))
+			commandStart = commandEnd + 1
+		}
+		pipelines.groups = append(pipelines.groups, group)
+	}
+	return pipelines
+}
+
+// functionsAt returns the functions applied to the operand at offset within the action. Only the
+// innermost group holding the operand is considered: the operand is an argument of the group's
+// command and is piped through every following command.
+func (ap *actionPipelines) functionsAt(offset int) []string {
+	group := ap.groupAt(offset)
+	offset -= group.start
+
+	var functions []string
+	for i, commandEnd := range group.commandEnds {
+		if commandEnd >= offset && group.functions[i] != "" {
+			functions = append(functions, group.functions[i])
+		}
+	}
+	return functions
+}
+
+// listFunctionAt returns the list function taking the operand at offset within the action as its
+// list: the operand is that argument of its command, or is piped into the command as its last
+// argument, possibly through passthrough functions such as default
+func (ap *actionPipelines) listFunctionAt(action string, offset int) string {
+	group := ap.groupAt(offset)
+	offset -= group.start
+
+	commandStart := 0
+	for i, commandEnd := range group.commandEnds {
+		if commandEnd < offset {
+			commandStart = commandEnd + 1
+			continue
+		}
+
+		args := argSpans(action[group.start+commandStart : group.start+commandEnd])
+		function := group.functions[i]
+		if function == "" {
+			// The operand is the whole command, so it is the last argument of the next one
+			return group.pipedListFunction(action, i+1)
+		}
+		position := -1
+		for j, span := range args {
+			if span[0] <= offset-commandStart && offset-commandStart < span[1] {
+				position = j
+			}
+		}
+		// Arguments follow the function, after a possible declaration
+		for len(args) > 0 && action[group.start+commandStart+args[0][0]:group.start+commandStart+args[0][1]] != function {
+			args = args[1:]
+			position--
+		}
+		count := len(args) - 1
+		if i > 0 {
+			count++
+		}
+		switch {
+		case position < 1:
+			return ""
+		case isListArgument(function, position-1, count):
+			return function
+		case passthroughFunctions[function] && position == count:
+			return group.pipedListFunction(action, i+1)
+		}
+		return ""
+	}
+	return ""
+}
+
+// pipedListFunction returns the function of command i when it is a list function taking its
+// piped input as its list, following passthrough functions
+func (group pipelineGroup) pipedListFunction(action string, i int) string {
+	for ; i < len(group.commandEnds); i++ {
+		function := group.functions[i]
+		commandStart := group.commandEnds[i-1] + 1
+		count := len(argSpans(action[group.start+commandStart : group.start+group.commandEnds[i]]))
+		switch {
+		case isListArgument(function, count-1, count):
+			return function
+		case !passthroughFunctions[function]:
+			return ""
+		}
+	}
+	return ""
+}
+
+// groupAt returns the innermost group holding the operand at offset within the action
+func (ap *actionPipelines) groupAt(offset int) pipelineGroup {
+	group := ap.groups[len(ap.groups)-1]
+	for _, inner := range ap.groups[:len(ap.groups)-1] {
+		if inner.start <= offset && offset < inner.end {
+			group = inner
+			break
+		}
+	}
+	return group
+}
+
+// parenGroups returns the bounds of the action body without its delimiters and of every
+// parenthesized group within it, excluding the parentheses, in the order the groups close
+func parenGroups(action string) (int, int, [][2]int) {
+	start, end := 0, len(action)
+	if strings.HasPrefix(action, "{{") {
+		start = 2
+		if strings.HasPrefix(action[start:], "-") {
+			start++
+		}
+	}
+	if strings.HasSuffix(action, "}}") {
+		end -= 2
+		if end > start && action[end-1] == '-' {
+			end--
+		}
+	}
+
+	var groups [][2]int
+	var stack []int
+	var quote byte
+	for i := start; i < end; i++ {
+		c := action[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(':
+			stack = append(stack, i)
+		case c == ')':
+			if len(stack) == 0 {
+				continue
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			groups = append(groups, [2]int{open + 1, i})
+		}
+	}
+
+	return start, end, groups
+}
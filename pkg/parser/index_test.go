@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTemplateIndex(t *testing.T) {
+	content := `name: {{ .Values.name | quote }}
+port: {{ (.Values.port | default 80) | int }}
+text outside {{ "{{" }} actions
+{{- range $k, $v := .Values.env }}
+  {{ $k }}: {{ $v.value | upper | quote }}
+{{- end }}`
+	index := newTemplateIndex(content)
+
+	tests := []struct {
+		reference string
+		functions []string
+		line      int
+		column    int
+	}{
+		{".Values.name", []string{"quote"}, 1, 10},
+		{".Values.port", []string{"default"}, 2, 11},
+		{"$v.value", []string{"upper", "quote"}, 5, 16},
+		{"text outside", nil, 3, 1},
+	}
+
+	for _, tt := range tests {
+		offset := strings.Index(content, tt.reference)
+		if functions := index.functionsAt(offset); !reflect.DeepEqual(functions, tt.functions) {
+			t.Errorf("functionsAt(%s) = %v, expected %v", tt.reference, functions, tt.functions)
+		}
+		if line, column := index.position(offset); line != tt.line || column != tt.column {
+			t.Errorf("position(%s) = %d:%d, expected %d:%d", tt.reference, line, column, tt.line, tt.column)
+		}
+	}
+
+	// Lookups agree with tokenizing the action on its own
+	for _, span := range index.actions {
+		action := index.masked[span[0]:span[1]]
+		for offset := range action {
+			if got, want := index.functionsAt(span[0]+offset), pipelineFunctions(action, offset); !reflect.DeepEqual(got, want) {
+				t.Errorf("functionsAt(%d) in %q = %v, expected %v", offset, action, got, want)
+			}
+		}
+	}
+}
+
+func TestParseLargeTemplate(t *testing.T) {
+	var builder strings.Builder
+	for i := range 5000 {
+		builder.WriteString("k")
+		builder.WriteString(strings.Repeat("x", i%7))
+		builder.WriteString(": {{ .Values.items.a")
+		builder.WriteString(strings.Repeat("b", i%50))
+		builder.WriteString(" | default \"x\" | quote }}\n")
+	}
+
+	parser := New()
+	unresolved := parser.parseTemplateContent("large.yaml", builder.String())
+	if len(unresolved) != 0 {
+		t.Errorf("Expected no unresolved constructs, got %v", unresolved)
+	}
+	if len(parser.values) != 51 {
+		t.Errorf("Expected 51 paths, found %d", len(parser.values))
+	}
+	last := parser.values["items.a"+strings.Repeat("b", 4999%50)]
+	if last == nil || last.Sources[len(last.Sources)-1].Line != 5000 {
+		t.Errorf("Expected the last reference on line 5000, got %+v", last)
+	}
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Naming rules of the keys of maps rendered as Kubernetes names, by how strict they are
+const (
+	// EnvVarNamePattern is the rule Kubernetes validates environment variable names with
+	EnvVarNamePattern = `^[-._a-zA-Z][-._a-zA-Z0-9]*$`
+	// ConfigMapKeyPattern is the rule Kubernetes validates ConfigMap and Secret data keys with
+	ConfigMapKeyPattern = `^[-._a-zA-Z0-9]+$`
+)
+
+var (
+	// Match: the env key of a container, holding a list of environment variables
+	envKeyRe = regexp.MustCompile(`^\s*(?:-\s+)?env:\s*$`)
+	// Match: the name key of an environment variable, before the action rendering the name
+	envNameRe = regexp.MustCompile(`^\s*(?:-\s+)?name:\s*$`)
+	// Match: the data keys of a ConfigMap or Secret, holding a map of data keys
+	dataKeyRe = regexp.MustCompile(`^\s*(?:data|stringData|binaryData):\s*$`)
+	// Match: an action rendering a variable alone, possibly quoted, capturing its name
+	variableActionRe = regexp.MustCompile(`^` + pipelineOpen + `\$` + capture(identifier) + `\s*(?:\|\s*s?quote\s*)?` + pipelineClose + `$`)
+)
+
+// parseRangeKeys finds the naming rule of the keys of a map the range action at index start
+// iterates with key, from the YAML the keys are rendered into: the names of the variables of an
+// env list, or the keys of ConfigMap data.
+//
+//	env:
+//	{{- range $name, $value := .Values.env }}
+//	  - name: {{ $name }}
+//	    value: {{ $value | quote }}
+//	{{- end }}
+func (tp *TemplateParser) parseRangeKeys(index *templateIndex, start int, path, key string) {
+	valuePath, exists := tp.values[tp.normalizePath(path)]
+	if !exists {
+		return
+	}
+	end := matchingEnd(index.masked, index.actions, start)
+	if end < 0 {
+		return
+	}
+
+	for i := start + 1; i < end; i++ {
+		span := index.actions[i]
+		match := variableActionRe.FindStringSubmatch(index.masked[span[0]:span[1]])
+		if match == nil || match[1] != key {
+			continue
+		}
+		line, _ := index.position(span[0])
+		line-- // 0-based from here on
+		before := index.literalText(index.lineStarts[line], span[0])
+		rest := index.source[span[1]:]
+		if newline := strings.IndexByte(rest, '\n'); newline >= 0 {
+			rest = rest[:newline]
+		}
+		after := index.literalText(span[1], span[1]+len(rest))
+		switch parent := index.parentKey(line); {
+		case envNameRe.MatchString(before) && envKeyRe.MatchString(parent):
+			valuePath.addKeyPattern(EnvVarNamePattern)
+		case strings.TrimSpace(before) == "" && strings.HasPrefix(after, ":") && dataKeyRe.MatchString(parent):
+			valuePath.addKeyPattern(ConfigMapKeyPattern)
+		}
+	}
+}
+
+// addKeyPattern records the naming rule of the keys of a map, keeping the stricter one when the
+// keys are rendered as both environment variable names and ConfigMap keys
+func (vp *ValuePath) addKeyPattern(pattern string) {
+	if vp.KeyPattern != EnvVarNamePattern {
+		vp.KeyPattern = pattern
+	}
+}
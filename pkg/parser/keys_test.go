@@ -0,0 +1,76 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestKeyPatterns(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "deployment.yaml")
+	template := `apiVersion: v1
+kind: ConfigMap
+data:
+  {{- range $key, $value := .Values.config }}
+  {{ $key }}: {{ $value | quote }}
+  {{- end }}
+  {{- range $file, $content := .Values.files }}
+  {{ $file | quote }}: |
+{{ $content | indent 4 }}
+  {{- end }}
+---
+spec:
+  containers:
+    - name: app
+      env:
+      {{- range $name, $value := .Values.env }}
+        - name: {{ $name }}
+          value: {{ $value | quote }}
+      {{- end }}
+      {{- range $name, $value := .Values.prefixed }}
+        - name: {{ printf "APP_%s" $name }}
+          value: {{ $value | quote }}
+      {{- end }}
+      {{- range $name, $value := .Values.both }}
+        - name: {{ $name }}
+          value: {{ $value | quote }}
+      {{- end }}
+  labels:
+    {{- range $key, $value := .Values.labels }}
+    {{ $key }}: {{ $value | quote }}
+    {{- end }}
+---
+data:
+  {{- range $key, $value := .Values.both }}
+  {{ $key }}: {{ $value | quote }}
+  {{- end }}
+---
+env:
+{{- range $name, $value := .Values.flat }}
+- name: {{ $name | quote }}
+  value: {{ $value | quote }}
+{{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	// Keys rendered through printf are not the names themselves, labels are not ConfigMap data
+	patterns := map[string]string{
+		"config": ConfigMapKeyPattern,
+		"files":  ConfigMapKeyPattern,
+		"env":    EnvVarNamePattern,
+		"both":   EnvVarNamePattern,
+		"flat":   EnvVarNamePattern,
+	}
+	for path, valuePath := range parser.GetValues() {
+		if valuePath.KeyPattern != patterns[path] {
+			t.Errorf("%s: expected key pattern %q, got %q", path, patterns[path], valuePath.KeyPattern)
+		}
+	}
+}
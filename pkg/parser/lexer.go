@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"strings"
+)
+
+// scanActions returns the [start, end) offsets of every template action in content.
+// String literals ("...", `...`, '.') and comments are honored, so delimiters they contain,
+// as in {{ "{{" }} or {{ `}}` }}, neither open nor close an action.
+func scanActions(content string) [][]int {
+	var actions [][]int
+
+	for pos := 0; pos < len(content); {
+		open := strings.Index(content[pos:], "{{")
+		if open < 0 {
+			break
+		}
+		start := pos + open
+		end := actionEnd(content, start+2)
+		actions = append(actions, []int{start, end})
+		pos = end
+	}
+
+	return actions
+}
+
+// actionEnd returns the offset just past the }} closing the action whose body starts at pos
+func actionEnd(content string, pos int) int {
+	body := strings.TrimLeft(strings.TrimPrefix(content[pos:], "-"), " \t\r\n")
+	if strings.HasPrefix(body, "/*") {
+		commentStart := len(content) - len(body)
+		closeComment := strings.Index(content[commentStart+2:], "*/")
+		if closeComment < 0 {
+			return len(content)
+		}
+		pos = commentStart + 2 + closeComment + 2
+	}
+
+	var quote byte
+	for i := pos; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '}' && i+1 < len(content) && content[i+1] == '}':
+			return i + 2
+		}
+	}
+
+	return len(content)
+}
+
+// maskTemplate blanks everything that cannot hold a .Values reference: literal text between
+// actions, template comments and the contents of string literals. Offsets, quotes and newlines
+// are preserved so positions found in the masked text map directly back to the original.
+func maskTemplate(content string) (string, [][]int) {
+	actions := scanActions(content)
+	masked := []byte(content)
+
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if masked[i] != '\n' {
+				masked[i] = ' '
+			}
+		}
+	}
+
+	last := 0
+	for _, span := range actions {
+		blank(last, span[0])
+		last = span[1]
+
+		body := strings.TrimLeft(strings.TrimPrefix(content[span[0]+2:span[1]], "-"), " \t\r\n")
+		if strings.HasPrefix(body, "/*") {
+			blank(span[0]+2, span[1]-2)
+			continue
+		}
+
+		var quote byte
+		for i := span[0] + 2; i < span[1]-2; i++ {
+			c := content[i]
+			switch {
+			case quote != 0:
+				if c == '\\' && quote != '`' {
+					blank(i, min(i+2, span[1]-2))
+					i++
+				} else if c == quote {
+					quote = 0
+				} else {
+					blank(i, i+1)
+				}
+			case c == '"' || c == '`' || c == '\'':
+				quote = c
+			}
+		}
+	}
+	blank(last, len(content))
+
+	return string(masked), actions
+}
@@ -0,0 +1,75 @@
+package parser
+
+import (
+	"testing"
+)
+
+func TestScanActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "simple actions",
+			content:  `a: {{ .Values.a }} b: {{- .Values.b -}}`,
+			expected: []string{`{{ .Values.a }}`, `{{- .Values.b -}}`},
+		},
+		{
+			name:     "escaped delimiters",
+			content:  `{{ "{{" }} .Values.literal {{ "}}" }}`,
+			expected: []string{`{{ "{{" }}`, `{{ "}}" }}`},
+		},
+		{
+			name:     "raw string with closing delimiter",
+			content:  "{{ `}}` | quote }}{{ .Values.x }}",
+			expected: []string{"{{ `}}` | quote }}", `{{ .Values.x }}`},
+		},
+		{
+			name:     "comment containing delimiters",
+			content:  `{{/* {{ .Values.ignored }} */}}{{ .Values.y }}`,
+			expected: []string{`{{/* {{ .Values.ignored }} */}}`, `{{ .Values.y }}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions := scanActions(tt.content)
+			if len(actions) != len(tt.expected) {
+				t.Fatalf("Expected %d actions, found %d", len(tt.expected), len(actions))
+			}
+			for i, span := range actions {
+				if got := tt.content[span[0]:span[1]]; got != tt.expected[i] {
+					t.Errorf("Action %d: expected %q, got %q", i, tt.expected[i], got)
+				}
+			}
+		})
+	}
+}
+
+func TestEscapedDelimitersAndStrings(t *testing.T) {
+	content := `annotations:
+  argo: {{ "{{" }} .Values.workflow.name {{ "}}" }}
+  raw: {{ ` + "`{{ .Values.raw.name }}`" + ` }}
+  message: {{ printf "%s .Values.fake" .Values.app.name }}
+  image: {{ .Values.image.repository }}:{{ .Values.image.tag }}
+  plain: .Values.outside.action
+`
+
+	parser := New()
+	parser.parseDirectValueReferences(content)
+
+	expected := []string{"app.name", "app", "image.repository", "image.tag", "image"}
+	for _, path := range expected {
+		if _, found := parser.values[path]; !found {
+			t.Errorf("Expected path %s not found", path)
+		}
+	}
+
+	if len(parser.values) != len(expected) {
+		t.Errorf("Expected %d paths, found %d", len(expected), len(parser.values))
+		for path := range parser.values {
+			t.Logf("Found path: %s", path)
+		}
+	}
+}
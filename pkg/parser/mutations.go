@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mutationFunctions modify the map given as their first argument in place
+var mutationFunctions = map[string]bool{
+	"set":   true,
+	"unset": true,
+}
+
+// mutation records a value the templates write with set or unset rather than read
+type mutation struct {
+	Path     string
+	Function string
+	Site     Site
+}
+
+var (
+	// Match: set or unset, followed by the mutated map
+	mutationRe = regexp.MustCompile(`\b(set|unset)\s+`)
+	// Match: the literal key argument following the map, e.g. "computed"
+	mutationKeyRe = regexp.MustCompile(`^\s+("(?:[^"\\]|\\.)*")`)
+)
+
+// parseMutations finds {{ $_ := set .Values "computed" ... }} and {{ unset .Values.x "key" }}
+// calls. The keys they write are outputs of the chart rather than inputs, unless the written
+// value is computed from the key itself, as in set .Values "name" (.Values.name | default "x").
+func (tp *TemplateParser) parseMutations(content string) {
+	index := tp.indexOf(content)
+	masked := index.masked
+	for _, match := range mutationRe.FindAllStringSubmatchIndex(masked, -1) {
+		if !isWordStart(masked, match[0]) {
+			continue
+		}
+
+		operandEnd := matchingArgEnd(masked, match[1])
+		basePath, ok := tp.resolveOperand(masked[match[1]:operandEnd])
+		if !ok {
+			continue
+		}
+
+		// Keys are read from the original content since masking blanks string contents
+		keyMatch := mutationKeyRe.FindStringSubmatchIndex(content[operandEnd:])
+		if keyMatch == nil {
+			continue
+		}
+		key, err := strconv.Unquote(content[operandEnd+keyMatch[2] : operandEnd+keyMatch[3]])
+		if err != nil {
+			continue
+		}
+		path := joinPath(tp.normalizePath(basePath), EscapeKey(key))
+
+		function := masked[match[2]:match[3]]
+		if function == "set" {
+			valueStart := operandEnd + keyMatch[1]
+			value := masked[valueStart:matchingArgEnd(masked, skipSpaces(masked, valueStart))]
+			if readsPath(tp, value, path) {
+				continue
+			}
+		}
+
+		if _, exists := tp.mutations[path]; !exists {
+			tp.mutations[path] = mutation{Path: path, Function: function, Site: tp.siteAt(index, match[0])}
+		}
+	}
+}
+
+// readsPath reports whether an operand refers to path or one of its fields
+func readsPath(tp *TemplateParser, operand, path string) bool {
+	for _, match := range tp.re.FindAllStringSubmatch(operand, -1) {
+		read := tp.normalizePath(match[1])
+		if read == path || strings.HasPrefix(read, path+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// skipSpaces returns the offset of the first non-space character at or after pos
+func skipSpaces(content string, pos int) int {
+	for pos < len(content) && isSpace(content[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// excludeMutations removes the values the templates write, and their fields, from the model
+func (tp *TemplateParser) excludeMutations() {
+	for path := range tp.values {
+		for mutated := range tp.mutations {
+			if path == mutated || strings.HasPrefix(path, mutated+".") || strings.HasPrefix(path, mutated+"[]") {
+				delete(tp.values, path)
+				break
+			}
+		}
+	}
+}
+
+// mutationWarnings reports the values written with set or unset, in path order
+func (tp *TemplateParser) mutationWarnings() []Warning {
+	var paths []string
+	for path := range tp.mutations {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	outcome := "excluded from the schema"
+	if tp.opts.KeepMutated {
+		outcome = "kept in the schema"
+	}
+
+	var warnings []Warning
+	for _, path := range paths {
+		written := tp.mutations[path]
+		warnings = append(warnings, Warning{
+			Path:    path,
+			Message: fmt.Sprintf("written with %s at %s, so it is an output of the chart rather than an input; %s", written.Function, written.Site, outcome),
+		})
+	}
+	return warnings
+}
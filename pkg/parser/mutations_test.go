@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMutationsExcluded(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: mutating\nversion: 0.1.0\n",
+		"templates/_helpers.tpl": `{{- define "mutating.init" -}}
+{{- $_ := set .Values "computed" (dict "host" "example.com") -}}
+{{- $_ := set .Values.image "fullRef" (printf "%s:%s" .Values.image.repository .Values.image.tag) -}}
+{{- $_ := set .Values "name" (.Values.name | default "app") -}}
+{{- $_ := unset .Values "legacy" -}}
+{{- end -}}`,
+		"templates/deployment.yaml": `{{- include "mutating.init" . }}
+{{- $_ := set .Values.image "fullRef" "x" }}
+host: {{ .Values.computed.host }}
+image: {{ .Values.image.fullRef }}
+repository: {{ .Values.image.repository }}
+name: {{ .Values.name }}
+legacy: {{ .Values.legacy }}
+`,
+	}
+	writeChartFiles(t, chartPath, files)
+
+	parser := New()
+	if err := parser.ParseChartWithOptions(chartPath, false); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+
+	values := parser.GetValues()
+	for _, path := range []string{"computed", "computed.host", "image.fullRef", "legacy"} {
+		if _, exists := values[path]; exists {
+			t.Errorf("Mutated value %s should be excluded", path)
+		}
+	}
+	// Values defaulted in place are still inputs
+	for _, path := range []string{"image", "image.repository", "name"} {
+		if _, exists := values[path]; !exists {
+			t.Errorf("Expected value %s not found", path)
+		}
+	}
+
+	var warned []string
+	for _, warning := range parser.Warnings() {
+		if strings.Contains(warning.Message, "excluded from the schema") {
+			warned = append(warned, warning.Path)
+		}
+	}
+	if strings.Join(warned, ",") != "computed,image.fullRef,legacy" {
+		t.Errorf("Expected a warning for every mutated value, got %v", warned)
+	}
+	if unresolved := parser.Unresolved(); len(unresolved) > 0 {
+		t.Errorf("Writes to literal keys should not be unresolved, got %v", unresolved)
+	}
+
+	keeping := NewWithOptions(Options{KeepMutated: true})
+	if err := keeping.ParseChartWithOptions(chartPath, false); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	if _, exists := keeping.GetValues()["computed.host"]; !exists {
+		t.Error("Mutated values should be kept with KeepMutated")
+	}
+}
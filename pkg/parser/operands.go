@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// passthroughFunctions return their piped operand unchanged in shape, so fields selected on
+// their result belong to the operand, e.g. (.Values.x | default dict).y
+var passthroughFunctions = map[string]bool{
+	"default":  true,
+	"required": true,
+}
+
+var (
+	// Match: .Values.path / $.Values.path as a whole operand
+	valuesOperandRe = regexp.MustCompile(`^\$?\.Values(?:\.(` + valuePath + `))?$`)
+	// Match: $var or $var.path as a whole operand
+	variableOperandRe = regexp.MustCompile(`^\$(` + identifier + `)(?:\.(` + valuePath + `))?$`)
+)
+
+// resolveOperand returns the value path an operand refers to: .Values chains, variables bound
+// to .Values paths and parenthesized groups followed by field selections
+func (tp *TemplateParser) resolveOperand(operand string) (string, bool) {
+	operand = strings.TrimSpace(operand)
+
+	if match := valuesOperandRe.FindStringSubmatch(operand); match != nil {
+		return match[1], true
+	}
+
+	if match := variableOperandRe.FindStringSubmatch(operand); match != nil {
+		basePath, exists := tp.variables[match[1]]
+		if !exists {
+			return "", false
+		}
+		return joinPath(basePath, match[2]), true
+	}
+
+	if !strings.HasPrefix(operand, "(") {
+		return "", false
+	}
+
+	close := matchingParen(operand, 0)
+	if close < 0 {
+		return "", false
+	}
+
+	fields := operand[close+1:]
+	if fields != "" && !strings.HasPrefix(fields, ".") {
+		return "", false
+	}
+
+	basePath, ok := tp.resolveGroup(operand[1:close])
+	if !ok {
+		return "", false
+	}
+	return joinPath(basePath, strings.TrimPrefix(fields, ".")), true
+}
+
+// resolveGroup returns the value path a parenthesized pipeline evaluates to, when it is a single
+// operand optionally passed through functions that preserve it
+func (tp *TemplateParser) resolveGroup(group string) (string, bool) {
+	commands := splitPipeline(group)
+
+	for _, command := range commands[1:] {
+		args := splitArgs(command)
+		if len(args) == 0 || !passthroughFunctions[args[0]] {
+			return "", false
+		}
+	}
+
+	args := splitArgs(commands[0])
+	switch {
+	case len(args) == 1:
+		return tp.resolveOperand(args[0])
+	case len(args) > 1 && passthroughFunctions[args[0]]:
+		// default dict .Values.x / required "msg" .Values.x
+		return tp.resolveOperand(args[len(args)-1])
+	}
+
+	return "", false
+}
+
+// matchingParen returns the offset of the parenthesis closing the one opened at open, or -1
+func matchingParen(expr string, open int) int {
+	var quote byte
+	depth := 0
+	for i := open; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitArgs splits a command into its whitespace separated arguments, keeping parenthesized
+// groups and string literals whole
+func splitArgs(command string) []string {
+	var args []string
+	for _, span := range argSpans(command) {
+		args = append(args, command[span[0]:span[1]])
+	}
+	return args
+}
+
+// argSpans returns the [start, end) offsets of the arguments splitArgs would return
+func argSpans(command string) [][]int {
+	var spans [][]int
+	var quote byte
+	depth, start := 0, -1
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		if start < 0 && !isSpace(c) {
+			start = i
+		}
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case isSpace(c) && depth == 0 && start >= 0:
+			spans = append(spans, []int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, []int{start, len(command)})
+	}
+	return spans
+}
+
+// isSpace reports whether c separates template arguments
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// openingParen returns the offset of the parenthesis matching the one closed at close, or -1
+func openingParen(content string, close int) int {
+	depth := 0
+	for i := close; i >= 0; i-- {
+		switch content[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -210,3 +210,196 @@ func TestParseDirectValueReferences(t *testing.T) {
 		})
 	}
 }
+
+func TestParseKeyLookups(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		vars     map[string]string
+		expected []string
+	}{
+		{
+			name:     "hasKey guard with get",
+			content:  `{{ if hasKey .Values.features "beta" }}{{ get .Values.features "beta" }}{{ end }}`,
+			expected: []string{"features.beta", "features"},
+		},
+		{
+			name:     "index with several keys",
+			content:  `{{ index .Values "database" "primary" "host" }}`,
+			expected: []string{"database.primary.host", "database.primary", "database"},
+		},
+		{
+			name:     "index with array position",
+			content:  `{{ index .Values.servers 0 "name" }}`,
+			expected: []string{"servers[].name", "servers[]", "servers"},
+		},
+		{
+			name:     "lookup through variable",
+			content:  `{{ get $cfg "timeout" }}`,
+			vars:     map[string]string{"cfg": "app.config"},
+			expected: []string{"app.config.timeout", "app.config", "app"},
+		},
+		{
+			name:     "computed key is ignored",
+			content:  `{{ index .Values.config $key }}`,
+			expected: []string{},
+		},
+		{
+			name:     "dotted key is escaped",
+			content:  `{{ index .Values.config "nginx.conf" }}`,
+			expected: []string{`config.nginx\.conf`, "config"},
+		},
+		{
+			name:     "dashed keys",
+			content:  `{{ index .Values "pod-annotations" "team" }}`,
+			expected: []string{"pod-annotations.team", "pod-annotations"},
+		},
+		{
+			name:     "hasKey only takes one key",
+			content:  `{{ hasKey .Values.a "b" "c" }}`,
+			expected: []string{"a.b", "a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := New()
+			if tt.vars != nil {
+				parser.variables = tt.vars
+			}
+
+			parser.parseKeyLookups(tt.content)
+
+			for _, expectedPath := range tt.expected {
+				if _, found := parser.values[expectedPath]; !found {
+					t.Errorf("Expected path %s not found", expectedPath)
+				}
+			}
+
+			if len(parser.values) != len(tt.expected) {
+				t.Errorf("Expected %d paths, found %d", len(tt.expected), len(parser.values))
+				for path := range parser.values {
+					t.Logf("Found path: %s", path)
+				}
+			}
+		})
+	}
+}
+
+func TestHasKeyTypesParentAsObject(t *testing.T) {
+	parser := New()
+	content := `{{- if hasKey .Values.features "beta" }}
+beta: {{ get .Values.features "beta" }}
+{{- end }}`
+
+	parser.parseDirectValueReferences(content)
+	parser.parseKeyLookups(content)
+
+	if features, found := parser.values["features"]; !found {
+		t.Error("Expected path features not found")
+	} else if features.Type != "object" {
+		t.Errorf("features should be object, got %s", features.Type)
+	}
+
+	if _, found := parser.values["features.beta"]; !found {
+		t.Error("Expected optional property features.beta")
+	}
+}
+
+func TestParseGroupFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		vars     map[string]string
+		expected []string
+	}{
+		{
+			name:     "field on parenthesized reference",
+			content:  `{{ (.Values.config).timeout }}`,
+			expected: []string{"config.timeout", "config"},
+		},
+		{
+			name:     "field on defaulted reference",
+			content:  `{{ (.Values.app.config | default dict).timeout }}`,
+			expected: []string{"app.config.timeout", "app.config", "app"},
+		},
+		{
+			name:     "default called with the operand as argument",
+			content:  `{{ (default dict .Values.resources).limits.cpu }}`,
+			expected: []string{"resources.limits.cpu", "resources.limits", "resources"},
+		},
+		{
+			name:     "nested groups",
+			content:  `{{ ((.Values.a).b).c }}`,
+			expected: []string{"a.b.c", "a.b", "a"},
+		},
+		{
+			name:     "group over variable",
+			content:  `{{ ($cfg | default dict).port }}`,
+			vars:     map[string]string{"cfg": "server"},
+			expected: []string{"server.port", "server"},
+		},
+		{
+			name:     "root reference",
+			content:  `{{ ($.Values.ingress).host }}`,
+			expected: []string{"ingress.host", "ingress"},
+		},
+		{
+			name:     "string literal parentheses are ignored",
+			content:  `{{ (.Values.x | default ")(").y }}`,
+			expected: []string{"x.y", "x"},
+		},
+		{
+			name:     "non passthrough function is not resolved",
+			content:  `{{ (deepCopy .Values.raw).key }}`,
+			expected: []string{},
+		},
+		{
+			name:     "decoded document field belongs to the string",
+			content:  `{{ (fromYaml .Values.raw).key }}`,
+			expected: []string{"raw"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parser := New()
+			if tt.vars != nil {
+				parser.variables = tt.vars
+			}
+
+			parser.parseGroupFields(tt.content)
+
+			for _, expectedPath := range tt.expected {
+				if _, found := parser.values[expectedPath]; !found {
+					t.Errorf("Expected path %s not found", expectedPath)
+				}
+			}
+
+			if len(parser.values) != len(tt.expected) {
+				t.Errorf("Expected %d paths, found %d", len(tt.expected), len(parser.values))
+				for path := range parser.values {
+					t.Logf("Found path: %s", path)
+				}
+			}
+		})
+	}
+}
+
+func TestParenthesizedConditions(t *testing.T) {
+	parser := New()
+	content := `{{ if (and .Values.a.enabled (not .Values.b)) }}{{ (.Values.config).timeout }}{{ end }}`
+
+	parser.parseDirectValueReferences(content)
+	parser.parseGroupFields(content)
+
+	for _, expectedPath := range []string{"a.enabled", "a", "b", "config.timeout", "config"} {
+		if _, found := parser.values[expectedPath]; !found {
+			t.Errorf("Expected path %s not found", expectedPath)
+		}
+	}
+
+	if config := parser.values["config"]; config != nil && config.Type != "object" {
+		t.Errorf("config should be object, got %s", config.Type)
+	}
+}
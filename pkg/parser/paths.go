@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"strings"
+)
+
+// pathSpecials are the characters with a meaning in value paths: segment separators, array
+// markers and the any-key segment. Keys containing them are escaped with a backslash.
+const pathSpecials = `\.[]*`
+
+// EscapeKey turns a map key into a path segment, so keys such as nginx.conf that can only be
+// reached with index or get are representable, e.g. config.nginx\.conf
+func EscapeKey(key string) string {
+	if !strings.ContainsAny(key, pathSpecials) {
+		return key
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(pathSpecials, key[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(key[i])
+	}
+	return b.String()
+}
+
+// UnescapeKey returns the map key a path segment stands for, without its array marker
+func UnescapeKey(segment string) string {
+	segment = strings.TrimSuffix(segment, "[]")
+	if !strings.Contains(segment, `\`) {
+		return segment
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		if segment[i] == '\\' && i+1 < len(segment) {
+			i++
+		}
+		b.WriteByte(segment[i])
+	}
+	return b.String()
+}
+
+// SplitPath splits a value path into its segments at unescaped dots. Segments keep their escapes
+// and array markers.
+func SplitPath(path string) []string {
+	if !strings.Contains(path, `\`) {
+		return strings.Split(path, ".")
+	}
+
+	var segments []string
+	start := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '.':
+			segments = append(segments, path[start:i])
+			start = i + 1
+		}
+	}
+	return append(segments, path[start:])
+}
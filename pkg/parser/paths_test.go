@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathKeys(t *testing.T) {
+	tests := []struct {
+		key     string
+		escaped string
+	}{
+		{key: "replicas", escaped: "replicas"},
+		{key: "pod-annotations", escaped: "pod-annotations"},
+		{key: "nginx.conf", escaped: `nginx\.conf`},
+		{key: "app.kubernetes.io/name", escaped: `app\.kubernetes\.io/name`},
+		{key: "*", escaped: `\*`},
+		{key: `C:\temp`, escaped: `C:\\temp`},
+	}
+
+	for _, tt := range tests {
+		if escaped := EscapeKey(tt.key); escaped != tt.escaped {
+			t.Errorf("EscapeKey(%q) = %q, expected %q", tt.key, escaped, tt.escaped)
+		}
+		if key := UnescapeKey(tt.escaped); key != tt.key {
+			t.Errorf("UnescapeKey(%q) = %q, expected %q", tt.escaped, key, tt.key)
+		}
+	}
+
+	path := joinPath(joinPath("config", EscapeKey("nginx.conf")), "servers[]")
+	expected := []string{"config", `nginx\.conf`, "servers[]"}
+	if segments := SplitPath(path); !reflect.DeepEqual(segments, expected) {
+		t.Errorf("SplitPath(%q) = %v, expected %v", path, segments, expected)
+	}
+	if key := UnescapeKey("servers[]"); key != "servers" {
+		t.Errorf("UnescapeKey should drop array markers, got %q", key)
+	}
+}
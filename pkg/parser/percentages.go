@@ -0,0 +1,25 @@
+package parser
+
+import "strings"
+
+// intOrPercentNames are the keys of the Kubernetes fields taking an integer or a percentage of
+// replicas, such as the maxSurge of a rolling update or the minAvailable of a disruption budget
+var intOrPercentNames = map[string]bool{
+	"maxSurge":       true,
+	"maxUnavailable": true,
+	"minAvailable":   true,
+}
+
+func init() {
+	hintRulesets["int-or-percent-names"] = intOrPercentNames
+}
+
+// IsIntOrPercent reports whether a value is an integer or a percentage string, as the Kubernetes
+// fields it is rendered as or named after take
+func (v *ValuePath) IsIntOrPercent() bool {
+	if v.IntOrPercent {
+		return true
+	}
+	segments := SplitPath(v.Path)
+	return intOrPercentNames[UnescapeKey(strings.TrimSuffix(segments[len(segments)-1], "[]"))]
+}
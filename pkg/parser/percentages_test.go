@@ -0,0 +1,52 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIntOrPercent(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "deployment.yaml")
+	template := `spec:
+  strategy:
+    rollingUpdate:
+      maxSurge: {{ .Values.rollout.surge }}
+      maxUnavailable: {{ .Values.rollout.unavailable | default "25%" }}
+  {{- if .Values.rollout.surge }}
+  replicas: {{ .Values.replicaCount }}
+  {{- end }}
+---
+spec:
+  minAvailable: {{ .Values.pdb.minAvailable }}
+  maxUnavailable:
+    {{- toYaml .Values.pdb.budget | nindent 4 }}
+  {{- with .Values.pdb.maxUnavailable }}
+  disruptions: {{ . }}
+  {{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	// Rendered as such a field or named after one
+	intOrPercent := map[string]bool{
+		"rollout.surge":       true,
+		"rollout.unavailable": true,
+		"pdb.minAvailable":    true,
+		"pdb.maxUnavailable":  true,
+	}
+	for path, valuePath := range parser.GetValues() {
+		if valuePath.IsIntOrPercent() != intOrPercent[path] {
+			t.Errorf("%s: expected integer or percentage %v, got %v", path, intOrPercent[path], valuePath.IsIntOrPercent())
+		}
+	}
+	if parser.GetValues()["pdb.maxUnavailable"].IntOrPercent {
+		t.Errorf("Expected pdb.maxUnavailable only named after such a field, not rendered as one")
+	}
+}
@@ -0,0 +1,27 @@
+package parser
+
+import "strings"
+
+// quantityNames are the keys of the Kubernetes fields taking a resource quantity, such as the cpu
+// and memory of resource requests and limits or the size of a persistent volume claim
+var quantityNames = map[string]bool{
+	"cpu":               true,
+	"memory":            true,
+	"storage":           true,
+	"ephemeral-storage": true,
+	"size":              true,
+}
+
+func init() {
+	hintRulesets["quantity-names"] = quantityNames
+}
+
+// IsQuantity reports whether a value is a Kubernetes resource quantity, as the fields it is
+// rendered as or named after take
+func (v *ValuePath) IsQuantity() bool {
+	if v.Quantity {
+		return true
+	}
+	segments := SplitPath(v.Path)
+	return quantityNames[UnescapeKey(strings.TrimSuffix(segments[len(segments)-1], "[]"))]
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQuantities(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "deployment.yaml")
+	template := `resources:
+  requests:
+    cpu: {{ .Values.resources.requests.cpu }}
+    memory: {{ .Values.requestMemory | quote }}
+  limits:
+    {{- toYaml .Values.limits | nindent 4 }}
+---
+spec:
+  resources:
+    requests:
+      storage: {{ .Values.persistence.capacity }}
+  storageClassName: {{ .Values.persistence.storageClass }}
+  {{- if .Values.persistence.size }}
+  replicas: {{ .Values.replicaCount }}
+  {{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	// Rendered as a resource quantity or named after one
+	quantities := map[string]bool{
+		"resources.requests.cpu": true,
+		"requestMemory":          true,
+		"persistence.capacity":   true,
+		"persistence.size":       true,
+	}
+	for path, valuePath := range parser.GetValues() {
+		if valuePath.IsQuantity() != quantities[path] {
+			t.Errorf("%s: expected quantity %v, got %v", path, quantities[path], valuePath.IsQuantity())
+		}
+	}
+	if parser.GetValues()["persistence.size"].Quantity {
+		t.Errorf("Expected persistence.size only named after a quantity, not rendered as one")
+	}
+}
@@ -0,0 +1,25 @@
+package parser
+
+import "strings"
+
+// AddRenderedValue records a value the rendered chart was found to consume though no template
+// reference to it was resolved, typed from its default in values.yaml. Paths below a subchart's
+// values key are recorded on the subchart.
+func (tp *TemplateParser) AddRenderedValue(path string, value any) {
+	segments := SplitPath(path)
+	if subchart, exists := tp.subcharts[UnescapeKey(segments[0])]; exists && len(segments) > 1 {
+		subchart.AddRenderedValue(strings.Join(segments[1:], "."), value)
+		return
+	}
+
+	valueType := valueTypeOf(value)
+	if valueType == "" {
+		tp.valuePath(path)
+		tp.addIntermediatePaths(path, Site{})
+		return
+	}
+	valuePath := tp.addTypeHint(path, TypeHint{Type: valueType, Reason: "consumed when rendered", Confidence: ConfidenceDefault})
+	if valuePath.Default == nil {
+		valuePath.Default = value
+	}
+}
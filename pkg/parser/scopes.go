@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// comparisonOperand is a variable, an integer or a parenthesized pipeline, one level of
+// parentheses deep, as compared with eq and ne
+const comparisonOperand = `\$` + identifier + `|-?\d+|\((?:[^()]|\([^()]*\))*\)`
+
+var (
+	// Match: a variable itself as an operand, e.g. {{ $item | quote }}
+	bareVariableRe = regexp.MustCompile(`\$(` + identifier + `)(?:[\s)}|]|$)`)
+	// Match: $last := sub (len .Values.hosts) 1, capturing the variable and its pipeline
+	assignmentRe = regexp.MustCompile(`\$(` + identifier + `)\s*:?=([^}]*)`)
+	// Match: eq $i 0 / ne $i $last / eq $i (sub (len $hosts) 1), capturing both operands
+	indexComparisonRe = regexp.MustCompile(`\b(?:eq|ne)\s+(` + comparisonOperand + `)\s+(` + comparisonOperand + `)(?:[\s)}]|$)`)
+	// Match: len as a function
+	lenRe = regexp.MustCompile(`\blen\b`)
+	// Match: an integer literal
+	integerRe = regexp.MustCompile(`^-?\d+$`)
+)
+
+// rangeScope binds the variable a range declares to the items of the value it iterates over,
+// within the body of the range
+type rangeScope struct {
+	name  string
+	path  string
+	start int // Offset just past the range action
+	end   int // Offset just past the matching end action
+}
+
+// bindRangeVariables binds the value variables of {{ range $k, $v := .Values.path }} to the
+// values of the map, as path.*, or to the items of the list, as path[], when the body uses $k
+// as a list index, and the item variables of {{ range $item := .Values.path }} to
+// the items of the list, as path[]. Ranges are visited in document order, so nested ranges
+// resolve through the variables bound by enclosing ones, and a variable name reused by
+// consecutive ranges is bound separately within each body.
+func (tp *TemplateParser) bindRangeVariables(index *templateIndex) {
+	masked, actions := index.masked, index.actions
+	tp.scopes = nil
+	for i, span := range actions {
+		action := masked[span[0]:span[1]]
+
+		var name, path string
+		if match := tp.rangeRe.FindStringSubmatchIndex(action); match != nil {
+			operandPath, ok := tp.resolveOperandAt(action[match[1]:matchingArgEnd(action, match[1])], span[0])
+			if !ok || operandPath == "" {
+				continue
+			}
+			name, path = action[match[4]:match[5]], joinPath(tp.normalizePath(operandPath), AnyKey)
+			if indexedRange(index, i, action[match[2]:match[3]]) {
+				path = tp.normalizePath(operandPath) + "[]"
+			}
+		} else if match := tp.listRangeRe.FindStringSubmatchIndex(action); match != nil && match[2] >= 0 {
+			operandPath, ok := tp.resolveOperandAt(action[match[1]:matchingArgEnd(action, match[1])], span[0])
+			if !ok || operandPath == "" {
+				continue
+			}
+			name, path = action[match[2]:match[3]], tp.normalizePath(operandPath)+"[]"
+		} else {
+			continue
+		}
+
+		end := len(masked)
+		if closing := matchingEnd(masked, actions, i); closing >= 0 {
+			end = actions[closing][1]
+		}
+		tp.scopes = append(tp.scopes, rangeScope{name: name, path: path, start: span[1], end: end})
+		// Passes without an offset at hand see the latest binding
+		tp.variables[name] = path
+	}
+}
+
+// indexFunctions do arithmetic on or order their operands, which makes a range key they are
+// applied to a list index
+var indexFunctions = map[string]bool{
+	"add":  true,
+	"add1": true,
+	"sub":  true,
+	"mul":  true,
+	"div":  true,
+	"mod":  true,
+	"max":  true,
+	"min":  true,
+	"gt":   true,
+	"ge":   true,
+	"lt":   true,
+	"le":   true,
+}
+
+// indexedRange reports whether the body of the range action at index start uses the key
+// variable as a list index rather than a map key: it does arithmetic on it, orders it or
+// compares it with a number or a length, as in {{ range $i, $host := .Values.hosts }}{{ if gt $i 0 }},{{ end }}
+// or {{ if ne $i $last }} with {{ $last := sub (len .Values.hosts) 1 }}
+func indexedRange(index *templateIndex, start int, key string) bool {
+	masked, actions := index.masked, index.actions
+	end := matchingEnd(masked, actions, start)
+	if end < 0 {
+		return false
+	}
+
+	lengths := lengthVariables(index)
+	numeric := func(operand string) bool {
+		if strings.HasPrefix(operand, "$") {
+			return lengths[operand[1:]]
+		}
+		return integerRe.MatchString(operand) || lenRe.MatchString(operand)
+	}
+	for _, span := range actions[start+1 : end] {
+		action := masked[span[0]:span[1]]
+		for _, match := range indexComparisonRe.FindAllStringSubmatch(action, -1) {
+			if match[1] == "$"+key && numeric(match[2]) || match[2] == "$"+key && numeric(match[1]) {
+				return true
+			}
+		}
+		for _, match := range bareVariableRe.FindAllStringSubmatchIndex(action, -1) {
+			if action[match[2]:match[3]] != key {
+				continue
+			}
+			for _, function := range index.functionsAt(span[0] + match[0]) {
+				if indexFunctions[function] {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// lengthVariables returns the variables of a template assigned a pipeline involving len, such
+// as the last index $last := sub (len .Values.hosts) 1, which hold numbers
+func lengthVariables(index *templateIndex) map[string]bool {
+	lengths := make(map[string]bool)
+	for _, span := range index.actions {
+		for _, match := range assignmentRe.FindAllStringSubmatch(index.masked[span[0]:span[1]], -1) {
+			if lenRe.MatchString(match[2]) {
+				lengths[match[1]] = true
+			}
+		}
+	}
+	return lengths
+}
+
+// lookupVariable returns the path a variable is bound to at offset: the innermost range
+// declaring it around offset, or else its template-wide binding
+func (tp *TemplateParser) lookupVariable(name string, offset int) (string, bool) {
+	if path, exists := tp.rangeBinding(name, offset); exists {
+		return path, true
+	}
+	path, exists := tp.variables[name]
+	return path, exists
+}
+
+// rangeBinding returns the path the innermost range declaring a variable around offset binds
+// it to
+func (tp *TemplateParser) rangeBinding(name string, offset int) (string, bool) {
+	var innermost *rangeScope
+	for i := range tp.scopes {
+		scope := &tp.scopes[i]
+		if scope.name != name || offset < scope.start || offset >= scope.end {
+			continue
+		}
+		if innermost == nil || scope.start > innermost.start {
+			innermost = scope
+		}
+	}
+	if innermost == nil {
+		return "", false
+	}
+	return innermost.path, true
+}
+
+// parseRangeVariableUses records the values range variables stand for where they are used as a
+// whole, as the items of a list in {{ range $item := .Values.tags }}{{ $item | quote }}
+func (tp *TemplateParser) parseRangeVariableUses(index *templateIndex) {
+	masked := index.masked
+	for _, match := range bareVariableRe.FindAllStringSubmatchIndex(masked, -1) {
+		if !isWordStart(masked, match[0]) {
+			continue
+		}
+		// Declarations bind the variable rather than use it
+		next := masked[skipSpaces(masked, match[3]):]
+		if strings.HasPrefix(next, ":=") || strings.HasPrefix(next, "=") || strings.HasPrefix(next, ",") {
+			continue
+		}
+		if path, exists := tp.rangeBinding(masked[match[2]:match[3]], match[0]); exists {
+			tp.addValuePathWithHints(path, index, match[0], tp.siteAt(index, match[0]))
+		}
+	}
+}
+
+// resolveOperandAt resolves an operand found at offset, looking variables up in the ranges
+// enclosing it
+func (tp *TemplateParser) resolveOperandAt(operand string, offset int) (string, bool) {
+	if match := variableOperandRe.FindStringSubmatch(strings.TrimSpace(operand)); match != nil {
+		basePath, exists := tp.lookupVariable(match[1], offset)
+		if !exists {
+			return "", false
+		}
+		return joinPath(basePath, match[2]), true
+	}
+	return tp.resolveOperand(operand)
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+)
+
+// setFunctions lists Sprig functions removing the duplicate elements of a list, whose lists the
+// chart treats as sets
+var setFunctions = map[string]bool{
+	"uniq":     true,
+	"mustUniq": true,
+}
+
+func init() {
+	hintRulesets["set-functions"] = setFunctions
+}
+
+var (
+	// Match: the values key of a label selector requirement, as in - values: or values:
+	selectorValuesRe = regexp.MustCompile(`^\s*(?:-\s+)?values:\s*$`)
+	// Match: the operator key of a label selector requirement taking a set of values
+	selectorOperatorRe = regexp.MustCompile(`^\s*(?:-\s+)?operator:\s*"?(?:In|NotIn)\b`)
+)
+
+// selectorLines is how many lines before its values key the operator of a label selector
+// requirement is looked for: the key, the operator and the values of a requirement are
+// usually written together
+const selectorLines = 3
+
+// hasSetFunction reports whether a list is passed through a function treating it as a set
+func hasSetFunction(functions []string) bool {
+	return slices.ContainsFunc(functions, func(function string) bool { return setFunctions[function] })
+}
+
+// inSelectorValues reports whether the action at offset renders the values of a label selector
+// requirement, a set of values, on the line of their key or on the lines below it, as a list or
+// ranging over one
+//
+//   - key: topology.kubernetes.io/zone
+//     operator: In
+//     values: {{ toYaml .Values.zones | nindent 4 }}
+func (ti *templateIndex) inSelectorValues(offset int) bool {
+	i := ti.actionAt(offset)
+	if i < 0 {
+		return false
+	}
+	start := ti.actions[i][0]
+	if match := blockKeywordRe.FindStringSubmatch(ti.masked[start:ti.actions[i][1]]); match != nil && match[1] != "range" {
+		return false
+	}
+	line, key := ti.keyLine(i)
+	if line < 0 || !selectorValuesRe.MatchString(key) {
+		return false
+	}
+	for operator := line - 1; operator >= 0 && operator >= line-selectorLines; operator-- {
+		if selectorOperatorRe.MatchString(ti.literalLine(operator)) {
+			return true
+		}
+	}
+	return false
+}
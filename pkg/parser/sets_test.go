@@ -0,0 +1,59 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestUniqueLists(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "deployment.yaml")
+	template := `args: {{ .Values.args | uniq | toJson }}
+hosts: {{ .Values.hosts | sortAlpha | uniq | join "," }}
+first: {{ .Values.extra | first }}
+affinity:
+  nodeAffinity:
+    requiredDuringSchedulingIgnoredDuringExecution:
+      nodeSelectorTerms:
+        - matchExpressions:
+            - key: topology.kubernetes.io/zone
+              operator: In
+              values: {{ toYaml .Values.zones | nindent 16 }}
+            - key: kubernetes.io/arch
+              operator: NotIn
+              values:
+              {{- range .Values.excludedArchs }}
+                - {{ . }}
+              {{- end }}
+            - key: tier
+              operator: In
+              values:
+                - {{ .Values.tier }}
+env:
+  - name: VALUES
+    values: {{ toYaml .Values.env | nindent 6 }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	unique := map[string]bool{
+		"args":          true,
+		"hosts":         true,
+		"zones":         true,
+		"excludedArchs": true,
+	}
+	for path, valuePath := range parser.GetValues() {
+		if valuePath.Unique != unique[path] {
+			t.Errorf("%s: expected unique %v, got %v", path, unique[path], valuePath.Unique)
+		}
+	}
+	if zones := parser.GetValues()["zones"]; zones.Type != "array" {
+		t.Errorf("Expected label selector values typed as a list, got %s", zones.Type)
+	}
+}
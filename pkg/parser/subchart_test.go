@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -121,3 +123,342 @@ func TestGetAllValues(t *testing.T) {
 
 	t.Logf("Successfully parsed %d main values and %d subchart values", mainCount, subchartCount)
 }
+
+func TestImportValues(t *testing.T) {
+	parser := New()
+	if err := parser.ParseChart("../../test-charts/imports"); err != nil {
+		t.Fatalf("Failed to parse chart importing values: %v", err)
+	}
+
+	values := parser.GetValues()
+	expected := map[string]string{
+		// Exports copied to the root, typed from the dependency's values.yaml
+		"scrapeInterval": "string",
+		"retention":      "object",
+		"retention.days": "integer",
+		// Child/parent mapping, typed from the dependency's templates
+		"metricsService":      "object",
+		"metricsService.type": "string",
+		"metricsService.port": "integer",
+	}
+	for path, valueType := range expected {
+		valuePath, exists := values[path]
+		if !exists {
+			t.Errorf("Expected imported value %s not found", path)
+			continue
+		}
+		if valuePath.Type != valueType {
+			t.Errorf("Imported value %s has type %s, expected %s", path, valuePath.Type, valueType)
+		}
+	}
+
+	if _, exists := parser.GetAllValues()["metrics.service.port"]; !exists {
+		t.Error("Imported values should remain available under the dependency key")
+	}
+}
+
+func TestAddRenderedValue(t *testing.T) {
+	parser := New()
+	if err := parser.ParseChart("../../test-charts/with-subcharts"); err != nil {
+		t.Fatalf("Failed to parse chart with subcharts: %v", err)
+	}
+
+	parser.AddRenderedValue("extra.port", 8080)
+	parser.AddRenderedValue("redis.extra.enabled", true)
+
+	port, exists := parser.GetValues()["extra.port"]
+	if !exists {
+		t.Fatal("Expected extra.port to be added to the main chart")
+	}
+	if port.Type != "integer" || port.Default != 8080 {
+		t.Errorf("Expected extra.port to be an integer defaulting to 8080, got %s %v", port.Type, port.Default)
+	}
+	if extra := parser.GetValues()["extra"]; extra == nil || extra.Type != "object" {
+		t.Errorf("Expected extra to be recorded as an object, got %+v", extra)
+	}
+
+	enabled, exists := parser.GetSubcharts()["redis"].GetValues()["extra.enabled"]
+	if !exists {
+		t.Fatal("Expected redis.extra.enabled to be added to the redis subchart")
+	}
+	if enabled.Type != "boolean" {
+		t.Errorf("Expected extra.enabled to be a boolean, got %s", enabled.Type)
+	}
+}
+
+func TestValuesDefaults(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: app\nversion: 0.1.0\ndependencies:\n- name: cache\n  version: 0.1.0\n",
+		"values.yaml": `image:
+  repository: nginx
+  tag: ""
+replicas: 2
+ports: [80, 443]
+nginx.conf: "worker_processes 1;"
+resources: {}
+persistence: null
+cache:
+  port: 6380
+`,
+		"templates/deployment.yaml": `image: {{ .Values.image.repository }}:{{ .Values.image.tag | default "latest" }}
+replicas: {{ .Values.replicas }}
+ports: {{ toJson .Values.ports }}
+timeout: {{ .Values.timeout | default 30 }}
+config: {{ index .Values "nginx.conf" }}
+resources: {{ toYaml .Values.resources }}
+{{- if .Values.persistence }}
+size: {{ .Values.persistence.size }}
+{{- end }}
+`,
+		"charts/cache/Chart.yaml":             "apiVersion: v2\nname: cache\nversion: 0.1.0\n",
+		"charts/cache/values.yaml":            "port: 6379\nname: cache\n",
+		"charts/cache/templates/service.yaml": "port: {{ .Values.port }}\nname: {{ .Values.name }}\n",
+	}
+	writeChartFiles(t, chartPath, files)
+
+	parser := New()
+	if err := parser.ParseChart(chartPath); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+
+	expected := map[string]any{
+		"image.repository": "nginx",
+		// values.yaml takes precedence over the template fallback
+		"image.tag":   "",
+		"replicas":    2,
+		"ports":       []any{80, 443},
+		"timeout":     30,
+		`nginx\.conf`: "worker_processes 1;",
+		"resources":   map[string]any{},
+		// Objects with fields have their defaults on the fields
+		"image": nil,
+	}
+	values := parser.GetValues()
+	for path, want := range expected {
+		valuePath, exists := values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if !reflect.DeepEqual(valuePath.Default, want) {
+			t.Errorf("Path %s defaults to %#v, expected %#v", path, valuePath.Default, want)
+		}
+	}
+
+	// Sections set to null accept null
+	for path, nullable := range map[string]bool{"persistence": true, "persistence.size": false, "resources": false, "timeout": false} {
+		if values[path].Nullable != nullable {
+			t.Errorf("Expected %s nullable to be %v", path, nullable)
+		}
+	}
+
+	// The parent chart overrides the defaults of its dependency
+	cache := parser.GetSubcharts()["cache"].GetValues()
+	if port := cache["port"].Default; port != 6380 {
+		t.Errorf("Expected cache port to default to the parent's 6380, got %#v", port)
+	}
+	if name := cache["name"].Default; name != "cache" {
+		t.Errorf("Expected cache name to default to its own cache, got %#v", name)
+	}
+}
+
+func TestValuesExamples(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml":                          "apiVersion: v2\nname: app\nversion: 0.1.0\ndependencies:\n- name: cache\n  version: 0.1.0\n",
+		"values.yaml":                         "replicas: 1\nhost: \"\"\n",
+		"values-production.yaml":              "replicas: 3\nhost: app.example.com\ncache:\n  port: 6380\n",
+		"values-staging.yml":                  "replicas: 1\n",
+		"templates/deployment.yaml":           "replicas: {{ .Values.replicas }}\nhost: {{ .Values.host }}\n",
+		"charts/cache/Chart.yaml":             "apiVersion: v2\nname: cache\nversion: 0.1.0\n",
+		"charts/cache/values.yaml":            "port: 6379\n",
+		"charts/cache/templates/service.yaml": "port: {{ .Values.port }}\n",
+	}
+	writeChartFiles(t, chartPath, files)
+
+	// values.yaml only by default
+	parser := New()
+	if err := parser.ParseChart(chartPath); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	if examples := parser.GetValues()["replicas"].Examples; !reflect.DeepEqual(examples, []any{1}) {
+		t.Errorf("Expected replicas examples from values.yaml, got %#v", examples)
+	}
+
+	parser = NewWithOptions(Options{ValuesVariants: true})
+	if err := parser.ParseChart(chartPath); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	expected := map[string][]any{
+		"replicas": {1, 3},
+		"host":     {"", "app.example.com"},
+	}
+	values := parser.GetValues()
+	for path, want := range expected {
+		if !reflect.DeepEqual(values[path].Examples, want) {
+			t.Errorf("Path %s has examples %#v, expected %#v", path, values[path].Examples, want)
+		}
+	}
+	// Variants of the parent chart set values of its dependency too
+	if examples := parser.GetSubcharts()["cache"].GetValues()["port"].Examples; !reflect.DeepEqual(examples, []any{6379, 6380}) {
+		t.Errorf("Expected cache port examples from both charts, got %#v", examples)
+	}
+}
+
+func TestShippedSubchartSchemas(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml":                "apiVersion: v2\nname: app\nversion: 0.1.0\ndependencies:\n- name: redis\n  version: 0.1.0\n- name: worker\n  version: 0.1.0\n- name: metrics\n  version: 0.1.0\n  import-values: [defaults]\n",
+		"templates/deployment.yaml": "replicas: {{ .Values.replicas }}\n",
+		// Shipped by the dependency
+		"charts/redis/Chart.yaml":             "apiVersion: v2\nname: redis\nversion: 0.1.0\n",
+		"charts/redis/values.schema.json":     `{"type": "object", "properties": {"architecture": {"enum": ["standalone", "replication"]}}}`,
+		"charts/redis/templates/service.yaml": "port: {{ .Values.port }}\n",
+		// Generated by an earlier run
+		"charts/worker/Chart.yaml":              "apiVersion: v2\nname: worker\nversion: 0.1.0\n",
+		"charts/worker/values.schema.json":      `{"type": "object", "x-generation": {}}`,
+		"charts/worker/templates/workers.yaml":  "count: {{ .Values.count }}\n",
+		"charts/metrics/Chart.yaml":             "apiVersion: v2\nname: metrics\nversion: 0.1.0\n",
+		"charts/metrics/values.schema.json":     `{"type": "object"}`,
+		"charts/metrics/values.yaml":            "exports:\n  defaults:\n    interval: 30s\n",
+		"charts/metrics/templates/monitor.yaml": "interval: {{ .Values.interval }}\n",
+	}
+	writeChartFiles(t, chartPath, files)
+
+	parser := New()
+	if err := parser.ParseChart(chartPath); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+
+	shipped := parser.ShippedSchemas()
+	if len(shipped) != 2 || shipped["redis"] == nil || shipped["metrics"] == nil {
+		t.Errorf("Expected the schemas of redis and metrics to be reused, got %v", shipped)
+	}
+	subcharts := parser.GetSubcharts()
+	if _, parsed := subcharts["redis"]; parsed {
+		t.Error("Expected redis, which ships a schema, not to be parsed")
+	}
+	if _, parsed := subcharts["worker"]; !parsed {
+		t.Error("Expected worker, whose schema was generated, to be parsed")
+	}
+	// Values the dependency exports are still looked for in its templates
+	if _, parsed := subcharts["metrics"]; !parsed {
+		t.Error("Expected metrics, which exports values, to be parsed")
+	}
+
+	parser = NewWithOptions(Options{DeriveSubcharts: true})
+	if err := parser.ParseChart(chartPath); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	if len(parser.ShippedSchemas()) != 0 || len(parser.GetSubcharts()) != 3 {
+		t.Errorf("Expected every subchart to be parsed, got %d", len(parser.GetSubcharts()))
+	}
+}
+
+func TestValuesFiles(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml":                          "apiVersion: v2\nname: app\nversion: 0.1.0\ndependencies:\n- name: cache\n  version: 0.1.0\n",
+		"values.yaml":                         "replicas: 1\nhost: \"\"\nimage:\n  repository: app\n  tag: latest\ndebug: false\n",
+		"overlays/production.yaml":            "replicas: 3\nimage:\n  tag: \"1.2\"\ntimeout: 30s\ncache:\n  port: 6380\n",
+		"overlays/secure.yaml":                "host: app.example.com\ndebug: null\n",
+		"templates/deployment.yaml":           "replicas: {{ .Values.replicas }}\nhost: {{ .Values.host }}\nimage: {{ .Values.image.repository }}:{{ .Values.image.tag }}\ndebug: {{ .Values.debug }}\ntimeout: {{ .Values.timeout }}\n",
+		"charts/cache/Chart.yaml":             "apiVersion: v2\nname: cache\nversion: 0.1.0\n",
+		"charts/cache/values.yaml":            "port: 6379\n",
+		"charts/cache/templates/service.yaml": "port: {{ .Values.port }}\n",
+	}
+	writeChartFiles(t, chartPath, files)
+
+	parser := NewWithOptions(Options{ValuesFiles: []string{
+		filepath.Join(chartPath, "overlays", "production.yaml"),
+		filepath.Join(chartPath, "overlays", "secure.yaml"),
+	}})
+	if err := parser.ParseChart(chartPath); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	expected := map[string]any{
+		"replicas":         3,
+		"host":             "app.example.com",
+		"image.repository": "app",
+		"image.tag":        "1.2",
+		// A null overlay value unsets the default, as with helm
+		"debug": nil,
+	}
+	values := parser.GetValues()
+	for path, want := range expected {
+		if !reflect.DeepEqual(values[path].Default, want) {
+			t.Errorf("Path %s has default %#v, expected %#v", path, values[path].Default, want)
+		}
+	}
+	// Types follow the merged values, overlays adding values values.yaml does not set
+	types := map[string]string{
+		"replicas":  "integer",
+		"host":      "string",
+		"image.tag": "string",
+		"timeout":   "string",
+		"debug":     "unknown",
+	}
+	for path, want := range types {
+		if values[path].Type != want {
+			t.Errorf("Path %s has type %s, expected %s", path, values[path].Type, want)
+		}
+	}
+	if port := parser.GetSubcharts()["cache"].GetValues()["port"].Default; port != 6380 {
+		t.Errorf("Expected the overlay to set the cache port default, got %#v", port)
+	}
+
+	parser = NewWithOptions(Options{ValuesFiles: []string{filepath.Join(chartPath, "missing.yaml")}})
+	if err := parser.ParseChart(chartPath); err == nil {
+		t.Error("Expected a missing values file to fail parsing")
+	}
+}
+
+func TestSkipSubcharts(t *testing.T) {
+	chartPath := t.TempDir()
+	files := map[string]string{
+		"Chart.yaml": "apiVersion: v2\nname: app\nversion: 0.1.0\ndependencies:\n" +
+			"- name: cache\n  version: 0.1.0\n" +
+			"- name: worker\n  version: 0.1.0\n  alias: jobs\n" +
+			"- name: kube-prometheus-stack\n  version: 58.0.0\n  repository: https://prometheus-community.github.io/helm-charts\n",
+		"templates/deployment.yaml":                    "replicas: {{ .Values.replicas }}\n",
+		"charts/cache/Chart.yaml":                      "apiVersion: v2\nname: cache\nversion: 0.1.0\ndependencies:\n- name: metrics\n  version: 0.1.0\n",
+		"charts/cache/templates/service.yaml":          "port: {{ .Values.port }}\n",
+		"charts/cache/charts/metrics/Chart.yaml":       "apiVersion: v2\nname: metrics\nversion: 0.1.0\n",
+		"charts/cache/charts/metrics/templates/m.yaml": "interval: {{ .Values.interval }}\n",
+		"charts/worker/Chart.yaml":                     "apiVersion: v2\nname: worker\nversion: 0.1.0\n",
+		"charts/worker/templates/job.yaml":             "count: {{ .Values.count }}\n",
+	}
+	writeChartFiles(t, chartPath, files)
+
+	// The remote dependency is skipped, so it is not built
+	parser := NewWithOptions(Options{SkipSubcharts: []string{"kube-prometheus-stack", "jobs", "metrics"}})
+	if err := parser.ParseChart(chartPath); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	subcharts := parser.GetSubcharts()
+	if _, parsed := subcharts["cache"]; !parsed || len(subcharts) != 1 {
+		t.Errorf("Expected only cache to be parsed, got %d subcharts", len(subcharts))
+	}
+	if nested := subcharts["cache"].GetSubcharts(); len(nested) != 0 {
+		t.Errorf("Expected the nested metrics subchart to be skipped, got %d subcharts", len(nested))
+	}
+	for _, name := range []string{"worker", "kube-prometheus-stack"} {
+		if !reflect.DeepEqual(parser.ShippedSchemas()[name], map[string]any{"type": "object"}) {
+			t.Errorf("Expected skipped subchart %s to accept any values, got %v", name, parser.ShippedSchemas()[name])
+		}
+	}
+
+	parser = NewWithOptions(Options{OnlySubcharts: []string{"cache"}})
+	if err := parser.ParseChart(chartPath); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+	subcharts = parser.GetSubcharts()
+	if _, parsed := subcharts["cache"]; !parsed || len(subcharts) != 1 {
+		t.Errorf("Expected only cache to be parsed, got %d subcharts", len(subcharts))
+	}
+	// The subcharts of those selected are kept
+	if nested := subcharts["cache"].GetSubcharts(); len(nested) != 1 {
+		t.Errorf("Expected the nested metrics subchart to be parsed, got %d subcharts", len(nested))
+	}
+}
@@ -1,31 +1,170 @@
 package parser
 
 import (
+	"errors"
 	"fmt"
-	"helm-schema/pkg/helm"
+	"io/fs"
+	"log/slog"
 	"maps"
 	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+
+	"helm-schema/pkg/cache"
+	"helm-schema/pkg/helm"
 )
 
 // ValuePath represents an intermediate representation of a discovered value path
 type ValuePath struct {
-	Path     string
-	Type     string
-	Required bool
-	Default  any
+	Path         string
+	Type         string
+	Required     bool
+	Nullable     bool // Accepts null, from a null values.yaml default or nullable=true
+	Default      any
+	Sensitive    bool     // Piped through functions that handle secret material (b64enc, htpasswd, ...)
+	Functions    []string // Template functions the value is passed through, sorted and unique
+	Types        []string // Conflicting types when hints disagree, in which case Type is "union"
+	Hints        []TypeHint
+	Confidence   float64      // Confidence in Type, from the strongest evidence for it
+	Sources      []Site       // Every place the value is referenced, in parse order
+	Encoding     string       // Format of the document a string value is decoded from with fromYaml/fromJson
+	Decoded      []string     // Fields read from the decoded document, sorted and unique
+	Description  string       // From the helm-docs # -- comment of its values.yaml key
+	Deprecated   bool         // Marked # @deprecated in values.yaml
+	Deprecation  string       // Message of the # @deprecated marker, such as "use image.repository"
+	Guards       []string     // Values every reference is only rendered with when truthy, as in {{ if .Values.ingress.enabled }}
+	Optional     bool         // Tested in a condition or given a fallback, so templates handle it being unset
+	NonEmpty     bool         // Rendering fails when the value is empty, as when passed to required
+	Unique       bool         // List the chart treats as a set, passed through uniq or rendered as label selector values
+	KeyPattern   string       // Naming rule of the keys of a map rendered as environment variable names or ConfigMap keys
+	IntOrPercent bool         // Rendered as a Kubernetes field taking an integer or a percentage, such as maxSurge
+	Quantity     bool         // Rendered as a Kubernetes resource quantity, such as a cpu request or a volume size
+	Exclusive    [][]string   // Values testing the branches of each if/else if chain the value selects a branch of
+	Examples     []any        // Values set in the chart's values files, unique, in the order found
+	Comparisons  []Comparison // Literals the value is compared with by eq or ne, unique, in parse order
+	// Constraints are schema keywords set by directive comments, applied over inferred ones
+	Constraints map[string]any
+}
+
+// TypeHint is a piece of evidence about the type of a value and where it was found
+type TypeHint struct {
+	Type       string
+	Reason     string
+	Site       Site
+	Confidence float64
+	Guard      bool // From a kindIs/typeIs check, which tells a kind is accepted but not that it is the only one
+}
+
+// Site locates an expression within the parsed templates. Line and Column are 1-based;
+// columns count bytes.
+type Site struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// String formats the site as file:line:column
+func (s Site) String() string {
+	if s.File == "" {
+		return fmt.Sprintf("line %d:%d", s.Line, s.Column)
+	}
+	return fmt.Sprintf("%s:%d:%d", s.File, s.Line, s.Column)
+}
+
+// Warning reports a template construct that degrades the generated schema
+type Warning struct {
+	Path    string
+	Message string
+}
+
+// addHint records a type hint, keeping the most confident site seen for each type, and
+// re-resolves the type
+func (vp *ValuePath) addHint(hint TypeHint) {
+	for i, existing := range vp.Hints {
+		if existing.Type == hint.Type {
+			if hint.Confidence > existing.Confidence {
+				vp.Hints[i] = hint
+				vp.Type, vp.Types, vp.Confidence = resolveHints(vp.Hints)
+			}
+			return
+		}
+	}
+	vp.Hints = append(vp.Hints, hint)
+	vp.Type, vp.Types, vp.Confidence = resolveHints(vp.Hints)
+}
+
+// addSource records a reference to the value, ignoring repeated sites
+func (vp *ValuePath) addSource(site Site) {
+	for _, existing := range vp.Sources {
+		if existing == site {
+			return
+		}
+	}
+	vp.Sources = append(vp.Sources, site)
+}
+
+// withPath returns a copy of the value path relocated to a new path
+func (vp *ValuePath) withPath(path string) *ValuePath {
+	copied := *vp
+	copied.Path = path
+	// Guards and chains name values relative to the original location
+	copied.Guards = nil
+	copied.Exclusive = nil
+	return &copied
 }
 
 // TemplateParser handles parsing Helm templates to extract .Values references
 type TemplateParser struct {
-	values    map[string]*ValuePath
-	variables map[string]string          // Maps variable names to their .Values paths
-	subcharts map[string]*TemplateParser // Maps subchart name to its parser
-	re        *regexp.Regexp
-	varRe     *regexp.Regexp
-	varRefRe  *regexp.Regexp
+	values       map[string]*ValuePath
+	variables    map[string]string          // Maps variable names to their .Values paths
+	scopes       []rangeScope               // Range variables of the template being parsed
+	decoded      map[string]decodedBinding  // Maps variable names to the values they decode
+	subcharts    map[string]*TemplateParser // Maps subchart name to its parser
+	shipped      map[string]map[string]any  // Maps subchart name to the values.schema.json it ships
+	helpers      map[string]string          // Maps named templates to their bodies
+	helperDigest string                     // Identifies the helpers, keying cached template results
+	chartRoot    string                     // Chart directory sites are reported relative to
+	file         string                     // Template currently being parsed
+	index        *templateIndex             // Lexed content of the template being parsed
+	ignored      []lineRange                // Lines of the template being parsed excluded by directives
+	unresolved   []Unresolved               // Constructs touching values that could not be resolved
+	mutations    map[string]mutation        // Values written with set or unset, by path
+	opts         Options
+	re           *regexp.Regexp
+	varRe        *regexp.Regexp
+	varRefRe     *regexp.Regexp
+	lookupRe     *regexp.Regexp
+	keyArgRe     *regexp.Regexp
+	groupFieldRe *regexp.Regexp
+	listRangeRe  *regexp.Regexp
+	rangeRe      *regexp.Regexp
+}
+
+// AnyKey is the path segment standing for every key of a map iterated with range, as in
+// tenants.*.replicas
+const AnyKey = "*"
+
+var (
+	// Match: {{ range or {{ with, optionally declaring a variable (operand follows)
+	blockOperandRe = regexp.MustCompile(`^` + pipelineOpen + `(range|with)\s+(?:\$` + identifier + assign + `)?`)
+	// Match: a field of the dot as a whole operand, e.g. .paths or .backend.service
+	dotFieldRe = regexp.MustCompile(`^\.(` + identifier + `)((?:\.` + identifier + `)*)$`)
+	// Match: the dot itself as an operand, e.g. {{ . | quote }}, after the character before it
+	bareDotRe = regexp.MustCompile(`[\s({|]\.(?:[\s)}|]|$)`)
+)
+
+// builtinObjects are the top-level objects of the root context, never fields of a value
+var builtinObjects = map[string]bool{
+	"Values":       true,
+	"Release":      true,
+	"Chart":        true,
+	"Capabilities": true,
+	"Template":     true,
+	"Files":        true,
 }
 
 const (
@@ -56,23 +195,80 @@ const (
 	pipelineBoundary = `(?:\s*[|}\s]|\s*-?\}\})`
 )
 
+// identifierRe matches a single path segment that can be written as .Values.a.b
+var identifierRe = regexp.MustCompile(`^` + identifier + `$`)
+
 // capture wraps a pattern in capturing parentheses for regex groups
 func capture(pattern string) string {
 	return `(` + pattern + `)`
 }
 
+// Options controls which templates are parsed and how strictly
+type Options struct {
+	// Strict fails parsing on constructs touching values that cannot be resolved, such as
+	// unknown variables, computed lookup keys or opaque functions, instead of skipping them
+	Strict bool
+	// Templates selects the template files parsed in the chart and its subcharts
+	Templates helm.TemplateOptions
+	// KeepMutated keeps values the templates write with set or unset in the model, which are
+	// otherwise excluded as outputs of the chart
+	KeepMutated bool
+	// Concurrency bounds how many template files are parsed at once; 0 uses every CPU
+	Concurrency int
+	// Cache stores what each template file contributes, so unchanged files are not parsed again
+	// on the next run; nil parses every file
+	Cache *cache.Cache
+	// DeriveSubcharts parses the templates of subcharts shipping a values.schema.json, which is
+	// otherwise reused as their schema
+	DeriveSubcharts bool
+	// ValuesVariants also takes examples of the values from the values-*.yaml files next to
+	// values.yaml, such as values-production.yaml
+	ValuesVariants bool
+	// ValuesFiles are merged over values.yaml in order, as helm install -f merges them, so the
+	// defaults and the types inferred from them are the effective ones; only the chart parsed
+	// reads them, its subcharts receive their part through it
+	ValuesFiles []string
+	// SkipSubcharts names dependencies, by name or alias, left out of parsing at any depth, such
+	// as a vendored kube-prometheus-stack; their values key accepts any values
+	SkipSubcharts []string
+	// OnlySubcharts restricts parsing to these direct dependencies, by name or alias, when set;
+	// the subcharts of those selected are still parsed
+	OnlySubcharts []string
+}
+
 // New creates a new template parser instance
 func New() *TemplateParser {
+	return NewWithOptions(Options{})
+}
+
+// NewWithOptions creates a new template parser instance with configurable strictness
+func NewWithOptions(opts Options) *TemplateParser {
 	return &TemplateParser{
+		opts:      opts,
 		values:    make(map[string]*ValuePath),
 		variables: make(map[string]string),
+		decoded:   make(map[string]decodedBinding),
+		mutations: make(map[string]mutation),
 		subcharts: make(map[string]*TemplateParser),
+		shipped:   make(map[string]map[string]any),
+		helpers:   make(map[string]string),
 		// Match: .Values.path
 		re: regexp.MustCompile(`\.Values\.` + capture(valuePath) + valueBoundary),
 		// Match: {{ $var := .Values.path }}
 		varRe: regexp.MustCompile(pipelineOpen + `\$` + capture(identifier) + assign + `\.Values\.` + capture(valuePath) + pipelineBoundary),
 		// Match: $var.field
 		varRefRe: regexp.MustCompile(`\$` + capture(identifier) + `\.` + capture(valuePath) + valueBoundary),
+		// Match: hasKey .Values.path "key" / get $var "key" / index .Values "a" "b" 0
+		lookupRe: regexp.MustCompile(capture(`hasKey|get|index`) + `\s+(?:\$?\.Values|\$` + capture(identifier) + `)` +
+			capture(`(?:\.`+identifier+`)*`) + capture(`(?:\s+(?:"[^"]*"|\d+))+`)),
+		// Match: a literal lookup argument, "key" or 0
+		keyArgRe: regexp.MustCompile(`"([^"]*)"|(\d+)`),
+		// Match: field selection on a parenthesized group, e.g. (...).timeout
+		groupFieldRe: regexp.MustCompile(`\)\.` + capture(valuePath) + valueBoundary),
+		// Match: range $key, $value := (operand follows)
+		rangeRe: regexp.MustCompile(`\brange\s+\$` + capture(identifier) + `\s*,\s*\$` + capture(identifier) + assign),
+		// Match: range or range $item := (operand follows)
+		listRangeRe: regexp.MustCompile(`\brange\s+(?:\$` + capture(identifier) + assign + `)?`),
 	}
 }
 
@@ -83,14 +279,32 @@ func (tp *TemplateParser) ParseTemplateFile(filePath string) error {
 		return fmt.Errorf("failed to read template file %s: %w", filePath, err)
 	}
 
-	contentStr := string(content)
+	// Named templates defined alongside the manifests can be included by it
+	tp.collectDefines(stripYAMLComments(string(content)))
+
+	unresolved := tp.parseTemplateContent(filePath, string(content))
+	return tp.strictError(unresolved)
+}
 
+// parseTemplateContent runs the parse passes over the content of a template file, returning the
+// constructs touching values that no pass could resolve
+func (tp *TemplateParser) parseTemplateContent(filePath, contentStr string) []Unresolved {
 	// Skip empty files
 	if strings.TrimSpace(contentStr) == "" {
 		return nil
 	}
 
-	// First pass: Find variable assignments {{ $var := .Values.path }}
+	tp.file = tp.relativePath(filePath)
+
+	// Commented-out YAML lines are dead code and must not contribute paths
+	contentStr = stripYAMLComments(contentStr)
+
+	// Every pass looks the template up in a single index instead of lexing it again
+	tp.index = newTemplateIndex(contentStr)
+	defer func() { tp.index, tp.ignored = nil, nil }()
+
+	// First pass: Find variable assignments {{ $var := .Values.path }} and
+	// {{ range $k, $v := .Values.path }}
 	tp.parseVariableAssignments(contentStr)
 
 	// Second pass: Find direct .Values.* references
@@ -99,7 +313,57 @@ func (tp *TemplateParser) ParseTemplateFile(filePath string) error {
 	// Third pass: Find variable references {{ $var.field }} and resolve them
 	tp.parseVariableReferences(contentStr)
 
-	return nil
+	// Fourth pass: Find literal keys in hasKey/get/index lookups
+	tp.parseKeyLookups(contentStr)
+
+	// Fifth pass: Find fields selected on parenthesized groups {{ (.Values.path).field }}
+	tp.parseGroupFields(contentStr)
+
+	// Sixth pass: Follow {{ include "helper" (dict "cfg" .Values.path) }} into the helper
+	tp.parseIncludes(contentStr)
+
+	// Seventh pass: Find {{ range $k, $v := .Values.path }} iterating over maps and the fields
+	// the loop body reads from each value
+	tp.parseRangeHints(contentStr)
+
+	// Eighth pass: Type values from the literals they default to {{ .Values.path | default 80 }}
+	tp.parseDefaultLiterals(contentStr)
+
+	// Ninth pass: Find values written rather than read {{ $_ := set .Values "computed" ... }}
+	tp.parseMutations(contentStr)
+
+	// Tenth pass: Find kinds the value is checked for {{ if kindIs "string" .Values.path }}
+	tp.parseKindGuards(contentStr)
+
+	// Eleventh pass: Find values compared with literals {{ if eq .Values.mode "standalone" }}
+	tp.parseComparisons(contentStr)
+
+	// Twelfth pass: Find values the chart fails to render without
+	// {{ if empty .Values.path }}{{ fail "path must be set" }}{{ end }}
+	tp.parseEmptyFailures(contentStr)
+
+	// Thirteenth pass: Find {{ if .Values.a }} ... {{ else if .Values.b }} chains selecting one of
+	// several branches
+	tp.parseExclusiveBranches(contentStr)
+
+	// Fourteenth pass: Apply directive comments {{/* helm-schema: type=integer */}} to the values
+	// referenced next to them and drop the references {{/* helm-schema:ignore */}} excludes
+	invalid := tp.parseDirectives(contentStr)
+
+	// Fifteenth pass: Find constructs touching values that no other pass could resolve
+	return append(invalid, tp.parseUnresolved(contentStr)...)
+}
+
+// strictError fails on unresolved constructs of the template being parsed in strict mode
+func (tp *TemplateParser) strictError(unresolved []Unresolved) error {
+	if !tp.opts.Strict || len(unresolved) == 0 {
+		return nil
+	}
+	var constructs []string
+	for _, construct := range unresolved {
+		constructs = append(constructs, construct.String())
+	}
+	return fmt.Errorf("unresolvable constructs in %s:\n  %s", tp.file, strings.Join(constructs, "\n  "))
 }
 
 // ParseChart processes an entire chart including its subcharts
@@ -109,28 +373,99 @@ func (tp *TemplateParser) ParseChart(chartPath string) error {
 
 // ParseChartWithOptions processes a chart with configurable subchart handling
 func (tp *TemplateParser) ParseChartWithOptions(chartPath string, includeSubcharts bool) error {
+	tp.chartRoot = chartPath
+	slog.Info("parsing chart", "chart", chartPath)
+
+	// Collect helper definitions first so includes in templates can be resolved
+	helperFiles, err := helm.FindHelpers(chartPath)
+	if err != nil {
+		return err
+	}
+
+	for _, helperFile := range helperFiles {
+		if err := tp.ParseHelperFile(helperFile); err != nil {
+			return err
+		}
+	}
+
 	// Parse main chart templates
-	templateFiles, err := helm.FindTemplates(chartPath)
+	templateFiles, err := helm.FindTemplatesWithOptions(chartPath, tp.opts.Templates)
 	if err != nil {
 		return err
 	}
 
-	for _, templateFile := range templateFiles {
-		if err := tp.ParseTemplateFile(templateFile); err != nil {
+	slog.Debug("found templates", "chart", chartPath, "templates", len(templateFiles), "helpers", len(helperFiles))
+	if err := tp.parseTemplates(templateFiles); err != nil {
+		return err
+	}
+
+	// Values written by any template are outputs wherever they are read
+	if !tp.opts.KeepMutated {
+		tp.excludeMutations()
+	}
+
+	// Defaults, descriptions, deprecations and @schema annotations of the values, from values.yaml
+	valuesFile := filepath.Join(chartPath, "values.yaml")
+	defaults, err := helm.LoadValuesFile(valuesFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	for _, file := range tp.opts.ValuesFiles {
+		overlay, err := helm.LoadValuesFile(file)
+		if err != nil {
 			return err
 		}
+		defaults = helm.MergeValues(defaults, overlay)
+	}
+	tp.addValuesDefaults(defaults)
+	var variants []map[string]any
+	if tp.opts.ValuesVariants {
+		if variants, err = loadValuesVariants(chartPath); err != nil {
+			return err
+		}
+	}
+	for _, variant := range variants {
+		tp.addValuesExamples(variant)
+	}
+	descriptions, err := loadValuesDescriptions(valuesFile)
+	if err != nil {
+		return err
+	}
+	tp.addValuesDescriptions(descriptions, "")
+	deprecations, err := loadValuesDeprecations(valuesFile)
+	if err != nil {
+		return err
+	}
+	tp.addValuesDeprecations(deprecations, "")
+	annotations, err := loadValuesAnnotations(valuesFile)
+	if err != nil {
+		return err
+	}
+	tp.addValuesAnnotations(annotations, "")
+	tp.file = tp.relativePath(valuesFile)
+	invalid := invalidAnnotations(annotations, tp.file)
+	tp.unresolved = append(tp.unresolved, invalid...)
+	if err := tp.strictError(invalid); err != nil {
+		return err
 	}
 
 	if !includeSubcharts {
 		return nil
 	}
 
-	// Check if we need to build remote dependencies
-	hasRemote, err := helm.HasRemoteDependencies(chartPath)
+	allDeps, err := helm.FindAllSubcharts(chartPath)
 	if err != nil {
 		return err
 	}
 
+	// Remote dependencies are only built when one that is parsed is not vendored under charts/
+	hasRemote := false
+	for _, dep := range allDeps {
+		if !dep.IsLocalDependency() && !tp.excludesSubchart(dep) && helm.ValidateChartDirectory(dep.GetSubchartPath(chartPath)) != nil {
+			hasRemote = true
+		}
+	}
+
 	if hasRemote {
 		// Ensure helm is available
 		if err := helm.EnsureHelmAvailable(); err != nil {
@@ -138,38 +473,89 @@ func (tp *TemplateParser) ParseChartWithOptions(chartPath string, includeSubchar
 		}
 
 		// Build dependencies to download remote charts
+		slog.Info("building dependencies", "chart", chartPath)
 		if err := helm.BuildDependencies(chartPath); err != nil {
 			return err
 		}
 	}
 
 	// Parse all subcharts recursively (local and remote after build)
-	allDeps, err := helm.FindAllSubcharts(chartPath)
-	if err != nil {
-		return err
-	}
-
 	for _, dep := range allDeps {
 		subchartPath := dep.GetSubchartPath(chartPath)
 
+		// Excluded subcharts accept any values, as if they shipped a schema doing so
+		if tp.excludesSubchart(dep) {
+			slog.Info("skipping subchart", "subchart", dep.Name, "reason", "excluded")
+			tp.shipped[dep.Name] = map[string]any{"type": "object"}
+			continue
+		}
+
 		// Validate subchart exists
 		if err := helm.ValidateChartDirectory(subchartPath); err != nil {
 			// Continue if subchart not available - might be conditional or optional
+			slog.Info("skipping subchart", "subchart", dep.Name, "path", subchartPath, "reason", err)
 			continue
 		}
 
+		// Schemas dependencies ship, such as those of Bitnami charts, describe their values more
+		// accurately than their templates do; only values they export are still looked for there
+		shipped, err := tp.shippedSchema(subchartPath)
+		if err != nil {
+			return fmt.Errorf("failed to load schema of subchart %s: %w", dep.Name, err)
+		}
+		if shipped != nil {
+			tp.shipped[dep.Name] = shipped
+			slog.Debug("using the schema shipped by subchart", "subchart", dep.Name)
+			if len(dep.ImportValues) == 0 {
+				continue
+			}
+		}
+
 		// Create parser for subchart
-		subchartParser := New()
+		subchartOpts := tp.opts
+		subchartOpts.ValuesFiles = nil
+		subchartOpts.OnlySubcharts = nil
+		subchartParser := NewWithOptions(subchartOpts)
 		if err := subchartParser.ParseChartWithOptions(subchartPath, true); err != nil {
 			return fmt.Errorf("failed to parse subchart %s at %s: %w", dep.Name, subchartPath, err)
 		}
 
 		tp.subcharts[dep.Name] = subchartParser
+
+		// Defaults, descriptions and annotations set for the dependency in this chart's
+		// values.yaml take precedence over its own
+		if overrides, ok := defaults[dep.ValuesKey()].(map[string]any); ok {
+			subchartParser.addValuesDefaults(overrides)
+		}
+		for _, variant := range variants {
+			if overrides, ok := variant[dep.ValuesKey()].(map[string]any); ok {
+				subchartParser.addValuesExamples(overrides)
+			}
+		}
+		subchartParser.addValuesDescriptions(descriptions, EscapeKey(dep.ValuesKey()))
+		subchartParser.addValuesDeprecations(deprecations, EscapeKey(dep.ValuesKey()))
+		subchartParser.addValuesAnnotations(annotations, EscapeKey(dep.ValuesKey()))
+
+		// Values the dependency imports into this chart also appear at the parent paths
+		if err := tp.importValues(dep, subchartParser, subchartPath); err != nil {
+			return fmt.Errorf("failed to import values of subchart %s: %w", dep.Name, err)
+		}
 	}
 
 	return nil
 }
 
+// excludesSubchart reports whether a dependency is left out by SkipSubcharts or OnlySubcharts
+func (tp *TemplateParser) excludesSubchart(dep *helm.Dependency) bool {
+	named := func(names []string) bool {
+		return slices.Contains(names, dep.Name) || slices.Contains(names, dep.ValuesKey())
+	}
+	if named(tp.opts.SkipSubcharts) {
+		return true
+	}
+	return len(tp.opts.OnlySubcharts) > 0 && !named(tp.opts.OnlySubcharts)
+}
+
 // GetValues returns the collected value paths
 func (tp *TemplateParser) GetValues() map[string]*ValuePath {
 	return tp.values
@@ -180,6 +566,33 @@ func (tp *TemplateParser) GetSubcharts() map[string]*TemplateParser {
 	return tp.subcharts
 }
 
+// ShippedSchemas returns the values.schema.json of the subcharts reused as their schema, by
+// subchart name, and the schema accepting any values of the subcharts excluded from parsing
+func (tp *TemplateParser) ShippedSchemas() map[string]map[string]any {
+	return tp.shipped
+}
+
+// shippedSchema returns the values.schema.json a subchart ships, or nil when it ships none,
+// generated ones included, or subcharts are always derived
+func (tp *TemplateParser) shippedSchema(subchartPath string) (map[string]any, error) {
+	if tp.opts.DeriveSubcharts {
+		return nil, nil
+	}
+	shipped, err := helm.LoadChartSchema(subchartPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Schemas helm-schema generated, recording how in x-generation, are derived again since
+	// the templates may have changed since
+	if _, generated := shipped["x-generation"]; generated {
+		return nil, nil
+	}
+	return shipped, nil
+}
+
 // GetAllValues returns all value paths including those from subcharts
 func (tp *TemplateParser) GetAllValues() map[string]*ValuePath {
 	allValues := make(map[string]*ValuePath)
@@ -199,13 +612,7 @@ func (tp *TemplateParser) GetAllValues() map[string]*ValuePath {
 		for path, valuePath := range subchartValues {
 			// Prefix subchart values with subchart name
 			prefixedPath := subchartName + "." + path
-			prefixedValuePath := &ValuePath{
-				Path:     prefixedPath,
-				Type:     valuePath.Type,
-				Required: valuePath.Required,
-				Default:  valuePath.Default,
-			}
-			allValues[prefixedPath] = prefixedValuePath
+			allValues[prefixedPath] = valuePath.withPath(prefixedPath)
 		}
 	}
 
@@ -231,12 +638,7 @@ func (tp *TemplateParser) getAllValuesParallel() map[string]*ValuePath {
 			defer mu.Unlock()
 			for path, valuePath := range subchartValues {
 				prefixedPath := name + "." + path
-				allValues[prefixedPath] = &ValuePath{
-					Path:     prefixedPath,
-					Type:     valuePath.Type,
-					Required: valuePath.Required,
-					Default:  valuePath.Default,
-				}
+				allValues[prefixedPath] = valuePath.withPath(prefixedPath)
 			}
 		}(subchartName, subchartParser)
 	}
@@ -245,8 +647,11 @@ func (tp *TemplateParser) getAllValuesParallel() map[string]*ValuePath {
 	return allValues
 }
 
-// parseVariableAssignments finds {{ $var := .Values.path }} patterns
+// parseVariableAssignments finds {{ $var := .Values.path }} patterns, and binds the variables
+// declared by ranges within their bodies
 func (tp *TemplateParser) parseVariableAssignments(content string) {
+	index := tp.indexOf(content)
+	content = index.masked
 	matches := tp.varRe.FindAllStringSubmatch(content, -1)
 	for _, match := range matches {
 		if len(match) > 2 {
@@ -254,19 +659,27 @@ func (tp *TemplateParser) parseVariableAssignments(content string) {
 			valuePath := tp.normalizePath(match[2])
 			if valuePath != "" {
 				tp.variables[varName] = valuePath
+				delete(tp.decoded, varName)
 			}
 		}
 	}
+
+	// Variables holding a decoded document are rebound to the document
+	tp.parseDecodedAssignments(index)
+
+	tp.bindRangeVariables(index)
 }
 
 // parseDirectValueReferences finds direct {{ .Values.path }} patterns
 func (tp *TemplateParser) parseDirectValueReferences(content string) {
-	matches := tp.re.FindAllStringSubmatch(content, -1)
+	index := tp.indexOf(content)
+	content = index.masked
+	matches := tp.re.FindAllStringSubmatchIndex(content, -1)
 	for _, match := range matches {
-		if len(match) > 1 {
-			path := tp.normalizePath(match[1])
+		if len(match) > 3 {
+			path := tp.normalizePath(content[match[2]:match[3]])
 			if path != "" {
-				tp.addValuePathWithHints(path)
+				tp.addValuePathWithHints(path, index, match[0], tp.siteAt(index, match[0]))
 			}
 		}
 	}
@@ -274,86 +687,432 @@ func (tp *TemplateParser) parseDirectValueReferences(content string) {
 
 // parseVariableReferences finds {{ $var.field }} patterns and resolves them
 func (tp *TemplateParser) parseVariableReferences(content string) {
-	matches := tp.varRefRe.FindAllStringSubmatch(content, -1)
+	index := tp.indexOf(content)
+	content = index.masked
+	matches := tp.varRefRe.FindAllStringSubmatchIndex(content, -1)
 	for _, match := range matches {
-		if len(match) > 2 {
-			varName := match[1]
-			fieldPath := tp.normalizePath(match[2])
+		if len(match) > 5 {
+			varName := content[match[2]:match[3]]
+			fieldPath := tp.normalizePath(content[match[4]:match[5]])
 
-			if basePath, exists := tp.variables[varName]; exists && fieldPath != "" {
+			if basePath, exists := tp.lookupVariable(varName, match[0]); exists && fieldPath != "" {
 				fullPath := basePath + "." + fieldPath
-				tp.addValuePathWithHints(fullPath)
+				tp.addValuePathWithHints(fullPath, index, match[0], tp.siteAt(index, match[0]))
+			} else if source, decoded := tp.decoded[varName]; decoded && fieldPath != "" {
+				tp.addDecodedField(source, fieldPath)
+			}
+		}
+	}
+}
+
+// parseKeyLookups finds {{ hasKey .Values.path "key" }}, {{ get .Values.path "key" }} and
+// {{ index .Values.path "a" "b" }} patterns and records the literal keys as nested paths
+func (tp *TemplateParser) parseKeyLookups(content string) {
+	index := tp.indexOf(content)
+	masked := index.masked
+	matches := tp.lookupRe.FindAllStringSubmatchIndex(masked, -1)
+	for _, match := range matches {
+		if !isWordStart(masked, match[0]) {
+			continue
+		}
+
+		function := masked[match[2]:match[3]]
+		basePath := ""
+		if match[4] >= 0 {
+			varPath, exists := tp.lookupVariable(masked[match[4]:match[5]], match[0])
+			if !exists {
+				continue
+			}
+			basePath = varPath
+		}
+		if suffix := strings.TrimPrefix(masked[match[6]:match[7]], "."); suffix != "" {
+			basePath = joinPath(basePath, suffix)
+		}
+
+		// Literal keys are read from the original content since masking blanks string contents
+		path := basePath
+		for _, arg := range tp.keyArgRe.FindAllStringSubmatch(content[match[8]:match[9]], -1) {
+			if arg[2] != "" {
+				path += "[]"
+			} else if arg[1] != "" {
+				// Keys such as nginx.conf or pod-annotations are only reachable this way
+				path = joinPath(path, EscapeKey(arg[1]))
+			} else {
+				break
+			}
+			// hasKey and get take a single key
+			if function != "index" {
+				break
 			}
 		}
+
+		if path != basePath {
+			tp.addValuePathWithHints(path, index, match[0], tp.siteAt(index, match[0]))
+		}
+	}
+}
+
+// parseGroupFields finds {{ (.Values.path).field }} and {{ (.Values.path | default dict).field }}
+// patterns and records the field relative to the path the group evaluates to
+func (tp *TemplateParser) parseGroupFields(content string) {
+	// Parentheses inside string literals are blanked by masking, so they cannot unbalance groups
+	index := tp.indexOf(content)
+	masked := index.masked
+	// Matches are searched one at a time since the boundary of ((...).a).b overlaps the next group
+	for pos := 0; pos < len(masked); {
+		match := tp.groupFieldRe.FindStringSubmatchIndex(masked[pos:])
+		if match == nil {
+			break
+		}
+		for i := range match {
+			match[i] += pos
+		}
+		pos = match[3]
+
+		open := openingParen(masked, match[0])
+		if open < 0 {
+			continue
+		}
+
+		path, ok := tp.resolveOperand(masked[open:match[3]])
+		if ok && path != "" {
+			tp.addValuePathWithHints(path, index, open, tp.siteAt(index, open))
+		} else if source, decoded := tp.resolveDecodedGroup(masked[open+1 : match[0]]); decoded {
+			// (.Values.raw | fromYaml).field reads the document, not the values
+			tp.addDecodedField(source, tp.normalizePath(masked[match[2]:match[3]]))
+		}
 	}
 }
 
-// addValuePathWithHints adds a value path with simple structural type inference
-func (tp *TemplateParser) addValuePathWithHints(path string) {
+// parseRangeHints finds {{ range $key, $value := .Values.path }} patterns, which iterate over
+// key/value pairs and hint that the value is a map, unless the body uses the key as a list index,
+// and {{ range .Values.path }} patterns, which iterate over the items of a list. How the body
+// uses each value as a whole, as in {{ $item | quote }} or {{ . | quote }}, types the values
+// iterated over.
+func (tp *TemplateParser) parseRangeHints(content string) {
+	index := tp.indexOf(content)
+	masked := index.masked
+	for _, match := range tp.rangeRe.FindAllStringSubmatchIndex(masked, -1) {
+		operand := masked[match[1]:matchingArgEnd(masked, match[1])]
+		path, ok := tp.resolveOperandAt(operand, match[0])
+		if !ok || path == "" {
+			continue
+		}
+
+		site := tp.siteAt(index, match[0])
+		action := index.actionAt(match[0])
+		if action >= 0 && indexedRange(index, action, masked[match[2]:match[3]]) {
+			tp.addTypeHint(path, TypeHint{Type: "array", Reason: "ranged over with an index", Site: site, Confidence: ConfidenceStructural})
+			tp.parseRangeBody(index, action, tp.normalizePath(path)+"[]")
+			continue
+		}
+
+		tp.addTypeHint(path, TypeHint{Type: "map", Reason: "ranged over as key/value pairs", Site: site, Confidence: ConfidenceStructural})
+		if action >= 0 {
+			tp.parseRangeBody(index, action, joinPath(tp.normalizePath(path), AnyKey))
+			tp.parseRangeKeys(index, action, path, masked[match[2]:match[3]])
+		}
+	}
+
+	for _, match := range tp.listRangeRe.FindAllStringIndex(masked, -1) {
+		operand := masked[match[1]:matchingArgEnd(masked, match[1])]
+		path, ok := tp.resolveOperandAt(operand, match[0])
+		if !ok || path == "" {
+			continue
+		}
+
+		site := tp.siteAt(index, match[0])
+		tp.addTypeHint(path, TypeHint{Type: "array", Reason: "ranged over as a list", Site: site, Confidence: ConfidenceStructural})
+
+		if action := index.actionAt(match[0]); action >= 0 {
+			tp.parseRangeBody(index, action, tp.normalizePath(path)+"[]")
+		}
+	}
+
+	tp.parseRangeVariableUses(index)
+}
+
+// parseRangeBody records the fields the body of the range action at index start reads from
+// the dot, which range rebinds to each value at path, and the dot itself where it is used as a
+// whole. Nested blocks rebinding the dot to one of
+// its fields, as in {{ range .paths }} or {{ with .tls }}, are followed; fields are not read
+// where the dot is rebound to anything else.
+func (tp *TemplateParser) parseRangeBody(index *templateIndex, start int, path string) {
+	masked, actions := index.masked, index.actions
+	end := matchingEnd(masked, actions, start)
+	if end < 0 {
+		return
+	}
+
+	// Keywords of the blocks open within the body, and how many of them rebind the dot
+	var blocks []string
+	rebound := 0
+	for i, span := range actions[start+1 : end] {
+		action := masked[span[0]:span[1]]
+		if rebound == 0 {
+			for _, match := range contextFieldRe.FindAllStringSubmatchIndex(action, -1) {
+				offset := span[0] + match[0]
+				if !isWordStart(masked, offset) || strings.ContainsRune(")]", rune(masked[offset-1])) {
+					continue
+				}
+				field := action[match[2]:match[3]]
+				if builtinObjects[field] {
+					continue
+				}
+				tp.addValuePathWithHints(joinPath(path, field+action[match[4]:match[5]]), index, offset, tp.siteAt(index, offset))
+			}
+			// The dot itself is the item, e.g. {{ . | quote }} types the items as strings
+			for _, match := range bareDotRe.FindAllStringIndex(action, -1) {
+				offset := span[0] + match[0] + 1
+				tp.addValuePathWithHints(path, index, offset, tp.siteAt(index, offset))
+			}
+		}
+
+		keyword := actionKeywordRe.FindStringSubmatch(action)
+		switch {
+		case keyword == nil:
+		case keyword[1] != "end":
+			blocks = append(blocks, keyword[1])
+			if keyword[1] == "with" || keyword[1] == "range" {
+				if rebound == 0 {
+					tp.parseNestedBlock(index, start+1+i, path)
+				}
+				rebound++
+			}
+		case len(blocks) > 0:
+			if last := blocks[len(blocks)-1]; last == "with" || last == "range" {
+				rebound--
+			}
+			blocks = blocks[:len(blocks)-1]
+		}
+	}
+}
+
+// parseNestedBlock follows a range or with block within a range body whose operand is a field
+// of the dot at path: {{ range .paths }} iterates over a list of the current item, and
+// {{ with .tls }} reads fields of one of its objects
+func (tp *TemplateParser) parseNestedBlock(index *templateIndex, block int, path string) {
+	span := index.actions[block]
+	action := index.masked[span[0]:span[1]]
+	match := blockOperandRe.FindStringSubmatchIndex(action)
+	if match == nil {
+		return
+	}
+	field := dotFieldRe.FindStringSubmatch(action[match[1]:matchingArgEnd(action, match[1])])
+	if field == nil || builtinObjects[field[1]] {
+		return
+	}
+
+	nested := joinPath(path, tp.normalizePath(field[1]+field[2]))
+	if action[match[2]:match[3]] == "range" {
+		site := tp.siteAt(index, span[0])
+		tp.addTypeHint(nested, TypeHint{Type: "array", Reason: "ranged over as a list", Site: site, Confidence: ConfidenceStructural})
+		nested += "[]"
+	}
+	tp.parseRangeBody(index, block, nested)
+}
+
+// addValuePathWithHints adds a value path referenced at offset of the indexed template, with
+// simple structural type inference refined by the template functions the value is passed through
+func (tp *TemplateParser) addValuePathWithHints(path string, index *templateIndex, offset int, site Site) {
 	normalizedPath := tp.normalizePath(path)
 
 	// Add the leaf path
-	if _, exists := tp.values[normalizedPath]; !exists {
-		tp.values[normalizedPath] = &ValuePath{
-			Path:     normalizedPath,
-			Type:     inferTypeFromHints(path),
-			Required: false,
+	valuePath := tp.valuePath(normalizedPath)
+	first := len(valuePath.Sources) == 0
+	valuePath.addSource(site)
+
+	var functions []string
+	var guards []string
+	if index != nil {
+		guards = tp.guardsAt(index, offset, normalizedPath)
+		valuePath.Optional = valuePath.Optional || inCondition(index, offset)
+		functions = index.functionsAt(offset)
+		if function := index.listFunctionAt(offset); function != "" {
+			valuePath.addHint(TypeHint{Type: "array", Reason: "passed to " + function, Site: site, Confidence: ConfidencePipeline})
+			valuePath.Unique = valuePath.Unique || hasSetFunction(functions)
+		}
+		if index.inSelectorValues(offset) {
+			valuePath.addHint(TypeHint{Type: "array", Reason: "rendered as label selector values", Site: site, Confidence: ConfidencePipeline})
+			valuePath.Unique = true
+		}
+		key := index.renderedKey(offset)
+		valuePath.IntOrPercent = valuePath.IntOrPercent || intOrPercentNames[key]
+		valuePath.Quantity = valuePath.Quantity || quantityNames[key]
+	}
+
+	if function := firstStringFunction(functions); function != "" {
+		valuePath.addHint(TypeHint{Type: "string", Reason: "piped through " + function, Site: site, Confidence: ConfidencePipeline})
+	}
+	if function := firstNumberFunction(functions); function != "" {
+		valuePath.addHint(TypeHint{Type: "number", Reason: "piped through " + function, Site: site, Confidence: ConfidencePipeline})
+	}
+	if hasSensitiveFunction(functions) {
+		valuePath.Sensitive = true
+	}
+	// Rendering fails unless the value is set, and not empty, when no default comes first
+	if required := slices.Index(functions, "required"); required >= 0 && !slices.Contains(functions[:required], "default") {
+		valuePath.Required = true
+		valuePath.NonEmpty = true
+	}
+	valuePath.addGuards(guards, first)
+	if slices.ContainsFunc(functions, func(function string) bool { return optionalFunctions[function] }) {
+		valuePath.Optional = true
+	}
+	valuePath.Functions = mergeSorted(valuePath.Functions, functions)
+	for _, function := range functions {
+		if encoding, decodes := decodeFunctions[function]; decodes {
+			valuePath.Encoding = encoding
+			break
 		}
 	}
 
 	// Create intermediate object paths for nested paths like a.b.c
 	// This ensures that a and a.b are created as objects
-	tp.addIntermediatePaths(normalizedPath)
+	tp.addIntermediatePaths(normalizedPath, site)
+}
+
+// addTypeHint attaches a type hint to a path found by a pass that refines references already
+// recorded elsewhere, so no new source is recorded
+func (tp *TemplateParser) addTypeHint(path string, hint TypeHint) *ValuePath {
+	normalizedPath := tp.normalizePath(path)
+	valuePath := tp.valuePath(normalizedPath)
+	valuePath.addHint(hint)
+	tp.addIntermediatePaths(normalizedPath, hint.Site)
+	return valuePath
+}
+
+// valuePath returns the value path recorded for path, creating it with an unknown type
+func (tp *TemplateParser) valuePath(path string) *ValuePath {
+	valuePath, exists := tp.values[path]
+	if !exists {
+		valuePath = &ValuePath{
+			Path:     path,
+			Type:     "unknown",
+			Required: false,
+		}
+		tp.values[path] = valuePath
+	}
+	return valuePath
+}
+
+// relativePath returns a template path relative to the chart being parsed, for reporting
+func (tp *TemplateParser) relativePath(filePath string) string {
+	if tp.chartRoot == "" {
+		return filePath
+	}
+	rel, err := filepath.Rel(tp.chartRoot, filePath)
+	if err != nil {
+		return filePath
+	}
+	return filepath.ToSlash(rel)
+}
+
+// siteAt returns the site of an offset within an indexed template
+func (tp *TemplateParser) siteAt(index *templateIndex, offset int) Site {
+	line, column := index.position(offset)
+	return Site{File: tp.file, Line: line, Column: column}
+}
+
+// Warnings returns the problems found while parsing the chart and its subcharts
+func (tp *TemplateParser) Warnings() []Warning {
+	var warnings []Warning
+
+	var paths []string
+	for path := range tp.values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		valuePath := tp.values[path]
+		if len(valuePath.Types) < 2 || guardedUnion(valuePath) {
+			continue
+		}
+		var sites []string
+		for _, hint := range valuePath.Hints {
+			sites = append(sites, fmt.Sprintf("%s (%s, %s)", hint.Type, hint.Site, hint.Reason))
+		}
+		warnings = append(warnings, Warning{
+			Path:    path,
+			Message: "conflicting type hints: " + strings.Join(sites, "; "),
+		})
+	}
+	warnings = append(warnings, tp.mutationWarnings()...)
+
+	var subchartNames []string
+	for name := range tp.subcharts {
+		subchartNames = append(subchartNames, name)
+	}
+	sort.Strings(subchartNames)
+
+	for _, name := range subchartNames {
+		for _, warning := range tp.subcharts[name].Warnings() {
+			warning.Path = name + "." + warning.Path
+			warnings = append(warnings, warning)
+		}
+	}
+
+	return warnings
 }
 
+// joinPath appends a child segment to a parent path, either of which may be empty
+func joinPath(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	if child == "" {
+		return parent
+	}
+	return parent + "." + child
+}
+
+// isWordStart reports whether offset begins a word rather than continuing an identifier
+func isWordStart(content string, offset int) bool {
+	if offset == 0 {
+		return true
+	}
+	c := content[offset-1]
+	return !(c == '_' || c == '.' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9'))
+}
+
+// arrayIndexRe matches array notation with a position, e.g. [0]
+var arrayIndexRe = regexp.MustCompile(`\[\d+\]`)
+
 // normalizePath cleans up path strings
 func (tp *TemplateParser) normalizePath(path string) string {
 	// Remove trailing punctuation
 	path = strings.TrimRight(path, ".,;:!?")
 	// Normalize array notation [0] to []
-	return regexp.MustCompile(`\[\d+\]`).ReplaceAllString(path, "[]")
-}
-
-// inferTypeFromHints performs simple structural type inference
-func inferTypeFromHints(path string) string {
-	// Array notation: path ending with [] (not just containing it)
-	if strings.HasSuffix(path, "[]") {
-		return "array"
-	}
-
-	// Default to unknown - we focus on getting the keyset right, not the datatypes
-	return "unknown"
+	return arrayIndexRe.ReplaceAllString(path, "[]")
 }
 
 // addIntermediatePaths creates intermediate object paths for nested paths
 // For path a.b.c, creates a (object) and a.b (object)
-// For path a[].b, creates a (array)
-func (tp *TemplateParser) addIntermediatePaths(path string) {
-	parts := strings.Split(path, ".")
+// For path a[].b, creates a (array) and a[] (object), the items of a
+// For path a[], creates a (array)
+// For path a.*.b, creates a (map) and a.* (object)
+func (tp *TemplateParser) addIntermediatePaths(path string, site Site) {
+	parts := SplitPath(path)
 
 	for i := 1; i < len(parts); i++ {
 		intermediatePath := strings.Join(parts[:i], ".")
 
-		// Determine if this intermediate path should be an array or object
-		pathType := "object" // Default to object
-
-		// Check if this part ends with [] indicating array
-		if strings.HasSuffix(parts[i-1], "[]") {
-			pathType = "array"
+		// Determine if this intermediate path should be a map or object
+		hint := TypeHint{Type: "object", Reason: "has nested field " + parts[i], Site: site, Confidence: ConfidenceStructural}
+		if parts[i] == AnyKey {
+			hint = TypeHint{Type: "map", Reason: "ranged over as key/value pairs", Site: site, Confidence: ConfidenceStructural}
 		}
 
-		if existing, exists := tp.values[intermediatePath]; exists {
-			// Update existing path if it's unknown (direct reference) but should be object/array
-			if existing.Type == "unknown" && pathType != "unknown" {
-				existing.Type = pathType
-			}
-		} else {
-			// Create new intermediate path
-			tp.values[intermediatePath] = &ValuePath{
-				Path:     intermediatePath,
-				Type:     pathType,
-				Required: false,
-			}
+		tp.valuePath(intermediatePath).addHint(hint)
+	}
+
+	// Segments with array markers index lists, whose items may be lists themselves as in a[][]
+	for i, part := range parts {
+		for strings.HasSuffix(part, "[]") {
+			part = strings.TrimSuffix(part, "[]")
+			list := strings.Join(append(parts[:i:i], part), ".")
+			tp.valuePath(list).addHint(TypeHint{Type: "array", Reason: "indexed as a list", Site: site, Confidence: ConfidenceStructural})
 		}
 	}
 }
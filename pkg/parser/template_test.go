@@ -1,7 +1,9 @@
 package parser
 
 import (
+	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -26,27 +28,31 @@ func TestParseBasicChart(t *testing.T) {
 
 	// Test expected basic paths are found
 	expectedPaths := map[string]string{
-		"app.name":          "unknown",
-		"app.debug":         "unknown",
-		"app.enabled":       "unknown",
-		"app.replicas":      "unknown",
-		"app":               "object", // Intermediate path
-		"image.repository":  "unknown",
-		"image.tag":         "unknown",
-		"image.pullPolicy":  "unknown",
-		"image":             "object", // Intermediate path
-		"service.port":      "unknown",
-		"service.type":      "unknown",
-		"service":           "object", // Intermediate path
-		"database.host":     "unknown",
-		"database.port":     "unknown",
-		"database":          "object", // Intermediate path
-		"config.data":       "unknown",
-		"config.properties": "unknown", // Range usage doesn't have [] in path
-		"config":            "object",  // Intermediate path
-		"secrets.name":      "unknown",
-		"secrets":           "object", // Intermediate path
-		"resources":         "unknown",
+		"app.name":                  "unknown",
+		"app.debug":                 "unknown",
+		"app.enabled":               "unknown",
+		"app.replicas":              "integer",
+		"app":                       "object", // Intermediate path
+		"image.repository":          "unknown",
+		"image.tag":                 "unknown",
+		"image.pullPolicy":          "unknown",
+		"image":                     "object", // Intermediate path
+		"service.port":              "unknown",
+		"service.type":              "string",
+		"service":                   "object", // Intermediate path
+		"database.host":             "unknown",
+		"database.port":             "unknown",
+		"database":                  "object",  // Intermediate path
+		"config.data":               "map",     // range $key, $value
+		"config.data.*":             "unknown", // $value | quote
+		"config.properties":         "array",   // range .Values.config.properties
+		"config.properties[]":       "object",  // Items read in the range body
+		"config.properties[].key":   "unknown",
+		"config.properties[].value": "unknown",
+		"config":                    "object", // Intermediate path
+		"secrets.name":              "unknown",
+		"secrets":                   "object", // Intermediate path
+		"resources":                 "unknown",
 	}
 
 	for expectedPath, expectedType := range expectedPaths {
@@ -87,40 +93,40 @@ func TestParseComplexConditionals(t *testing.T) {
 
 	// Test some key complex conditional paths - simplified to focus on keyset correctness
 	expectedComplexPaths := map[string]string{
-		// Leaf values are all unknown
+		// Leaf values are unknown unless given a default literal
 		"rollout.enabled":                    "unknown",
-		"rollout.revision":                   "unknown",
+		"rollout.revision":                   "integer",
 		"rollout.strategy":                   "unknown",
 		"rollout.maxSurge":                   "unknown",
-		"rollout.maxUnavailable":             "unknown",
+		"rollout.maxUnavailable":             "string",
 		"app.environment":                    "unknown",
 		"global.env":                         "unknown",
 		"scaling.enabled":                    "unknown",
 		"scaling.replicas":                   "unknown",
 		"security.runAsNonRoot":              "unknown",
-		"security.runAsUser":                 "unknown",
-		"security.capabilities.drop":         "unknown", // No explicit [] in path
-		"security.capabilities.add":          "unknown", // No explicit [] in path
-		"monitoring.prometheus.scrape":       "unknown",
+		"security.runAsUser":                 "integer",
+		"security.capabilities.drop":         "array", // Ranged over as a list
+		"security.capabilities.add":          "array", // Ranged over as a list
+		"monitoring.prometheus.scrape":       "boolean",
 		"monitoring.prometheus.port":         "unknown",
 		"metrics.enabled":                    "unknown",
-		"metrics.path":                       "unknown",
-		"features.experimental.enabled":      "unknown",
+		"metrics.path":                       "string",
+		"features.experimental.enabled":      "boolean",
 		"features.experimental.flags":        "unknown",
-		"features.flags":                     "unknown",
+		"features.flags":                     "array", // $index is compared with $lastIndex, sub (len ...) 1
 		"database.config":                    "unknown",
-		"database.migrations.enabled":        "unknown",
-		"database.migrations.scripts":        "unknown", // No explicit [] in path
+		"database.migrations.enabled":        "boolean",
+		"database.migrations.scripts":        "array", // Ranged over as a list
 		"external.database.connectionString": "unknown",
-		"service.additionalPorts":            "unknown", // No explicit [] in path
-		"service.external.ips":               "unknown", // No explicit [] in path
+		"service.additionalPorts":            "array", // Ranged over as a list
+		"service.external.ips":               "array", // Ranged over as a list
 		"loadBalancer.enabled":               "unknown",
-		"loadBalancer.type":                  "unknown",
+		"loadBalancer.type":                  "string",
 		"loadBalancer.internal":              "unknown",
 		"loadBalancer.subnets":               "unknown",
 		"logging.level":                      "unknown",
-		"logging.config":                     "unknown",
-		"logging.appenders":                  "unknown", // No explicit [] in path
+		"logging.config":                     "map",   // range $logger, $level
+		"logging.appenders":                  "array", // Ranged over as a list
 		// Intermediate paths are objects
 		"rollout":               "object",
 		"security":              "object",
@@ -172,22 +178,22 @@ func TestParseDefaultValues(t *testing.T) {
 
 	values := parser.GetValues()
 
-	// Expected paths and their types - all leaf values are unknown, intermediate paths are objects
+	// Expected paths and their types - leaves are typed after their default literal, intermediate paths are objects
 	expectedPaths := map[string]string{
-		"app.name":         "unknown",
-		"app.replicas":     "unknown",
-		"app.debug":        "unknown",
-		"app.enabled":      "unknown",
-		"app.vendor.host":  "unknown",
+		"app.name":         "string",
+		"app.replicas":     "integer",
+		"app.debug":        "boolean",
+		"app.enabled":      "boolean",
+		"app.vendor.host":  "string",
 		"app.vendor":       "object", // Intermediate path
 		"app":              "object", // Intermediate path
-		"image.repository": "unknown",
-		"image.tag":        "unknown",
+		"image.repository": "string",
+		"image.tag":        "string",
 		"image":            "object", // Intermediate path
-		"service.port":     "unknown",
+		"service.port":     "integer",
 		"service":          "object", // Intermediate path
-		"database.host":    "unknown",
-		"database.port":    "unknown",
+		"database.host":    "string",
+		"database.port":    "integer",
 		"database":         "object", // Intermediate path
 	}
 
@@ -216,37 +222,494 @@ func TestSimpleTypeInference(t *testing.T) {
 	tests := []struct {
 		name     string
 		path     string
-		expected string
+		expected map[string]string
 	}{
 		{
 			name:     "array syntax",
 			path:     "items[]",
-			expected: "array",
+			expected: map[string]string{"items": "array", "items[]": "unknown"},
 		},
 		{
 			name:     "nested path",
 			path:     "app.config.host",
-			expected: "unknown",
+			expected: map[string]string{"app": "object", "app.config": "object", "app.config.host": "unknown"},
 		},
 		{
 			name:     "simple path",
 			path:     "enabled",
-			expected: "unknown",
+			expected: map[string]string{"enabled": "unknown"},
 		},
 		{
 			name:     "array with nested path",
 			path:     "items[].name",
-			expected: "unknown",
+			expected: map[string]string{"items": "array", "items[]": "object", "items[].name": "unknown"},
+		},
+		{
+			name:     "nested arrays",
+			path:     "matrix[][]",
+			expected: map[string]string{"matrix": "array", "matrix[]": "array", "matrix[][]": "unknown"},
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			result := inferTypeFromHints(test.path)
-			if result != test.expected {
-				t.Errorf("inferTypeFromHints(%s) = %s, expected %s",
-					test.path, result, test.expected)
+			parser := New()
+			parser.addValuePathWithHints(test.path, nil, 0, Site{})
+
+			values := parser.GetValues()
+			if len(values) != len(test.expected) {
+				t.Errorf("Expected %d paths, found %d", len(test.expected), len(values))
+			}
+			for path, expected := range test.expected {
+				if valuePath, exists := values[path]; !exists {
+					t.Errorf("Expected path %s not found", path)
+				} else if valuePath.Type != expected {
+					t.Errorf("Path %s has type %s, expected %s", path, valuePath.Type, expected)
+				}
 			}
 		})
 	}
 }
+
+func TestParseMultiLineExpressions(t *testing.T) {
+	parser := New()
+	chartPath := "../../test-charts/multiline"
+
+	templateFiles := []string{
+		filepath.Join(chartPath, "templates/deployment.yaml"),
+		filepath.Join(chartPath, "templates/configmap.yaml"),
+	}
+
+	for _, file := range templateFiles {
+		if err := parser.ParseTemplateFile(file); err != nil {
+			t.Fatalf("Failed to parse %s: %v", file, err)
+		}
+	}
+
+	values := parser.GetValues()
+
+	expectedPaths := map[string]string{
+		"app.config":         "object",
+		"app.config.timeout": "unknown",
+		"app.name":           "string",
+		"app":                "object",
+		"containers":         "map",
+		"worker":             "unknown",
+		"auth.password":      "string", // b64enc on the following line
+		"auth":               "object",
+		"metrics.enabled":    "unknown",
+		"metrics.port":       "unknown", // hasKey key on the following line
+		"metrics":            "object",
+		"greeting.prefix":    "unknown",
+		"greeting.name":      "unknown",
+		"greeting.suffix":    "unknown",
+		"greeting":           "object",
+	}
+
+	for expectedPath, expectedType := range expectedPaths {
+		if valuePath, exists := values[expectedPath]; !exists {
+			t.Errorf("Expected path %s not found", expectedPath)
+		} else if valuePath.Type != expectedType {
+			t.Errorf("Path %s has type %s, expected %s", expectedPath, valuePath.Type, expectedType)
+		}
+	}
+
+	if len(values) != len(expectedPaths) {
+		t.Errorf("Expected %d paths, found %d", len(expectedPaths), len(values))
+		for path := range values {
+			t.Logf("Found path: %s (%s)", path, values[path].Type)
+		}
+	}
+
+	if parser.variables["cfg"] != "app.config" {
+		t.Errorf("Expected $cfg to be bound to app.config, got %q", parser.variables["cfg"])
+	}
+}
+
+func TestParseIncludeContext(t *testing.T) {
+	parser := New()
+	if err := parser.ParseChartWithOptions("../../test-charts/helpers", false); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+
+	values := parser.GetValues()
+
+	expectedPaths := map[string]string{
+		// include "app.image" .Values.init.image
+		"init":                  "object",
+		"init.image":            "object",
+		"init.image.registry":   "unknown",
+		"init.image.repository": "unknown",
+		// include "app.container" (dict "cfg" .Values.api "root" $)
+		"api":                     "object",
+		"api.image":               "object",
+		"api.image.repository":    "unknown",
+		"api.image.tag":           "unknown",
+		"api.resources":           "unknown",
+		"api.env":                 "unknown",
+		"worker":                  "object",
+		"worker.image":            "object",
+		"worker.image.repository": "unknown",
+		"worker.image.tag":        "unknown",
+		"worker.resources":        "unknown",
+		"worker.env":              "unknown",
+		// .root.Values.global.logLevel in the nested app.env helper
+		"global":          "object",
+		"global.logLevel": "unknown",
+	}
+
+	for expectedPath, expectedType := range expectedPaths {
+		if valuePath, exists := values[expectedPath]; !exists {
+			t.Errorf("Expected path %s not found", expectedPath)
+		} else if valuePath.Type != expectedType {
+			t.Errorf("Path %s has type %s, expected %s", expectedPath, valuePath.Type, expectedType)
+		}
+	}
+
+	if len(values) != len(expectedPaths) {
+		t.Errorf("Expected %d paths, found %d", len(expectedPaths), len(values))
+		for path := range values {
+			t.Logf("Found path: %s (%s)", path, values[path].Type)
+		}
+	}
+}
+
+func TestValueSources(t *testing.T) {
+	parser := New()
+	if err := parser.ParseChartWithOptions("../../test-charts/helpers", false); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+
+	values := parser.GetValues()
+
+	// Paths read inside helpers are attributed to the include call of each component
+	expectedSources := map[string][]Site{
+		"init.image.registry":     {{File: "templates/deployment.yaml", Line: 10, Column: 19}},
+		"worker.image.repository": {{File: "templates/deployment.yaml", Line: 15, Column: 13}},
+		"global.logLevel": {
+			{File: "templates/deployment.yaml", Line: 13, Column: 13},
+			{File: "templates/deployment.yaml", Line: 15, Column: 13},
+		},
+	}
+
+	for path, expected := range expectedSources {
+		valuePath, exists := values[path]
+		if !exists {
+			t.Errorf("Expected path %s not found", path)
+			continue
+		}
+		if !reflect.DeepEqual(valuePath.Sources, expected) {
+			t.Errorf("Path %s has sources %v, expected %v", path, valuePath.Sources, expected)
+		}
+	}
+}
+
+func TestParseRangeValues(t *testing.T) {
+	parser := New()
+	if err := parser.ParseChartWithOptions("../../test-charts/tenants", false); err != nil {
+		t.Fatalf("Failed to parse chart: %v", err)
+	}
+
+	values := parser.GetValues()
+
+	expectedPaths := map[string]string{
+		"image":            "object",
+		"image.repository": "unknown",
+		// range $name, $tenant := .Values.tenants
+		"tenants":   "map",
+		"tenants.*": "object",
+		// $tenant.namespace
+		"tenants.*.namespace": "unknown",
+		// .replicas and .image.tag, read through the rebound dot
+		"tenants.*.replicas":  "unknown",
+		"tenants.*.image":     "object",
+		"tenants.*.image.tag": "unknown",
+		// with .resources rebinds the dot to a field of the tenant
+		"tenants.*.resources":        "object",
+		"tenants.*.resources.limits": "unknown",
+		// range $key, $var := $tenant.env
+		"tenants.*.env":         "map",
+		"tenants.*.env.*":       "object",
+		"tenants.*.env.*.value": "unknown",
+	}
+
+	for expectedPath, expectedType := range expectedPaths {
+		if valuePath, exists := values[expectedPath]; !exists {
+			t.Errorf("Expected path %s not found", expectedPath)
+		} else if valuePath.Type != expectedType {
+			t.Errorf("Path %s has type %s, expected %s", expectedPath, valuePath.Type, expectedType)
+		}
+	}
+
+	if len(values) != len(expectedPaths) {
+		t.Errorf("Expected %d paths, found %d", len(expectedPaths), len(values))
+		for path := range values {
+			t.Logf("Found path: %s (%s)", path, values[path].Type)
+		}
+	}
+}
+
+func TestParseNestedRanges(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "ingress.yaml")
+	template := `rules:
+{{- range .Values.ingress.hosts }}
+  - host: {{ .host | quote }}
+    http:
+      paths:
+      {{- range .paths }}
+        - path: {{ .path }}
+          pathType: {{ .pathType }}
+      {{- end }}
+{{- end }}
+tls:
+{{- range $tls := .Values.ingress.tls }}
+  - secretName: {{ $tls.secretName }}
+    {{- with .hosts }}
+    hosts: {{ toYaml . | nindent 6 }}
+    {{- end }}
+{{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	values := parser.GetValues()
+
+	expectedPaths := map[string]string{
+		"ingress": "object",
+		// range .Values.ingress.hosts
+		"ingress.hosts":        "array",
+		"ingress.hosts[]":      "object",
+		"ingress.hosts[].host": "unknown",
+		// range .paths within the hosts body
+		"ingress.hosts[].paths":            "array",
+		"ingress.hosts[].paths[]":          "object",
+		"ingress.hosts[].paths[].path":     "unknown",
+		"ingress.hosts[].paths[].pathType": "unknown",
+		// range $tls := .Values.ingress.tls, and with .hosts reading the item
+		"ingress.tls":              "array",
+		"ingress.tls[]":            "object",
+		"ingress.tls[].secretName": "unknown",
+		"ingress.tls[].hosts":      "unknown",
+	}
+
+	for expectedPath, expectedType := range expectedPaths {
+		if valuePath, exists := values[expectedPath]; !exists {
+			t.Errorf("Expected path %s not found", expectedPath)
+		} else if valuePath.Type != expectedType {
+			t.Errorf("Path %s has type %s, expected %s", expectedPath, valuePath.Type, expectedType)
+		}
+	}
+
+	if len(values) != len(expectedPaths) {
+		t.Errorf("Expected %d paths, found %d", len(expectedPaths), len(values))
+		for path := range values {
+			t.Logf("Found path: %s (%s)", path, values[path].Type)
+		}
+	}
+}
+
+func TestRangeVariablesScopedToBody(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "deployment.yaml")
+	template := `env:
+{{- range $k, $v := .Values.extraEnv }}
+  - name: {{ $v.name }}
+    value: {{ $v.value | quote }}
+{{- end }}
+{{- range $k, $v := .Values.sidecars }}
+  - image: {{ $v.image }}
+  {{- range $k, $v := $v.env }}
+    {{ $k }}: {{ $v.value }}
+  {{- end }}
+    pull: {{ $v.pullPolicy }}
+{{- end }}
+{{- range $item := .Values.volumes }}
+  - {{ $item.name }}
+{{- end }}
+{{- range $item := .Values.mounts }}
+  - {{ $item.path }}
+{{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	values := parser.GetValues()
+
+	expectedPaths := map[string]string{
+		"extraEnv":               "map",
+		"extraEnv.*":             "object",
+		"extraEnv.*.name":        "unknown",
+		"extraEnv.*.value":       "unknown",
+		"sidecars":               "map",
+		"sidecars.*":             "object",
+		"sidecars.*.image":       "unknown",
+		"sidecars.*.pullPolicy":  "unknown",
+		"sidecars.*.env":         "map",
+		"sidecars.*.env.*":       "object",
+		"sidecars.*.env.*.value": "unknown",
+		"volumes":                "array",
+		"volumes[]":              "object",
+		"volumes[].name":         "unknown",
+		"mounts":                 "array",
+		"mounts[]":               "object",
+		"mounts[].path":          "unknown",
+	}
+
+	for expectedPath, expectedType := range expectedPaths {
+		if valuePath, exists := values[expectedPath]; !exists {
+			t.Errorf("Expected path %s not found", expectedPath)
+		} else if valuePath.Type != expectedType {
+			t.Errorf("Path %s has type %s, expected %s", expectedPath, valuePath.Type, expectedType)
+		}
+	}
+
+	if len(values) != len(expectedPaths) {
+		t.Errorf("Expected %d paths, found %d", len(expectedPaths), len(values))
+		for path := range values {
+			t.Logf("Found path: %s (%s)", path, values[path].Type)
+		}
+	}
+}
+
+func TestRangeItemUsage(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "secret.yaml")
+	template := `data:
+{{- range .Values.tokens }}
+  - {{ . | b64enc }}
+{{- end }}
+ratios:
+{{- range $ratio := .Values.ratios }}
+  - {{ $ratio | mulf 100 }}
+{{- end }}
+{{- range $name, $weight := .Values.weights }}
+  {{ $name }}: {{ $weight | round }}
+{{- end }}
+hosts:
+{{- range .Values.hosts }}
+  - {{ .name }}
+{{- end }}
+names:
+{{- range $name := .Values.names }}
+  - {{ $name }}
+{{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	values := parser.GetValues()
+
+	expectedPaths := map[string]string{
+		"tokens":       "array",
+		"tokens[]":     "string", // Piped through b64enc
+		"ratios":       "array",
+		"ratios[]":     "number", // Piped through mulf
+		"weights":      "map",
+		"weights.*":    "number", // Piped through round
+		"hosts":        "array",
+		"hosts[]":      "object", // Fields read
+		"hosts[].name": "unknown",
+		"names":        "array",
+		"names[]":      "unknown", // Interpolated without evidence of a type
+	}
+
+	for expectedPath, expectedType := range expectedPaths {
+		if valuePath, exists := values[expectedPath]; !exists {
+			t.Errorf("Expected path %s not found", expectedPath)
+		} else if valuePath.Type != expectedType {
+			t.Errorf("Path %s has type %s, expected %s", expectedPath, valuePath.Type, expectedType)
+		}
+	}
+
+	if len(values) != len(expectedPaths) {
+		t.Errorf("Expected %d paths, found %d", len(expectedPaths), len(values))
+		for path := range values {
+			t.Logf("Found path: %s (%s)", path, values[path].Type)
+		}
+	}
+}
+
+func TestIndexedRange(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "configmap.yaml")
+	template := `data:
+  servers: |
+{{- range $i, $server := .Values.servers }}
+    server.{{ add1 $i }}={{ $server.host }}:{{ $server.port }}
+{{- end }}
+  peers: {{ range $i, $peer := .Values.peers }}{{ if ne $i 0 }},{{ end }}{{ $peer }}{{ end }}
+  ordered: {{ range $n, $zone := .Values.zones }}{{ if gt $n 0 }} {{ end }}{{ .name }}{{ end }}
+{{- $last := sub (len .Values.brokers) 1 }}
+  brokers: {{ range $i, $broker := .Values.brokers }}{{ $broker }}{{ if ne $i $last }},{{ end }}{{ end }}
+  seeds: {{ range $i, $seed := .Values.seeds }}{{ $seed }}{{ if ne $i (sub (len $.Values.seeds) 1) }},{{ end }}{{ end }}
+{{- range $key, $value := .Values.labels }}
+  {{ $key }}: {{ $value | quote }}
+{{- end }}
+{{- range $key, $value := .Values.annotations }}
+  {{- if eq $key "team" }}
+  team: {{ $value.name }}
+  {{- end }}
+{{- end }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Failed to parse template: %v", err)
+	}
+
+	values := parser.GetValues()
+
+	expectedPaths := map[string]string{
+		"servers":            "array", // The key is incremented with add1
+		"servers[]":          "object",
+		"servers[].host":     "unknown",
+		"servers[].port":     "unknown",
+		"peers":              "array", // The key is compared with a number
+		"peers[]":            "unknown",
+		"zones":              "array", // The key is ordered with gt
+		"zones[]":            "object",
+		"zones[].name":       "unknown",
+		"brokers":            "array", // The key is compared with the last index
+		"brokers[]":          "unknown",
+		"seeds":              "array", // The key is compared with a length
+		"seeds[]":            "unknown",
+		"labels":             "map",
+		"labels.*":           "unknown",
+		"annotations":        "map", // The key is compared with a string
+		"annotations.*":      "object",
+		"annotations.*.name": "unknown",
+	}
+
+	for expectedPath, expectedType := range expectedPaths {
+		if valuePath, exists := values[expectedPath]; !exists {
+			t.Errorf("Expected path %s not found", expectedPath)
+		} else if valuePath.Type != expectedType {
+			t.Errorf("Path %s has type %s, expected %s", expectedPath, valuePath.Type, expectedType)
+		}
+	}
+
+	if len(values) != len(expectedPaths) {
+		t.Errorf("Expected %d paths, found %d", len(expectedPaths), len(values))
+		for path := range values {
+			t.Logf("Found path: %s (%s)", path, values[path].Type)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Kinds of constructs touching values that the parser cannot resolve
+const (
+	UnresolvedVariable  = "unknown-variable"     // Field access on a variable that is never declared
+	UnresolvedKey       = "dynamic-key"          // Lookup of a value by a computed key
+	UnresolvedFunction  = "unsupported-function" // Value passed through a function whose result is opaque
+	UnresolvedTpl       = "tpl"                  // Value rendered as a template, hiding the values it reads
+	UnresolvedDirective = "invalid-directive"    // Directive comment that cannot be parsed or is next to no value
+)
+
+// opaqueFunctions derive new structures from their arguments in ways the parser does not follow,
+// so fields read from their results cannot be traced back to values
+var opaqueFunctions = map[string]bool{
+	"merge":          true,
+	"mergeOverwrite": true,
+	"deepCopy":       true,
+	"pick":           true,
+	"omit":           true,
+	"pluck":          true,
+	"dig":            true,
+	"set":            true,
+	"unset":          true,
+}
+
+// lookupFunctions select a key of their first argument
+var lookupFunctions = map[string]bool{
+	"index":  true,
+	"get":    true,
+	"hasKey": true,
+}
+
+var (
+	// Match: variable declarations, $x := ... and range $k, $v := ...
+	declaredVariableRe = regexp.MustCompile(`\$(` + identifier + `)\s*(?:,\s*\$(` + identifier + `)\s*)?:?=`)
+	// Match: literal lookup keys, "key" or 0
+	literalKeyRe = regexp.MustCompile(`^(?:"[^"]*"|\d+)$`)
+)
+
+// Unresolved describes a construct touching values that the parser could not resolve, leaving
+// the schema incomplete
+type Unresolved struct {
+	Kind    string
+	Site    Site
+	Snippet string
+}
+
+// String formats the construct with its location
+func (u Unresolved) String() string {
+	return fmt.Sprintf("%s: %s: %s", u.Site, u.Kind, u.Snippet)
+}
+
+// Unresolved returns the unresolvable constructs found in the chart and its subcharts
+func (tp *TemplateParser) Unresolved() []Unresolved {
+	unresolved := append([]Unresolved{}, tp.unresolved...)
+	for name, subchart := range tp.subcharts {
+		for _, construct := range subchart.Unresolved() {
+			construct.Site.File = name + "/" + construct.Site.File
+			unresolved = append(unresolved, construct)
+		}
+	}
+	return unresolved
+}
+
+// parseUnresolved records constructs in content that touch values in ways the other passes
+// cannot follow, returning the ones found in this content
+func (tp *TemplateParser) parseUnresolved(content string) []Unresolved {
+	index := tp.indexOf(content)
+	masked, actions := index.masked, index.actions
+
+	declared := make(map[string]bool)
+	for _, match := range declaredVariableRe.FindAllStringSubmatch(masked, -1) {
+		declared[match[1]] = true
+		declared[match[2]] = true
+	}
+
+	var found []Unresolved
+	report := func(kind string, offset int, snippet string) {
+		site := tp.siteAt(index, offset)
+		if tp.ignoredSite(site) {
+			return
+		}
+		found = append(found, Unresolved{Kind: kind, Site: site, Snippet: strings.Join(strings.Fields(snippet), " ")})
+	}
+
+	for _, match := range tp.varRefRe.FindAllStringSubmatchIndex(masked, -1) {
+		name := masked[match[2]:match[3]]
+		if _, bound := tp.variables[name]; !bound && !declared[name] {
+			report(UnresolvedVariable, match[0], content[match[0]:match[5]])
+		}
+	}
+
+	for _, span := range actions {
+		start, end := enclosingGroup(masked[span[0]:span[1]], 0)
+		tp.unresolvedPipeline(content, masked, span[0]+start, span[0]+end, report)
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Site.Line != found[j].Site.Line {
+			return found[i].Site.Line < found[j].Site.Line
+		}
+		return found[i].Site.Column < found[j].Site.Column
+	})
+	tp.unresolved = append(tp.unresolved, found...)
+	return found
+}
+
+// unresolvedPipeline reports the commands of the pipeline at masked[start:end], and of the
+// parenthesized groups within it, that look up computed keys of values or pass values through
+// opaque functions
+func (tp *TemplateParser) unresolvedPipeline(content, masked string, start, end int, report func(string, int, string)) {
+	pipedValue := false
+	offset := start
+	for _, command := range splitPipeline(masked[start:end]) {
+		spans := commandArgSpans(command)
+		var args []string
+		for _, span := range spans {
+			arg := command[span[0]:span[1]]
+			args = append(args, arg)
+			// Groups may be followed by field selections, as in (... | deepCopy).name
+			if strings.HasPrefix(arg, "(") {
+				if close := matchingParen(arg, 0); close > 0 {
+					tp.unresolvedPipeline(content, masked, offset+span[0]+1, offset+span[0]+close, report)
+				}
+			}
+		}
+
+		isValue := func(i int) bool {
+			if i < len(args) {
+				_, ok := tp.resolveOperand(args[i])
+				return ok
+			}
+			// The piped value is passed as the last argument
+			return i == len(args) && pipedValue
+		}
+
+		if len(args) > 0 {
+			commandStart := offset + spans[0][0]
+			snippet := content[commandStart : offset+len(command)]
+			kind := UnresolvedFunction
+			if args[0] == "tpl" {
+				kind = UnresolvedTpl
+			}
+			switch {
+			case mutationFunctions[args[0]] && isValue(1) && len(args) > 2 && literalKeyRe.MatchString(args[2]):
+				// Writes to literal keys are recorded as mutations
+			case opaqueFunctions[args[0]] || args[0] == "tpl":
+				for i := 1; i <= len(args); i++ {
+					if !isValue(i) {
+						continue
+					}
+					if i == len(args) {
+						// Show the value being piped in along with the function
+						report(kind, start, content[start:offset+len(command)])
+					} else {
+						report(kind, commandStart, snippet)
+					}
+					break
+				}
+			case lookupFunctions[args[0]] && isValue(1):
+				for _, key := range args[2:] {
+					if !literalKeyRe.MatchString(key) {
+						report(UnresolvedKey, commandStart, snippet)
+						break
+					}
+				}
+			}
+		}
+
+		pipedValue = len(args) == 1 && isValue(0)
+		offset += len(command) + 1
+	}
+}
@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnresolvedConstructs(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "configmap.yaml")
+	template := `{{- $cfg := merge .Values.overrides .Values.defaults }}
+{{- $key := .Values.selector }}
+{{- range $name, $tenant := .Values.tenants }}
+{{ $name }}: {{ $tenant.port }}
+{{- end }}
+data:
+  port: {{ $cfg.port }}
+  dynamic: {{ index .Values.config $key | quote }}
+  fixed: {{ index .Values.config "fixed" 0 }}
+  copied: {{ (.Values.extra | deepCopy).name }}
+  missing: {{ $missing.field }}
+  rendered: {{ tpl .Values.template . }}
+`
+	if err := os.WriteFile(templatePath, []byte(template), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	parser := New()
+	if err := parser.ParseTemplateFile(templatePath); err != nil {
+		t.Fatalf("Lenient parsing should not fail: %v", err)
+	}
+
+	expected := []struct {
+		kind string
+		line int
+	}{
+		{UnresolvedFunction, 1},
+		{UnresolvedKey, 8},
+		{UnresolvedFunction, 10},
+		{UnresolvedVariable, 11},
+		{UnresolvedTpl, 12},
+	}
+
+	unresolved := parser.Unresolved()
+	if len(unresolved) != len(expected) {
+		t.Fatalf("Expected %d unresolved constructs, got %v", len(expected), unresolved)
+	}
+	for i, want := range expected {
+		if unresolved[i].Kind != want.kind || unresolved[i].Site.Line != want.line {
+			t.Errorf("Construct %d: expected %s on line %d, got %s", i, want.kind, want.line, unresolved[i])
+		}
+	}
+	if unresolved[2].Snippet != ".Values.extra | deepCopy" {
+		t.Errorf("Piped values should be shown with the function, got %q", unresolved[2].Snippet)
+	}
+
+	strict := NewWithOptions(Options{Strict: true})
+	err := strict.ParseTemplateFile(templatePath)
+	if err == nil {
+		t.Fatal("Strict parsing should fail on unresolvable constructs")
+	}
+	if !strings.Contains(err.Error(), "$missing.field") {
+		t.Errorf("Error should list the unresolved constructs: %v", err)
+	}
+}
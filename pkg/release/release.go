@@ -0,0 +1,222 @@
+package release
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Release is an installed Helm release as recorded in Helm's storage
+type Release struct {
+	Name      string         `json:"name"`
+	Namespace string         `json:"namespace"`
+	Version   int            `json:"version"`
+	Info      Info           `json:"info"`
+	Chart     Chart          `json:"chart"`
+	Config    map[string]any `json:"config"` // User-supplied values
+}
+
+// Info holds the release status
+type Info struct {
+	Status string `json:"status"`
+}
+
+// Chart is the chart a release was installed from; Helm does not persist its dependencies
+type Chart struct {
+	Metadata  map[string]any `json:"metadata"`
+	Templates []File         `json:"templates"`
+	Files     []File         `json:"files"`
+	Values    map[string]any `json:"values"`
+}
+
+// File is a chart file relative to the chart root
+type File struct {
+	Name string `json:"name"`
+	Data []byte `json:"data"`
+}
+
+// gzipMagic prefixes gzip compressed payloads
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Decode parses a release payload from Helm's storage. Payloads are gzip compressed JSON,
+// base64 encoded by Helm and, when read from a Secret, base64 encoded once more.
+func Decode(payload string) (*Release, error) {
+	data := []byte(payload)
+	for i := 0; i < 2 && !bytes.HasPrefix(data, gzipMagic) && !bytes.HasPrefix(data, []byte("{")); i++ {
+		decoded, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("decoding release payload: %w", err)
+		}
+		data = decoded
+	}
+
+	if bytes.HasPrefix(data, gzipMagic) {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decompressing release payload: %w", err)
+		}
+		defer reader.Close()
+		if data, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("decompressing release payload: %w", err)
+		}
+	}
+
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, fmt.Errorf("parsing release payload: %w", err)
+	}
+	return &release, nil
+}
+
+// ChartName returns the name of the release's chart
+func (r *Release) ChartName() string {
+	name, _ := r.Chart.Metadata["name"].(string)
+	return name
+}
+
+// ChartVersion returns the version of the release's chart
+func (r *Release) ChartVersion() string {
+	version, _ := r.Chart.Metadata["version"].(string)
+	return version
+}
+
+// Dependencies returns the keys under which the chart's dependencies read their values
+func (r *Release) Dependencies() []string {
+	dependencies, _ := r.Chart.Metadata["dependencies"].([]any)
+
+	var keys []string
+	for _, dependency := range dependencies {
+		fields, ok := dependency.(map[string]any)
+		if !ok {
+			continue
+		}
+		key, _ := fields["alias"].(string)
+		if key == "" {
+			key, _ = fields["name"].(string)
+		}
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// WriteChart materializes the release's chart below dir and returns the chart directory
+func (r *Release) WriteChart(dir string) (string, error) {
+	name := r.ChartName()
+	if name == "" {
+		return "", fmt.Errorf("release %s has no chart metadata", r.Name)
+	}
+	chartPath := filepath.Join(dir, name)
+
+	chartYAML, err := yaml.Marshal(r.Chart.Metadata)
+	if err != nil {
+		return "", fmt.Errorf("encoding Chart.yaml: %w", err)
+	}
+	valuesYAML, err := yaml.Marshal(r.Chart.Values)
+	if err != nil {
+		return "", fmt.Errorf("encoding values.yaml: %w", err)
+	}
+
+	files := []File{{Name: "Chart.yaml", Data: chartYAML}, {Name: "values.yaml", Data: valuesYAML}}
+	files = append(files, r.Chart.Files...)
+	files = append(files, r.Chart.Templates...)
+
+	for _, file := range files {
+		target := filepath.Join(chartPath, filepath.FromSlash(file.Name))
+		if !strings.HasPrefix(target, chartPath+string(filepath.Separator)) {
+			return "", fmt.Errorf("illegal chart file path: %s", file.Name)
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return "", err
+		}
+		if err := os.WriteFile(target, file.Data, 0644); err != nil {
+			return "", err
+		}
+	}
+
+	// Charts without templates still need the directory to be recognized as a chart
+	if err := os.MkdirAll(filepath.Join(chartPath, "templates"), 0755); err != nil {
+		return "", err
+	}
+
+	return chartPath, nil
+}
+
+// Client reads releases from a cluster's Helm storage through kubectl
+type Client struct {
+	Kubeconfig string
+	Context    string
+}
+
+// secretList is the subset of kubectl's Secret list output holding release payloads
+type secretList struct {
+	Items []struct {
+		Data map[string]string `json:"data"`
+	} `json:"items"`
+}
+
+// Deployed returns the currently deployed revision of every release, optionally restricted to
+// a namespace, ordered by namespace and name
+func (c *Client) Deployed(namespace string) ([]*Release, error) {
+	args := []string{"get", "secrets", "-l", "owner=helm,status=deployed", "-o", "json"}
+	if namespace != "" {
+		args = append(args, "--namespace", namespace)
+	} else {
+		args = append(args, "--all-namespaces")
+	}
+	if c.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", c.Kubeconfig)
+	}
+	if c.Context != "" {
+		args = append(args, "--context", c.Context)
+	}
+
+	cmd := exec.Command("kubectl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("kubectl get secrets failed: %w\nOutput: %s", err, stderr.String())
+	}
+
+	var secrets secretList
+	if err := json.Unmarshal(output, &secrets); err != nil {
+		return nil, fmt.Errorf("parsing kubectl output: %w", err)
+	}
+
+	latest := make(map[string]*Release)
+	for _, secret := range secrets.Items {
+		release, err := Decode(secret.Data["release"])
+		if err != nil {
+			return nil, err
+		}
+		key := release.Namespace + "/" + release.Name
+		if existing, ok := latest[key]; !ok || release.Version > existing.Version {
+			latest[key] = release
+		}
+	}
+
+	var releases []*Release
+	for _, release := range latest {
+		releases = append(releases, release)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		if releases[i].Namespace != releases[j].Namespace {
+			return releases[i].Namespace < releases[j].Namespace
+		}
+		return releases[i].Name < releases[j].Name
+	})
+
+	return releases, nil
+}
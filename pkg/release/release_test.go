@@ -0,0 +1,100 @@
+package release
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// encodeRelease produces a payload as stored in a Helm release Secret
+func encodeRelease(t *testing.T, release map[string]any) string {
+	t.Helper()
+	data, err := json.Marshal(release)
+	if err != nil {
+		t.Fatalf("Failed to encode release: %v", err)
+	}
+
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	writer.Write(data)
+	writer.Close()
+
+	helmEncoded := base64.StdEncoding.EncodeToString(compressed.Bytes())
+	return base64.StdEncoding.EncodeToString([]byte(helmEncoded))
+}
+
+func TestDecodeAndWriteChart(t *testing.T) {
+	payload := encodeRelease(t, map[string]any{
+		"name":      "web",
+		"namespace": "prod",
+		"version":   3,
+		"info":      map[string]any{"status": "deployed"},
+		"chart": map[string]any{
+			"metadata": map[string]any{
+				"apiVersion": "v2",
+				"name":       "web",
+				"version":    "1.2.0",
+				"dependencies": []any{
+					map[string]any{"name": "redis", "alias": "cache"},
+					map[string]any{"name": "postgresql"},
+				},
+			},
+			"templates": []any{
+				map[string]any{"name": "templates/deployment.yaml", "data": []byte("image: {{ .Values.image }}")},
+			},
+			"values": map[string]any{"image": "nginx"},
+		},
+		"config": map[string]any{"image": "nginx:1.25"},
+	})
+
+	release, err := Decode(payload)
+	if err != nil {
+		t.Fatalf("Failed to decode release: %v", err)
+	}
+
+	if release.Name != "web" || release.Namespace != "prod" || release.Version != 3 {
+		t.Errorf("Unexpected release identity: %s/%s v%d", release.Namespace, release.Name, release.Version)
+	}
+	if release.ChartName() != "web" || release.ChartVersion() != "1.2.0" {
+		t.Errorf("Unexpected chart: %s-%s", release.ChartName(), release.ChartVersion())
+	}
+	if release.Config["image"] != "nginx:1.25" {
+		t.Errorf("Expected user-supplied image, got %v", release.Config["image"])
+	}
+
+	dependencies := release.Dependencies()
+	if len(dependencies) != 2 || dependencies[0] != "cache" || dependencies[1] != "postgresql" {
+		t.Errorf("Expected dependency keys [cache postgresql], got %v", dependencies)
+	}
+
+	chartPath, err := release.WriteChart(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to write chart: %v", err)
+	}
+
+	template, err := os.ReadFile(filepath.Join(chartPath, "templates", "deployment.yaml"))
+	if err != nil || string(template) != "image: {{ .Values.image }}" {
+		t.Errorf("Template not restored: %q, %v", template, err)
+	}
+	if _, err := os.Stat(filepath.Join(chartPath, "Chart.yaml")); err != nil {
+		t.Errorf("Chart.yaml not written: %v", err)
+	}
+}
+
+func TestDecodeConfigMapPayload(t *testing.T) {
+	// The ConfigMap driver stores Helm's encoding without the extra Secret layer
+	payload := encodeRelease(t, map[string]any{"name": "api"})
+	helmEncoded, _ := base64.StdEncoding.DecodeString(payload)
+
+	release, err := Decode(string(helmEncoded))
+	if err != nil {
+		t.Fatalf("Failed to decode release: %v", err)
+	}
+	if release.Name != "api" {
+		t.Errorf("Expected release api, got %s", release.Name)
+	}
+}
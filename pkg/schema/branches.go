@@ -0,0 +1,86 @@
+package schema
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/parser"
+)
+
+// addExclusiveBranches accepts at most one of the values testing the branches of an if/else if
+// chain being truthy, as the chart only renders the branch of the first, with a oneOf on the
+// innermost object holding them: one member per value, and one for none of them. Chains testing
+// a value with a truthy default, which would always be set, and values within lists or maps
+// are left out.
+func addExclusiveBranches(schema map[string]any, values map[string]*parser.ValuePath) {
+	var paths []string
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	seen := make(map[string]bool)
+	for _, path := range paths {
+		for _, chain := range values[path].Exclusive {
+			key := strings.Join(chain, ",")
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			addExclusiveChain(schema, values, chain)
+		}
+	}
+}
+
+// addExclusiveChain adds the oneOf of a single chain
+func addExclusiveChain(schema map[string]any, values map[string]*parser.ValuePath, chain []string) {
+	var owner []string
+	for i, path := range chain {
+		valuePath := values[path]
+		if valuePath == nil || !plainPath(path) || truthy(valuePath.Default) {
+			return
+		}
+		if i == 0 {
+			owner = parser.SplitPath(path)
+		} else {
+			owner = commonPrefix(owner, parser.SplitPath(path))
+		}
+	}
+	node := objectAt(schema, owner)
+	if node == nil {
+		return
+	}
+
+	// Members are built anew for each use, so none is shared within the schema
+	selected := func() []any {
+		var members []any
+		for _, path := range chain {
+			member := make(map[string]any)
+			requirePath(member, parser.SplitPath(path)[len(owner):], truthySchema(values[path]))
+			members = append(members, member)
+		}
+		return members
+	}
+	oneOf := append(selected(), map[string]any{"not": map[string]any{"anyOf": selected()}})
+
+	if _, exists := node["oneOf"]; !exists {
+		node["oneOf"] = oneOf
+		return
+	}
+	allOf, _ := node["allOf"].([]any)
+	node["allOf"] = append(allOf, map[string]any{"oneOf": oneOf})
+}
+
+// truthy reports whether Go templates treat a value as true
+func truthy(value any) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() > 0
+	default:
+		return !v.IsZero()
+	}
+}
@@ -0,0 +1,199 @@
+package schema
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/parser"
+)
+
+// conditionalKeywords are the keywords of an object schema applying subschemas or requirements
+// to it as a whole
+var conditionalKeywords = []string{"if", "then", "allOf", "oneOf", "dependentRequired", "dependentSchemas", "dependencies"}
+
+// falsyValues are the values Go templates treat as false
+var falsyValues = []any{false, 0, "", nil, []any{}, map[string]any{}}
+
+// conditional requires values when others are truthy, on the object holding them all
+type conditional struct {
+	guards   [][]string // Paths of the values tested, relative to the object
+	required [][]string // Paths of the values required
+}
+
+// addConditionals requires the values the templates only render within blocks testing other
+// values, such as ingress.host within {{ if .Values.ingress.enabled }}, when those are truthy.
+// if/then keywords go on the innermost object holding the values tested and required, in allOf
+// when it holds several. Values the templates test themselves or give a fallback, and values
+// within lists or maps, are left out.
+func addConditionals(schema map[string]any, values map[string]*parser.ValuePath) {
+	byOwner := make(map[string]map[string]*conditional)
+	var paths []string
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		valuePath := values[path]
+		if len(valuePath.Guards) == 0 || valuePath.Required || valuePath.Optional || valuePath.Default != nil || !plainPath(path) {
+			continue
+		}
+		segments := parser.SplitPath(path)
+		owner := segments[:len(segments)-1]
+		usable := true
+		for _, guard := range valuePath.Guards {
+			if !plainPath(guard) {
+				usable = false
+				break
+			}
+			owner = commonPrefix(owner, parser.SplitPath(guard))
+		}
+		if !usable {
+			continue
+		}
+
+		ownerPath := strings.Join(owner, ".")
+		if byOwner[ownerPath] == nil {
+			byOwner[ownerPath] = make(map[string]*conditional)
+		}
+		key := strings.Join(valuePath.Guards, ",")
+		group, exists := byOwner[ownerPath][key]
+		if !exists {
+			group = &conditional{}
+			for _, guard := range valuePath.Guards {
+				group.guards = append(group.guards, parser.SplitPath(guard)[len(owner):])
+			}
+			byOwner[ownerPath][key] = group
+		}
+		group.required = append(group.required, segments[len(owner):])
+	}
+
+	for ownerPath, groups := range byOwner {
+		var owner []string
+		if ownerPath != "" {
+			owner = parser.SplitPath(ownerPath)
+		}
+		node := objectAt(schema, owner)
+		if node == nil {
+			continue
+		}
+
+		var keys []string
+		for key := range groups {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		var entries []any
+		for _, key := range keys {
+			group := groups[key]
+			condition, consequence := make(map[string]any), make(map[string]any)
+			for _, guard := range group.guards {
+				requirePath(condition, guard, truthySchema(values[joinPath(owner, guard)]))
+			}
+			for _, required := range group.required {
+				requirePath(consequence, required, nil)
+			}
+			entries = append(entries, map[string]any{"if": condition, "then": consequence})
+		}
+
+		if _, exists := node["if"]; !exists && len(entries) == 1 {
+			for keyword, value := range entries[0].(map[string]any) {
+				node[keyword] = value
+			}
+			continue
+		}
+		allOf, _ := node["allOf"].([]any)
+		node["allOf"] = append(allOf, entries...)
+	}
+}
+
+// copyConditionals carries the conditional keywords of a chart's schema over to the object
+// describing its values in a merged schema
+func copyConditionals(dst, src map[string]any) {
+	for _, keyword := range conditionalKeywords {
+		if value, ok := src[keyword]; ok {
+			dst[keyword] = value
+		}
+	}
+}
+
+// truthySchema accepts the values Go templates treat as true, only true for flags
+func truthySchema(valuePath *parser.ValuePath) map[string]any {
+	if valuePath != nil {
+		if _, flag := valuePath.Default.(bool); flag || valuePath.Type == "boolean" {
+			return map[string]any{"const": true}
+		}
+	}
+	return map[string]any{"not": map[string]any{"enum": falsyValues}}
+}
+
+// requirePath requires the value at the segments of a path below a schema, applying leaf to it
+// when set
+func requirePath(schema map[string]any, segments []string, leaf map[string]any) {
+	key := parser.UnescapeKey(segments[0])
+	required, _ := schema["required"].([]string)
+	if !slices.Contains(required, key) {
+		schema["required"] = append(required, key)
+	}
+	if len(segments) == 1 && leaf == nil {
+		return
+	}
+
+	properties, ok := schema["properties"].(map[string]any)
+	if !ok {
+		properties = make(map[string]any)
+		schema["properties"] = properties
+	}
+	child, ok := properties[key].(map[string]any)
+	if !ok {
+		child = make(map[string]any)
+		properties[key] = child
+	}
+	if len(segments) > 1 {
+		requirePath(child, segments[1:], leaf)
+		return
+	}
+	for keyword, value := range leaf {
+		child[keyword] = value
+	}
+}
+
+// objectAt returns the object schema describing the value at the segments of a path
+func objectAt(schema map[string]any, segments []string) map[string]any {
+	node := schema
+	for _, segment := range segments {
+		properties, ok := node["properties"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		if node, ok = properties[parser.UnescapeKey(segment)].(map[string]any); !ok {
+			return nil
+		}
+	}
+	return node
+}
+
+// plainPath reports whether a path leads to a single value, through no list or map key
+func plainPath(path string) bool {
+	for _, segment := range parser.SplitPath(path) {
+		if segment == parser.AnyKey || strings.HasSuffix(segment, "[]") {
+			return false
+		}
+	}
+	return true
+}
+
+// joinPath returns the path of the value at the segments of rest below those of prefix
+func joinPath(prefix, rest []string) string {
+	return strings.Join(append(slices.Clone(prefix), rest...), ".")
+}
+
+// commonPrefix returns the segments two paths start with
+func commonPrefix(a, b []string) []string {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return a[:n]
+}
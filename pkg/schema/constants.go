@@ -0,0 +1,37 @@
+package schema
+
+import (
+	"reflect"
+
+	"helm-schema/pkg/parser"
+)
+
+// Constants returns the values the templates only ever compare with one literal, by path, with
+// that literal. Such values are effectively hard-coded: the chart tells apart the literal from
+// anything else, whatever else is set.
+func Constants(values map[string]*parser.ValuePath) map[string]any {
+	constants := make(map[string]any)
+	for path, valuePath := range values {
+		if literal, ok := constantOf(valuePath); ok {
+			constants[path] = literal
+		}
+	}
+	return constants
+}
+
+// constantOf returns the literal a value is only compared with, unless its default differs, in
+// which case the chart would not validate against its own values
+func constantOf(valuePath *parser.ValuePath) (any, bool) {
+	literal, ok := valuePath.ComparedLiteral()
+	if !ok || (valuePath.Default != nil && !reflect.DeepEqual(valuePath.Default, literal)) {
+		return nil, false
+	}
+	return literal, true
+}
+
+// addConstant restricts a value the templates only compare with one literal to that literal
+func addConstant(prop map[string]any, valuePath *parser.ValuePath) {
+	if literal, ok := constantOf(valuePath); ok {
+		prop["const"] = literal
+	}
+}
@@ -0,0 +1,157 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Deduplicate hoists the object schemas occurring more than once, such as the image or resources
+// blocks umbrella charts repeat across their dependencies, into the definitions of the schema
+// ($defs, or definitions for draft-07) and replaces every occurrence with a $ref. Schemas are
+// identical when they serialize identically, descriptions and defaults included; those found
+// within hoisted ones are shared too. It returns the number of definitions added.
+func Deduplicate(schema map[string]any) int {
+	keyword := DefinitionsKeyword(DraftOf(schema))
+	defs, _ := schema[keyword].(map[string]any)
+	if defs == nil {
+		defs = make(map[string]any)
+	}
+	// Definitions by the serialization of their schema
+	names := make(map[string]string)
+	for name, def := range defs {
+		names[canonicalSchema(def)] = name
+	}
+
+	added := 0
+	for {
+		counts := make(map[string]int)
+		var count func(node map[string]any, name string)
+		count = func(node map[string]any, name string) {
+			forEachSubschema(node, name, func(child map[string]any, childName string, _ func(any)) {
+				if hoistable(child) {
+					counts[canonicalSchema(child)]++
+				}
+				count(child, childName)
+			})
+		}
+		count(schema, "")
+		for _, name := range sortedKeys(defs) {
+			if def, ok := defs[name].(map[string]any); ok {
+				count(def, name)
+			}
+		}
+
+		replaced := false
+		var rewrite func(node map[string]any, name string)
+		rewrite = func(node map[string]any, name string) {
+			forEachSubschema(node, name, func(child map[string]any, childName string, replace func(any)) {
+				serialized := canonicalSchema(child)
+				defName, defined := names[serialized]
+				if !hoistable(child) || (!defined && counts[serialized] < 2) {
+					rewrite(child, childName)
+					return
+				}
+				if !defined {
+					defName = definitionName(defs, childName)
+					defs[defName] = child
+					names[serialized] = defName
+					added++
+				}
+				replace(map[string]any{"$ref": "#/" + keyword + "/" + escapePointer(defName)})
+				replaced = true
+			})
+		}
+		// Definitions are rewritten below their root, which they would otherwise reference
+		rewrite(schema, "")
+		for _, name := range sortedKeys(defs) {
+			if def, ok := defs[name].(map[string]any); ok {
+				rewrite(def, name)
+			}
+		}
+
+		if !replaced {
+			break
+		}
+	}
+
+	if len(defs) > 0 {
+		schema[keyword] = defs
+	}
+	return added
+}
+
+// forEachSubschema calls fn with the schemas of the properties, map values, list items and anyOf
+// members of node in a stable order, named after the property they describe, and a function
+// replacing them.
+// Definitions are left out.
+func forEachSubschema(node map[string]any, name string, fn func(child map[string]any, childName string, replace func(any))) {
+	if properties, ok := node["properties"].(map[string]any); ok {
+		for _, key := range sortedKeys(properties) {
+			if child, ok := properties[key].(map[string]any); ok {
+				fn(child, key, func(replacement any) { properties[key] = replacement })
+			}
+		}
+	}
+	if patterns, ok := node["patternProperties"].(map[string]any); ok {
+		for _, pattern := range sortedKeys(patterns) {
+			if child, ok := patterns[pattern].(map[string]any); ok {
+				fn(child, name+"Value", func(replacement any) { patterns[pattern] = replacement })
+			}
+		}
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		fn(items, name+"Item", func(replacement any) { node["items"] = replacement })
+	}
+	if members, ok := node["anyOf"].([]any); ok {
+		for i := range members {
+			if child, ok := members[i].(map[string]any); ok {
+				fn(child, name, func(replacement any) { members[i] = replacement })
+			}
+		}
+	}
+}
+
+// hoistable reports whether a schema is a structure worth sharing: an object with properties
+func hoistable(node map[string]any) bool {
+	properties, ok := node["properties"].(map[string]any)
+	return ok && len(properties) > 0
+}
+
+// canonicalSchema serializes a schema with its keys sorted, so identical schemas compare equal
+func canonicalSchema(node any) string {
+	serialized, err := json.Marshal(node)
+	if err != nil {
+		return fmt.Sprintf("%v", node)
+	}
+	return string(serialized)
+}
+
+// definitionName names a definition after the property it was first found at, numbering it
+// when another definition has that name
+func definitionName(defs map[string]any, name string) string {
+	if name == "" || strings.HasPrefix(name, "Value") || strings.HasPrefix(name, "Item") {
+		name = "schema" + name
+	}
+	candidate := name
+	for i := 2; defs[candidate] != nil; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	return candidate
+}
+
+// escapePointer escapes a key for use as a JSON pointer token
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// sortedKeys returns the keys of a schema map in order
+func sortedKeys(node map[string]any) []string {
+	keys := make([]string, 0, len(node))
+	for key := range node {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
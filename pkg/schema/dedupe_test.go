@@ -0,0 +1,172 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func imageSchema() map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"repository": map[string]any{"type": "string"},
+			"tag":        map[string]any{"type": "string"},
+		},
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	chartSchema := map[string]any{
+		"$schema": SchemaURI(Draft202012),
+		"type":    "object",
+		"properties": map[string]any{
+			"image": imageSchema(),
+			"redis": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"image": imageSchema(),
+					"port":  map[string]any{"type": "integer"},
+				},
+			},
+			"sidecars": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type": "object",
+					"properties": map[string]any{
+						"image": imageSchema(),
+					},
+				},
+			},
+		},
+	}
+
+	if added := Deduplicate(chartSchema); added != 1 {
+		t.Fatalf("expected 1 definition, got %d", added)
+	}
+
+	defs, ok := chartSchema["$defs"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected $defs, got %v", chartSchema)
+	}
+	if !reflect.DeepEqual(defs["image"], imageSchema()) {
+		t.Errorf("expected the image schema under $defs/image, got %v", defs["image"])
+	}
+
+	ref := map[string]any{"$ref": "#/$defs/image"}
+	properties := chartSchema["properties"].(map[string]any)
+	redis := properties["redis"].(map[string]any)["properties"].(map[string]any)
+	items := properties["sidecars"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)
+	for name, got := range map[string]any{
+		"image":            properties["image"],
+		"redis.image":      redis["image"],
+		"sidecars[].image": items["image"],
+	} {
+		if !reflect.DeepEqual(got, ref) {
+			t.Errorf("expected %s to reference the definition, got %v", name, got)
+		}
+	}
+	if reflect.DeepEqual(redis["port"], ref) {
+		t.Errorf("scalars should not be hoisted")
+	}
+}
+
+func TestDeduplicateNested(t *testing.T) {
+	container := func() map[string]any {
+		return map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"image": imageSchema(),
+				"name":  map[string]any{"type": "string"},
+			},
+		}
+	}
+	chartSchema := map[string]any{
+		"$schema": SchemaURI(Draft07),
+		"type":    "object",
+		"properties": map[string]any{
+			"main":   container(),
+			"worker": container(),
+			"image":  imageSchema(),
+		},
+	}
+
+	Deduplicate(chartSchema)
+
+	if _, exists := chartSchema["$defs"]; exists {
+		t.Errorf("draft-07 schemas should keep definitions under definitions")
+	}
+	defs, ok := chartSchema["definitions"].(map[string]any)
+	if !ok {
+		t.Fatalf("expected definitions, got %v", chartSchema)
+	}
+	if len(defs) != 2 {
+		t.Fatalf("expected the container and image definitions, got %v", defs)
+	}
+
+	properties := chartSchema["properties"].(map[string]any)
+	if !reflect.DeepEqual(properties["image"], map[string]any{"$ref": "#/definitions/image"}) {
+		t.Errorf("expected image to reference its definition, got %v", properties["image"])
+	}
+	main := properties["main"].(map[string]any)["$ref"]
+	if main == nil || !reflect.DeepEqual(properties["worker"], properties["main"]) {
+		t.Fatalf("expected main and worker to share a definition, got %v and %v", properties["main"], properties["worker"])
+	}
+	// The image within the shared container is shared as well
+	shared := defs["main"].(map[string]any)["properties"].(map[string]any)
+	if !reflect.DeepEqual(shared["image"], map[string]any{"$ref": "#/definitions/image"}) {
+		t.Errorf("expected the container definition to reference image, got %v", shared["image"])
+	}
+}
+
+func TestDeduplicateNames(t *testing.T) {
+	other := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"digest": map[string]any{"type": "string"},
+		},
+	}
+	chartSchema := map[string]any{
+		"$defs": map[string]any{
+			"image": map[string]any{"type": "string"},
+		},
+		"properties": map[string]any{
+			"a": map[string]any{"type": "object", "properties": map[string]any{"image": imageSchema()}},
+			"b": map[string]any{"type": "object", "properties": map[string]any{"image": imageSchema(), "x": map[string]any{"type": "string"}}},
+			"c": map[string]any{"type": "object", "properties": map[string]any{"image": other}},
+			"d": map[string]any{"type": "object", "properties": map[string]any{"image": other, "y": map[string]any{"type": "string"}}},
+		},
+	}
+
+	if added := Deduplicate(chartSchema); added != 2 {
+		t.Fatalf("expected 2 definitions, got %d", added)
+	}
+	defs := chartSchema["$defs"].(map[string]any)
+	if !reflect.DeepEqual(defs["image"], map[string]any{"type": "string"}) {
+		t.Errorf("existing definitions should be kept, got %v", defs["image"])
+	}
+	if !reflect.DeepEqual(defs["image2"], imageSchema()) || !reflect.DeepEqual(defs["image3"], other) {
+		t.Errorf("expected numbered definitions, got %v", defs)
+	}
+}
+
+func TestDeduplicateUnique(t *testing.T) {
+	chartSchema := map[string]any{
+		"properties": map[string]any{
+			"image":   imageSchema(),
+			"service": map[string]any{"type": "object", "properties": map[string]any{"port": map[string]any{"type": "integer"}}},
+		},
+	}
+	want := map[string]any{
+		"properties": map[string]any{
+			"image":   imageSchema(),
+			"service": map[string]any{"type": "object", "properties": map[string]any{"port": map[string]any{"type": "integer"}}},
+		},
+	}
+
+	if added := Deduplicate(chartSchema); added != 0 {
+		t.Errorf("expected no definitions, got %d", added)
+	}
+	if !reflect.DeepEqual(chartSchema, want) {
+		t.Errorf("schemas without repetition should be unchanged, got %v", chartSchema)
+	}
+}
@@ -0,0 +1,84 @@
+package schema
+
+import (
+	"slices"
+	"sort"
+
+	"helm-schema/pkg/parser"
+)
+
+// dependencyKeywords returns the keywords of a draft naming the properties and the schemas a
+// property being set requires, both dependencies for draft-07
+func dependencyKeywords(draft string) (string, string) {
+	if draft == Draft07 {
+		return "dependencies", "dependencies"
+	}
+	return "dependentRequired", "dependentSchemas"
+}
+
+// addDependencies makes setting a value the templates only read within blocks testing other
+// values of its object, such as tls.secretName within {{ if .Values.tls.enabled }}, depend on
+// those: dependentRequired names the values tested when they are siblings without a default,
+// whose presence is what matters, and dependentSchemas requires them truthy otherwise. Values
+// with a default are always set so they are left out, as are values within lists or maps and
+// the values tested outside the object.
+func addDependencies(schema map[string]any, values map[string]*parser.ValuePath, draft string) {
+	requiredKeyword, schemasKeyword := dependencyKeywords(draft)
+	var paths []string
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		valuePath := values[path]
+		if len(valuePath.Guards) == 0 || valuePath.Default != nil || !plainPath(path) {
+			continue
+		}
+		segments := parser.SplitPath(path)
+		parent := segments[:len(segments)-1]
+
+		var guards [][]string
+		siblings := true
+		for _, guard := range valuePath.Guards {
+			guardSegments := parser.SplitPath(guard)
+			if !plainPath(guard) || len(guardSegments) <= len(parent) || !slices.Equal(guardSegments[:len(parent)], parent) {
+				continue
+			}
+			guards = append(guards, guardSegments[len(parent):])
+			if len(guardSegments) > len(parent)+1 || values[guard] == nil || values[guard].Default != nil {
+				siblings = false
+			}
+		}
+		node := objectAt(schema, parent)
+		if len(guards) == 0 || node == nil {
+			continue
+		}
+
+		key := parser.UnescapeKey(segments[len(segments)-1])
+		if siblings {
+			var names []string
+			for _, guard := range guards {
+				names = append(names, parser.UnescapeKey(guard[0]))
+			}
+			dependencies(node, requiredKeyword)[key] = names
+			continue
+		}
+		dependency := make(map[string]any)
+		for _, guard := range guards {
+			requirePath(dependency, guard, truthySchema(values[joinPath(parent, guard)]))
+		}
+		dependencies(node, schemasKeyword)[key] = dependency
+	}
+}
+
+// dependencies returns the map under a dependency keyword of an object schema, adding it when
+// missing
+func dependencies(node map[string]any, keyword string) map[string]any {
+	if existing, ok := node[keyword].(map[string]any); ok {
+		return existing
+	}
+	created := make(map[string]any)
+	node[keyword] = created
+	return created
+}
@@ -0,0 +1,21 @@
+package schema
+
+import (
+	"strings"
+)
+
+// deprecate marks a property deprecated, for editors to warn about, and says so at the end of its
+// description along with the message, if any, such as what to set instead
+func deprecate(prop map[string]any, message string) {
+	prop["deprecated"] = true
+
+	notice := "Deprecated."
+	if message != "" {
+		notice = "Deprecated: " + strings.TrimSuffix(message, ".") + "."
+	}
+	description, _ := prop["description"].(string)
+	if strings.HasSuffix(description, notice) {
+		return
+	}
+	prop["description"] = strings.TrimSpace(description + " " + notice)
+}
@@ -0,0 +1,47 @@
+package schema
+
+// structureKeywords describe the values within an object, rather than the object itself
+var structureKeywords = []string{
+	"properties", "patternProperties", "additionalProperties", "propertyNames", "required",
+	"if", "then", "allOf", "oneOf", "dependentRequired", "dependentSchemas", "dependencies",
+}
+
+// LimitDepth collapses the values nested more than depth levels below the root into permissive
+// objects, described by their type, description and default alone. List items are at the level
+// of the list and anyOf members at the level of the value. A depth of 0 or less keeps every
+// level.
+func LimitDepth(schema map[string]any, depth int) {
+	if depth <= 0 {
+		return
+	}
+	limitDepth(schema, 0, depth)
+}
+
+// limitDepth collapses the structure of node when it lies at the deepest level kept
+func limitDepth(node map[string]any, level, depth int) {
+	if members, ok := node["anyOf"].([]any); ok {
+		for _, member := range members {
+			if member, ok := member.(map[string]any); ok {
+				limitDepth(member, level, depth)
+			}
+		}
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		limitDepth(items, level, depth)
+	}
+	if level == depth {
+		for _, keyword := range structureKeywords {
+			delete(node, keyword)
+		}
+		return
+	}
+
+	for _, keyword := range []string{"properties", "patternProperties"} {
+		children, _ := node[keyword].(map[string]any)
+		for _, child := range children {
+			if child, ok := child.(map[string]any); ok {
+				limitDepth(child, level+1, depth)
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func TestLimitDepth(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"replicas":                    {Path: "replicas", Type: "integer"},
+		"ingress.hosts[].host":        {Path: "ingress.hosts[].host", Type: "string"},
+		"ingress.hosts[].paths[]":     {Path: "ingress.hosts[].paths[]", Type: "string"},
+		"ingress.tls.enabled":         {Path: "ingress.tls.enabled", Type: "boolean", Required: true},
+		"ingress.annotations":         {Path: "ingress.annotations", Type: "map"},
+		"config.server.http.port":     {Path: "config.server.http.port", Type: "integer"},
+		"config.server.http.timeouts": {Path: "config.server.http.timeouts", Type: "object", Description: "Timeouts"},
+	}
+
+	schema := GenerateWithOptions(values, Options{MaxDepth: 2}).ToMap()
+	properties := schema["properties"].(map[string]any)
+	if replicas := properties["replicas"].(map[string]any); replicas["type"] != "integer" {
+		t.Errorf("Expected values above the limit to stay as they are, got %v", replicas)
+	}
+
+	ingress := properties["ingress"].(map[string]any)["properties"].(map[string]any)
+	if tls := ingress["tls"].(map[string]any); !reflect.DeepEqual(tls, map[string]any{"type": "object"}) {
+		t.Errorf("Expected tls collapsed into a permissive object, got %v", tls)
+	}
+	// List items are at the level of the list
+	hosts := ingress["hosts"].(map[string]any)
+	if items := hosts["items"].(map[string]any); !reflect.DeepEqual(items, map[string]any{"type": "object"}) {
+		t.Errorf("Expected host items collapsed into permissive objects, got %v", hosts)
+	}
+	if annotations := ingress["annotations"].(map[string]any); annotations["additionalProperties"] != nil || annotations["type"] != "object" {
+		t.Errorf("Expected annotations to accept any value, got %v", annotations)
+	}
+
+	server := properties["config"].(map[string]any)["properties"].(map[string]any)["server"].(map[string]any)
+	if _, exists := server["properties"]; exists {
+		t.Errorf("Expected server collapsed, got %v", server)
+	}
+
+	// Subcharts nest their values a level deeper
+	merged := MergeSchemas(ChartSchema{Name: "main", Schema: Generate(values)}, []ChartSchema{
+		{Name: "redis", Schema: Generate(map[string]*parser.ValuePath{"auth.password": {Path: "auth.password", Type: "string"}})},
+	}).ToMap()
+	LimitDepth(merged, 2)
+	redis := merged["properties"].(map[string]any)["redis"].(map[string]any)
+	if auth := redis["properties"].(map[string]any)["auth"].(map[string]any); !reflect.DeepEqual(auth, map[string]any{"type": "object"}) {
+		t.Errorf("Expected the subchart's auth collapsed, got %v", auth)
+	}
+
+	// No limit by default
+	config := Generate(values).ToMap()["properties"].(map[string]any)["config"].(map[string]any)
+	if _, exists := config["properties"].(map[string]any)["server"].(map[string]any)["properties"]; !exists {
+		t.Errorf("Expected every level without a limit, got %v", config)
+	}
+}
@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/parser"
+)
+
+// Kinds of differences between two schemas
+const (
+	ChangeAdded   = "added"
+	ChangeRemoved = "removed"
+	ChangeType    = "type-changed"
+)
+
+// Change describes how a value path differs between two schemas
+type Change struct {
+	Path     string `json:"path"`
+	Kind     string `json:"kind"`
+	Old      string `json:"old,omitempty"`
+	New      string `json:"new,omitempty"`
+	Breaking bool   `json:"breaking"` // Values valid against the old schema may be rejected
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s (%s)", c.Path, c.New)
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s (%s)", c.Path, c.Old)
+	default:
+		return fmt.Sprintf("~ %s (%s -> %s)", c.Path, c.Old, c.New)
+	}
+}
+
+// Compare lists the value paths added, removed or retyped between two versions of a schema, in
+// path order. Removed values are breaking since generated objects reject unknown properties, and
+// so are type changes no longer admitting every old type.
+func Compare(before, after map[string]any) []Change {
+	oldPaths, newPaths := flattenSchema(before), flattenSchema(after)
+
+	var changes []Change
+	for path, oldType := range oldPaths {
+		newType, exists := newPaths[path]
+		switch {
+		case !exists:
+			changes = append(changes, Change{Path: path, Kind: ChangeRemoved, Old: oldType, Breaking: true})
+		case newType != oldType:
+			changes = append(changes, Change{Path: path, Kind: ChangeType, Old: oldType, New: newType, Breaking: !admitsTypes(newType, oldType)})
+		}
+	}
+	for path, newType := range newPaths {
+		if _, exists := oldPaths[path]; !exists {
+			changes = append(changes, Change{Path: path, Kind: ChangeAdded, New: newType})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// HasBreakingChanges reports whether any of the changes is breaking
+func HasBreakingChanges(changes []Change) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// flattenSchema maps the value paths a schema describes to their types, "any" when untyped
+func flattenSchema(schema map[string]any) map[string]string {
+	paths := make(map[string]string)
+	flattenNode(schema, "", paths)
+	return paths
+}
+
+// flattenNode records the children of a schema node found at path
+func flattenNode(node map[string]any, path string, paths map[string]string) {
+	record := func(childPath string, child any) {
+		childNode, ok := child.(map[string]any)
+		if !ok {
+			return
+		}
+		paths[childPath] = schemaTypes(childNode)
+		flattenNode(childNode, childPath, paths)
+	}
+
+	if properties, ok := node["properties"].(map[string]any); ok {
+		for key, child := range properties {
+			record(joinSchemaPath(path, parser.EscapeKey(key)), child)
+		}
+	}
+	if patterns, ok := node["patternProperties"].(map[string]any); ok {
+		for _, child := range patterns {
+			record(joinSchemaPath(path, parser.AnyKey), child)
+		}
+	}
+	if items, ok := node["items"].(map[string]any); ok && path != "" {
+		flattenNode(items, path+"[]", paths)
+	}
+}
+
+// joinSchemaPath appends a segment to a value path
+func joinSchemaPath(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+// schemaTypes renders the type keyword of a schema node, e.g. "string" or "integer|string"
+func schemaTypes(node map[string]any) string {
+	switch types := node["type"].(type) {
+	case string:
+		return types
+	case []string:
+		return strings.Join(types, "|")
+	case []any:
+		var names []string
+		for _, name := range types {
+			names = append(names, fmt.Sprint(name))
+		}
+		return strings.Join(names, "|")
+	}
+	return "any"
+}
+
+// admitsTypes reports whether the new types accept every value of the old types
+func admitsTypes(newTypes, oldTypes string) bool {
+	if newTypes == "any" {
+		return true
+	}
+	if oldTypes == "any" {
+		return false
+	}
+
+	admitted := make(map[string]bool)
+	for _, name := range strings.Split(newTypes, "|") {
+		admitted[name] = true
+	}
+	for _, name := range strings.Split(oldTypes, "|") {
+		// Every integer is a number
+		if !admitted[name] && !(name == "integer" && admitted["number"]) {
+			return false
+		}
+	}
+	return true
+}
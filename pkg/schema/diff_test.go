@@ -0,0 +1,45 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func TestCompare(t *testing.T) {
+	before := Generate(map[string]*parser.ValuePath{
+		"image":            {Path: "image", Type: "object"},
+		"image.repository": {Path: "image.repository", Type: "string"},
+		"image.tag":        {Path: "image.tag", Type: "string"},
+		"replicas":         {Path: "replicas", Type: "integer"},
+		"port":             {Path: "port", Type: "string"},
+		"hosts[]":          {Path: "hosts[]", Type: "string"},
+	}).ToMap()
+	after := Generate(map[string]*parser.ValuePath{
+		"image":            {Path: "image", Type: "object"},
+		"image.repository": {Path: "image.repository", Type: "string"},
+		"replicas":         {Path: "replicas", Type: "number"},
+		"port":             {Path: "port", Type: "integer"},
+		"hosts[]":          {Path: "hosts[]", Type: "string"},
+		"resources":        {Path: "resources", Type: "object"},
+	}).ToMap()
+
+	expected := []Change{
+		{Path: "image.tag", Kind: ChangeRemoved, Old: "string", Breaking: true},
+		{Path: "port", Kind: ChangeType, Old: "string", New: "integer", Breaking: true},
+		{Path: "replicas", Kind: ChangeType, Old: "integer", New: "number"},
+		{Path: "resources", Kind: ChangeAdded, New: "object"},
+	}
+
+	changes := Compare(before, after)
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected changes:\n%v\ngot:\n%v", expected, changes)
+	}
+	if !HasBreakingChanges(changes) {
+		t.Error("Removing a value should be breaking")
+	}
+	if len(Compare(before, before)) != 0 {
+		t.Error("A schema should not differ from itself")
+	}
+}
@@ -0,0 +1,40 @@
+package schema
+
+// JSON Schema drafts the generator can target
+const (
+	Draft07     = "draft-07" // The draft Helm validates values against
+	Draft202012 = "2020-12"
+)
+
+// draftURIs are the meta-schemas $schema names for each draft
+var draftURIs = map[string]string{
+	Draft07:     "http://json-schema.org/draft-07/schema#",
+	Draft202012: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// SchemaURI returns the $schema of a draft, defaulting to 2020-12
+func SchemaURI(draft string) string {
+	if uri, ok := draftURIs[draft]; ok {
+		return uri
+	}
+	return draftURIs[Draft202012]
+}
+
+// DraftOf returns the draft a schema declares with $schema, defaulting to 2020-12
+func DraftOf(schema map[string]any) string {
+	for draft, uri := range draftURIs {
+		if schema["$schema"] == uri {
+			return draft
+		}
+	}
+	return Draft202012
+}
+
+// DefinitionsKeyword returns the keyword holding reusable subschemas in a draft: definitions
+// before 2019-09 introduced $defs
+func DefinitionsKeyword(draft string) string {
+	if draft == Draft07 {
+		return "definitions"
+	}
+	return "$defs"
+}
@@ -0,0 +1,128 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ChangeValue is a keyword holding a different value in two schema documents
+const ChangeValue = "changed"
+
+// Difference is a keyword added, removed or changed between two schema documents, located by the
+// JSON pointer of the keyword
+type Difference struct {
+	Pointer string `json:"pointer"`
+	Kind    string `json:"kind"`
+	Old     any    `json:"old,omitempty"`
+	New     any    `json:"new,omitempty"`
+}
+
+func (d Difference) String() string {
+	switch d.Kind {
+	case ChangeAdded:
+		return fmt.Sprintf("+ %s: %s", d.Pointer, compactJSON(d.New))
+	case ChangeRemoved:
+		return fmt.Sprintf("- %s: %s", d.Pointer, compactJSON(d.Old))
+	default:
+		return fmt.Sprintf("~ %s: %s -> %s", d.Pointer, compactJSON(d.Old), compactJSON(d.New))
+	}
+}
+
+// Diff lists every keyword that differs between two schema documents, in pointer order. Unlike
+// Compare, which tracks the types of value paths, it reports any difference, down to a changed
+// description or default. Objects are compared key by key and lists of schemas, such as anyOf,
+// item by item; other lists, such as required or enum, are compared as a whole.
+func Diff(before, after map[string]any) ([]Difference, error) {
+	old, err := jsonValue(before)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := jsonValue(after)
+	if err != nil {
+		return nil, err
+	}
+	var differences []Difference
+	diffValues("", old, updated, &differences)
+	return differences, nil
+}
+
+// diffValues records the differences between two JSON values found at pointer
+func diffValues(pointer string, before, after any, differences *[]Difference) {
+	beforeMap, beforeIsMap := before.(map[string]any)
+	afterMap, afterIsMap := after.(map[string]any)
+	if beforeIsMap && afterIsMap {
+		for _, key := range sortedKeys(unionKeys(beforeMap, afterMap)) {
+			child := pointer + "/" + escapePointer(key)
+			oldValue, inBefore := beforeMap[key]
+			newValue, inAfter := afterMap[key]
+			switch {
+			case !inAfter:
+				*differences = append(*differences, Difference{Pointer: child, Kind: ChangeRemoved, Old: oldValue})
+			case !inBefore:
+				*differences = append(*differences, Difference{Pointer: child, Kind: ChangeAdded, New: newValue})
+			default:
+				diffValues(child, oldValue, newValue, differences)
+			}
+		}
+		return
+	}
+
+	beforeList, beforeIsList := before.([]any)
+	afterList, afterIsList := after.([]any)
+	if beforeIsList && afterIsList && len(beforeList) == len(afterList) && isSchemaList(beforeList) && isSchemaList(afterList) {
+		for i := range beforeList {
+			diffValues(fmt.Sprintf("%s/%d", pointer, i), beforeList[i], afterList[i], differences)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(before, after) {
+		*differences = append(*differences, Difference{Pointer: pointer, Kind: ChangeValue, Old: before, New: after})
+	}
+}
+
+// unionKeys returns a map holding the keys of both maps
+func unionKeys(a, b map[string]any) map[string]any {
+	keys := make(map[string]any, len(a)+len(b))
+	for key := range a {
+		keys[key] = nil
+	}
+	for key := range b {
+		keys[key] = nil
+	}
+	return keys
+}
+
+// isSchemaList reports whether a list holds schemas, as anyOf, oneOf or allOf do
+func isSchemaList(list []any) bool {
+	for _, item := range list {
+		if _, ok := item.(map[string]any); !ok {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// jsonValue converts a schema into its JSON representation, as read back from a file, so schemas
+// built in memory compare equal to their encoded form
+func jsonValue(schema map[string]any) (any, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("encoding schema: %w", err)
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("decoding schema: %w", err)
+	}
+	return value, nil
+}
+
+// compactJSON renders a value on a single line
+func compactJSON(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
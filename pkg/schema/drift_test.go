@@ -0,0 +1,60 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var committed map[string]any
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["image"],
+		"properties": {
+			"image": {"type": "object", "properties": {"tag": {"type": "string", "default": "1.0"}}},
+			"mode": {"anyOf": [{"type": "string"}, {"type": "null"}]},
+			"legacy": {"type": "boolean"}
+		}
+	}`), &committed); err != nil {
+		t.Fatal(err)
+	}
+	generated := map[string]any{
+		"type":     "object",
+		"required": []string{"image", "port"},
+		"properties": map[string]any{
+			"image": map[string]any{"type": "object", "properties": map[string]any{
+				"tag": map[string]any{"type": "string", "default": "1.1"},
+			}},
+			"mode": map[string]any{"anyOf": []any{
+				map[string]any{"type": "string"},
+				map[string]any{"type": "integer"},
+			}},
+			"port":  map[string]any{"type": "integer"},
+			"a/b~c": map[string]any{"type": "string"},
+		},
+	}
+
+	differences, err := Diff(committed, generated)
+	if err != nil {
+		t.Fatalf("Failed to diff: %v", err)
+	}
+	expected := []Difference{
+		{Pointer: "/properties/a~1b~0c", Kind: ChangeAdded, New: map[string]any{"type": "string"}},
+		{Pointer: "/properties/image/properties/tag/default", Kind: ChangeValue, Old: "1.0", New: "1.1"},
+		{Pointer: "/properties/legacy", Kind: ChangeRemoved, Old: map[string]any{"type": "boolean"}},
+		{Pointer: "/properties/mode/anyOf/1/type", Kind: ChangeValue, Old: "null", New: "integer"},
+		{Pointer: "/properties/port", Kind: ChangeAdded, New: map[string]any{"type": "integer"}},
+		{Pointer: "/required", Kind: ChangeValue, Old: []any{"image"}, New: []any{"image", "port"}},
+	}
+	if !reflect.DeepEqual(differences, expected) {
+		t.Errorf("Expected differences:\n%v\ngot:\n%v", expected, differences)
+	}
+	if got := differences[1].String(); got != `~ /properties/image/properties/tag/default: "1.0" -> "1.1"` {
+		t.Errorf("Unexpected rendering %s", got)
+	}
+
+	if differences, err := Diff(generated, generated); err != nil || len(differences) != 0 {
+		t.Errorf("Expected no differences between identical schemas, got %v (%v)", differences, err)
+	}
+}
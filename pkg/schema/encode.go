@@ -0,0 +1,176 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+)
+
+// keywordOrder is the order keywords of a schema are written in: identification first, then
+// what describes the value itself, the keywords of each type, composition and definitions
+// last. Keywords not listed, such as x- extensions, follow the listed ones alphabetically,
+// before definitions.
+var keywordOrder = []string{
+	"$schema", "$id", "$anchor", "$ref", "$comment",
+	"title", "description", "type", "nullable", "format", "enum", "const", "default", "examples", "example",
+	"deprecated", "readOnly", "writeOnly",
+	"minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "pattern", "contentEncoding", "contentMediaType",
+	"items", "prefixItems", "contains", "minItems", "maxItems", "uniqueItems",
+	"properties", "patternProperties", "additionalProperties", "propertyNames", "required",
+	"minProperties", "maxProperties", "dependentRequired", "dependentSchemas", "dependencies",
+	"allOf", "anyOf", "oneOf", "not", "if", "then", "else",
+}
+
+// trailingKeywords are written after every other keyword
+var trailingKeywords = []string{"$defs", "definitions"}
+
+// schemaMapKeywords hold maps from names to schemas
+var schemaMapKeywords = map[string]bool{
+	"properties": true, "patternProperties": true, "dependentSchemas": true,
+	"dependencies": true, "$defs": true, "definitions": true,
+}
+
+// schemaListKeywords hold lists of schemas
+var schemaListKeywords = map[string]bool{"allOf": true, "anyOf": true, "oneOf": true, "prefixItems": true}
+
+// subschemaKeywords hold a single schema
+var subschemaKeywords = map[string]bool{
+	"items": true, "contains": true, "additionalProperties": true, "propertyNames": true,
+	"not": true, "if": true, "then": true, "else": true,
+}
+
+// keywordRanks are the positions of keywords in the order they are written in
+var keywordRanks = func() map[string]int {
+	ranks := make(map[string]int)
+	for i, keyword := range keywordOrder {
+		ranks[keyword] = i
+	}
+	for i, keyword := range trailingKeywords {
+		ranks[keyword] = len(keywordOrder) + 1 + i
+	}
+	return ranks
+}()
+
+// Marshal encodes a schema as JSON with its keywords in a fixed, conventional order, $schema and
+// type before properties and definitions last, rather than alphabetically, so schemas read
+// naturally and regenerating one produces minimal diffs. Property names and the content of
+// values such as defaults are written alphabetically.
+func Marshal(schema map[string]any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeSchema(&buf, schema); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalIndent is Marshal with each element on its own line, indented as json.MarshalIndent
+// does
+func MarshalIndent(schema map[string]any, prefix, indent string) ([]byte, error) {
+	compact, err := Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, compact, prefix, indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeSchema writes a schema, or any other value found where a schema is expected
+func encodeSchema(buf *bytes.Buffer, node any) error {
+	object, ok := node.(map[string]any)
+	if !ok {
+		return encodeValue(buf, node)
+	}
+
+	return encodeObject(buf, object, orderedKeywords(object), func(key string, value any) error {
+		switch {
+		case schemaMapKeywords[key]:
+			return encodeSchemaMap(buf, value)
+		case schemaListKeywords[key]:
+			return encodeSchemaList(buf, value)
+		case subschemaKeywords[key]:
+			return encodeSchema(buf, value)
+		}
+		return encodeValue(buf, value)
+	})
+}
+
+// orderedKeywords returns the keywords of a schema in the order they are written in
+func orderedKeywords(object map[string]any) []string {
+	keys := sortedKeys(object)
+	sort.SliceStable(keys, func(i, j int) bool {
+		return keywordRank(keys[i]) < keywordRank(keys[j])
+	})
+	return keys
+}
+
+// keywordRank returns the position of a keyword, unlisted keywords sharing the one between the
+// listed and the trailing ones
+func keywordRank(keyword string) int {
+	if rank, ok := keywordRanks[keyword]; ok {
+		return rank
+	}
+	return len(keywordOrder)
+}
+
+// encodeSchemaMap writes a map from names to schemas, in name order
+func encodeSchemaMap(buf *bytes.Buffer, node any) error {
+	object, ok := node.(map[string]any)
+	if !ok {
+		return encodeValue(buf, node)
+	}
+	return encodeObject(buf, object, sortedKeys(object), func(_ string, value any) error {
+		return encodeSchema(buf, value)
+	})
+}
+
+// encodeSchemaList writes a list of schemas
+func encodeSchemaList(buf *bytes.Buffer, node any) error {
+	list, ok := node.([]any)
+	if !ok {
+		return encodeValue(buf, node)
+	}
+	buf.WriteByte('[')
+	for i, value := range list {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeSchema(buf, value); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+// encodeObject writes the members of an object in the order of keys
+func encodeObject(buf *bytes.Buffer, object map[string]any, keys []string, encode func(key string, value any) error) error {
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := encodeValue(buf, key); err != nil {
+			return err
+		}
+		buf.WriteByte(':')
+		if err := encode(key, object[key]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// encodeValue writes a value that is not a schema as encoding/json does
+func encodeValue(buf *bytes.Buffer, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	buf.Write(encoded)
+	return nil
+}
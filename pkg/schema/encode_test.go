@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func TestMarshal(t *testing.T) {
+	schema := map[string]any{
+		"additionalProperties": false,
+		"$defs":                map[string]any{"port": map[string]any{"type": "integer", "minimum": 1}},
+		"x-generation":         map[string]any{"version": "dev", "flags": map[string]any{"b": "1", "a": "2"}},
+		"type":                 "object",
+		"required":             []string{"replicas"},
+		"properties": map[string]any{
+			"replicas": map[string]any{"default": 1, "type": "integer", "description": "Pods"},
+			"image": map[string]any{
+				"properties": map[string]any{"tag": map[string]any{"type": "string"}},
+				"type":       "object",
+			},
+			"hosts": map[string]any{"items": map[string]any{"format": "hostname", "type": "string"}, "type": "array"},
+			"mode":  map[string]any{"anyOf": []any{map[string]any{"type": "string", "enum": []any{"a"}}}},
+		},
+		"$schema": SchemaURI(Draft202012),
+	}
+
+	output, err := Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+	expected := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object",` +
+		`"properties":{` +
+		`"hosts":{"type":"array","items":{"type":"string","format":"hostname"}},` +
+		`"image":{"type":"object","properties":{"tag":{"type":"string"}}},` +
+		`"mode":{"anyOf":[{"type":"string","enum":["a"]}]},` +
+		`"replicas":{"description":"Pods","type":"integer","default":1}},` +
+		`"additionalProperties":false,"required":["replicas"],` +
+		`"x-generation":{"flags":{"a":"2","b":"1"},"version":"dev"},` +
+		`"$defs":{"port":{"type":"integer","minimum":1}}}`
+	if string(output) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, output)
+	}
+
+	// The order changes nothing of the content
+	var decoded, original map[string]any
+	if err := json.Unmarshal(output, &decoded); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+	plain, _ := json.Marshal(schema)
+	if err := json.Unmarshal(plain, &original); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, original) {
+		t.Errorf("Expected the same content as encoding/json\n%v\ngot\n%v", original, decoded)
+	}
+}
+
+func TestMarshalIndentStable(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"replicas":     {Path: "replicas", Type: "integer", Default: 1},
+		"image.tag":    {Path: "image.tag", Type: "string", Required: true},
+		"ingress.host": {Path: "ingress.host", Type: "string"},
+	}
+	first, err := MarshalIndent(Generate(values).ToMap(), "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+	for range 10 {
+		again, err := MarshalIndent(Generate(values).ToMap(), "", "  ")
+		if err != nil {
+			t.Fatalf("Failed to encode schema: %v", err)
+		}
+		if string(again) != string(first) {
+			t.Fatalf("Expected identical output for identical schemas\n%s\ngot\n%s", first, again)
+		}
+	}
+}
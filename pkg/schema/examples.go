@@ -0,0 +1,33 @@
+package schema
+
+import (
+	"reflect"
+
+	"helm-schema/pkg/parser"
+)
+
+// addExamples lists the values set in the chart's values files, for editors to show on hover and
+// offer on completion. Empty values, such as the "" charts leave to be filled in, show nothing.
+func addExamples(prop map[string]any, valuePath *parser.ValuePath) {
+	var examples []any
+	for _, example := range valuePath.Examples {
+		if !emptyValue(example) {
+			examples = append(examples, example)
+		}
+	}
+	if len(examples) > 0 {
+		prop["examples"] = examples
+	}
+}
+
+// emptyValue reports whether a value is null or an empty string, list or map
+func emptyValue(value any) bool {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Invalid:
+		return true
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	}
+	return false
+}
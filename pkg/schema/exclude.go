@@ -0,0 +1,132 @@
+package schema
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"helm-schema/pkg/parser"
+)
+
+// ExcludePaths removes the properties whose value path matches one of the patterns from a
+// schema, as if the templates did not read them, along with everything below them. Patterns are
+// value paths whose segments are globs: internal.* matches every value of internal, *.experimental
+// the experimental value of every top-level object, ** any number of segments and labels.* the
+// values of a map. It returns the number of properties removed.
+func ExcludePaths(schema map[string]any, patterns []string) (int, error) {
+	var split [][]string
+	for _, pattern := range patterns {
+		segments := parser.SplitPath(pattern)
+		for _, segment := range segments {
+			if _, err := path.Match(strings.TrimSuffix(segment, "[]"), ""); err != nil {
+				return 0, fmt.Errorf("exclude pattern %q: %w", pattern, err)
+			}
+		}
+		split = append(split, segments)
+	}
+	return excludePaths(schema, nil, split), nil
+}
+
+// excludePaths removes the properties below node matching a pattern, node being the schema of
+// the value at segments
+func excludePaths(node map[string]any, segments []string, patterns [][]string) int {
+	removed := 0
+	if properties, ok := node["properties"].(map[string]any); ok {
+		for _, key := range sortedKeys(properties) {
+			child, _ := properties[key].(map[string]any)
+			childSegments := append(slices.Clone(segments), parser.EscapeKey(key))
+			if matchesAny(patterns, childSegments) {
+				delete(properties, key)
+				removeRequired(node, key)
+				removed++
+				continue
+			}
+			removed += excludeBelow(child, childSegments, patterns)
+		}
+	}
+	if values, ok := node["patternProperties"].(map[string]any); ok {
+		for _, pattern := range sortedKeys(values) {
+			child, _ := values[pattern].(map[string]any)
+			childSegments := append(slices.Clone(segments), parser.AnyKey)
+			if matchesAny(patterns, childSegments) {
+				delete(values, pattern)
+				removed++
+				continue
+			}
+			removed += excludeBelow(child, childSegments, patterns)
+		}
+		if len(values) == 0 {
+			delete(node, "patternProperties")
+		}
+	}
+	return removed
+}
+
+// excludeBelow removes the properties matching a pattern within the value a property describes,
+// and within its list items
+func excludeBelow(child map[string]any, segments []string, patterns [][]string) int {
+	if child == nil {
+		return 0
+	}
+	removed := excludePaths(child, segments, patterns)
+	if items, ok := child["items"].(map[string]any); ok {
+		last := len(segments) - 1
+		itemSegments := append(slices.Clone(segments[:last]), segments[last]+"[]")
+		removed += excludePaths(items, itemSegments, patterns)
+	}
+	return removed
+}
+
+// removeRequired drops a key from the required list of an object schema
+func removeRequired(node map[string]any, key string) {
+	required, ok := node["required"].([]string)
+	if !ok {
+		return
+	}
+	required = slices.DeleteFunc(slices.Clone(required), func(name string) bool { return name == key })
+	if len(required) == 0 {
+		delete(node, "required")
+		return
+	}
+	node["required"] = required
+}
+
+// matchesAny reports whether a value path matches one of the patterns
+func matchesAny(patterns [][]string, segments []string) bool {
+	for _, pattern := range patterns {
+		if matchSegments(pattern, segments) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches the segments of a value path against those of a pattern, ** matching
+// any number of segments
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(segments); i++ {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(segments) == 0 || !matchSegment(pattern[0], segments[0]) {
+		return false
+	}
+	return matchSegments(pattern[1:], segments[1:])
+}
+
+// matchSegment matches a single segment, list items only matching patterns marked with []
+func matchSegment(pattern, segment string) bool {
+	if strings.HasSuffix(pattern, "[]") != strings.HasSuffix(segment, "[]") {
+		return false
+	}
+	matched, _ := path.Match(strings.TrimSuffix(pattern, "[]"), parser.UnescapeKey(segment))
+	return matched
+}
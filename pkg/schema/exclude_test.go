@@ -0,0 +1,65 @@
+package schema
+
+import (
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func TestExcludePaths(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"replicas":                  {Path: "replicas", Type: "integer", Required: true},
+		"internal.tuning.gcPercent": {Path: "internal.tuning.gcPercent", Type: "integer"},
+		"internal.debug":            {Path: "internal.debug", Type: "boolean"},
+		"internal":                  {Path: "internal", Type: "object"},
+		"api.experimental":          {Path: "api.experimental", Type: "boolean", Required: true},
+		"api.port":                  {Path: "api.port", Type: "integer", Required: true},
+		"worker.queue.experimental": {Path: "worker.queue.experimental", Type: "boolean"},
+		"hosts[].name":              {Path: "hosts[].name", Type: "string"},
+		"hosts[].legacyName":        {Path: "hosts[].legacyName", Type: "string"},
+		`nginx\.conf`:               {Path: `nginx\.conf`, Type: "string"},
+		"labels.*":                  {Path: "labels.*", Type: "string"},
+	}
+	generated := Generate(values).ToMap()
+
+	removed, err := ExcludePaths(generated, []string{"internal.*", "*.experimental", "**.queue.experimental", "hosts[].legacy*", `nginx\.conf`, "labels.*", "missing"})
+	if err != nil {
+		t.Fatalf("Failed to exclude paths: %v", err)
+	}
+	if removed != 7 {
+		t.Errorf("Expected 7 properties removed, got %d", removed)
+	}
+
+	properties := generated["properties"].(map[string]any)
+	if _, exists := properties["nginx.conf"]; exists {
+		t.Error("Expected nginx.conf removed")
+	}
+	if internal := properties["internal"].(map[string]any); len(internal["properties"].(map[string]any)) != 0 {
+		t.Errorf("Expected the values of internal removed, got %v", internal)
+	}
+	api := properties["api"].(map[string]any)
+	if _, exists := api["properties"].(map[string]any)["experimental"]; exists {
+		t.Errorf("Expected api.experimental removed, got %v", api)
+	}
+	if required := api["required"].([]string); len(required) != 1 || required[0] != "port" {
+		t.Errorf("Expected api to only require port, got %v", required)
+	}
+	queue := properties["worker"].(map[string]any)["properties"].(map[string]any)["queue"].(map[string]any)
+	if len(queue["properties"].(map[string]any)) != 0 {
+		t.Errorf("Expected worker.queue.experimental removed, got %v", queue)
+	}
+	items := properties["hosts"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)
+	if _, exists := items["legacyName"]; exists || items["name"] == nil {
+		t.Errorf("Expected only hosts[].legacyName removed, got %v", items)
+	}
+	if _, exists := properties["labels"].(map[string]any)["patternProperties"]; exists {
+		t.Errorf("Expected the values of labels removed, got %v", properties["labels"])
+	}
+	if _, exists := properties["replicas"]; !exists {
+		t.Error("Expected replicas kept")
+	}
+
+	if _, err := ExcludePaths(generated, []string{"a.[b"}); err == nil {
+		t.Error("Expected malformed patterns to fail")
+	}
+}
@@ -0,0 +1,100 @@
+package schema
+
+import (
+	"slices"
+)
+
+// Precedences of the keywords an existing schema and the generated one both set
+const (
+	PreferExisting  = "existing"  // Hand-written keywords are kept
+	PreferGenerated = "generated" // Inferred keywords replace hand-written ones
+)
+
+// subschemaMaps are the keywords holding schemas by name, which are merged name by name
+var subschemaMaps = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"$defs":             true,
+	"definitions":       true,
+}
+
+// generationKeywords describe how a schema was produced, which only the new generation tells
+var generationKeywords = []string{"$comment", "x-generation"}
+
+// MergeExisting merges a schema a chart already ships, such as a hand-written values.schema.json,
+// into the generated one, so regenerating keeps the descriptions, enums and constraints added by
+// hand while describing the values found since. Properties are merged one by one and required
+// lists combined; keywords both schemas set are taken from the one precedence prefers.
+func MergeExisting(generated, existing map[string]any, precedence string) map[string]any {
+	existing = copyValue(existing).(map[string]any)
+	for _, keyword := range generationKeywords {
+		delete(existing, keyword)
+	}
+	return mergeExisting(generated, existing, precedence == PreferGenerated)
+}
+
+// mergeExisting merges two schemas, preferring the keywords of the generated one when
+// preferGenerated is set
+func mergeExisting(generated, existing map[string]any, preferGenerated bool) map[string]any {
+	merged := make(map[string]any, len(generated)+len(existing))
+	for keyword, value := range existing {
+		merged[keyword] = value
+	}
+
+	for keyword, value := range generated {
+		current, exists := merged[keyword]
+		if !exists {
+			merged[keyword] = value
+			continue
+		}
+
+		generatedMap, generatedIsMap := value.(map[string]any)
+		existingMap, existingIsMap := current.(map[string]any)
+		switch {
+		case subschemaMaps[keyword] && generatedIsMap && existingIsMap:
+			schemas := make(map[string]any, len(generatedMap)+len(existingMap))
+			for name, schema := range existingMap {
+				schemas[name] = schema
+			}
+			for name, schema := range generatedMap {
+				generatedSchema, generatedIsSchema := schema.(map[string]any)
+				existingSchema, existingIsSchema := schemas[name].(map[string]any)
+				if generatedIsSchema && existingIsSchema {
+					schemas[name] = mergeExisting(generatedSchema, existingSchema, preferGenerated)
+				} else if _, exists := schemas[name]; !exists || preferGenerated {
+					schemas[name] = schema
+				}
+			}
+			merged[keyword] = schemas
+		case (keyword == "items" || keyword == "additionalProperties") && generatedIsMap && existingIsMap:
+			merged[keyword] = mergeExisting(generatedMap, existingMap, preferGenerated)
+		case keyword == "required":
+			merged[keyword] = mergeRequired(current, value)
+		case preferGenerated:
+			merged[keyword] = value
+		}
+	}
+	return merged
+}
+
+// mergeRequired combines two required lists, keeping their order
+func mergeRequired(existing, generated any) []string {
+	var required []string
+	for _, list := range []any{existing, generated} {
+		switch names := list.(type) {
+		case []string:
+			for _, name := range names {
+				if !slices.Contains(required, name) {
+					required = append(required, name)
+				}
+			}
+		case []any:
+			for _, name := range names {
+				if name, isString := name.(string); isString && !slices.Contains(required, name) {
+					required = append(required, name)
+				}
+			}
+		}
+	}
+	return required
+}
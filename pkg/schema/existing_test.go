@@ -0,0 +1,94 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func existingSchema() map[string]any {
+	return map[string]any{
+		"$schema":      "https://json-schema.org/draft/2020-12/schema",
+		"$comment":     "Generated by helm-schema v0.1.0",
+		"x-generation": map[string]any{"generatorVersion": "v0.1.0"},
+		"type":         "object",
+		"required":     []any{"image"},
+		"properties": map[string]any{
+			"image": map[string]any{
+				"type":        "object",
+				"description": "Container image",
+				"properties": map[string]any{
+					"pullPolicy": map[string]any{"type": "string", "enum": []any{"Always", "IfNotPresent"}},
+				},
+			},
+			"replicas": map[string]any{"type": "number", "minimum": 1.0},
+			// Read through tpl, which the templates do not reveal
+			"extraConfig": map[string]any{"type": "string"},
+		},
+	}
+}
+
+func TestMergeExisting(t *testing.T) {
+	generated := map[string]any{
+		"$schema":  "https://json-schema.org/draft/2020-12/schema",
+		"type":     "object",
+		"required": []string{"host"},
+		"properties": map[string]any{
+			"image": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"pullPolicy": map[string]any{"type": "string", "default": "IfNotPresent"},
+					"tag":        map[string]any{"type": "string"},
+				},
+				"additionalProperties": false,
+			},
+			"replicas": map[string]any{"type": "integer"},
+			"host":     map[string]any{"type": "string"},
+		},
+		"additionalProperties": false,
+	}
+	existing := existingSchema()
+
+	merged := MergeExisting(generated, existing, PreferExisting)
+
+	if !reflect.DeepEqual(existing, existingSchema()) {
+		t.Errorf("the existing schema should not be modified")
+	}
+	for _, keyword := range generationKeywords {
+		if _, exists := merged[keyword]; exists {
+			t.Errorf("expected the previous %s left out", keyword)
+		}
+	}
+	if !reflect.DeepEqual(merged["required"], []string{"image", "host"}) {
+		t.Errorf("expected required lists combined, got %v", merged["required"])
+	}
+
+	properties := merged["properties"].(map[string]any)
+	image := properties["image"].(map[string]any)
+	if image["description"] != "Container image" || image["additionalProperties"] != false {
+		t.Errorf("expected image to keep its description and gain generated keywords, got %v", image)
+	}
+	imageProperties := image["properties"].(map[string]any)
+	wantPullPolicy := map[string]any{"type": "string", "enum": []any{"Always", "IfNotPresent"}, "default": "IfNotPresent"}
+	if !reflect.DeepEqual(imageProperties["pullPolicy"], wantPullPolicy) {
+		t.Errorf("expected pullPolicy merged, got %v", imageProperties["pullPolicy"])
+	}
+	if _, exists := imageProperties["tag"]; !exists {
+		t.Errorf("expected the newly found tag added")
+	}
+	if _, exists := properties["extraConfig"]; !exists {
+		t.Errorf("expected hand-written properties kept")
+	}
+	if _, exists := properties["host"]; !exists {
+		t.Errorf("expected the newly found host added")
+	}
+	replicas := properties["replicas"].(map[string]any)
+	if replicas["type"] != "number" || replicas["minimum"] != 1.0 {
+		t.Errorf("expected the hand-written replicas keywords to take precedence, got %v", replicas)
+	}
+
+	merged = MergeExisting(generated, existingSchema(), PreferGenerated)
+	replicas = merged["properties"].(map[string]any)["replicas"].(map[string]any)
+	if replicas["type"] != "integer" || replicas["minimum"] != 1.0 {
+		t.Errorf("expected the generated type with the hand-written minimum, got %v", replicas)
+	}
+}
@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"regexp"
+	"strings"
+)
+
+// nonAnchorRe matches the characters not allowed in a JSON Schema $anchor
+var nonAnchorRe = regexp.MustCompile(`[^-A-Za-z0-9._]+`)
+
+// Fragment returns a copy of a chart schema meant for embedding under an arbitrary key of a
+// parent schema, such as the values key of a dependency. The copy carries no $schema or $id,
+// which only belong on a document root, and local references are rewritten to anchors named
+// after the chart so they keep resolving wherever the fragment is placed. Anchors are $anchor
+// keywords, or $id fragments such as "#redis.defs.endpoint" for draft-07.
+func Fragment(schema map[string]any, chartName string) map[string]any {
+	return fragmentFor(schema, chartName, DraftOf(schema))
+}
+
+// fragmentFor returns a fragment of a chart schema with anchors for the draft of the document
+// embedding it
+func fragmentFor(schema map[string]any, chartName, draft string) map[string]any {
+	fragment := copyValue(schema).(map[string]any)
+	delete(fragment, "$schema")
+	delete(fragment, "$id")
+
+	rewriteRefs(fragment, fragment, chartName, draft)
+	return fragment
+}
+
+// rewriteRefs replaces references to JSON pointers within the fragment, which are relative to the
+// document root, with references to anchors placed on their targets
+func rewriteRefs(node any, fragment map[string]any, chartName, draft string) {
+	switch value := node.(type) {
+	case map[string]any:
+		if ref, ok := value["$ref"].(string); ok && strings.HasPrefix(ref, "#/") {
+			if target, ok := resolvePointer(fragment, ref[2:]).(map[string]any); ok {
+				value["$ref"] = "#" + anchorOf(target, anchorName(chartName, ref[2:]), draft)
+			}
+		}
+		for _, child := range value {
+			rewriteRefs(child, fragment, chartName, draft)
+		}
+	case []any:
+		for _, child := range value {
+			rewriteRefs(child, fragment, chartName, draft)
+		}
+	}
+}
+
+// anchorOf returns the anchor of a referenced schema, placing name on it when it has none
+func anchorOf(target map[string]any, name, draft string) string {
+	if draft == Draft07 {
+		if id, ok := target["$id"].(string); ok && strings.HasPrefix(id, "#") {
+			return id[1:]
+		}
+		target["$id"] = "#" + name
+		return name
+	}
+	if anchor, ok := target["$anchor"].(string); ok {
+		return anchor
+	}
+	target["$anchor"] = name
+	return name
+}
+
+// resolvePointer returns the node a JSON pointer without its leading # and / points at, or nil
+func resolvePointer(node any, pointer string) any {
+	for _, token := range strings.Split(pointer, "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		object, ok := node.(map[string]any)
+		if !ok {
+			return nil
+		}
+		node = object[token]
+	}
+	return node
+}
+
+// anchorName derives an anchor from the chart name and a pointer, e.g. redis.defs.endpoint for
+// $defs/endpoint in the redis chart
+func anchorName(chartName, pointer string) string {
+	name := strings.ReplaceAll(chartName+"."+pointer, "$", "")
+	name = nonAnchorRe.ReplaceAllString(name, ".")
+	if name == "" || !isAnchorStart(name[0]) {
+		name = "_" + name
+	}
+	return name
+}
+
+// isAnchorStart reports whether an anchor may begin with the character
+func isAnchorStart(c byte) bool {
+	return c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z'
+}
+
+// copyValue deep copies the maps and slices of a schema
+func copyValue(node any) any {
+	switch value := node.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(value))
+		for key, child := range value {
+			copied[key] = copyValue(child)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(value))
+		for i, child := range value {
+			copied[i] = copyValue(child)
+		}
+		return copied
+	default:
+		return node
+	}
+}
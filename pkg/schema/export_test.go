@@ -0,0 +1,74 @@
+package schema
+
+import (
+	"testing"
+)
+
+func TestFragment(t *testing.T) {
+	chartSchema := map[string]any{
+		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$id":                  "https://example.com/redis.schema.json",
+		"type":                 "object",
+		"additionalProperties": false,
+		"$defs": map[string]any{
+			"endpoint": map[string]any{"type": "string"},
+		},
+		"properties": map[string]any{
+			"primary": map[string]any{"$ref": "#/$defs/endpoint"},
+			"replica": map[string]any{"$ref": "#/$defs/endpoint"},
+			"auth":    map[string]any{"$ref": "https://example.com/auth.schema.json"},
+		},
+	}
+
+	fragment := Fragment(chartSchema, "redis")
+
+	for _, key := range []string{"$schema", "$id"} {
+		if _, exists := fragment[key]; exists {
+			t.Errorf("Fragment should not carry %s", key)
+		}
+	}
+	if fragment["type"] != "object" || fragment["additionalProperties"] != false {
+		t.Errorf("Fragment should keep the chart schema, got %v", fragment)
+	}
+
+	properties := fragment["properties"].(map[string]any)
+	for _, name := range []string{"primary", "replica"} {
+		if ref := properties[name].(map[string]any)["$ref"]; ref != "#redis.defs.endpoint" {
+			t.Errorf("%s should reference the anchored definition, got %v", name, ref)
+		}
+	}
+	if ref := properties["auth"].(map[string]any)["$ref"]; ref != "https://example.com/auth.schema.json" {
+		t.Errorf("Absolute references should be kept, got %v", ref)
+	}
+	endpoint := fragment["$defs"].(map[string]any)["endpoint"].(map[string]any)
+	if endpoint["$anchor"] != "redis.defs.endpoint" {
+		t.Errorf("Referenced definition should carry an anchor, got %v", endpoint)
+	}
+
+	original := chartSchema["properties"].(map[string]any)["primary"].(map[string]any)
+	if original["$ref"] != "#/$defs/endpoint" || chartSchema["$schema"] == nil {
+		t.Error("Fragment should not modify the chart schema")
+	}
+}
+
+func TestFragmentDraft07(t *testing.T) {
+	chartSchema := map[string]any{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"definitions": map[string]any{
+			"endpoint": map[string]any{"type": "string"},
+		},
+		"properties": map[string]any{
+			"primary": map[string]any{"$ref": "#/definitions/endpoint"},
+		},
+	}
+
+	fragment := Fragment(chartSchema, "redis")
+
+	if ref := fragment["properties"].(map[string]any)["primary"].(map[string]any)["$ref"]; ref != "#redis.definitions.endpoint" {
+		t.Errorf("primary should reference the anchored definition, got %v", ref)
+	}
+	endpoint := fragment["definitions"].(map[string]any)["endpoint"].(map[string]any)
+	if endpoint["$id"] != "#redis.definitions.endpoint" || endpoint["$anchor"] != nil {
+		t.Errorf("Draft-07 definitions should be anchored with a plain-name $id, got %v", endpoint)
+	}
+}
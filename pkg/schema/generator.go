@@ -1,23 +1,94 @@
 package schema
 
 import (
+	"fmt"
+	"log/slog"
+	"slices"
 	"sort"
 	"strings"
 
 	"helm-schema/pkg/parser"
 )
 
+// Options controls optional schema generation features
+type Options struct {
+	// MarkSensitive tags values handled as secret material with x-helm-sensitive
+	MarkSensitive bool
+	// EmitUsage lists the template functions each value is passed through in x-helm-usage
+	EmitUsage bool
+	// MinConfidence omits inferred types whose confidence falls below the threshold
+	MinConfidence float64
+	// Unknown is the handling of values whose type could not be inferred, one of the Unknown*
+	// policies; empty means UnknownAny
+	Unknown string
+	// OmitRequired leaves out the required lists naming the values the templates require
+	OmitRequired bool
+	// Draft is the JSON Schema draft to target, Draft07 or Draft202012; empty means Draft202012
+	Draft string
+	// StringMaps names keys holding maps from strings to strings, besides the well-known labels,
+	// annotations, nodeSelector and env maps
+	StringMaps []string
+	// Nullable accepts null for every value the templates do not require, besides those set to
+	// null in values.yaml or marked nullable
+	Nullable bool
+	// OmitFormats leaves out the formats inferred from the names of values and the functions
+	// they are passed to, such as uri for externalURL or hostname for getHostByName
+	OmitFormats bool
+	// AnyOf renders values accepting several types as an anyOf of one schema per type rather
+	// than a type list, for tools that handle type lists poorly
+	AnyOf bool
+	// Conditionals requires the values the templates only read when others are truthy with
+	// if/then keywords, such as ingress.host when ingress.enabled is true
+	Conditionals bool
+	// Dependencies makes setting the values the templates only read when others of their object
+	// are truthy depend on those, with dependentRequired and dependentSchemas
+	Dependencies bool
+	// OneOf accepts at most one of the values testing the branches of an if/else if chain being
+	// truthy, with a oneOf, as the chart only renders the branch of the first
+	OneOf bool
+	// MaxDepth collapses the values nested more than this many levels below the root into
+	// permissive objects; 0 keeps every level
+	MaxDepth int
+	// Examples lists the values set in the chart's values files in examples, except for
+	// sensitive values
+	Examples bool
+	// Constants restricts the values the templates only ever compare with one literal, as in
+	// {{ if eq .Values.mode "standalone" }}, to that literal with const
+	Constants bool
+	// Provenance records where each value comes from: the template lines referencing it in
+	// x-helm-sources, the values it is only rendered when truthy in x-helm-condition and the
+	// subchart reading it in x-helm-subchart
+	Provenance bool
+}
+
+// Policies for values whose type could not be inferred
+const (
+	UnknownAny         = "any"           // Property without a type, accepting any value
+	UnknownString      = "string"        // Property typed as a string
+	UnknownOmit        = "omit-property" // No property, so the value is not accepted
+	UnknownStrictError = "strict-error"  // Fail generation, see CheckUnknown
+)
+
 // Generate creates a JSON Schema from the collected value paths
-func Generate(values map[string]*parser.ValuePath) map[string]any {
+func Generate(values map[string]*parser.ValuePath) *Node {
+	return GenerateWithOptions(values, Options{})
+}
+
+// GenerateWithOptions creates a JSON Schema from the collected value paths with configurable features
+func GenerateWithOptions(values map[string]*parser.ValuePath, opts Options) *Node {
+	return FromMap(generateMap(values, opts))
+}
+
+// generateMap builds the schema GenerateWithOptions returns as the map the passes of the package
+// work on
+func generateMap(values map[string]*parser.ValuePath, opts Options) map[string]any {
 	schema := map[string]any{
-		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$schema":              SchemaURI(opts.Draft),
 		"type":                 "object",
 		"properties":           make(map[string]any),
 		"additionalProperties": false,
 	}
 
-	properties := schema["properties"].(map[string]any)
-
 	// Sort paths for consistent output
 	var paths []string
 	for path := range values {
@@ -26,8 +97,22 @@ func Generate(values map[string]*parser.ValuePath) map[string]any {
 	sort.Strings(paths)
 
 	for _, path := range paths {
-		addPropertyToSchema(properties, path, values[path])
+		addPropertyToSchema(schema, path, values[path], opts)
+	}
+	addStringMaps(schema, opts)
+	if opts.Conditionals && !opts.OmitRequired {
+		addConditionals(schema, values)
+	}
+	if opts.Dependencies {
+		addDependencies(schema, values, opts.Draft)
+	}
+	if opts.OneOf {
+		addExclusiveBranches(schema, values)
 	}
+	if opts.AnyOf {
+		anyOfUnions(schema)
+	}
+	LimitDepth(schema, opts.MaxDepth)
 
 	return schema
 }
@@ -35,34 +120,76 @@ func Generate(values map[string]*parser.ValuePath) map[string]any {
 // ChartSchema represents a schema for a single chart with its metadata
 type ChartSchema struct {
 	Name   string
-	Schema map[string]any
+	Schema *Node
+	// Shipped marks the values.schema.json a subchart ships, embedded as it is
+	Shipped bool
+}
+
+// chartMap is a ChartSchema with its schema as a map, as merging works on it
+type chartMap struct {
+	Name    string
+	Schema  map[string]any
+	Shipped bool
+}
+
+// chartMaps converts the schemas of charts into maps
+func chartMaps(charts []ChartSchema) []chartMap {
+	converted := make([]chartMap, 0, len(charts))
+	for _, chart := range charts {
+		converted = append(converted, chartMap{Name: chart.Name, Schema: chart.Schema.ToMap(), Shipped: chart.Shipped})
+	}
+	return converted
 }
 
 // GenerateChartSchemas creates separate schemas for parent and subcharts
 func GenerateChartSchemas(parser *parser.TemplateParser) (ChartSchema, []ChartSchema) {
+	return GenerateChartSchemasWithOptions(parser, Options{})
+}
+
+// GenerateChartSchemasWithOptions creates separate schemas for parent and subcharts with configurable features
+func GenerateChartSchemasWithOptions(parser *parser.TemplateParser, opts Options) (ChartSchema, []ChartSchema) {
 	// Generate main chart schema
 	mainSchema := ChartSchema{
 		Name:   "main",
-		Schema: Generate(parser.GetValues()),
+		Schema: GenerateWithOptions(parser.GetValues(), opts),
 	}
 
 	// Generate subchart schemas
 	var subchartSchemas []ChartSchema
+	shipped := parser.ShippedSchemas()
 	for name, subchartParser := range parser.GetSubcharts() {
-		subchartSchema := ChartSchema{
-			Name:   name,
-			Schema: Generate(subchartParser.GetValues()),
+		if _, exists := shipped[name]; exists {
+			continue
 		}
-		subchartSchemas = append(subchartSchemas, subchartSchema)
+		subchartSchema := generateMap(subchartParser.GetValues(), opts)
+		if opts.Provenance {
+			markSubchart(subchartSchema, name)
+		}
+		subchartSchemas = append(subchartSchemas, ChartSchema{Name: name, Schema: FromMap(subchartSchema)})
+	}
+	for name, schema := range shipped {
+		if opts.Provenance {
+			schema = copyValue(schema).(map[string]any)
+			markSubchart(schema, name)
+		}
+		slog.Debug("embedding the schema shipped by subchart", "subchart", name)
+		subchartSchemas = append(subchartSchemas, ChartSchema{Name: name, Schema: FromMap(schema), Shipped: true})
 	}
 
 	return mainSchema, subchartSchemas
 }
 
-// MergeSchemas combines main chart and subchart schemas into a single schema
-func MergeSchemas(mainSchema ChartSchema, subchartSchemas []ChartSchema) map[string]any {
+// MergeSchemas combines main chart and subchart schemas into a single schema. The global values
+// Helm shares between charts are collected into one global property at the root, see
+// GlobalConflicts for those the charts disagree on.
+func MergeSchemas(mainSchema ChartSchema, subchartSchemas []ChartSchema) *Node {
+	return FromMap(mergeSchemaMaps(chartMaps([]ChartSchema{mainSchema})[0], chartMaps(subchartSchemas)))
+}
+
+// mergeSchemaMaps merges the schemas of charts as maps, see MergeSchemas
+func mergeSchemaMaps(mainSchema chartMap, subchartSchemas []chartMap) map[string]any {
 	mergedSchema := map[string]any{
-		"$schema":              "https://json-schema.org/draft/2020-12/schema",
+		"$schema":              SchemaURI(DraftOf(mainSchema.Schema)),
 		"type":                 "object",
 		"properties":           make(map[string]any),
 		"additionalProperties": false,
@@ -76,115 +203,384 @@ func MergeSchemas(mainSchema ChartSchema, subchartSchemas []ChartSchema) map[str
 			properties[key] = value
 		}
 	}
+	if required, ok := mainSchema.Schema["required"]; ok {
+		mergedSchema["required"] = required
+	}
+	copyConditionals(mergedSchema, mainSchema.Schema)
 
 	// Add subchart properties under their respective names
 	for _, subchartSchema := range subchartSchemas {
+		if subchartSchema.Shipped {
+			properties[subchartSchema.Name] = fragmentFor(subchartSchema.Schema, subchartSchema.Name, DraftOf(mainSchema.Schema))
+			continue
+		}
 		if subchartProps, ok := subchartSchema.Schema["properties"].(map[string]any); ok {
+			subchartProps, required := withoutGlobal(subchartProps, subchartSchema.Schema["required"])
 			// Create a nested object for the subchart
-			properties[subchartSchema.Name] = map[string]any{
+			subchart := map[string]any{
 				"type":                 "object",
 				"properties":           subchartProps,
 				"additionalProperties": false,
 			}
+			// The subchart key itself is optional: its values default from the subchart, which
+			// may be disabled, so requirements stop at it
+			if required != nil {
+				subchart["required"] = required
+			}
+			copyConditionals(subchart, subchartSchema.Schema)
+			properties[subchartSchema.Name] = subchart
 		}
 	}
+	mergeGlobals(mergedSchema, mainSchema, subchartSchemas)
 
 	return mergedSchema
 }
 
+// anyKeyPattern matches every key of a map whose values share a schema
+const anyKeyPattern = "^.*$"
+
 // addPropertyToSchema recursively builds the nested property structure in the JSON schema
-func addPropertyToSchema(properties map[string]any, path string, valuePath *parser.ValuePath) {
-	parts := strings.Split(path, ".")
-	current := properties
+func addPropertyToSchema(schema map[string]any, path string, valuePath *parser.ValuePath, opts Options) {
+	parts := parser.SplitPath(path)
+	current := schema["properties"].(map[string]any)
+	// Schema holding current as its properties
+	owner := schema
+	// Objects the value is nested in below the nearest list or map, each with the schema holding it
+	var parents []requiredParent
 
 	for i, part := range parts {
-		// Handle array notation
-		if strings.HasSuffix(part, "[]") {
-			part = strings.TrimSuffix(part, "[]")
+		// Values of iterated maps are described for any key
+		if part == parser.AnyKey {
+			if i == 0 {
+				return
+			}
+			parents = nil
+			if _, exists := owner["patternProperties"]; !exists {
+				owner["patternProperties"] = make(map[string]any)
+			}
+			patterns := owner["patternProperties"].(map[string]any)
 
-			if _, exists := current[part]; !exists {
-				current[part] = map[string]any{
-					"type":  "array",
-					"items": map[string]any{},
+			if i == len(parts)-1 {
+				if prop := leafProperty(valuePath, opts); prop != nil {
+					patterns[anyKeyPattern] = prop
 				}
+			} else {
+				owner = intermediateObject(patterns, anyKeyPattern)
+				current = owner["properties"].(map[string]any)
 			}
+			continue
+		}
+
+		// Property names are the keys segments stand for, e.g. nginx.conf for nginx\.conf
+		isArray := strings.HasSuffix(part, "[]")
+		part = parser.UnescapeKey(part)
+
+		// Handle array notation
+		if isArray {
+			arrayProp := arrayProperty(current, part)
+			parents = nil
 
 			if i == len(parts)-1 {
-				// This is the final part, set the array item type
-				arrayProp := current[part].(map[string]any)
+				// This is the final part, the path stands for the items, typed by how they are used
 				items := arrayProp["items"].(map[string]any)
-				itemType := getArrayItemType(valuePath.Type)
-				if itemType != "unknown" {
+				if itemType, known := schemaType(valuePath, opts); known {
 					items["type"] = itemType
 				}
+				addUsage(arrayProp, valuePath, opts)
+				addProvenance(arrayProp, valuePath, opts)
+				addConstraints(items, valuePath)
 			} else {
 				// Navigate into the array items for nested properties
-				arrayProp := current[part].(map[string]any)
-				items := arrayProp["items"].(map[string]any)
-				if _, hasType := items["type"]; !hasType {
-					items["type"] = "object"
-					items["properties"] = make(map[string]any)
-					items["additionalProperties"] = false
-				}
-				if props, ok := items["properties"]; ok {
-					current = props.(map[string]any)
-				} else {
-					items["properties"] = make(map[string]any)
-					current = items["properties"].(map[string]any)
-				}
+				items := objectSchema(arrayProp["items"].(map[string]any))
+				current = items["properties"].(map[string]any)
+				owner = items
 			}
 		} else {
 			if i == len(parts)-1 {
 				// Final property
-				prop := make(map[string]any)
-				if valuePath.Type != "unknown" && valuePath.Type != "map" {
-					prop["type"] = valuePath.Type
-				} else if valuePath.Type == "map" {
-					prop["type"] = "object"
-				}
-				// For "unknown" type, we add no type field - let JSON Schema infer from values
-				current[part] = prop
-			} else {
-				// Intermediate object - ensure it exists and has correct structure
-				if existingProp, exists := current[part]; exists {
-					// If it already exists, make sure it's an object with properties
-					if obj, ok := existingProp.(map[string]any); ok {
-						if obj["type"] != "object" {
-							obj["type"] = "object"
-						}
-						if _, hasProps := obj["properties"]; !hasProps {
-							obj["properties"] = make(map[string]any)
+				if prop := leafProperty(valuePath, opts); prop != nil {
+					current[part] = prop
+					if valuePath.Required && !opts.OmitRequired {
+						addRequired(owner, part)
+						// Unless only rendered when other values are set, the value is always read
+						if len(valuePath.Guards) == 0 {
+							for _, parent := range parents {
+								addRequired(parent.owner, parent.name)
+							}
 						}
-						if _, hasAdditional := obj["additionalProperties"]; !hasAdditional {
-							obj["additionalProperties"] = false
-						}
-						current = obj["properties"].(map[string]any)
-					}
-				} else {
-					// Create new intermediate object
-					current[part] = map[string]any{
-						"type":                 "object",
-						"properties":           make(map[string]any),
-						"additionalProperties": false,
 					}
-					obj := current[part].(map[string]any)
-					current = obj["properties"].(map[string]any)
 				}
+			} else {
+				// Intermediate object - ensure it exists and has correct structure
+				parents = append(parents, requiredParent{owner: owner, name: part})
+				owner = intermediateObject(current, part)
+				current = owner["properties"].(map[string]any)
 			}
 		}
 	}
 }
 
-// getArrayItemType determines the appropriate type for array items
-func getArrayItemType(arrayType string) string {
-	if arrayType == "array" {
-		return "object"
+// leafProperty builds the schema of the value a path ends at, or returns nil when the value is
+// left out of the schema
+func leafProperty(valuePath *parser.ValuePath, opts Options) map[string]any {
+	if untyped(valuePath) && opts.Unknown == UnknownOmit {
+		slog.Debug("omitting value of unknown type", "path", valuePath.Path)
+		return nil
+	}
+
+	prop := make(map[string]any)
+	if valueType, known := schemaType(valuePath, opts); known {
+		prop["type"] = valueType
+	}
+	// Values of unknown type get no type field, accepting any value, unless the policy types
+	// them as strings
+	if untyped(valuePath) && opts.Unknown == UnknownString {
+		prop["type"] = "string"
+	}
+	// Charts disable optional sections by setting them to null
+	if valueType, typed := prop["type"]; typed && (valuePath.Nullable || (opts.Nullable && !valuePath.Required)) {
+		prop["type"] = withNull(valueType)
+	}
+	// Kubernetes fields such as maxSurge take a number of replicas or a percentage of them
+	if valuePath.IsIntOrPercent() {
+		addIntOrPercent(prop)
+	}
+	// and such as the cpu of resource requests a resource quantity
+	if valuePath.IsQuantity() {
+		addQuantity(prop)
+	}
+	if valuePath.Description != "" {
+		prop["description"] = valuePath.Description
+	}
+	// Defaults from values.yaml or template fallbacks document the value and seed forms
+	if valuePath.Default != nil {
+		prop["default"] = valuePath.Default
+	}
+	if opts.Examples && !valuePath.Sensitive {
+		addExamples(prop, valuePath)
+	}
+	if opts.Constants {
+		addConstant(prop, valuePath)
+	}
+	// Lists the chart treats as sets
+	if valueType := prop["type"]; valuePath.Unique && (valueType == nil || valueType == "array" || admitsType(valueType, "array")) {
+		prop["uniqueItems"] = true
+	}
+	// Rendering fails on empty strings, as with required
+	if valueType := prop["type"]; valuePath.NonEmpty && (valueType == nil || valueType == "string" || admitsType(valueType, "string")) {
+		prop["minLength"] = 1
+	}
+	// Maps whose keys are rendered as environment variable names or ConfigMap keys
+	if valueType := prop["type"]; valuePath.KeyPattern != "" && (valueType == nil || valueType == "object" || admitsType(valueType, "object")) {
+		prop["propertyNames"] = map[string]any{"pattern": valuePath.KeyPattern}
+	}
+	if opts.MarkSensitive && valuePath.Sensitive {
+		prop["x-helm-sensitive"] = true
+	}
+	addEncoding(prop, valuePath)
+	if format := valuePath.InferredFormat(); format != "" && !opts.OmitFormats {
+		prop["format"] = format
+	}
+	addUsage(prop, valuePath, opts)
+	addProvenance(prop, valuePath, opts)
+	addConstraints(prop, valuePath)
+	// After the constraints, which may describe the value
+	if valuePath.Deprecated {
+		deprecate(prop, valuePath.Deprecation)
+	}
+	return prop
+}
+
+// schemaType returns the JSON Schema type of a value, unless it is unknown or, since weakly
+// inferred types are left out rather than producing an overly strict schema, below the
+// confidence threshold
+func schemaType(valuePath *parser.ValuePath, opts Options) (any, bool) {
+	if valuePath.Confidence < opts.MinConfidence {
+		slog.Debug("leaving out weakly inferred type", "path", valuePath.Path, "type", valuePath.Type, "confidence", valuePath.Confidence)
+		return nil, false
+	}
+	switch valuePath.Type {
+	case "unknown":
+		return nil, false
+	case "union":
+		return unionTypes(valuePath.Types), true
+	case "map":
+		return "object", true
+	default:
+		return valuePath.Type, true
+	}
+}
+
+// untyped reports whether neither the templates nor a directive tell the type of a value
+func untyped(valuePath *parser.ValuePath) bool {
+	_, hasType := valuePath.Constraints["type"]
+	return valuePath.Type == "unknown" && !hasType
+}
+
+// CheckUnknown fails with the UnknownStrictError policy when the type of values the schema
+// describes could not be inferred, listing them
+func CheckUnknown(values map[string]*parser.ValuePath, opts Options) error {
+	if opts.Unknown != UnknownStrictError {
+		return nil
+	}
+
+	var paths []string
+	for path, valuePath := range values {
+		// Untyped list items are described by their array
+		if untyped(valuePath) && !strings.HasSuffix(path, "[]") {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+	sort.Strings(paths)
+	return fmt.Errorf("the type of %d values could not be inferred: %s", len(paths), strings.Join(paths, ", "))
+}
+
+// intermediateObject returns the object schema stored under key, creating it or making an
+// existing schema an object with properties
+func intermediateObject(container map[string]any, key string) map[string]any {
+	obj, ok := container[key].(map[string]any)
+	if !ok {
+		obj = map[string]any{
+			"type":                 "object",
+			"properties":           make(map[string]any),
+			"additionalProperties": false,
+		}
+		container[key] = obj
+		return obj
+	}
+	return objectSchema(obj)
+}
+
+// objectSchema makes an existing schema, such as the items of a list, an object with properties
+func objectSchema(obj map[string]any) map[string]any {
+	// A union already admitting objects keeps its other members
+	if obj["type"] != "object" && !admitsType(obj["type"], "object") {
+		obj["type"] = "object"
+	}
+	if _, hasProps := obj["properties"]; !hasProps {
+		obj["properties"] = make(map[string]any)
+	}
+	if _, hasAdditional := obj["additionalProperties"]; !hasAdditional {
+		obj["additionalProperties"] = false
+	}
+	return obj
+}
+
+// encodingMediaTypes maps the encodings of decoded string values to their media types
+var encodingMediaTypes = map[string]string{
+	"yaml": "application/yaml",
+	"json": "application/json",
+}
+
+// addEncoding describes string values the templates decode with fromYaml/fromJson, and the
+// fields they read from the decoded document unless the value is described already
+func addEncoding(prop map[string]any, valuePath *parser.ValuePath) {
+	mediaType, ok := encodingMediaTypes[valuePath.Encoding]
+	if !ok {
+		return
+	}
+	prop["contentMediaType"] = mediaType
+	if _, described := prop["description"]; !described && len(valuePath.Decoded) > 0 {
+		prop["description"] = strings.ToUpper(valuePath.Encoding) + " document with fields: " +
+			strings.Join(valuePath.Decoded, ", ")
+	}
+}
+
+// arrayProperty returns the array schema stored under key, creating it or making an existing
+// schema, such as the leaf of a value ranged over, an array with items
+func arrayProperty(container map[string]any, key string) map[string]any {
+	prop, ok := container[key].(map[string]any)
+	if !ok {
+		prop = make(map[string]any)
+		container[key] = prop
+	}
+
+	// A union already admitting arrays keeps its other members
+	if prop["type"] != "array" && !admitsType(prop["type"], "array") {
+		prop["type"] = "array"
+	}
+	if _, hasItems := prop["items"].(map[string]any); !hasItems {
+		prop["items"] = make(map[string]any)
+	}
+	return prop
+}
+
+// requiredParent is an object a value is nested in, named in the schema holding it
+type requiredParent struct {
+	owner map[string]any
+	name  string
+}
+
+// addRequired lists a property among the required ones of the object schema holding it.
+// addPropertyToSchema also requires the objects holding a value the templates always require,
+// or validation would accept leaving them out as a whole; not those holding a value only rendered
+// within {{ if .Values.parent }} and such, nor lists and maps, which may be empty.
+func addRequired(owner map[string]any, name string) {
+	required, _ := owner["required"].([]string)
+	for _, existing := range required {
+		if existing == name {
+			return
+		}
+	}
+	owner["required"] = append(required, name)
+}
+
+// addUsage records the template functions applied to a value when usage output is enabled
+func addUsage(prop map[string]any, valuePath *parser.ValuePath, opts Options) {
+	if opts.EmitUsage && len(valuePath.Functions) > 0 {
+		prop["x-helm-usage"] = valuePath.Functions
+	}
+}
+
+// addConstraints applies the schema keywords set by directive comments, which take precedence
+// over inferred ones
+func addConstraints(prop map[string]any, valuePath *parser.ValuePath) {
+	for keyword, value := range valuePath.Constraints {
+		prop[keyword] = value
 	}
-	if arrayType == "unknown" {
-		return "unknown" // Will be filtered out by caller
+}
+
+// unionTypes converts conflicting value types into a JSON Schema type list
+func unionTypes(types []string) []string {
+	seen := make(map[string]bool)
+	var union []string
+	for _, valueType := range types {
+		if valueType == "map" {
+			valueType = "object"
+		}
+		if !seen[valueType] {
+			seen[valueType] = true
+			union = append(union, valueType)
+		}
 	}
-	if arrayType == "map" {
-		return "object"
+	sort.Strings(union)
+	return union
+}
+
+// withNull adds null to the types a schema accepts
+func withNull(schemaType any) []string {
+	switch valueType := schemaType.(type) {
+	case string:
+		return unionTypes([]string{valueType, "null"})
+	case []string:
+		return unionTypes(append(slices.Clone(valueType), "null"))
+	}
+	return []string{"null"}
+}
+
+// admitsType reports whether a type list produced by unionTypes includes the named type
+func admitsType(schemaType any, name string) bool {
+	types, ok := schemaType.([]string)
+	if !ok {
+		return false
+	}
+	for _, valueType := range types {
+		if valueType == name {
+			return true
+		}
 	}
-	return "unknown"
+	return false
 }
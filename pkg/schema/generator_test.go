@@ -2,6 +2,9 @@ package schema
 
 import (
 	"encoding/json"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
 
 	"helm-schema/pkg/parser"
@@ -32,12 +35,12 @@ func TestGenerateBasicSchema(t *testing.T) {
 		},
 		"items[]": {
 			Path:     "items[]",
-			Type:     "array",
+			Type:     "object",
 			Required: false,
 		},
 	}
 
-	schema := Generate(values)
+	schema := Generate(values).ToMap()
 
 	// Verify schema structure
 	if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
@@ -101,7 +104,7 @@ func TestGenerateBasicSchema(t *testing.T) {
 
 	items := itemsProp["items"].(map[string]interface{})
 	if items["type"] != "object" {
-		t.Error("array items should be object type for array type")
+		t.Error("array items should be object type for items used as objects")
 	}
 }
 
@@ -120,7 +123,7 @@ func TestGenerateSchemaWithNestedArrays(t *testing.T) {
 		},
 	}
 
-	schema := Generate(values)
+	schema := Generate(values).ToMap()
 	properties := schema["properties"].(map[string]interface{})
 
 	// Test features.flags array
@@ -159,7 +162,7 @@ func TestSchemaValidJSON(t *testing.T) {
 		},
 	}
 
-	schema := Generate(values)
+	schema := Generate(values).ToMap()
 
 	// Should be able to marshal to JSON without error
 	jsonBytes, err := json.MarshalIndent(schema, "", "  ")
@@ -181,22 +184,27 @@ func TestSchemaValidJSON(t *testing.T) {
 
 func TestArrayItemTypeInference(t *testing.T) {
 	tests := []struct {
-		arrayType string
-		expected  string
+		itemType string
+		types    []string
+		expected any
 	}{
-		{"array", "object"},
-		{"string", "unknown"},
-		{"boolean", "unknown"},
-		{"integer", "unknown"},
-		{"map", "object"},
-		{"unknown", "unknown"},
+		{"array", nil, "array"},
+		{"string", nil, "string"},
+		{"boolean", nil, "boolean"},
+		{"integer", nil, "integer"},
+		{"map", nil, "object"},
+		{"union", []string{"number", "string"}, []string{"number", "string"}},
+		{"unknown", nil, nil},
 	}
 
 	for _, test := range tests {
-		result := getArrayItemType(test.arrayType)
-		if result != test.expected {
-			t.Errorf("getArrayItemType(%s) = %s, expected %s",
-				test.arrayType, result, test.expected)
+		values := map[string]*parser.ValuePath{
+			"list":   {Path: "list", Type: "array"},
+			"list[]": {Path: "list[]", Type: test.itemType, Types: test.types},
+		}
+		items := Generate(values).ToMap()["properties"].(map[string]interface{})["list"].(map[string]interface{})["items"].(map[string]interface{})
+		if !reflect.DeepEqual(items["type"], test.expected) {
+			t.Errorf("Items used as %s have type %v, expected %v", test.itemType, items["type"], test.expected)
 		}
 	}
 }
@@ -221,7 +229,7 @@ func TestMapTypesToObjectConversion(t *testing.T) {
 		},
 	}
 
-	schema := Generate(values)
+	schema := Generate(values).ToMap()
 	properties := schema["properties"].(map[string]interface{})
 
 	// Test config.data (map type should become object)
@@ -246,3 +254,983 @@ func TestMapTypesToObjectConversion(t *testing.T) {
 		t.Error("unknown type should not have a type field")
 	}
 }
+
+func TestSensitiveValues(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"auth.password": {
+			Path:      "auth.password",
+			Type:      "string",
+			Sensitive: true,
+		},
+		"auth.user": {
+			Path: "auth.user",
+			Type: "string",
+		},
+	}
+
+	// Sensitive tagging is opt-in
+	schema := Generate(values).ToMap()
+	authProperties := schema["properties"].(map[string]interface{})["auth"].(map[string]interface{})["properties"].(map[string]interface{})
+	if _, tagged := authProperties["password"].(map[string]interface{})["x-helm-sensitive"]; tagged {
+		t.Error("x-helm-sensitive should not be emitted by default")
+	}
+
+	schema = GenerateWithOptions(values, Options{MarkSensitive: true}).ToMap()
+	authProperties = schema["properties"].(map[string]interface{})["auth"].(map[string]interface{})["properties"].(map[string]interface{})
+
+	passwordProp := authProperties["password"].(map[string]interface{})
+	if passwordProp["x-helm-sensitive"] != true {
+		t.Error("auth.password should be tagged as sensitive")
+	}
+	if passwordProp["type"] != "string" {
+		t.Error("auth.password should be string type")
+	}
+
+	userProp := authProperties["user"].(map[string]interface{})
+	if _, tagged := userProp["x-helm-sensitive"]; tagged {
+		t.Error("auth.user should not be tagged as sensitive")
+	}
+}
+
+func TestUsageExtension(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"config": {
+			Path:      "config",
+			Type:      "unknown",
+			Functions: []string{"nindent", "toYaml"},
+		},
+		"hosts[]": {
+			Path:      "hosts[]",
+			Type:      "array",
+			Functions: []string{"join"},
+		},
+		"name": {
+			Path: "name",
+			Type: "unknown",
+		},
+	}
+
+	// Usage output is opt-in
+	schema := Generate(values).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+	if _, listed := properties["config"].(map[string]interface{})["x-helm-usage"]; listed {
+		t.Error("x-helm-usage should not be emitted by default")
+	}
+
+	schema = GenerateWithOptions(values, Options{EmitUsage: true}).ToMap()
+	properties = schema["properties"].(map[string]interface{})
+
+	configUsage, _ := properties["config"].(map[string]interface{})["x-helm-usage"].([]string)
+	if len(configUsage) != 2 || configUsage[0] != "nindent" || configUsage[1] != "toYaml" {
+		t.Errorf("config should list nindent and toYaml, got %v", configUsage)
+	}
+
+	hostsUsage, _ := properties["hosts"].(map[string]interface{})["x-helm-usage"].([]string)
+	if len(hostsUsage) != 1 || hostsUsage[0] != "join" {
+		t.Errorf("hosts should list join, got %v", hostsUsage)
+	}
+
+	if _, listed := properties["name"].(map[string]interface{})["x-helm-usage"]; listed {
+		t.Error("name is not passed through any function and should have no x-helm-usage")
+	}
+}
+
+func TestUnionTypes(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"config": {
+			Path:  "config",
+			Type:  "union",
+			Types: []string{"map", "string"},
+		},
+		"extra": {
+			Path:  "extra",
+			Type:  "union",
+			Types: []string{"object", "string"},
+		},
+		"extra.name": {
+			Path: "extra.name",
+			Type: "unknown",
+		},
+	}
+
+	schema := Generate(values).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+
+	configType := properties["config"].(map[string]interface{})["type"]
+	if !reflect.DeepEqual(configType, []string{"object", "string"}) {
+		t.Errorf("config should allow object and string, got %v", configType)
+	}
+
+	// Nested fields must not narrow the union back to object
+	extra := properties["extra"].(map[string]interface{})
+	if !reflect.DeepEqual(extra["type"], []string{"object", "string"}) {
+		t.Errorf("extra should allow object and string, got %v", extra["type"])
+	}
+	if _, hasName := extra["properties"].(map[string]interface{})["name"]; !hasName {
+		t.Error("extra should still describe its nested name field")
+	}
+}
+
+func TestMinConfidence(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"port": {
+			Path:       "port",
+			Type:       "integer",
+			Confidence: parser.ConfidenceDefault,
+		},
+		"token": {
+			Path:       "token",
+			Type:       "string",
+			Confidence: parser.ConfidencePipeline,
+		},
+	}
+
+	schema := GenerateWithOptions(values, Options{MinConfidence: 0.8}).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+
+	if properties["port"].(map[string]interface{})["type"] != "integer" {
+		t.Error("port is typed by a default literal and should keep its type")
+	}
+	if _, typed := properties["token"].(map[string]interface{})["type"]; typed {
+		t.Error("token is only hinted by a function and should be left untyped")
+	}
+
+	// Without a threshold every inferred type is emitted
+	schema = Generate(values).ToMap()
+	properties = schema["properties"].(map[string]interface{})
+	if properties["token"].(map[string]interface{})["type"] != "string" {
+		t.Error("token should be string without a confidence threshold")
+	}
+}
+
+func TestMapValuePatternProperties(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"tenants":            {Path: "tenants", Type: "map"},
+		"tenants.*":          {Path: "tenants.*", Type: "object"},
+		"tenants.*.replicas": {Path: "tenants.*.replicas", Type: "integer"},
+		"tenants.*.labels":   {Path: "tenants.*.labels", Type: "map"},
+		"tenants.*.labels.*": {Path: "tenants.*.labels.*", Type: "string"},
+	}
+
+	schema := Generate(values).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+
+	tenants := properties["tenants"].(map[string]interface{})
+	if tenants["type"] != "object" {
+		t.Errorf("tenants should be an object, got %v", tenants["type"])
+	}
+	tenant, ok := tenants["patternProperties"].(map[string]interface{})[anyKeyPattern].(map[string]interface{})
+	if !ok {
+		t.Fatalf("tenants should describe every tenant with patternProperties, got %v", tenants)
+	}
+
+	tenantProps := tenant["properties"].(map[string]interface{})
+	if replicas := tenantProps["replicas"].(map[string]interface{}); replicas["type"] != "integer" {
+		t.Errorf("tenant replicas should be an integer, got %v", replicas["type"])
+	}
+
+	labels := tenantProps["labels"].(map[string]interface{})
+	label := labels["patternProperties"].(map[string]interface{})[anyKeyPattern].(map[string]interface{})
+	if label["type"] != "string" {
+		t.Errorf("tenant labels should map to strings, got %v", label["type"])
+	}
+}
+
+func TestEscapedKeys(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"config":                  {Path: "config", Type: "object"},
+		`config.nginx\.conf`:      {Path: `config.nginx\.conf`, Type: "string"},
+		"podAnnotations":          {Path: "podAnnotations", Type: "object"},
+		`podAnnotations.team\.io`: {Path: `podAnnotations.team\.io`, Type: "object"},
+		`podAnnotations.team\.io.owner`: {
+			Path: `podAnnotations.team\.io.owner`,
+			Type: "unknown",
+		},
+	}
+
+	schema := Generate(values).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+
+	configProps := properties["config"].(map[string]interface{})["properties"].(map[string]interface{})
+	nginx, ok := configProps["nginx.conf"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("config should have a nginx.conf property, got %v", configProps)
+	}
+	if nginx["type"] != "string" {
+		t.Errorf("nginx.conf should be a string, got %v", nginx["type"])
+	}
+
+	annotations := properties["podAnnotations"].(map[string]interface{})["properties"].(map[string]interface{})
+	team, ok := annotations["team.io"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("podAnnotations should have a team.io property, got %v", annotations)
+	}
+	if _, hasOwner := team["properties"].(map[string]interface{})["owner"]; !hasOwner {
+		t.Error("team.io should describe its nested owner field")
+	}
+}
+
+func TestDecodedStrings(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"rawConfig": {Path: "rawConfig", Type: "string", Encoding: "yaml", Decoded: []string{"port", "tls.enabled"}},
+		"policy":    {Path: "policy", Type: "string", Encoding: "json"},
+	}
+
+	schema := Generate(values).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+
+	rawConfig := properties["rawConfig"].(map[string]interface{})
+	if rawConfig["type"] != "string" || rawConfig["contentMediaType"] != "application/yaml" {
+		t.Errorf("rawConfig should be a YAML string, got %v", rawConfig)
+	}
+	if rawConfig["description"] != "YAML document with fields: port, tls.enabled" {
+		t.Errorf("rawConfig should document the decoded fields, got %v", rawConfig["description"])
+	}
+
+	policy := properties["policy"].(map[string]interface{})
+	if policy["contentMediaType"] != "application/json" {
+		t.Errorf("policy should be a JSON string, got %v", policy)
+	}
+	if _, hasDescription := policy["description"]; hasDescription {
+		t.Error("policy has no decoded fields to document")
+	}
+}
+
+func TestNestedArraysOfObjects(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"ingress":                          {Path: "ingress", Type: "object"},
+		"ingress.hosts":                    {Path: "ingress.hosts", Type: "array"},
+		"ingress.hosts[]":                  {Path: "ingress.hosts[]", Type: "object"},
+		"ingress.hosts[].host":             {Path: "ingress.hosts[].host", Type: "string"},
+		"ingress.hosts[].paths":            {Path: "ingress.hosts[].paths", Type: "array"},
+		"ingress.hosts[].paths[]":          {Path: "ingress.hosts[].paths[]", Type: "object"},
+		"ingress.hosts[].paths[].path":     {Path: "ingress.hosts[].paths[].path", Type: "string"},
+		"ingress.hosts[].paths[].pathType": {Path: "ingress.hosts[].paths[].pathType", Type: "unknown"},
+	}
+
+	schema := Generate(values).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+	ingress := properties["ingress"].(map[string]interface{})["properties"].(map[string]interface{})
+
+	hosts := ingress["hosts"].(map[string]interface{})
+	if hosts["type"] != "array" {
+		t.Fatalf("ingress.hosts should be an array, got %v", hosts)
+	}
+	hostItems := hosts["items"].(map[string]interface{})
+	if hostItems["type"] != "object" {
+		t.Fatalf("ingress.hosts items should be objects, got %v", hostItems)
+	}
+	hostProperties := hostItems["properties"].(map[string]interface{})
+	if _, exists := hostProperties["host"]; !exists {
+		t.Error("ingress.hosts items should have a host property")
+	}
+
+	paths := hostProperties["paths"].(map[string]interface{})
+	if paths["type"] != "array" {
+		t.Fatalf("ingress.hosts[].paths should be an array, got %v", paths)
+	}
+	pathProperties := paths["items"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, name := range []string{"path", "pathType"} {
+		if _, exists := pathProperties[name]; !exists {
+			t.Errorf("ingress.hosts[].paths items should have a %s property", name)
+		}
+	}
+}
+
+func TestDirectiveConstraints(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"replicas": {Path: "replicas", Type: "string", Confidence: 0.6, Constraints: map[string]any{"type": "integer", "minimum": 1}},
+		"hosts":    {Path: "hosts", Type: "array", Confidence: 1, Constraints: map[string]any{"minItems": 1}},
+		"hosts[]":  {Path: "hosts[]", Type: "unknown", Constraints: map[string]any{"pattern": "^[a-z.]+$"}},
+	}
+
+	schema := GenerateWithOptions(values, Options{MinConfidence: 0.9}).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+
+	replicas := properties["replicas"].(map[string]interface{})
+	if replicas["type"] != "integer" || replicas["minimum"] != 1 {
+		t.Errorf("replicas should take the directive's type and minimum, got %v", replicas)
+	}
+	hosts := properties["hosts"].(map[string]interface{})
+	if hosts["type"] != "array" || hosts["minItems"] != 1 {
+		t.Errorf("hosts should be an array of at least one item, got %v", hosts)
+	}
+	if items := hosts["items"].(map[string]interface{}); items["pattern"] != "^[a-z.]+$" {
+		t.Errorf("hosts items should take the directive's pattern, got %v", items)
+	}
+}
+
+func TestUnknownPolicies(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"name":     {Path: "name", Type: "unknown"},
+		"port":     {Path: "port", Type: "integer", Confidence: 0.9},
+		"tag":      {Path: "tag", Type: "unknown", Constraints: map[string]any{"type": "string"}},
+		"labels":   {Path: "labels", Type: "map"},
+		"labels.*": {Path: "labels.*", Type: "unknown"},
+		"hosts":    {Path: "hosts", Type: "array"},
+		"hosts[]":  {Path: "hosts[]", Type: "unknown"},
+	}
+
+	tests := []struct {
+		policy   string
+		nameType any
+		omitted  bool
+	}{
+		{"", nil, false},
+		{UnknownAny, nil, false},
+		{UnknownString, "string", false},
+		{UnknownOmit, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			schema := GenerateWithOptions(values, Options{Unknown: tt.policy}).ToMap()
+			properties := schema["properties"].(map[string]interface{})
+
+			name, exists := properties["name"].(map[string]interface{})
+			if exists == tt.omitted {
+				t.Fatalf("Expected name to be omitted: %v, got %v", tt.omitted, properties["name"])
+			}
+			if exists && name["type"] != tt.nameType {
+				t.Errorf("Expected name to have type %v, got %v", tt.nameType, name["type"])
+			}
+			patterns, _ := properties["labels"].(map[string]interface{})["patternProperties"].(map[string]interface{})
+			if _, exists := patterns[anyKeyPattern]; exists == tt.omitted {
+				t.Errorf("Expected label values to be omitted: %v, got %v", tt.omitted, patterns)
+			}
+			for _, path := range []string{"port", "tag", "hosts"} {
+				if _, exists := properties[path]; !exists {
+					t.Errorf("Expected typed value %s to be kept", path)
+				}
+			}
+		})
+	}
+
+	if err := CheckUnknown(values, Options{Unknown: UnknownString}); err != nil {
+		t.Errorf("Expected only strict-error to fail, got %v", err)
+	}
+	err := CheckUnknown(values, Options{Unknown: UnknownStrictError})
+	if err == nil || !strings.Contains(err.Error(), "2 values") || !strings.Contains(err.Error(), "labels.*, name") {
+		t.Errorf("Expected name and labels.* to fail strict-error, got %v", err)
+	}
+}
+
+func TestRequiredProperties(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"image":            {Path: "image", Type: "object"},
+		"image.repository": {Path: "image.repository", Type: "unknown", Required: true},
+		"image.tag":        {Path: "image.tag", Type: "unknown"},
+		"name":             {Path: "name", Type: "string", Required: true},
+		"hosts":            {Path: "hosts", Type: "array"},
+		"hosts[]":          {Path: "hosts[]", Type: "object"},
+		"hosts[].host":     {Path: "hosts[].host", Type: "string", Required: true},
+		"labels":           {Path: "labels", Type: "map"},
+		"labels.*":         {Path: "labels.*", Type: "object"},
+		"labels.*.value":   {Path: "labels.*.value", Type: "string", Required: true},
+		"db.auth.password": {Path: "db.auth.password", Type: "string", Required: true},
+		"ingress.host":     {Path: "ingress.host", Type: "string", Required: true, Guards: []string{"ingress.enabled"}},
+	}
+
+	schema := GenerateWithOptions(values, Options{}).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+
+	// Objects holding values always required are required too, not lists, maps or guarded objects
+	if !reflect.DeepEqual(schema["required"], []string{"db", "image", "name"}) {
+		t.Errorf("Expected db, image and name to be required at the root, got %v", schema["required"])
+	}
+	db := properties["db"].(map[string]interface{})
+	if !reflect.DeepEqual(db["required"], []string{"auth"}) {
+		t.Errorf("Expected db.auth to be required, got %v", db["required"])
+	}
+	if ingress := properties["ingress"].(map[string]interface{}); !reflect.DeepEqual(ingress["required"], []string{"host"}) {
+		t.Errorf("Expected ingress.host to be required within ingress, got %v", ingress["required"])
+	}
+	image := properties["image"].(map[string]interface{})
+	if !reflect.DeepEqual(image["required"], []string{"repository"}) {
+		t.Errorf("Expected image.repository to be required, got %v", image["required"])
+	}
+	items := properties["hosts"].(map[string]interface{})["items"].(map[string]interface{})
+	if !reflect.DeepEqual(items["required"], []string{"host"}) {
+		t.Errorf("Expected host to be required in hosts items, got %v", items["required"])
+	}
+	patterns := properties["labels"].(map[string]interface{})["patternProperties"].(map[string]interface{})
+	if required := patterns[anyKeyPattern].(map[string]interface{})["required"]; !reflect.DeepEqual(required, []string{"value"}) {
+		t.Errorf("Expected value to be required in label values, got %v", required)
+	}
+
+	omitted := GenerateWithOptions(values, Options{OmitRequired: true}).ToMap()
+	if _, exists := omitted["required"]; exists {
+		t.Errorf("Expected no required list with OmitRequired, got %v", omitted["required"])
+	}
+	image = omitted["properties"].(map[string]interface{})["image"].(map[string]interface{})
+	if _, exists := image["required"]; exists {
+		t.Errorf("Expected no nested required list with OmitRequired, got %v", image["required"])
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"replicas": {Path: "replicas", Type: "integer", Default: 2},
+		"ports":    {Path: "ports", Type: "array", Default: []any{80, 443}},
+		"ports[]":  {Path: "ports[]", Type: "unknown"},
+		"name":     {Path: "name", Type: "unknown"},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	if got := properties["replicas"].(map[string]interface{})["default"]; got != 2 {
+		t.Errorf("Expected replicas to default to 2, got %v", got)
+	}
+	if got := properties["ports"].(map[string]interface{})["default"]; !reflect.DeepEqual(got, []any{80, 443}) {
+		t.Errorf("Expected ports to default to [80 443], got %v", got)
+	}
+	if _, exists := properties["name"].(map[string]interface{})["default"]; exists {
+		t.Error("Expected no default for a value without one")
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"replicas": {Path: "replicas", Type: "integer", Description: "Number of pods"},
+		"config":   {Path: "config", Type: "string", Encoding: "yaml", Decoded: []string{"level"}, Description: "Logging configuration"},
+		"extra":    {Path: "extra", Type: "string", Encoding: "yaml", Decoded: []string{"level"}},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	expected := map[string]string{
+		"replicas": "Number of pods",
+		// The values.yaml description is kept over the decoded fields
+		"config": "Logging configuration",
+		"extra":  "YAML document with fields: level",
+	}
+	for path, want := range expected {
+		if got := properties[path].(map[string]interface{})["description"]; got != want {
+			t.Errorf("Expected %s to be described as %q, got %v", path, want, got)
+		}
+	}
+}
+
+func TestSchemaDraft(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"name": {Path: "name", Type: "string"},
+	}
+
+	if uri := Generate(values).ToMap()["$schema"]; uri != "https://json-schema.org/draft/2020-12/schema" {
+		t.Errorf("Expected 2020-12 by default, got %v", uri)
+	}
+
+	draft07 := GenerateWithOptions(values, Options{Draft: Draft07}).ToMap()
+	if uri := draft07["$schema"]; uri != "http://json-schema.org/draft-07/schema#" {
+		t.Errorf("Expected the draft-07 meta-schema, got %v", uri)
+	}
+	if draft := DraftOf(draft07); draft != Draft07 || DefinitionsKeyword(draft) != "definitions" {
+		t.Errorf("Expected draft-07 with definitions, got %s", draft)
+	}
+
+	merged := MergeSchemas(ChartSchema{Name: "main", Schema: FromMap(draft07)}, []ChartSchema{{Name: "sub", Schema: GenerateWithOptions(values, Options{Draft: Draft07})}}).ToMap()
+	if merged["$schema"] != draft07["$schema"] {
+		t.Errorf("Expected the merged schema to keep the draft, got %v", merged["$schema"])
+	}
+}
+
+func TestStringMaps(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"podLabels":           {Path: "podLabels", Type: "unknown"},
+		"podAnnotations":      {Path: "podAnnotations", Type: "map"},
+		"service.annotations": {Path: "service.annotations", Type: "unknown"},
+		"nodeSelector.*":      {Path: "nodeSelector.*", Type: "unknown"},
+		"commonLabels.team":   {Path: "commonLabels.team", Type: "string"},
+		"env":                 {Path: "env", Type: "unknown"},
+		"extraEnv":            {Path: "extraEnv", Type: "map"},
+		"showLabels":          {Path: "showLabels", Type: "boolean"},
+		"extraSelectors":      {Path: "extraSelectors", Type: "unknown"},
+	}
+
+	properties := GenerateWithOptions(values, Options{StringMaps: []string{"extraSelectors"}}).ToMap()["properties"].(map[string]interface{})
+	stringValues := map[string]interface{}{"type": "string"}
+	for _, path := range []string{"podLabels", "podAnnotations", "extraEnv", "extraSelectors"} {
+		prop := properties[path].(map[string]interface{})
+		if prop["type"] != "object" || !reflect.DeepEqual(prop["additionalProperties"], stringValues) {
+			t.Errorf("Expected %s to be a map of strings, got %v", path, prop)
+		}
+	}
+	annotations := properties["service"].(map[string]interface{})["properties"].(map[string]interface{})["annotations"].(map[string]interface{})
+	if !reflect.DeepEqual(annotations["additionalProperties"], stringValues) {
+		t.Errorf("Expected nested annotations to be a map of strings, got %v", annotations)
+	}
+
+	// Ranged over maps get their values typed
+	nodeSelector := properties["nodeSelector"].(map[string]interface{})
+	if got := nodeSelector["patternProperties"].(map[string]interface{})[anyKeyPattern]; !reflect.DeepEqual(got, stringValues) {
+		t.Errorf("Expected nodeSelector values to be strings, got %v", got)
+	}
+
+	// Keys the templates read stay described, others are accepted
+	commonLabels := properties["commonLabels"].(map[string]interface{})
+	if _, ok := commonLabels["properties"].(map[string]interface{})["team"]; !ok || !reflect.DeepEqual(commonLabels["additionalProperties"], stringValues) {
+		t.Errorf("Expected commonLabels to keep team and accept other labels, got %v", commonLabels)
+	}
+
+	// env may be a list, only objects are described as maps
+	if env := properties["env"].(map[string]interface{}); len(env) != 0 {
+		t.Errorf("Expected env of unknown type to accept any value, got %v", env)
+	}
+	if showLabels := properties["showLabels"].(map[string]interface{}); showLabels["type"] != "boolean" {
+		t.Errorf("Expected showLabels to stay a boolean, got %v", showLabels)
+	}
+}
+
+func TestAnyOfUnions(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"hosts": {
+			Path:        "hosts",
+			Type:        "union",
+			Types:       []string{"array", "string"},
+			Description: "Hosts to route",
+			Constraints: map[string]interface{}{"minLength": 1},
+		},
+		"hosts[]":  {Path: "hosts[]", Type: "string"},
+		"config":   {Path: "config", Type: "union", Types: []string{"map", "string"}},
+		"config.a": {Path: "config.a", Type: "integer", Required: true},
+		"replicas": {Path: "replicas", Type: "integer"},
+	}
+
+	// Type lists by default
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	if hosts := properties["hosts"].(map[string]interface{}); !reflect.DeepEqual(hosts["type"], []string{"array", "string"}) {
+		t.Errorf("Expected a type list, got %v", hosts)
+	}
+
+	properties = GenerateWithOptions(values, Options{AnyOf: true}).ToMap()["properties"].(map[string]interface{})
+	hosts := properties["hosts"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"description": "Hosts to route",
+		"anyOf": []interface{}{
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			map[string]interface{}{"type": "string", "minLength": 1},
+		},
+	}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("Expected hosts as anyOf\n%v\ngot\n%v", expected, hosts)
+	}
+
+	config := properties["config"].(map[string]interface{})
+	members, ok := config["anyOf"].([]interface{})
+	if !ok || len(members) != 2 {
+		t.Fatalf("Expected config as anyOf of two schemas, got %v", config)
+	}
+	object := members[0].(map[string]interface{})
+	if object["type"] != "object" || object["additionalProperties"] != false || !reflect.DeepEqual(object["required"], []string{"a"}) {
+		t.Errorf("Expected the object member to hold the object keywords, got %v", object)
+	}
+	if _, exists := object["properties"].(map[string]interface{})["a"]; !exists {
+		t.Errorf("Expected the object member to describe a, got %v", object)
+	}
+	if !reflect.DeepEqual(members[1], map[string]interface{}{"type": "string"}) {
+		t.Errorf("Expected a string member, got %v", members[1])
+	}
+
+	if replicas := properties["replicas"].(map[string]interface{}); replicas["type"] != "integer" {
+		t.Errorf("Single types should stay as they are, got %v", replicas)
+	}
+}
+
+func TestNullableValues(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"persistence":      {Path: "persistence", Type: "object", Nullable: true},
+		"persistence.size": {Path: "persistence.size", Type: "string"},
+		"host":             {Path: "host", Type: "string", Required: true},
+		"port":             {Path: "port", Type: "union", Types: []string{"integer", "string"}},
+		"extra":            {Path: "extra", Type: "unknown"},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	persistence := properties["persistence"].(map[string]interface{})
+	if !reflect.DeepEqual(persistence["type"], []string{"null", "object"}) {
+		t.Errorf("Expected persistence to accept null, got %v", persistence["type"])
+	}
+	if _, ok := persistence["properties"].(map[string]interface{})["size"]; !ok {
+		t.Errorf("Expected persistence to keep its fields, got %v", persistence)
+	}
+	if !reflect.DeepEqual(properties["port"].(map[string]interface{})["type"], []string{"integer", "string"}) {
+		t.Errorf("Only values marked nullable should accept null by default")
+	}
+
+	properties = GenerateWithOptions(values, Options{Nullable: true}).ToMap()["properties"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"persistence": []string{"null", "object"},
+		"host":        "string",
+		"port":        []string{"integer", "null", "string"},
+		"extra":       nil,
+	}
+	for path, want := range expected {
+		if got := properties[path].(map[string]interface{})["type"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %s to have type %v, got %v", path, want, got)
+		}
+	}
+	// A resource quantity, which only accepts null when nullable
+	size := persistence["properties"].(map[string]interface{})["size"].(map[string]interface{})
+	if members, _ := size["anyOf"].([]interface{}); len(members) != 2 {
+		t.Errorf("Expected the default generation to leave size alone, got %v", size)
+	}
+}
+
+func TestInferredFormats(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"externalURL": {Path: "externalURL", Type: "string"},
+		"backend":     {Path: "backend", Type: "string", Functions: []string{"getHostByName"}},
+		"adminEmail":  {Path: "adminEmail", Type: "string", Constraints: map[string]interface{}{"format": "idn-email"}},
+		"host":        {Path: "host", Type: "string", Default: ""},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"externalURL": "uri",
+		"backend":     "hostname",
+		// Directives take precedence
+		"adminEmail": "idn-email",
+		"host":       nil,
+	}
+	for path, want := range expected {
+		if got := properties[path].(map[string]interface{})["format"]; got != want {
+			t.Errorf("Expected %s to have format %v, got %v", path, want, got)
+		}
+	}
+
+	properties = GenerateWithOptions(values, Options{OmitFormats: true}).ToMap()["properties"].(map[string]interface{})
+	if format, exists := properties["externalURL"].(map[string]interface{})["format"]; exists {
+		t.Errorf("Expected no inferred format, got %v", format)
+	}
+}
+
+func TestConditionals(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"ingress":           {Path: "ingress", Type: "object"},
+		"ingress.enabled":   {Path: "ingress.enabled", Type: "boolean"},
+		"ingress.host":      {Path: "ingress.host", Type: "string", Guards: []string{"ingress.enabled"}},
+		"ingress.path":      {Path: "ingress.path", Type: "string", Guards: []string{"ingress.enabled"}, Default: "/"},
+		"ingress.className": {Path: "ingress.className", Type: "string", Guards: []string{"ingress.enabled"}, Optional: true},
+		"metrics":           {Path: "metrics", Type: "object"},
+		"metrics.port":      {Path: "metrics.port", Type: "integer", Guards: []string{"metrics.enabled", "serviceMonitor"}},
+		"metrics.enabled":   {Path: "metrics.enabled", Type: "boolean"},
+		"serviceMonitor":    {Path: "serviceMonitor", Type: "unknown"},
+		"hosts[]":           {Path: "hosts[]", Type: "string", Guards: []string{"ingress.enabled"}},
+	}
+
+	// Left out by default
+	if schema := Generate(values).ToMap(); schema["if"] != nil || schema["allOf"] != nil {
+		t.Errorf("Expected no conditionals by default, got %v", schema)
+	}
+
+	schema := GenerateWithOptions(values, Options{Conditionals: true}).ToMap()
+	ingress := schema["properties"].(map[string]interface{})["ingress"].(map[string]interface{})
+	expectedIf := map[string]interface{}{
+		"properties": map[string]interface{}{"enabled": map[string]interface{}{"const": true}},
+		"required":   []string{"enabled"},
+	}
+	if !reflect.DeepEqual(ingress["if"], expectedIf) {
+		t.Errorf("Expected ingress to test enabled\n%v\ngot\n%v", expectedIf, ingress["if"])
+	}
+	if expected := map[string]interface{}{"required": []string{"host"}}; !reflect.DeepEqual(ingress["then"], expected) {
+		t.Errorf("Expected ingress to require only host, got %v", ingress["then"])
+	}
+
+	// Guards on different objects meet at the root
+	expectedRoot := map[string]interface{}{
+		"if": map[string]interface{}{
+			"properties": map[string]interface{}{
+				"metrics": map[string]interface{}{
+					"properties": map[string]interface{}{"enabled": map[string]interface{}{"const": true}},
+					"required":   []string{"enabled"},
+				},
+				"serviceMonitor": map[string]interface{}{"not": map[string]interface{}{"enum": falsyValues}},
+			},
+			"required": []string{"metrics", "serviceMonitor"},
+		},
+		"then": map[string]interface{}{
+			"properties": map[string]interface{}{"metrics": map[string]interface{}{"required": []string{"port"}}},
+			"required":   []string{"metrics"},
+		},
+	}
+	if !reflect.DeepEqual(map[string]interface{}{"if": schema["if"], "then": schema["then"]}, expectedRoot) {
+		t.Errorf("Expected the root to require metrics.port\n%v\ngot\n%v", expectedRoot, map[string]interface{}{"if": schema["if"], "then": schema["then"]})
+	}
+
+	// Required lists are left out altogether
+	schema = GenerateWithOptions(values, Options{Conditionals: true, OmitRequired: true}).ToMap()
+	if ingress := schema["properties"].(map[string]interface{})["ingress"].(map[string]interface{}); ingress["if"] != nil {
+		t.Errorf("Expected no conditionals without required lists, got %v", ingress)
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"tls":            {Path: "tls", Type: "object"},
+		"tls.enabled":    {Path: "tls.enabled", Type: "boolean", Default: false},
+		"tls.secretName": {Path: "tls.secretName", Type: "string", Guards: []string{"tls.enabled"}},
+		"tls.issuer":     {Path: "tls.issuer", Type: "string", Guards: []string{"tls.enabled"}, Default: "letsencrypt"},
+		"auth":           {Path: "auth", Type: "object"},
+		"auth.username":  {Path: "auth.username", Type: "string"},
+		"auth.password":  {Path: "auth.password", Type: "string", Guards: []string{"auth.username", "rbac"}},
+		"rbac":           {Path: "rbac", Type: "boolean"},
+	}
+
+	schema := GenerateWithOptions(values, Options{Dependencies: true}).ToMap()
+	properties := schema["properties"].(map[string]interface{})
+	tls := properties["tls"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"secretName": map[string]interface{}{
+			"properties": map[string]interface{}{"enabled": map[string]interface{}{"const": true}},
+			"required":   []string{"enabled"},
+		},
+	}
+	if !reflect.DeepEqual(tls["dependentSchemas"], expected) {
+		t.Errorf("Expected secretName to require enabled true\n%v\ngot\n%v", expected, tls["dependentSchemas"])
+	}
+
+	// Siblings without a default only need to be set, values tested outside the object are left out
+	auth := properties["auth"].(map[string]interface{})
+	if expected := map[string]interface{}{"password": []string{"username"}}; !reflect.DeepEqual(auth["dependentRequired"], expected) {
+		t.Errorf("Expected password to require username, got %v", auth["dependentRequired"])
+	}
+
+	schema = GenerateWithOptions(values, Options{Dependencies: true, Draft: Draft07}).ToMap()
+	auth = schema["properties"].(map[string]interface{})["auth"].(map[string]interface{})
+	if expected := map[string]interface{}{"password": []string{"username"}}; !reflect.DeepEqual(auth["dependencies"], expected) || auth["dependentRequired"] != nil {
+		t.Errorf("Expected draft-07 dependencies, got %v", auth)
+	}
+
+	if tls := Generate(values).ToMap()["properties"].(map[string]interface{})["tls"].(map[string]interface{}); tls["dependentSchemas"] != nil {
+		t.Errorf("Expected no dependencies by default, got %v", tls)
+	}
+}
+
+func TestExclusiveBranches(t *testing.T) {
+	chain := []string{"persistence.existingClaim", "persistence.hostPath"}
+	values := map[string]*parser.ValuePath{
+		"persistence":               {Path: "persistence", Type: "object"},
+		"persistence.existingClaim": {Path: "persistence.existingClaim", Type: "string", Default: "", Exclusive: [][]string{chain}},
+		"persistence.hostPath":      {Path: "persistence.hostPath", Type: "string", Exclusive: [][]string{chain}},
+		"tls":                       {Path: "tls", Type: "object"},
+		"tls.secretName":            {Path: "tls.secretName", Type: "string", Exclusive: [][]string{{"tls.secretName", "tls.enabled"}}},
+		"tls.enabled":               {Path: "tls.enabled", Type: "boolean", Default: true, Exclusive: [][]string{{"tls.secretName", "tls.enabled"}}},
+	}
+
+	if persistence := Generate(values).ToMap()["properties"].(map[string]interface{})["persistence"].(map[string]interface{}); persistence["oneOf"] != nil {
+		t.Errorf("Expected no oneOf by default, got %v", persistence)
+	}
+
+	properties := GenerateWithOptions(values, Options{OneOf: true}).ToMap()["properties"].(map[string]interface{})
+	set := func(key string) map[string]interface{} {
+		return map[string]interface{}{
+			"properties": map[string]interface{}{key: map[string]interface{}{"not": map[string]interface{}{"enum": falsyValues}}},
+			"required":   []string{key},
+		}
+	}
+	expected := []interface{}{
+		set("existingClaim"),
+		set("hostPath"),
+		map[string]interface{}{"not": map[string]interface{}{"anyOf": []interface{}{set("existingClaim"), set("hostPath")}}},
+	}
+	if persistence := properties["persistence"].(map[string]interface{}); !reflect.DeepEqual(persistence["oneOf"], expected) {
+		t.Errorf("Expected at most one persistence source\n%v\ngot\n%v", expected, persistence["oneOf"])
+	}
+
+	// A value set by default would always select its branch
+	if tls := properties["tls"].(map[string]interface{}); tls["oneOf"] != nil {
+		t.Errorf("Expected no oneOf for a chain testing a value defaulting to true, got %v", tls)
+	}
+}
+
+func TestExamples(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"replicas": {Path: "replicas", Type: "integer", Default: 1, Examples: []any{1, 3}},
+		"host":     {Path: "host", Type: "string", Default: "", Examples: []any{"", "app.example.com"}},
+		"tags":     {Path: "tags", Type: "array", Examples: []any{[]any{}}},
+		"password": {Path: "password", Type: "string", Sensitive: true, Examples: []any{"hunter2"}},
+	}
+
+	if replicas := Generate(values).ToMap()["properties"].(map[string]interface{})["replicas"].(map[string]interface{}); replicas["examples"] != nil {
+		t.Errorf("Expected no examples by default, got %v", replicas)
+	}
+
+	properties := GenerateWithOptions(values, Options{Examples: true}).ToMap()["properties"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"replicas": []any{1, 3},
+		// Empty values show nothing
+		"host": []any{"app.example.com"},
+		"tags": nil,
+		// Secrets are not disclosed
+		"password": nil,
+	}
+	for key, want := range expected {
+		got, _ := properties[key].(map[string]interface{})["examples"].([]any)
+		if want == nil && got != nil || want != nil && !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected %s examples %v, got %v", key, want, got)
+		}
+	}
+}
+
+func TestConstants(t *testing.T) {
+	site := parser.Site{File: "deployment.yaml", Line: 1, Column: 10}
+	other := parser.Site{File: "deployment.yaml", Line: 2, Column: 10}
+	values := map[string]*parser.ValuePath{
+		"mode": {Path: "mode", Type: "unknown", Default: "standalone", Sources: []parser.Site{site},
+			Comparisons: []parser.Comparison{{Literal: "standalone", Site: site}}},
+		"arch": {Path: "arch", Type: "unknown", Sources: []parser.Site{site},
+			Comparisons: []parser.Comparison{{Literal: "amd64", Site: site}, {Literal: "arm64", Site: site}}},
+		"tier": {Path: "tier", Type: "string", Sources: []parser.Site{site, other},
+			Comparisons: []parser.Comparison{{Literal: "frontend", Site: site}}},
+		// The chart would not validate against its own values
+		"replicas": {Path: "replicas", Type: "unknown", Default: 1, Sources: []parser.Site{site},
+			Comparisons: []parser.Comparison{{Literal: 3, Site: site}}},
+	}
+
+	if mode := Generate(values).ToMap()["properties"].(map[string]interface{})["mode"].(map[string]interface{}); mode["const"] != nil {
+		t.Errorf("Expected no const by default, got %v", mode)
+	}
+
+	properties := GenerateWithOptions(values, Options{Constants: true}).ToMap()["properties"].(map[string]interface{})
+	for key, want := range map[string]any{"mode": "standalone", "arch": nil, "tier": nil, "replicas": nil} {
+		if got := properties[key].(map[string]interface{})["const"]; got != want {
+			t.Errorf("Expected %s const %v, got %v", key, want, got)
+		}
+	}
+	if constants := Constants(values); !reflect.DeepEqual(constants, map[string]any{"mode": "standalone"}) {
+		t.Errorf("Expected mode reported as a constant, got %v", constants)
+	}
+}
+
+func TestNonEmptyStrings(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"image.tag": {Path: "image.tag", Type: "string", Required: true, NonEmpty: true},
+		"host":      {Path: "host", Type: "unknown", NonEmpty: true},
+		"port":      {Path: "port", Type: "union", Types: []string{"integer", "string"}, NonEmpty: true},
+		"replicas":  {Path: "replicas", Type: "integer", Required: true, NonEmpty: true},
+		"name":      {Path: "name", Type: "string", Required: true},
+		"domain":    {Path: "domain", Type: "string", NonEmpty: true, Constraints: map[string]any{"minLength": 3}},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	image := properties["image"].(map[string]interface{})["properties"].(map[string]interface{})
+	expected := map[string]interface{}{
+		"tag":      1,
+		"host":     1,
+		"port":     1,
+		"replicas": nil,
+		"name":     nil,
+		// Directives take precedence
+		"domain": 3,
+	}
+	for key, want := range expected {
+		prop := properties[key]
+		if key == "tag" {
+			prop = image[key]
+		}
+		if got := prop.(map[string]interface{})["minLength"]; got != want {
+			t.Errorf("Expected %s minLength %v, got %v", key, want, got)
+		}
+	}
+}
+
+func TestUniqueItems(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"zones":   {Path: "zones", Type: "array", Unique: true},
+		"extra":   {Path: "extra", Type: "unknown", Unique: true},
+		"hosts":   {Path: "hosts", Type: "union", Types: []string{"array", "string"}, Unique: true},
+		"args":    {Path: "args", Type: "array"},
+		"command": {Path: "command", Type: "string", Unique: true},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	for key, want := range map[string]interface{}{"zones": true, "extra": true, "hosts": true, "args": nil, "command": nil} {
+		if got := properties[key].(map[string]interface{})["uniqueItems"]; got != want {
+			t.Errorf("Expected %s uniqueItems %v, got %v", key, want, got)
+		}
+	}
+}
+
+func TestPropertyNames(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"env":    {Path: "env", Type: "map", KeyPattern: parser.EnvVarNamePattern},
+		"env.*":  {Path: "env.*", Type: "string"},
+		"config": {Path: "config", Type: "unknown", KeyPattern: parser.ConfigMapKeyPattern},
+		"labels": {Path: "labels", Type: "map"},
+		"name":   {Path: "name", Type: "string", KeyPattern: parser.EnvVarNamePattern},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	for key, want := range map[string]string{"env": parser.EnvVarNamePattern, "config": parser.ConfigMapKeyPattern, "labels": "", "name": ""} {
+		got := ""
+		if names, ok := properties[key].(map[string]interface{})["propertyNames"].(map[string]any); ok {
+			got, _ = names["pattern"].(string)
+		}
+		if got != want {
+			t.Errorf("Expected %s propertyNames pattern %q, got %q", key, want, got)
+		}
+	}
+	if env := properties["env"].(map[string]interface{}); env["additionalProperties"] == nil {
+		t.Errorf("Expected env to keep its value schema, got %v", env)
+	}
+}
+
+func TestIntOrPercent(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"rollout.surge":       {Path: "rollout.surge", Type: "integer", IntOrPercent: true},
+		"rollout.unavailable": {Path: "rollout.unavailable", Type: "string", IntOrPercent: true, Nullable: true},
+		"maxSurge":            {Path: "maxSurge", Type: "unknown"},
+		"minAvailable":        {Path: "minAvailable", Type: "object"},
+		"replicas":            {Path: "replicas", Type: "integer"},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	intOrPercent := []interface{}{
+		map[string]interface{}{"type": "integer", "minimum": 0},
+		map[string]interface{}{"type": "string", "pattern": percentPattern},
+	}
+	rollout := properties["rollout"].(map[string]interface{})["properties"].(map[string]interface{})
+	for key, prop := range map[string]interface{}{"rollout.surge": rollout["surge"], "maxSurge": properties["maxSurge"]} {
+		if expected := map[string]interface{}{"anyOf": intOrPercent}; !reflect.DeepEqual(prop, expected) {
+			t.Errorf("Expected %s to take an integer or a percentage, got %v", key, prop)
+		}
+	}
+	nullable := append(intOrPercent[:2:2], map[string]interface{}{"type": "null"})
+	if prop := rollout["unavailable"].(map[string]interface{}); !reflect.DeepEqual(prop["anyOf"], nullable) {
+		t.Errorf("Expected rollout.unavailable to also accept null, got %v", prop)
+	}
+	for _, key := range []string{"minAvailable", "replicas"} {
+		if prop := properties[key].(map[string]interface{}); prop["anyOf"] != nil || prop["type"] == nil {
+			t.Errorf("Expected %s left typed, got %v", key, prop)
+		}
+	}
+}
+
+func TestQuantities(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"resources.requests.cpu": {Path: "resources.requests.cpu", Type: "string", Default: "100m"},
+		"requestMemory":          {Path: "requestMemory", Type: "string", Quantity: true},
+		"persistence.size":       {Path: "persistence.size", Type: "union", Types: []string{"integer", "string"}},
+		"persistence.memory":     {Path: "persistence.memory", Type: "boolean"},
+	}
+
+	properties := Generate(values).ToMap()["properties"].(map[string]interface{})
+	quantity := []interface{}{
+		map[string]interface{}{"type": "number", "minimum": 0},
+		map[string]interface{}{"type": "string", "pattern": quantityPattern},
+	}
+	persistence := properties["persistence"].(map[string]interface{})["properties"].(map[string]interface{})
+	cpu := properties["resources"].(map[string]interface{})["properties"].(map[string]interface{})["requests"].(map[string]interface{})["properties"].(map[string]interface{})["cpu"]
+	for key, prop := range map[string]interface{}{"cpu": cpu, "requestMemory": properties["requestMemory"], "persistence.size": persistence["size"]} {
+		if got := prop.(map[string]interface{})["anyOf"]; !reflect.DeepEqual(got, quantity) {
+			t.Errorf("Expected %s to take a number or a quantity, got %v", key, prop)
+		}
+	}
+	if memory := persistence["memory"].(map[string]interface{}); memory["type"] != "boolean" || memory["anyOf"] != nil {
+		t.Errorf("Expected persistence.memory left a boolean, got %v", memory)
+	}
+
+	pattern := regexp.MustCompile(quantityPattern)
+	for quantity, valid := range map[string]bool{"100m": true, "1.5": true, "256Mi": true, "2Gi": true, "1e3": true, "10k": true, "1K": false, "-1": false, "Mi": false, "1.5.0": false} {
+		if pattern.MatchString(quantity) != valid {
+			t.Errorf("Expected %q to match the quantity pattern: %v", quantity, valid)
+		}
+	}
+}
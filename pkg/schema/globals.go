@@ -0,0 +1,174 @@
+package schema
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// globalKey is the root key Helm shares between a chart and all of its subcharts
+const globalKey = "global"
+
+// GlobalUsage records the type a chart reads a global value as
+type GlobalUsage struct {
+	Chart string `json:"chart"`
+	Type  string `json:"type"`
+}
+
+// GlobalConflict describes a global value charts read as different types, accepted as any of
+// them by the merged schema
+type GlobalConflict struct {
+	Path   string        `json:"path"`
+	Usages []GlobalUsage `json:"usages"`
+}
+
+// GlobalConflicts returns the global values the charts MergeSchemas combines read as different
+// types, by path. Values of unknown type are compatible with anything and are left out, as are
+// the schemas subcharts ship.
+func GlobalConflicts(mainSchema ChartSchema, subchartSchemas []ChartSchema) []GlobalConflict {
+	usages := make(map[string][]GlobalUsage)
+	for _, chart := range chartMaps(append([]ChartSchema{mainSchema}, subchartSchemas...)) {
+		if global := chartGlobal(chart); global != nil && !chart.Shipped {
+			collectGlobalUsages(global, globalKey, chart.Name, usages)
+		}
+	}
+
+	paths := make([]string, 0, len(usages))
+	for path := range usages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var conflicts []GlobalConflict
+	for _, path := range paths {
+		types := make(map[string]bool)
+		for _, usage := range usages[path] {
+			types[usage.Type] = true
+		}
+		if len(types) < 2 {
+			continue
+		}
+		typed := usages[path]
+		sort.SliceStable(typed, func(i, j int) bool {
+			if typed[i].Type != typed[j].Type {
+				return typed[i].Type < typed[j].Type
+			}
+			return typed[i].Chart < typed[j].Chart
+		})
+		conflicts = append(conflicts, GlobalConflict{Path: path, Usages: typed})
+	}
+	return conflicts
+}
+
+// collectGlobalUsages records the known type of a global value and of the values below it
+func collectGlobalUsages(node map[string]any, path, chart string, usages map[string][]GlobalUsage) {
+	if types := schemaTypes(node); types != "any" {
+		usages[path] = append(usages[path], GlobalUsage{Chart: chart, Type: types})
+	}
+	if properties, ok := node["properties"].(map[string]any); ok {
+		for key, child := range properties {
+			if child, ok := child.(map[string]any); ok {
+				collectGlobalUsages(child, path+"."+key, chart, usages)
+			}
+		}
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		collectGlobalUsages(items, path+"[]", chart, usages)
+	}
+}
+
+// chartGlobal returns the schema of the global values a chart reads, if any
+func chartGlobal(chart chartMap) map[string]any {
+	properties, _ := chart.Schema["properties"].(map[string]any)
+	global, _ := properties[globalKey].(map[string]any)
+	return global
+}
+
+// withoutGlobal returns the properties and required list of a subchart schema without its global
+// values, which Helm reads from the parent's global key
+func withoutGlobal(properties map[string]any, required any) (map[string]any, any) {
+	if _, ok := properties[globalKey]; !ok {
+		return properties, required
+	}
+	rest := make(map[string]any, len(properties)-1)
+	for key, value := range properties {
+		if key != globalKey {
+			rest[key] = value
+		}
+	}
+	if required == nil {
+		return rest, nil
+	}
+	names := slices.DeleteFunc(mergeRequired(required, nil), func(name string) bool { return name == globalKey })
+	if len(names) == 0 {
+		return rest, nil
+	}
+	return rest, names
+}
+
+// mergeGlobals combines the global values of the charts into the global property of the merged
+// schema, requiring it when any chart does
+func mergeGlobals(merged map[string]any, mainSchema chartMap, subchartSchemas []chartMap) {
+	properties := merged["properties"].(map[string]any)
+	var global map[string]any
+	if main := chartGlobal(mainSchema); main != nil {
+		global = copyValue(main).(map[string]any)
+	}
+	for _, subchart := range subchartSchemas {
+		sub := chartGlobal(subchart)
+		if sub == nil || subchart.Shipped {
+			continue
+		}
+		if global == nil {
+			global = copyValue(sub).(map[string]any)
+		} else {
+			mergeGlobal(global, sub)
+		}
+		if required, ok := subchart.Schema["required"]; ok && slices.Contains(mergeRequired(required, nil), globalKey) {
+			merged["required"] = mergeRequired(merged["required"], []string{globalKey})
+		}
+	}
+	if global != nil {
+		properties[globalKey] = global
+	}
+}
+
+// mergeGlobal merges the schema of a global value another chart reads into the one found so far.
+// Properties are merged one by one and required lists combined, differing types are unioned and
+// other keywords both set are taken from the first chart.
+func mergeGlobal(target, source map[string]any) {
+	targetTypes, sourceTypes := schemaTypes(target), schemaTypes(source)
+	for keyword, value := range source {
+		current, exists := target[keyword]
+		if !exists {
+			target[keyword] = copyValue(value)
+			continue
+		}
+		targetMap, targetIsMap := current.(map[string]any)
+		sourceMap, sourceIsMap := value.(map[string]any)
+		switch {
+		case (keyword == "properties" || keyword == "patternProperties") && targetIsMap && sourceIsMap:
+			for name, schema := range sourceMap {
+				targetSchema, targetIsSchema := targetMap[name].(map[string]any)
+				sourceSchema, sourceIsSchema := schema.(map[string]any)
+				if targetIsSchema && sourceIsSchema {
+					mergeGlobal(targetSchema, sourceSchema)
+				} else if _, exists := targetMap[name]; !exists {
+					targetMap[name] = copyValue(schema)
+				}
+			}
+		case keyword == "items" && targetIsMap && sourceIsMap:
+			mergeGlobal(targetMap, sourceMap)
+		case keyword == "required":
+			target[keyword] = mergeRequired(current, value)
+		}
+	}
+
+	if targetTypes == "any" || sourceTypes == "any" || targetTypes == sourceTypes {
+		return
+	}
+	target["type"] = unionTypes(append(strings.Split(targetTypes, "|"), strings.Split(sourceTypes, "|")...))
+	// Values of one of the types would not be among those listed for the other
+	delete(target, "enum")
+	delete(target, "const")
+}
@@ -0,0 +1,197 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DefaultKubernetesSchemasURL is where kubernetes-json-schema publishes the definitions of the
+// latest Kubernetes types
+const DefaultKubernetesSchemasURL = "https://raw.githubusercontent.com/yannh/kubernetes-json-schema/master/master"
+
+// KubernetesDefinitionsFile is the file of a kubernetes-json-schema release holding the
+// definitions of every Kubernetes type
+const KubernetesDefinitionsFile = "_definitions.json"
+
+// kubernetesType is a Kubernetes type charts pass values through to as they are
+type kubernetesType struct {
+	definition string // Name in the definitions of kubernetes-json-schema
+	list       bool   // Whether the value is a list of the type
+}
+
+// kubernetesTypes maps the keys of well-known values to the Kubernetes type they hold
+var kubernetesTypes = map[string]kubernetesType{
+	"resources":                 {definition: "io.k8s.api.core.v1.ResourceRequirements"},
+	"affinity":                  {definition: "io.k8s.api.core.v1.Affinity"},
+	"podSecurityContext":        {definition: "io.k8s.api.core.v1.PodSecurityContext"},
+	"securityContext":           {definition: "io.k8s.api.core.v1.SecurityContext"},
+	"containerSecurityContext":  {definition: "io.k8s.api.core.v1.SecurityContext"},
+	"livenessProbe":             {definition: "io.k8s.api.core.v1.Probe"},
+	"readinessProbe":            {definition: "io.k8s.api.core.v1.Probe"},
+	"startupProbe":              {definition: "io.k8s.api.core.v1.Probe"},
+	"lifecycle":                 {definition: "io.k8s.api.core.v1.Lifecycle"},
+	"tolerations":               {definition: "io.k8s.api.core.v1.Toleration", list: true},
+	"topologySpreadConstraints": {definition: "io.k8s.api.core.v1.TopologySpreadConstraint", list: true},
+	"imagePullSecrets":          {definition: "io.k8s.api.core.v1.LocalObjectReference", list: true},
+	"volumes":                   {definition: "io.k8s.api.core.v1.Volume", list: true},
+	"extraVolumes":              {definition: "io.k8s.api.core.v1.Volume", list: true},
+	"volumeMounts":              {definition: "io.k8s.api.core.v1.VolumeMount", list: true},
+	"extraVolumeMounts":         {definition: "io.k8s.api.core.v1.VolumeMount", list: true},
+}
+
+// KubernetesSchemas is where the schemas of Kubernetes types are taken from
+type KubernetesSchemas struct {
+	// BaseURL is the kubernetes-json-schema release the types are referenced at, e.g.
+	// DefaultKubernetesSchemasURL
+	BaseURL string
+	// Definitions are those of a vendored kubernetes-json-schema release, embedded in the
+	// schema instead of referenced, for validating offline
+	Definitions map[string]any
+}
+
+// ReferenceKubernetes replaces the objects generated for well-known values holding Kubernetes
+// types, such as resources, affinity or tolerations, with a $ref to the canonical schema of the
+// type. The description and default of the value are kept. Values used as other types are left
+// as they are. It returns the number of values referencing a type.
+func ReferenceKubernetes(schema map[string]any, source KubernetesSchemas) (int, error) {
+	keyword := DefinitionsKeyword(DraftOf(schema))
+	var embedded map[string]any
+	if source.Definitions != nil {
+		embedded, _ = schema[keyword].(map[string]any)
+		if embedded == nil {
+			embedded = make(map[string]any)
+		}
+	}
+
+	// ref returns the reference to a definition, embedding it and those it references
+	ref := func(definition string) (string, error) {
+		if embedded == nil {
+			return strings.TrimSuffix(source.BaseURL, "/") + "/" + KubernetesDefinitionsFile + "#/definitions/" + definition, nil
+		}
+		if err := embedDefinition(embedded, source.Definitions, definition, keyword); err != nil {
+			return "", err
+		}
+		return "#/" + keyword + "/" + escapePointer(definition), nil
+	}
+
+	count, err := referenceKubernetes(schema, ref)
+	if err != nil {
+		return 0, err
+	}
+	if len(embedded) > 0 {
+		schema[keyword] = embedded
+	}
+	return count, nil
+}
+
+// referenceKubernetes replaces the well-known values below node
+func referenceKubernetes(node map[string]any, ref func(string) (string, error)) (int, error) {
+	count := 0
+	var children []map[string]any
+	if properties, ok := node["properties"].(map[string]any); ok {
+		for _, key := range sortedKeys(properties) {
+			prop, ok := properties[key].(map[string]any)
+			if !ok {
+				continue
+			}
+			kind, known := kubernetesTypes[key]
+			if !known || !kubernetesShaped(prop, kind) {
+				children = append(children, prop)
+				continue
+			}
+			target, err := ref(kind.definition)
+			if err != nil {
+				return 0, fmt.Errorf("%s: %w", key, err)
+			}
+			reference := map[string]any{"$ref": target}
+			if kind.list {
+				reference = map[string]any{"type": "array", "items": reference}
+			}
+			for _, keyword := range []string{"description", "default"} {
+				if value, ok := prop[keyword]; ok {
+					reference[keyword] = value
+				}
+			}
+			properties[key] = reference
+			count++
+		}
+	}
+	if patterns, ok := node["patternProperties"].(map[string]any); ok {
+		for _, pattern := range sortedKeys(patterns) {
+			if prop, ok := patterns[pattern].(map[string]any); ok {
+				children = append(children, prop)
+			}
+		}
+	}
+	if items, ok := node["items"].(map[string]any); ok {
+		children = append(children, items)
+	}
+
+	for _, child := range children {
+		added, err := referenceKubernetes(child, ref)
+		if err != nil {
+			return 0, err
+		}
+		count += added
+	}
+	return count, nil
+}
+
+// kubernetesShaped reports whether the schema of a value allows it to hold a Kubernetes type:
+// an object, or a list for list types, or a value of unknown type
+func kubernetesShaped(prop map[string]any, kind kubernetesType) bool {
+	switch prop["type"] {
+	case nil:
+		return prop["$ref"] == nil && prop["anyOf"] == nil
+	case "object":
+		return !kind.list
+	case "array":
+		return kind.list
+	}
+	return false
+}
+
+// embedDefinition copies a definition of a kubernetes-json-schema release, and those it
+// references, into the definitions of a schema
+func embedDefinition(embedded, definitions map[string]any, name, keyword string) error {
+	if _, done := embedded[name]; done {
+		return nil
+	}
+	definition, ok := definitions[name]
+	if !ok {
+		return fmt.Errorf("definition %s not found in the Kubernetes schemas", name)
+	}
+
+	copied := copyValue(definition)
+	embedded[name] = copied
+	var references []string
+	relocateRefs(copied, keyword, &references)
+	sort.Strings(references)
+	for _, reference := range references {
+		if err := embedDefinition(embedded, definitions, reference, keyword); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relocateRefs points the references between definitions of a copied definition at the
+// definitions keyword of the schema, collecting the definitions referenced
+func relocateRefs(value any, keyword string, references *[]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok && strings.HasPrefix(ref, "#/definitions/") {
+			token := strings.TrimPrefix(ref, "#/definitions/")
+			v["$ref"] = "#/" + keyword + "/" + token
+			*references = append(*references, strings.NewReplacer("~1", "/", "~0", "~").Replace(token))
+		}
+		for _, child := range v {
+			relocateRefs(child, keyword, references)
+		}
+	case []any:
+		for _, child := range v {
+			relocateRefs(child, keyword, references)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func kubernetesValues() map[string]*parser.ValuePath {
+	return map[string]*parser.ValuePath{
+		"resources":              {Path: "resources", Type: "object", Description: "Container resources", Default: map[string]any{}},
+		"worker.affinity":        {Path: "worker.affinity", Type: "unknown"},
+		"tolerations":            {Path: "tolerations", Type: "array"},
+		"securityContext":        {Path: "securityContext", Type: "string"},
+		"probes.livenessProbe.x": {Path: "probes.livenessProbe.x", Type: "string"},
+	}
+}
+
+func TestReferenceKubernetes(t *testing.T) {
+	generated := Generate(kubernetesValues()).ToMap()
+	count, err := ReferenceKubernetes(generated, KubernetesSchemas{BaseURL: "https://example.com/v1.30.0/"})
+	if err != nil {
+		t.Fatalf("Failed to reference Kubernetes types: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("Expected 4 values referencing Kubernetes types, got %d", count)
+	}
+
+	properties := generated["properties"].(map[string]any)
+	expected := map[string]any{
+		"$ref":        "https://example.com/v1.30.0/_definitions.json#/definitions/io.k8s.api.core.v1.ResourceRequirements",
+		"description": "Container resources",
+		"default":     map[string]any{},
+	}
+	if !reflect.DeepEqual(properties["resources"], expected) {
+		t.Errorf("Expected resources\n%v\ngot\n%v", expected, properties["resources"])
+	}
+	tolerations := map[string]any{
+		"type":  "array",
+		"items": map[string]any{"$ref": "https://example.com/v1.30.0/_definitions.json#/definitions/io.k8s.api.core.v1.Toleration"},
+	}
+	if !reflect.DeepEqual(properties["tolerations"], tolerations) {
+		t.Errorf("Expected tolerations as a list of the type, got %v", properties["tolerations"])
+	}
+	if affinity := properties["worker"].(map[string]any)["properties"].(map[string]any)["affinity"].(map[string]any); affinity["$ref"] == nil {
+		t.Errorf("Expected nested values of unknown type referenced, got %v", affinity)
+	}
+	// Other uses of the names are left as they are
+	if securityContext := properties["securityContext"].(map[string]any); securityContext["type"] != "string" {
+		t.Errorf("Expected a string securityContext left as it is, got %v", securityContext)
+	}
+}
+
+func TestReferenceKubernetesVendored(t *testing.T) {
+	definitions := map[string]any{
+		"io.k8s.api.core.v1.ResourceRequirements": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"limits": map[string]any{"additionalProperties": map[string]any{"$ref": "#/definitions/io.k8s.apimachinery.pkg.api.resource.Quantity"}},
+			},
+		},
+		"io.k8s.apimachinery.pkg.api.resource.Quantity": map[string]any{"type": []any{"string", "integer"}},
+		"io.k8s.api.core.v1.Affinity":                   map[string]any{"type": "object"},
+		"io.k8s.api.core.v1.Toleration":                 map[string]any{"type": "object"},
+		"io.k8s.api.core.v1.Probe":                      map[string]any{"type": "object"},
+		"io.k8s.api.core.v1.Volume":                     map[string]any{"type": "object"},
+	}
+
+	generated := GenerateWithOptions(kubernetesValues(), Options{Draft: Draft07}).ToMap()
+	if _, err := ReferenceKubernetes(generated, KubernetesSchemas{Definitions: definitions}); err != nil {
+		t.Fatalf("Failed to reference Kubernetes types: %v", err)
+	}
+
+	resources := generated["properties"].(map[string]any)["resources"].(map[string]any)
+	if resources["$ref"] != "#/definitions/io.k8s.api.core.v1.ResourceRequirements" {
+		t.Errorf("Expected a local reference, got %v", resources)
+	}
+	embedded := generated["definitions"].(map[string]any)
+	// Definitions referenced by those embedded are embedded too, referenced locally
+	quantity := embedded["io.k8s.api.core.v1.ResourceRequirements"].(map[string]any)["properties"].(map[string]any)["limits"].(map[string]any)["additionalProperties"]
+	if !reflect.DeepEqual(quantity, map[string]any{"$ref": "#/definitions/io.k8s.apimachinery.pkg.api.resource.Quantity"}) || embedded["io.k8s.apimachinery.pkg.api.resource.Quantity"] == nil {
+		t.Errorf("Expected Quantity embedded, got %v", embedded)
+	}
+	if _, exists := embedded["io.k8s.api.core.v1.Volume"]; exists {
+		t.Error("Expected only the definitions referenced embedded")
+	}
+	// The bundle is left untouched
+	if ref := definitions["io.k8s.api.core.v1.ResourceRequirements"].(map[string]any)["properties"].(map[string]any)["limits"].(map[string]any)["additionalProperties"].(map[string]any)["$ref"]; ref != "#/definitions/io.k8s.apimachinery.pkg.api.resource.Quantity" {
+		t.Errorf("Expected the bundle unchanged, got %v", ref)
+	}
+
+	delete(definitions, "io.k8s.api.core.v1.Toleration")
+	if _, err := ReferenceKubernetes(Generate(kubernetesValues()).ToMap(), KubernetesSchemas{Definitions: definitions}); err == nil {
+		t.Error("Expected definitions missing from the bundle to fail")
+	}
+}
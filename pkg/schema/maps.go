@@ -0,0 +1,79 @@
+package schema
+
+import (
+	"strings"
+)
+
+// stringMapSuffixes are the key suffixes of well-known maps from strings to strings, such as
+// podLabels, commonAnnotations or matchLabels, which charts pass through to Kubernetes objects
+var stringMapSuffixes = []string{"labels", "annotations"}
+
+// stringMapKeys are the keys of other well-known maps from strings to strings. Those mapped to
+// false may also be lists, as env often is, and are only described when known to be objects.
+var stringMapKeys = map[string]bool{
+	"nodeSelector": true,
+	"env":          false,
+	"extraEnv":     false,
+	"envVars":      false,
+}
+
+// stringMap reports whether the value under key is a well-known or configured map from strings
+// to strings, and whether that holds even when the type of the value is unknown
+func stringMap(key string, opts Options) (bool, bool) {
+	lower := strings.ToLower(key)
+	for _, suffix := range stringMapSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true, true
+		}
+	}
+	for _, configured := range opts.StringMaps {
+		if key == configured {
+			return true, true
+		}
+	}
+	untyped, known := stringMapKeys[key]
+	return known, untyped
+}
+
+// addStringMaps describes the values of the well-known free-form maps of a schema as strings:
+// any key is accepted, unlike the objects read field by field, but only with a string value
+func addStringMaps(schema map[string]any, opts Options) {
+	properties, _ := schema["properties"].(map[string]any)
+	for key, value := range properties {
+		prop, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		if known, untyped := stringMap(key, opts); known && (prop["type"] == "object" || (untyped && prop["type"] == nil)) {
+			addStringValues(prop)
+		}
+		addStringMaps(prop, opts)
+	}
+	if patterns, ok := schema["patternProperties"].(map[string]any); ok {
+		for _, value := range patterns {
+			if prop, ok := value.(map[string]any); ok {
+				addStringMaps(prop, opts)
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		addStringMaps(items, opts)
+	}
+}
+
+// addStringValues makes a schema an object whose keys, other than the properties it describes,
+// have string values
+func addStringValues(prop map[string]any) {
+	prop["type"] = "object"
+	if patterns, ok := prop["patternProperties"].(map[string]any); ok {
+		// Ranged over as key/value pairs
+		if values, ok := patterns[anyKeyPattern].(map[string]any); ok && values["type"] == nil {
+			values["type"] = "string"
+		}
+		return
+	}
+	if properties, ok := prop["properties"].(map[string]any); ok && len(properties) == 0 {
+		delete(prop, "properties")
+	}
+	prop["additionalProperties"] = map[string]any{"type": "string"}
+}
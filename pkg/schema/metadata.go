@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+)
+
+// Metadata describes how a schema was produced so that differences between two schemas can be
+// attributed to their inputs or to the tool that generated them
+type Metadata struct {
+	GeneratorVersion string
+	RulesetDigest    string
+	ChartDigest      string
+	ChartVersion     string // Version of the chart in Chart.yaml, left out when empty
+	Flags            map[string]string
+	Unresolved       int       // Constructs touching values that could not be resolved
+	GeneratedAt      time.Time // When the schema was generated, left out when zero so output is reproducible
+}
+
+// AddMetadata embeds generation metadata as a human readable $comment and an x-generation block
+func AddMetadata(schema map[string]any, metadata Metadata) {
+	flags := make(map[string]any, len(metadata.Flags))
+	for name, value := range metadata.Flags {
+		flags[name] = value
+	}
+
+	schema["$comment"] = fmt.Sprintf("Generated by helm-schema %s; regenerate instead of editing by hand", metadata.GeneratorVersion)
+	generation := map[string]any{
+		"generatorVersion": metadata.GeneratorVersion,
+		"rulesetDigest":    metadata.RulesetDigest,
+		"chartDigest":      metadata.ChartDigest,
+		"flags":            flags,
+		"unresolved":       metadata.Unresolved,
+	}
+	if metadata.ChartVersion != "" {
+		generation["chartVersion"] = metadata.ChartVersion
+	}
+	if !metadata.GeneratedAt.IsZero() {
+		generation["generatedAt"] = metadata.GeneratedAt.UTC().Format(time.RFC3339)
+	}
+	schema["x-generation"] = generation
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddMetadata(t *testing.T) {
+	merged := MergeSchemas(ChartSchema{Name: "main", Schema: Generate(nil)}, nil).ToMap()
+
+	AddMetadata(merged, Metadata{
+		GeneratorVersion: "1.2.3",
+		RulesetDigest:    "abc123",
+		ChartDigest:      "sha256:def",
+		Flags:            map[string]string{"no-subcharts": "true"},
+		Unresolved:       2,
+	})
+
+	if merged["$comment"] != "Generated by helm-schema 1.2.3; regenerate instead of editing by hand" {
+		t.Errorf("Unexpected $comment: %v", merged["$comment"])
+	}
+
+	generation, ok := merged["x-generation"].(map[string]interface{})
+	if !ok {
+		t.Fatal("x-generation block not found")
+	}
+
+	expected := map[string]string{
+		"generatorVersion": "1.2.3",
+		"rulesetDigest":    "abc123",
+		"chartDigest":      "sha256:def",
+	}
+	for key, value := range expected {
+		if generation[key] != value {
+			t.Errorf("x-generation.%s = %v, expected %s", key, generation[key], value)
+		}
+	}
+
+	if generation["unresolved"] != 2 {
+		t.Errorf("Expected 2 unresolved constructs, got %v", generation["unresolved"])
+	}
+
+	flags := generation["flags"].(map[string]interface{})
+	if flags["no-subcharts"] != "true" {
+		t.Errorf("Expected recorded flag no-subcharts=true, got %v", flags)
+	}
+}
+
+func TestAddMetadataReproducible(t *testing.T) {
+	merged := MergeSchemas(ChartSchema{Name: "main", Schema: Generate(nil)}, nil).ToMap()
+
+	// Reproducible output leaves out the timestamp and whatever is unknown
+	AddMetadata(merged, Metadata{GeneratorVersion: "1.2.3"})
+	generation := merged["x-generation"].(map[string]interface{})
+	for _, key := range []string{"generatedAt", "chartVersion"} {
+		if _, exists := generation[key]; exists {
+			t.Errorf("Expected no x-generation.%s, got %v", key, generation[key])
+		}
+	}
+
+	AddMetadata(merged, Metadata{
+		GeneratorVersion: "1.2.3",
+		ChartVersion:     "0.4.0",
+		GeneratedAt:      time.Date(2024, 5, 1, 14, 30, 0, 0, time.FixedZone("CEST", 2*60*60)),
+	})
+	generation = merged["x-generation"].(map[string]interface{})
+	if generation["chartVersion"] != "0.4.0" || generation["generatedAt"] != "2024-05-01T12:30:00Z" {
+		t.Errorf("Expected the chart version and UTC timestamp recorded, got %v", generation)
+	}
+}
@@ -0,0 +1,309 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+)
+
+// Node is a JSON Schema as a typed model, as Generate and MergeSchemas return it, for callers
+// reading or building schemas without type assertions on maps. FromMap and ToMap convert from and
+// to the maps the passes of the package, such as Deduplicate or ApplyOverrides, work on.
+// Keywords without a field of their own, and those whose value does not fit their field, are
+// kept in Extra so nothing is lost on the way.
+type Node struct {
+	// Boolean is set for the true and false schemas, accepting every value or none, in which
+	// case no other field is
+	Boolean *bool
+
+	Schema  string // $schema
+	ID      string // $id
+	Anchor  string // $anchor
+	Ref     string // $ref
+	Comment string // $comment
+
+	Title       string
+	Description string
+	Type        []string // A single type is written as a string
+	Format      string
+	Enum        []any
+	Default     any // Nil when there is none
+	Examples    []any
+	Deprecated  bool
+
+	Properties           map[string]*Node
+	PatternProperties    map[string]*Node
+	AdditionalProperties *Node
+	Required             []string
+	Items                *Node
+
+	AllOf []*Node
+	AnyOf []*Node
+	OneOf []*Node
+	Not   *Node
+	If    *Node
+	Then  *Node
+	Else  *Node
+
+	Defs        map[string]*Node // $defs
+	Definitions map[string]*Node // definitions, as draft-07 names them
+
+	// Extra holds every other keyword, such as minimum, pattern or x- extensions
+	Extra map[string]any
+}
+
+// FromMap converts a schema map, or a boolean schema, into the typed model. Other values are
+// taken as the empty schema.
+func FromMap(schema any) *Node {
+	if boolean, ok := schema.(bool); ok {
+		return &Node{Boolean: &boolean}
+	}
+	object, _ := schema.(map[string]any)
+
+	node := &Node{}
+	for keyword, value := range object {
+		if !node.set(keyword, value) {
+			if node.Extra == nil {
+				node.Extra = make(map[string]any)
+			}
+			node.Extra[keyword] = value
+		}
+	}
+	return node
+}
+
+// set assigns a keyword to its field, reporting false when it has none or the value does not
+// fit it
+func (n *Node) set(keyword string, value any) bool {
+	var ok bool
+	switch keyword {
+	case "$schema":
+		n.Schema, ok = value.(string)
+	case "$id":
+		n.ID, ok = value.(string)
+	case "$anchor":
+		n.Anchor, ok = value.(string)
+	case "$ref":
+		n.Ref, ok = value.(string)
+	case "$comment":
+		n.Comment, ok = value.(string)
+	case "title":
+		n.Title, ok = value.(string)
+	case "description":
+		n.Description, ok = value.(string)
+	case "format":
+		n.Format, ok = value.(string)
+	case "deprecated":
+		n.Deprecated, ok = value.(bool)
+	case "type":
+		n.Type, ok = stringsOf(value)
+	case "required":
+		n.Required, ok = stringsOf(value)
+	case "enum":
+		n.Enum, ok = value.([]any)
+	case "examples":
+		n.Examples, ok = value.([]any)
+	case "default":
+		n.Default, ok = value, value != nil
+	case "properties":
+		n.Properties, ok = nodeMap(value)
+	case "patternProperties":
+		n.PatternProperties, ok = nodeMap(value)
+	case "$defs":
+		n.Defs, ok = nodeMap(value)
+	case "definitions":
+		n.Definitions, ok = nodeMap(value)
+	case "additionalProperties":
+		n.AdditionalProperties, ok = subnode(value)
+	case "items":
+		n.Items, ok = subnode(value)
+	case "not":
+		n.Not, ok = subnode(value)
+	case "if":
+		n.If, ok = subnode(value)
+	case "then":
+		n.Then, ok = subnode(value)
+	case "else":
+		n.Else, ok = subnode(value)
+	case "allOf":
+		n.AllOf, ok = nodeList(value)
+	case "anyOf":
+		n.AnyOf, ok = nodeList(value)
+	case "oneOf":
+		n.OneOf, ok = nodeList(value)
+	}
+	return ok
+}
+
+// stringsOf returns a string or a list of strings as a list
+func stringsOf(value any) ([]string, bool) {
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []string:
+		return v, true
+	case []any:
+		strings := make([]string, 0, len(v))
+		for _, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				return nil, false
+			}
+			strings = append(strings, s)
+		}
+		return strings, true
+	}
+	return nil, false
+}
+
+// subnode converts a keyword holding a single schema
+func subnode(value any) (*Node, bool) {
+	switch value.(type) {
+	case bool, map[string]any:
+		return FromMap(value), true
+	}
+	return nil, false
+}
+
+// nodeMap converts a keyword holding schemas by name
+func nodeMap(value any) (map[string]*Node, bool) {
+	object, ok := value.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	nodes := make(map[string]*Node, len(object))
+	for name, child := range object {
+		node, ok := subnode(child)
+		if !ok {
+			return nil, false
+		}
+		nodes[name] = node
+	}
+	return nodes, true
+}
+
+// nodeList converts a keyword holding a list of schemas
+func nodeList(value any) ([]*Node, bool) {
+	list, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	nodes := make([]*Node, 0, len(list))
+	for _, child := range list {
+		node, ok := subnode(child)
+		if !ok {
+			return nil, false
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, true
+}
+
+// ToMap converts the model into the schema map the rest of the package works with. Boolean
+// schemas become their equivalent maps, {} for true and {"not": {}} for false; they are kept as
+// booleans below the root.
+func (n *Node) ToMap() map[string]any {
+	if n.Boolean != nil {
+		if *n.Boolean {
+			return map[string]any{}
+		}
+		return map[string]any{"not": map[string]any{}}
+	}
+
+	schema := make(map[string]any, len(n.Extra))
+	maps.Copy(schema, n.Extra)
+	strings := map[string]string{
+		"$schema": n.Schema, "$id": n.ID, "$anchor": n.Anchor, "$ref": n.Ref, "$comment": n.Comment,
+		"title": n.Title, "description": n.Description, "format": n.Format,
+	}
+	for keyword, value := range strings {
+		if value != "" {
+			schema[keyword] = value
+		}
+	}
+	switch len(n.Type) {
+	case 0:
+	case 1:
+		schema["type"] = n.Type[0]
+	default:
+		schema["type"] = n.Type
+	}
+	if n.Enum != nil {
+		schema["enum"] = n.Enum
+	}
+	if n.Default != nil {
+		schema["default"] = n.Default
+	}
+	if n.Examples != nil {
+		schema["examples"] = n.Examples
+	}
+	if n.Deprecated {
+		schema["deprecated"] = true
+	}
+	if n.Required != nil {
+		schema["required"] = n.Required
+	}
+
+	for keyword, nodes := range map[string]map[string]*Node{
+		"properties": n.Properties, "patternProperties": n.PatternProperties,
+		"$defs": n.Defs, "definitions": n.Definitions,
+	} {
+		if nodes == nil {
+			continue
+		}
+		object := make(map[string]any, len(nodes))
+		for name, node := range nodes {
+			object[name] = node.value()
+		}
+		schema[keyword] = object
+	}
+	for keyword, node := range map[string]*Node{
+		"additionalProperties": n.AdditionalProperties, "items": n.Items,
+		"not": n.Not, "if": n.If, "then": n.Then, "else": n.Else,
+	} {
+		if node != nil {
+			schema[keyword] = node.value()
+		}
+	}
+	for keyword, nodes := range map[string][]*Node{"allOf": n.AllOf, "anyOf": n.AnyOf, "oneOf": n.OneOf} {
+		if nodes == nil {
+			continue
+		}
+		list := make([]any, 0, len(nodes))
+		for _, node := range nodes {
+			list = append(list, node.value())
+		}
+		schema[keyword] = list
+	}
+	return schema
+}
+
+// value returns the schema map of a subschema, or its boolean
+func (n *Node) value() any {
+	if n.Boolean != nil {
+		return *n.Boolean
+	}
+	return n.ToMap()
+}
+
+// MarshalJSON encodes the schema with its keywords in the order Marshal writes them in
+func (n *Node) MarshalJSON() ([]byte, error) {
+	if n.Boolean != nil {
+		return json.Marshal(*n.Boolean)
+	}
+	return Marshal(n.ToMap())
+}
+
+// UnmarshalJSON decodes a schema, or a boolean schema
+func (n *Node) UnmarshalJSON(data []byte) error {
+	var schema any
+	if err := json.Unmarshal(data, &schema); err != nil {
+		return err
+	}
+	switch schema.(type) {
+	case bool, map[string]any:
+		*n = *FromMap(schema)
+		return nil
+	}
+	return fmt.Errorf("schema is neither an object nor a boolean")
+}
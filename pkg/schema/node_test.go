@@ -0,0 +1,80 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func TestNodeRoundTrip(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"replicas":     {Path: "replicas", Type: "integer", Default: 1, Required: true, Constraints: map[string]any{"minimum": 1}},
+		"image.tag":    {Path: "image.tag", Type: "string", Description: "Image tag"},
+		"hosts[]":      {Path: "hosts[]", Type: "string"},
+		"port":         {Path: "port", Type: "union", Types: []string{"integer", "string"}},
+		"labels.*":     {Path: "labels.*", Type: "string"},
+		"ingress.host": {Path: "ingress.host", Type: "string", Guards: []string{"ingress.enabled"}},
+	}
+	generated := GenerateWithOptions(values, Options{Conditionals: true}).ToMap()
+
+	node := FromMap(generated)
+	if node.Schema != SchemaURI(Draft202012) || !reflect.DeepEqual(node.Type, []string{"object"}) {
+		t.Errorf("Expected the root keywords in their fields, got %+v", node)
+	}
+	if additional := node.AdditionalProperties; additional == nil || additional.Boolean == nil || *additional.Boolean {
+		t.Errorf("Expected additionalProperties false, got %+v", additional)
+	}
+	replicas := node.Properties["replicas"]
+	if !reflect.DeepEqual(replicas.Type, []string{"integer"}) || replicas.Default != 1 || replicas.Extra["minimum"] != 1 {
+		t.Errorf("Expected replicas typed with its constraints kept, got %+v", replicas)
+	}
+	if tag := node.Properties["image"].Properties["tag"]; tag.Description != "Image tag" {
+		t.Errorf("Expected nested properties, got %+v", tag)
+	}
+	if items := node.Properties["hosts"].Items; items == nil || !reflect.DeepEqual(items.Type, []string{"string"}) {
+		t.Errorf("Expected hosts items, got %+v", items)
+	}
+	if port := node.Properties["port"]; !reflect.DeepEqual(port.Type, []string{"integer", "string"}) {
+		t.Errorf("Expected a type list, got %+v", port)
+	}
+	if !reflect.DeepEqual(node.Required, []string{"replicas"}) {
+		t.Errorf("Expected required replicas, got %v", node.Required)
+	}
+
+	// Nothing is lost converting back, or through JSON
+	if back := node.ToMap(); !reflect.DeepEqual(canonicalSchema(back), canonicalSchema(generated)) {
+		t.Errorf("Expected the same schema back\n%s\ngot\n%s", canonicalSchema(generated), canonicalSchema(back))
+	}
+	data, err := json.Marshal(node)
+	if err != nil {
+		t.Fatalf("Failed to encode node: %v", err)
+	}
+	var decoded Node
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to decode node: %v", err)
+	}
+	if canonicalSchema(decoded.ToMap()) != canonicalSchema(generated) {
+		t.Errorf("Expected the same schema through JSON\n%s\ngot\n%s", canonicalSchema(generated), canonicalSchema(decoded.ToMap()))
+	}
+}
+
+func TestNodeValues(t *testing.T) {
+	// Values not fitting their field are kept as they are
+	node := FromMap(map[string]any{"type": []any{"string", 1}, "items": []any{true}, "default": nil})
+	if node.Type != nil || node.Items != nil || !reflect.DeepEqual(node.Extra, map[string]any{"type": []any{"string", 1}, "items": []any{true}, "default": nil}) {
+		t.Errorf("Expected the values kept in Extra, got %+v", node)
+	}
+
+	var boolean Node
+	if err := json.Unmarshal([]byte("false"), &boolean); err != nil || boolean.Boolean == nil || *boolean.Boolean {
+		t.Errorf("Expected the false schema, got %+v (%v)", boolean, err)
+	}
+	if !reflect.DeepEqual(boolean.ToMap(), map[string]any{"not": map[string]any{}}) {
+		t.Errorf("Expected the false schema as a map rejecting everything, got %v", boolean.ToMap())
+	}
+	if err := json.Unmarshal([]byte("1"), &boolean); err == nil {
+		t.Error("Expected numbers not to decode as schemas")
+	}
+}
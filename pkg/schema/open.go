@@ -0,0 +1,37 @@
+package schema
+
+// OpenObjects lets every object of a schema that rejects properties besides those it lists
+// accept them, for charts passing values the templates do not read through to configuration.
+// It returns the number of objects opened.
+func OpenObjects(schema map[string]any) int {
+	count := 0
+	if schema["additionalProperties"] == false {
+		schema["additionalProperties"] = true
+		count++
+	}
+	for keyword, value := range schema {
+		switch {
+		case schemaMapKeywords[keyword]:
+			if schemas, ok := value.(map[string]any); ok {
+				for _, child := range schemas {
+					if child, ok := child.(map[string]any); ok {
+						count += OpenObjects(child)
+					}
+				}
+			}
+		case schemaListKeywords[keyword]:
+			if schemas, ok := value.([]any); ok {
+				for _, child := range schemas {
+					if child, ok := child.(map[string]any); ok {
+						count += OpenObjects(child)
+					}
+				}
+			}
+		case subschemaKeywords[keyword]:
+			if child, ok := value.(map[string]any); ok {
+				count += OpenObjects(child)
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func TestOpenObjects(t *testing.T) {
+	schema := Generate(map[string]*parser.ValuePath{
+		"image":             {Path: "image", Type: "object"},
+		"image.tag":         {Path: "image.tag", Type: "string", Default: map[string]any{"additionalProperties": false}},
+		"hosts[]":           {Path: "hosts[]", Type: "object"},
+		"hosts[].name":      {Path: "hosts[].name", Type: "string"},
+		"labels.*":          {Path: "labels.*", Type: "string"},
+		"extra.*.enabled":   {Path: "extra.*.enabled", Type: "boolean"},
+		"extra.*.threshold": {Path: "extra.*.threshold", Type: "integer"},
+	}).ToMap()
+
+	if count := OpenObjects(schema); count == 0 {
+		t.Fatal("Expected objects to be opened")
+	}
+	if schema["additionalProperties"] != true {
+		t.Errorf("Expected the root to accept additional properties, got %v", schema["additionalProperties"])
+	}
+
+	properties := schema["properties"].(map[string]any)
+	image := properties["image"].(map[string]any)
+	if image["additionalProperties"] != true {
+		t.Errorf("Expected image to accept additional properties, got %v", image)
+	}
+	hosts := properties["hosts"].(map[string]any)["items"].(map[string]any)
+	if hosts["additionalProperties"] != true {
+		t.Errorf("Expected list items to accept additional properties, got %v", hosts)
+	}
+	extra := properties["extra"].(map[string]any)["patternProperties"].(map[string]any)[anyKeyPattern].(map[string]any)
+	if extra["additionalProperties"] != true {
+		t.Errorf("Expected map values to accept additional properties, got %v", extra)
+	}
+
+	// Defaults are values, not schemas
+	tag := image["properties"].(map[string]any)["tag"].(map[string]any)
+	if tag["default"].(map[string]any)["additionalProperties"] != false {
+		t.Errorf("Expected the default to be left alone, got %v", tag["default"])
+	}
+
+	if count := OpenObjects(schema); count != 0 {
+		t.Errorf("Expected nothing left to open, opened %d", count)
+	}
+}
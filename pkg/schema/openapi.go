@@ -0,0 +1,255 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Output formats a schema can be written in
+const (
+	FormatJSON    = "json"    // JSON Schema, see MarshalIndent
+	FormatYAML    = "yaml"    // JSON Schema written as YAML, see MarshalYAML
+	FormatOpenAPI = "openapi" // OpenAPI 3.0 document, see MarshalOpenAPI
+)
+
+// OpenAPIVersion is the version of the OpenAPI documents MarshalOpenAPI writes
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPISchemaName names the schema of the values among the components of an OpenAPI document
+const OpenAPISchemaName = "Values"
+
+// openAPIUnsupported are keywords OpenAPI 3.0 schema objects lack an equivalent of, left out
+var openAPIUnsupported = map[string]bool{
+	"$schema": true, "$id": true, "$anchor": true, "$comment": true, "$defs": true, "definitions": true,
+	"if": true, "then": true, "else": true, "dependentRequired": true, "dependentSchemas": true,
+	"dependencies": true, "propertyNames": true, "prefixItems": true, "contains": true,
+	"contentEncoding": true, "contentMediaType": true,
+}
+
+// MarshalOpenAPI encodes a schema as an OpenAPI 3.0 document describing the values under
+// components.schemas.Values, next to its definitions, for API tooling and code generators that
+// do not read JSON Schema. Keywords are translated where OpenAPI 3.0 has an equivalent: type
+// lists become nullable or anyOf, const a single enum and examples example. Others, such as
+// conditionals or propertyNames, are left out, so the document may accept more than the schema.
+func MarshalOpenAPI(schema map[string]any, title, version string) ([]byte, error) {
+	schemas := make(map[string]any)
+	for _, keyword := range []string{"$defs", "definitions"} {
+		definitions, _ := schema[keyword].(map[string]any)
+		for name, definition := range definitions {
+			if name == OpenAPISchemaName {
+				return nil, fmt.Errorf("definition %s clashes with the schema of the values", name)
+			}
+			schemas[name] = openAPISchema(definition)
+		}
+	}
+	schemas[OpenAPISchemaName] = openAPISchema(schema)
+
+	document := map[string]any{
+		"openapi":    OpenAPIVersion,
+		"info":       map[string]any{"title": title, "version": version},
+		"paths":      map[string]any{},
+		"components": map[string]any{"schemas": schemas},
+	}
+	var buf bytes.Buffer
+	err := encodeObject(&buf, document, []string{"openapi", "info", "paths", "components"}, func(key string, value any) error {
+		if key != "components" {
+			return encodeValue(&buf, value)
+		}
+		return encodeObject(&buf, value.(map[string]any), []string{"schemas"}, func(_ string, schemas any) error {
+			return encodeSchemaMap(&buf, schemas)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+// openAPISchema translates a JSON Schema into an OpenAPI 3.0 schema object
+func openAPISchema(node any) any {
+	switch node {
+	case true:
+		return map[string]any{}
+	case false:
+		return map[string]any{"not": map[string]any{}}
+	}
+	object, ok := node.(map[string]any)
+	if !ok {
+		return node
+	}
+
+	converted := make(map[string]any, len(object))
+	for key, value := range object {
+		switch {
+		case openAPIUnsupported[key]:
+		case key == "additionalProperties":
+			if _, isBool := value.(bool); isBool {
+				converted[key] = value
+			} else {
+				converted[key] = openAPISchema(value)
+			}
+		case schemaMapKeywords[key]:
+			if schemas, ok := value.(map[string]any); ok {
+				translated := make(map[string]any, len(schemas))
+				for name, schema := range schemas {
+					translated[name] = openAPISchema(schema)
+				}
+				converted[key] = translated
+			}
+		case schemaListKeywords[key]:
+			if schemas, ok := value.([]any); ok {
+				var translated []any
+				for _, schema := range schemas {
+					translated = append(translated, openAPISchema(schema))
+				}
+				converted[key] = translated
+			}
+		case subschemaKeywords[key]:
+			converted[key] = openAPISchema(value)
+		default:
+			converted[key] = copyValue(value)
+		}
+	}
+
+	openAPIPatterns(converted)
+	openAPITypes(converted)
+	openAPICompositions(converted)
+	if value, ok := converted["const"]; ok {
+		if _, hasEnum := converted["enum"]; !hasEnum {
+			converted["enum"] = []any{value}
+		}
+		delete(converted, "const")
+	}
+	if examples, ok := converted["examples"].([]any); ok && len(examples) > 0 {
+		converted["example"] = examples[0]
+	}
+	delete(converted, "examples")
+	for exclusive, bound := range map[string]string{"exclusiveMinimum": "minimum", "exclusiveMaximum": "maximum"} {
+		if value, ok := converted[exclusive]; ok {
+			if _, isBool := value.(bool); !isBool {
+				converted[bound] = value
+				converted[exclusive] = true
+			}
+		}
+	}
+	if ref, ok := converted["$ref"].(string); ok {
+		for _, prefix := range []string{"#/$defs/", "#/definitions/"} {
+			if strings.HasPrefix(ref, prefix) {
+				converted["$ref"] = "#/components/schemas/" + strings.TrimPrefix(ref, prefix)
+			}
+		}
+	}
+	return converted
+}
+
+// openAPIPatterns replaces the patternProperties of a map by additionalProperties when the map
+// has a single pattern, and otherwise admits any additional property the patterns might match
+func openAPIPatterns(converted map[string]any) {
+	patterns, ok := converted["patternProperties"].(map[string]any)
+	if !ok {
+		return
+	}
+	delete(converted, "patternProperties")
+	if additional, ok := converted["additionalProperties"]; ok && additional != false {
+		return
+	}
+	if len(patterns) == 1 {
+		for _, schema := range patterns {
+			converted["additionalProperties"] = openAPISchema(schema)
+		}
+		return
+	}
+	delete(converted, "additionalProperties")
+}
+
+// openAPITypes replaces a list of types, which OpenAPI 3.0 lacks, by nullable for null and anyOf
+// for several other types
+func openAPITypes(converted map[string]any) {
+	var types []string
+	switch value := converted["type"].(type) {
+	case string:
+		types = []string{value}
+	case []string:
+		types = value
+	case []any:
+		for _, name := range value {
+			types = append(types, fmt.Sprint(name))
+		}
+	default:
+		return
+	}
+
+	var nonNull []any
+	for _, name := range types {
+		if name == "null" {
+			converted["nullable"] = true
+		} else {
+			nonNull = append(nonNull, name)
+		}
+	}
+	switch len(nonNull) {
+	case 0:
+		delete(converted, "type")
+	case 1:
+		converted["type"] = nonNull[0]
+	default:
+		delete(converted, "type")
+		var alternatives []any
+		for _, name := range nonNull {
+			alternatives = append(alternatives, map[string]any{"type": name})
+		}
+		anyOf, exists := converted["anyOf"]
+		if !exists {
+			converted["anyOf"] = alternatives
+			return
+		}
+		// Both lists of alternatives must hold
+		allOf, _ := converted["allOf"].([]any)
+		converted["allOf"] = append(allOf, map[string]any{"anyOf": anyOf}, map[string]any{"anyOf": alternatives})
+		delete(converted, "anyOf")
+	}
+}
+
+// openAPICompositions marks a schema nullable for an anyOf or oneOf alternative accepting null
+// alone, and drops allOf members nothing was left of, such as conditionals
+func openAPICompositions(converted map[string]any) {
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		alternatives, ok := converted[keyword].([]any)
+		if !ok {
+			continue
+		}
+		var kept []any
+		for _, alternative := range alternatives {
+			if member, ok := alternative.(map[string]any); ok && len(member) == 1 && member["nullable"] == true {
+				converted["nullable"] = true
+				continue
+			}
+			kept = append(kept, alternative)
+		}
+		if len(kept) == 0 {
+			delete(converted, keyword)
+		} else {
+			converted[keyword] = kept
+		}
+	}
+	if members, ok := converted["allOf"].([]any); ok {
+		var kept []any
+		for _, member := range members {
+			if member, ok := member.(map[string]any); ok && len(member) == 0 {
+				continue
+			}
+			kept = append(kept, member)
+		}
+		if len(kept) == 0 {
+			delete(converted, "allOf")
+		} else {
+			converted["allOf"] = kept
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMarshalOpenAPI(t *testing.T) {
+	schema := map[string]any{
+		"$schema":  SchemaURI(Draft202012),
+		"$comment": "Generated",
+		"type":     "object",
+		"properties": map[string]any{
+			"replicas": map[string]any{"type": []any{"integer", "null"}, "exclusiveMinimum": 0},
+			"port":     map[string]any{"type": []any{"integer", "string"}},
+			"mode":     map[string]any{"const": "standalone", "examples": []any{"standalone"}},
+			"labels": map[string]any{
+				"type":                 "object",
+				"patternProperties":    map[string]any{"^.*$": map[string]any{"type": "string"}},
+				"additionalProperties": false,
+				"propertyNames":        map[string]any{"pattern": "^[a-z]+$"},
+			},
+			"image": map[string]any{"anyOf": []any{map[string]any{"$ref": "#/$defs/image"}, map[string]any{"type": "null"}}},
+		},
+		"allOf": []any{map[string]any{"if": map[string]any{}, "then": map[string]any{}}},
+		"$defs": map[string]any{"image": map[string]any{"type": "object"}},
+	}
+
+	output, err := MarshalOpenAPI(schema, "app", "1.2.3")
+	if err != nil {
+		t.Fatalf("Failed to encode document: %v", err)
+	}
+	var document map[string]any
+	if err := json.Unmarshal(output, &document); err != nil {
+		t.Fatalf("Failed to decode document: %v", err)
+	}
+	if document["openapi"] != OpenAPIVersion {
+		t.Errorf("Expected OpenAPI %s, got %v", OpenAPIVersion, document["openapi"])
+	}
+	if info := document["info"].(map[string]any); info["title"] != "app" || info["version"] != "1.2.3" {
+		t.Errorf("Expected the chart in info, got %v", info)
+	}
+
+	schemas := document["components"].(map[string]any)["schemas"].(map[string]any)
+	if !reflect.DeepEqual(schemas["image"], map[string]any{"type": "object"}) {
+		t.Errorf("Expected the definitions among the components, got %v", schemas["image"])
+	}
+	expected := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"replicas": map[string]any{"type": "integer", "nullable": true, "minimum": float64(0), "exclusiveMinimum": true},
+			"port":     map[string]any{"anyOf": []any{map[string]any{"type": "integer"}, map[string]any{"type": "string"}}},
+			"mode":     map[string]any{"enum": []any{"standalone"}, "example": "standalone"},
+			"labels": map[string]any{
+				"type":                 "object",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+			"image": map[string]any{"anyOf": []any{map[string]any{"$ref": "#/components/schemas/image"}}, "nullable": true},
+		},
+	}
+	if !reflect.DeepEqual(schemas[OpenAPISchemaName], expected) {
+		t.Errorf("Expected values schema\n%v\ngot\n%v", expected, schemas[OpenAPISchemaName])
+	}
+
+	schema["$defs"] = map[string]any{OpenAPISchemaName: map[string]any{}}
+	if _, err := MarshalOpenAPI(schema, "app", "1.2.3"); err == nil {
+		t.Error("Expected a definition named like the values schema to be rejected")
+	}
+}
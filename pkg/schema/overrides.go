@@ -0,0 +1,118 @@
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"helm-schema/pkg/parser"
+)
+
+// OverridesFile is the file of a chart declaring overrides of its generated schema
+const OverridesFile = "helm-schema.overrides.yaml"
+
+// ApplyOverrides deep-merges schema fragments onto the properties of a generated schema, keyed by
+// value path as in image.tag, hosts[].name or labels.* so authors can fix what inference gets
+// wrong and still regenerate:
+//
+//	image.pullPolicy:
+//	  enum: [Always, IfNotPresent, Never]
+//	  description: When the kubelet pulls the image
+//
+// Nested keywords are merged, others replace the generated ones and null removes them. Properties
+// the templates were not found to read are added. Deprecated ones, marked with deprecated: true or
+// a message saying what to set instead, say so in their description.
+func ApplyOverrides(schema map[string]any, overrides map[string]any) error {
+	paths := make([]string, 0, len(overrides))
+	for path := range overrides {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		override, ok := overrides[path].(map[string]any)
+		if !ok {
+			return fmt.Errorf("override of %s is not a schema", path)
+		}
+		prop, err := overriddenProperty(schema, path)
+		if err != nil {
+			return fmt.Errorf("override of %s: %w", path, err)
+		}
+		mergeSchema(prop, override)
+		// deprecated: true, or the message saying what to set instead
+		switch deprecation := override["deprecated"].(type) {
+		case string:
+			deprecate(prop, deprecation)
+		case bool:
+			if deprecation {
+				deprecate(prop, "")
+			}
+		}
+	}
+	return nil
+}
+
+// overriddenProperty returns the schema of the value a path stands for, creating it and the
+// objects holding it when missing
+func overriddenProperty(schema map[string]any, path string) (map[string]any, error) {
+	parts := parser.SplitPath(path)
+	if _, ok := schema["properties"].(map[string]any); !ok {
+		schema["properties"] = make(map[string]any)
+	}
+	owner := schema
+
+	for i, part := range parts {
+		var container map[string]any
+		var key string
+		if part == parser.AnyKey {
+			if i == 0 {
+				return nil, fmt.Errorf("the root has no map values")
+			}
+			if _, exists := owner["patternProperties"]; !exists {
+				owner["patternProperties"] = make(map[string]any)
+			}
+			container, key = owner["patternProperties"].(map[string]any), anyKeyPattern
+		} else {
+			if part == "" || part == "[]" {
+				return nil, fmt.Errorf("empty key")
+			}
+			container, key = owner["properties"].(map[string]any), parser.UnescapeKey(part)
+		}
+		if _, ok := container[key]; ok {
+			if _, isSchema := container[key].(map[string]any); !isSchema {
+				return nil, fmt.Errorf("%s is not a schema", key)
+			}
+		}
+
+		prop := container[key]
+		if strings.HasSuffix(part, "[]") {
+			prop = arrayProperty(container, key)["items"]
+		} else if prop == nil {
+			prop = make(map[string]any)
+			container[key] = prop
+		}
+		if i == len(parts)-1 {
+			return prop.(map[string]any), nil
+		}
+		owner = objectSchema(prop.(map[string]any))
+	}
+	return owner, nil
+}
+
+// mergeSchema merges override into target: nested keywords are merged, any other overriding
+// keyword replaces the target's and a null one removes it
+func mergeSchema(target, override map[string]any) {
+	for keyword, value := range override {
+		if value == nil {
+			delete(target, keyword)
+			continue
+		}
+		targetMap, targetIsMap := target[keyword].(map[string]any)
+		overrideMap, overrideIsMap := value.(map[string]any)
+		if targetIsMap && overrideIsMap {
+			mergeSchema(targetMap, overrideMap)
+		} else {
+			target[keyword] = value
+		}
+	}
+}
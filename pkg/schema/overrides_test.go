@@ -0,0 +1,141 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func TestApplyOverrides(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"image.pullPolicy": {Path: "image.pullPolicy", Type: "string", Default: "IfNotPresent"},
+		"port":             {Path: "port", Type: "string", Constraints: map[string]any{"format": "hostname"}},
+		"hosts[].name":     {Path: "hosts[].name", Type: "string"},
+		"labels.*":         {Path: "labels.*", Type: "string"},
+	}
+	generated := Generate(values).ToMap()
+
+	overrides := map[string]any{
+		"image.pullPolicy": map[string]any{
+			"enum":        []any{"Always", "IfNotPresent", "Never"},
+			"description": "When the kubelet pulls the image",
+		},
+		// Inference typed the port as a string
+		"port":         map[string]any{"type": "integer", "format": nil},
+		"hosts[].name": map[string]any{"minLength": 1},
+		"labels.*":     map[string]any{"maxLength": 63},
+		// Only reached through tpl
+		"extra.config": map[string]any{"type": "string"},
+	}
+	if err := ApplyOverrides(generated, overrides); err != nil {
+		t.Fatalf("ApplyOverrides failed: %v", err)
+	}
+
+	properties := generated["properties"].(map[string]any)
+	pullPolicy := properties["image"].(map[string]any)["properties"].(map[string]any)["pullPolicy"].(map[string]any)
+	want := map[string]any{
+		"type":        "string",
+		"default":     "IfNotPresent",
+		"enum":        []any{"Always", "IfNotPresent", "Never"},
+		"description": "When the kubelet pulls the image",
+	}
+	if !reflect.DeepEqual(pullPolicy, want) {
+		t.Errorf("expected overrides merged onto the generated schema, got %v", pullPolicy)
+	}
+
+	port := properties["port"].(map[string]any)
+	if port["type"] != "integer" {
+		t.Errorf("expected the type replaced, got %v", port["type"])
+	}
+	if _, exists := port["format"]; exists {
+		t.Errorf("expected null to remove the keyword, got %v", port["format"])
+	}
+
+	name := properties["hosts"].(map[string]any)["items"].(map[string]any)["properties"].(map[string]any)["name"].(map[string]any)
+	if name["minLength"] != 1 || name["type"] != "string" {
+		t.Errorf("expected the item property overridden, got %v", name)
+	}
+	label := properties["labels"].(map[string]any)["patternProperties"].(map[string]any)[anyKeyPattern].(map[string]any)
+	if label["maxLength"] != 63 {
+		t.Errorf("expected the map values overridden, got %v", label)
+	}
+
+	extra := properties["extra"].(map[string]any)
+	if extra["type"] != "object" || !reflect.DeepEqual(extra["properties"], map[string]any{"config": map[string]any{"type": "string"}}) {
+		t.Errorf("expected missing properties added, got %v", extra)
+	}
+}
+
+func TestApplyOverridesIdempotent(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"image.tag": {Path: "image.tag", Type: "string"},
+	}
+	overrides := map[string]any{
+		"image": map[string]any{"description": "Container image", "properties": map[string]any{"tag": map[string]any{"pattern": "^v"}}},
+	}
+
+	first := Generate(values).ToMap()
+	second := Generate(values).ToMap()
+	for _, generated := range []map[string]any{first, second} {
+		if err := ApplyOverrides(generated, overrides); err != nil {
+			t.Fatalf("ApplyOverrides failed: %v", err)
+		}
+	}
+	if err := ApplyOverrides(second, overrides); err != nil {
+		t.Fatalf("ApplyOverrides failed: %v", err)
+	}
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("applying overrides again should not change the schema:\n%v\n%v", first, second)
+	}
+}
+
+func TestApplyOverridesErrors(t *testing.T) {
+	for name, overrides := range map[string]map[string]any{
+		"not a schema": {"image": "string"},
+		"root map":     {"*": map[string]any{"type": "string"}},
+		"empty key":    {"image..tag": map[string]any{"type": "string"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			generated := Generate(map[string]*parser.ValuePath{}).ToMap()
+			if err := ApplyOverrides(generated, overrides); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestDeprecated(t *testing.T) {
+	values := map[string]*parser.ValuePath{
+		"imageName": {Path: "imageName", Type: "string", Description: "Image to run", Deprecated: true, Deprecation: "use image.repository"},
+		"legacy":    {Path: "legacy", Type: "boolean", Deprecated: true},
+		"port":      {Path: "port", Type: "integer", Description: "Service port"},
+		"host":      {Path: "host", Type: "string"},
+	}
+	generated := Generate(values).ToMap()
+
+	overrides := map[string]any{
+		"port": map[string]any{"deprecated": "set service.port instead"},
+		"host": map[string]any{"deprecated": true},
+	}
+	// Deprecation notices are added once
+	for i := 0; i < 2; i++ {
+		if err := ApplyOverrides(generated, overrides); err != nil {
+			t.Fatalf("ApplyOverrides failed: %v", err)
+		}
+	}
+
+	properties := generated["properties"].(map[string]any)
+	expected := map[string]string{
+		"imageName": "Image to run Deprecated: use image.repository.",
+		"legacy":    "Deprecated.",
+		"port":      "Service port Deprecated: set service.port instead.",
+		"host":      "Deprecated.",
+	}
+	for key, description := range expected {
+		prop := properties[key].(map[string]any)
+		if prop["deprecated"] != true || prop["description"] != description {
+			t.Errorf("Expected %s deprecated with description %q, got %v", key, description, prop)
+		}
+	}
+}
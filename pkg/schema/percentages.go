@@ -0,0 +1,14 @@
+package schema
+
+// percentPattern matches the percentages Kubernetes accepts in fields taking an integer or a
+// percentage, such as 25%
+const percentPattern = `^[0-9]+%$`
+
+// addIntOrPercent describes a value Kubernetes takes as an integer or a percentage string with an
+// anyOf of both, in place of the single type the templates suggest
+func addIntOrPercent(prop map[string]any) {
+	addScalarAlternatives(prop,
+		map[string]any{"type": "integer", "minimum": 0},
+		map[string]any{"type": "string", "pattern": percentPattern},
+	)
+}
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"fmt"
+
+	"helm-schema/pkg/parser"
+)
+
+// addProvenance records where a value comes from when provenance output is enabled: the template
+// lines referencing it in x-helm-sources and the values it is only rendered when truthy in
+// x-helm-condition
+func addProvenance(prop map[string]any, valuePath *parser.ValuePath, opts Options) {
+	if !opts.Provenance {
+		return
+	}
+	var sources []string
+	seen := make(map[string]bool)
+	for _, site := range valuePath.Sources {
+		source := fmt.Sprintf("%s:%d", site.File, site.Line)
+		if site.File == "" || seen[source] {
+			continue
+		}
+		seen[source] = true
+		sources = append(sources, source)
+	}
+	if len(sources) > 0 {
+		prop["x-helm-sources"] = sources
+	}
+	if len(valuePath.Guards) > 0 {
+		prop["x-helm-condition"] = valuePath.Guards
+	}
+}
+
+// markSubchart tags the properties of a subchart's schema with the subchart in x-helm-subchart,
+// except its global values, which Helm shares with the parent chart and the other subcharts
+func markSubchart(chartSchema map[string]any, subchart string) {
+	forEachSubschema(chartSchema, "", func(child map[string]any, name string, _ func(any)) {
+		if name != globalKey {
+			markProperty(child, subchart)
+		}
+	})
+}
+
+// markProperty tags a property of a subchart's schema and those nested in it
+func markProperty(prop map[string]any, subchart string) {
+	prop["x-helm-subchart"] = subchart
+	forEachSubschema(prop, "", func(child map[string]any, _ string, _ func(any)) {
+		markProperty(child, subchart)
+	})
+}
@@ -0,0 +1,14 @@
+package schema
+
+// quantityPattern matches the resource quantities Kubernetes accepts, such as 500m, 1.5, 256Mi
+// or 1e3: a decimal number followed by a binary or decimal SI suffix, or a decimal exponent
+const quantityPattern = `^([0-9]+(\.[0-9]*)?|\.[0-9]+)([KMGTPE]i|[numkMGTPE]|[eE][+-]?[0-9]+)?$`
+
+// addQuantity describes a value Kubernetes takes as a resource quantity with an anyOf of a number
+// and a quantity string, in place of the single type the templates suggest
+func addQuantity(prop map[string]any) {
+	addScalarAlternatives(prop,
+		map[string]any{"type": "number", "minimum": 0},
+		map[string]any{"type": "string", "pattern": quantityPattern},
+	)
+}
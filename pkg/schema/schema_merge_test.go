@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"reflect"
 	"testing"
 
 	"helm-schema/pkg/parser"
@@ -34,8 +35,8 @@ func TestGenerateChartSchemas(t *testing.T) {
 		t.Errorf("Expected main schema name 'main', got '%s'", mainSchema.Name)
 	}
 
-	mainProps, ok := mainSchema.Schema["properties"].(map[string]interface{})
-	if !ok {
+	mainProps := mainSchema.Schema.Properties
+	if mainProps == nil {
 		t.Fatal("Main schema properties not found")
 	}
 
@@ -62,8 +63,8 @@ func TestGenerateChartSchemas(t *testing.T) {
 	if databaseSchema == nil {
 		t.Error("Database subchart schema not found")
 	} else {
-		dbProps, ok := databaseSchema.Schema["properties"].(map[string]interface{})
-		if !ok {
+		dbProps := databaseSchema.Schema.Properties
+		if dbProps == nil {
 			t.Error("Database schema properties not found")
 		} else if len(dbProps) != 2 {
 			t.Errorf("Expected 2 database properties, got %d", len(dbProps))
@@ -73,8 +74,8 @@ func TestGenerateChartSchemas(t *testing.T) {
 	if cacheSchema == nil {
 		t.Error("Cache subchart schema not found")
 	} else {
-		cacheProps, ok := cacheSchema.Schema["properties"].(map[string]interface{})
-		if !ok {
+		cacheProps := cacheSchema.Schema.Properties
+		if cacheProps == nil {
 			t.Error("Cache schema properties not found")
 		} else if len(cacheProps) != 1 {
 			t.Errorf("Expected 1 cache property, got %d", len(cacheProps))
@@ -86,7 +87,7 @@ func TestMergeSchemas(t *testing.T) {
 	// Create mock schemas
 	mainSchema := ChartSchema{
 		Name: "main",
-		Schema: map[string]interface{}{
+		Schema: FromMap(map[string]interface{}{
 			"$schema": "https://json-schema.org/draft/2020-12/schema",
 			"type":    "object",
 			"properties": map[string]interface{}{
@@ -97,35 +98,35 @@ func TestMergeSchemas(t *testing.T) {
 					},
 				},
 			},
-		},
+		}),
 	}
 
 	subchartSchemas := []ChartSchema{
 		{
 			Name: "database",
-			Schema: map[string]interface{}{
+			Schema: FromMap(map[string]interface{}{
 				"$schema": "https://json-schema.org/draft/2020-12/schema",
 				"type":    "object",
 				"properties": map[string]interface{}{
 					"host": map[string]interface{}{},
 					"port": map[string]interface{}{},
 				},
-			},
+			}),
 		},
 		{
 			Name: "redis",
-			Schema: map[string]interface{}{
+			Schema: FromMap(map[string]interface{}{
 				"$schema": "https://json-schema.org/draft/2020-12/schema",
 				"type":    "object",
 				"properties": map[string]interface{}{
 					"enabled": map[string]interface{}{},
 				},
-			},
+			}),
 		},
 	}
 
 	// Merge schemas
-	merged := MergeSchemas(mainSchema, subchartSchemas)
+	merged := MergeSchemas(mainSchema, subchartSchemas).ToMap()
 
 	// Validate merged schema structure
 	if merged["$schema"] != "https://json-schema.org/draft/2020-12/schema" {
@@ -180,3 +181,152 @@ func TestMergeSchemas(t *testing.T) {
 		t.Error("Database port property not found")
 	}
 }
+
+func TestMergeShippedSchemas(t *testing.T) {
+	mainSchema := ChartSchema{Name: "main", Schema: Generate(map[string]*parser.ValuePath{
+		"replicas": {Path: "replicas", Type: "integer"},
+	})}
+	shipped := ChartSchema{
+		Name:    "redis",
+		Shipped: true,
+		Schema: FromMap(map[string]interface{}{
+			"$schema": SchemaURI(Draft07),
+			"type":    "object",
+			"definitions": map[string]interface{}{
+				"endpoint": map[string]interface{}{"type": "string"},
+			},
+			"properties": map[string]interface{}{
+				"architecture": map[string]interface{}{"enum": []interface{}{"standalone", "replication"}},
+				"primary":      map[string]interface{}{"$ref": "#/definitions/endpoint"},
+			},
+		}),
+	}
+
+	merged := MergeSchemas(mainSchema, []ChartSchema{shipped}).ToMap()
+
+	redis := merged["properties"].(map[string]interface{})["redis"].(map[string]interface{})
+	if _, exists := redis["$schema"]; exists {
+		t.Error("The shipped schema should be embedded without $schema")
+	}
+	if _, exists := redis["additionalProperties"]; exists {
+		t.Error("The shipped schema should be embedded as it is")
+	}
+	properties := redis["properties"].(map[string]interface{})
+	if _, exists := properties["architecture"]; !exists {
+		t.Errorf("Expected the shipped properties, got %v", properties)
+	}
+	// References keep resolving under the subchart key, with anchors of the embedding draft
+	if ref := properties["primary"].(map[string]interface{})["$ref"]; ref != "#redis.definitions.endpoint" {
+		t.Errorf("Expected the reference rewritten to an anchor, got %v", ref)
+	}
+	endpoint := redis["definitions"].(map[string]interface{})["endpoint"].(map[string]interface{})
+	if endpoint["$anchor"] != "redis.definitions.endpoint" {
+		t.Errorf("Expected a 2020-12 anchor on the target, got %v", endpoint)
+	}
+	if ref := shipped.Schema.ToMap()["properties"].(map[string]interface{})["primary"].(map[string]interface{})["$ref"]; ref != "#/definitions/endpoint" {
+		t.Error("The shipped schema should not be modified")
+	}
+}
+
+func TestMergeGlobals(t *testing.T) {
+	mainSchema := ChartSchema{Name: "main", Schema: Generate(map[string]*parser.ValuePath{
+		"global.registry": {Path: "global.registry", Type: "string"},
+		"replicas":        {Path: "replicas", Type: "integer"},
+	})}
+	subchartSchemas := []ChartSchema{
+		{Name: "worker", Schema: Generate(map[string]*parser.ValuePath{
+			"global.registry.url": {Path: "global.registry.url", Type: "string"},
+			"global.pullPolicy":   {Path: "global.pullPolicy", Type: "string", Required: true},
+			"queue":               {Path: "queue", Type: "string"},
+		})},
+		{Name: "web", Schema: Generate(map[string]*parser.ValuePath{
+			"global.pullPolicy": {Path: "global.pullPolicy", Type: "unknown"},
+		})},
+	}
+
+	merged := MergeSchemas(mainSchema, subchartSchemas).ToMap()
+
+	properties := merged["properties"].(map[string]interface{})
+	for _, name := range []string{"worker", "web"} {
+		if _, exists := properties[name].(map[string]interface{})["properties"].(map[string]interface{})["global"]; exists {
+			t.Errorf("Expected the global values of %s at the root", name)
+		}
+	}
+	if _, exists := properties["worker"].(map[string]interface{})["required"]; exists {
+		t.Error("Expected worker to require no values of its own")
+	}
+
+	global := properties["global"].(map[string]interface{})
+	globals := global["properties"].(map[string]interface{})
+	registry := globals["registry"].(map[string]interface{})
+	if !reflect.DeepEqual(registry["type"], []string{"object", "string"}) {
+		t.Errorf("Expected the registry types unioned, got %v", registry["type"])
+	}
+	if _, exists := registry["properties"].(map[string]interface{})["url"]; !exists {
+		t.Errorf("Expected the registry properties of worker, got %v", registry)
+	}
+	if globals["pullPolicy"].(map[string]interface{})["type"] != "string" {
+		t.Errorf("Expected pullPolicy typed by the chart knowing its type, got %v", globals["pullPolicy"])
+	}
+	if !reflect.DeepEqual(global["required"], []string{"pullPolicy"}) {
+		t.Errorf("Expected the required global values kept, got %v", global["required"])
+	}
+	// The main chart schema is left as it is
+	if mainGlobal := mainSchema.Schema.ToMap()["properties"].(map[string]interface{})["global"].(map[string]interface{}); mainGlobal["properties"].(map[string]interface{})["registry"].(map[string]interface{})["type"] != "string" {
+		t.Error("Expected the main chart schema unchanged")
+	}
+
+	expected := []GlobalConflict{{Path: "global.registry", Usages: []GlobalUsage{{Chart: "worker", Type: "object"}, {Chart: "main", Type: "string"}}}}
+	if conflicts := GlobalConflicts(mainSchema, subchartSchemas); !reflect.DeepEqual(conflicts, expected) {
+		t.Errorf("Expected conflicts %+v, got %+v", expected, conflicts)
+	}
+}
+
+func TestProvenance(t *testing.T) {
+	deployment := parser.Site{File: "templates/deployment.yaml", Line: 12, Column: 20}
+	mainParser := parser.New()
+	mainParser.GetValues()["ingress.host"] = &parser.ValuePath{
+		Path:    "ingress.host",
+		Type:    "string",
+		Sources: []parser.Site{deployment, {File: "templates/deployment.yaml", Line: 12, Column: 40}, {File: "templates/ingress.yaml", Line: 3, Column: 9}},
+		Guards:  []string{"ingress.enabled"},
+	}
+	mainParser.GetValues()["hosts[]"] = &parser.ValuePath{Path: "hosts[]", Type: "string", Sources: []parser.Site{deployment}}
+	subchartParser := parser.New()
+	subchartParser.GetValues()["auth.password"] = &parser.ValuePath{Path: "auth.password", Type: "string", Sources: []parser.Site{deployment}}
+	subchartParser.GetValues()["global.region"] = &parser.ValuePath{Path: "global.region", Type: "string"}
+	mainParser.GetSubcharts()["redis"] = subchartParser
+
+	// Left out by default
+	mainSchema, _ := GenerateChartSchemas(mainParser)
+	host := mainSchema.Schema.ToMap()["properties"].(map[string]interface{})["ingress"].(map[string]interface{})["properties"].(map[string]interface{})["host"].(map[string]interface{})
+	if host["x-helm-sources"] != nil || host["x-helm-condition"] != nil {
+		t.Errorf("Expected no provenance by default, got %v", host)
+	}
+
+	mainSchema, subchartSchemas := GenerateChartSchemasWithOptions(mainParser, Options{Provenance: true})
+	properties := mainSchema.Schema.ToMap()["properties"].(map[string]interface{})
+	host = properties["ingress"].(map[string]interface{})["properties"].(map[string]interface{})["host"].(map[string]interface{})
+	if expected := []string{"templates/deployment.yaml:12", "templates/ingress.yaml:3"}; !reflect.DeepEqual(host["x-helm-sources"], expected) {
+		t.Errorf("Expected the lines referencing ingress.host, got %v", host["x-helm-sources"])
+	}
+	if !reflect.DeepEqual(host["x-helm-condition"], []string{"ingress.enabled"}) {
+		t.Errorf("Expected ingress.host conditioned on ingress.enabled, got %v", host["x-helm-condition"])
+	}
+	if host["x-helm-subchart"] != nil {
+		t.Errorf("Expected no subchart for the main chart's values, got %v", host["x-helm-subchart"])
+	}
+	if hosts := properties["hosts"].(map[string]interface{}); !reflect.DeepEqual(hosts["x-helm-sources"], []string{"templates/deployment.yaml:12"}) {
+		t.Errorf("Expected the lines referencing hosts items on hosts, got %v", hosts)
+	}
+
+	subchart := subchartSchemas[0].Schema.ToMap()["properties"].(map[string]interface{})
+	auth := subchart["auth"].(map[string]interface{})
+	password := auth["properties"].(map[string]interface{})["password"].(map[string]interface{})
+	if auth["x-helm-subchart"] != "redis" || password["x-helm-subchart"] != "redis" {
+		t.Errorf("Expected the redis values tagged with their subchart, got %v", auth)
+	}
+	if global := subchart["global"].(map[string]interface{}); global["x-helm-subchart"] != nil {
+		t.Errorf("Expected the shared global values left untagged, got %v", global)
+	}
+}
@@ -0,0 +1,66 @@
+package schema
+
+import "strings"
+
+// Switch is a boolean value enabling or disabling dependencies of a chart, the condition or a tag
+// of a dependency, which Helm reads rather than the templates
+type Switch struct {
+	Path        string // Dot separated keys, such as redis.enabled or tags.cache
+	Description string
+}
+
+// AddSwitches adds the boolean properties switches stand for to a merged schema, creating the
+// objects holding them. Properties the schema has already are typed boolean when they have no
+// type and left as they are otherwise; the objects holding them, such as the schema a subchart
+// ships, keep their additionalProperties.
+func AddSwitches(schema map[string]any, switches []Switch) {
+	for _, sw := range switches {
+		keys := strings.Split(sw.Path, ".")
+		current := schema
+		for _, key := range keys[:len(keys)-1] {
+			current = switchParent(current, key)
+		}
+
+		properties := switchProperties(current)
+		name := keys[len(keys)-1]
+		prop, exists := properties[name].(map[string]any)
+		if !exists {
+			prop = make(map[string]any)
+			properties[name] = prop
+		}
+		if _, typed := prop["type"]; !typed {
+			prop["type"] = "boolean"
+		}
+		if _, described := prop["description"]; !described && sw.Description != "" {
+			prop["description"] = sw.Description
+		}
+	}
+}
+
+// switchParent returns the object schema of key below object, creating it when missing
+func switchParent(object map[string]any, key string) map[string]any {
+	properties := switchProperties(object)
+	if child, ok := properties[key].(map[string]any); ok {
+		if _, typed := child["type"]; !typed {
+			child["type"] = "object"
+		}
+		return child
+	}
+	child := map[string]any{
+		"type":                 "object",
+		"properties":           make(map[string]any),
+		"additionalProperties": false,
+	}
+	properties[key] = child
+	return child
+}
+
+// switchProperties returns the properties of an object schema, adding them when missing
+func switchProperties(object map[string]any) map[string]any {
+	properties, ok := object["properties"].(map[string]any)
+	if !ok {
+		properties = make(map[string]any)
+		object["properties"] = properties
+	}
+	return properties
+}
@@ -0,0 +1,50 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddSwitches(t *testing.T) {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			// Shipped by the subchart, open to values it does not describe
+			"redis": map[string]any{"type": "object", "properties": map[string]any{}},
+			"cache": map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"enabled": map[string]any{"type": "string", "description": "Read by the templates"}},
+				"additionalProperties": false,
+			},
+		},
+		"additionalProperties": false,
+	}
+
+	AddSwitches(schema, []Switch{
+		{Path: "redis.enabled", Description: "Enables the redis dependency"},
+		{Path: "cache.enabled", Description: "Enables the cache dependency"},
+		{Path: "tags.backend", Description: "Enables the dependencies tagged backend"},
+	})
+
+	properties := schema["properties"].(map[string]any)
+	redis := properties["redis"].(map[string]any)
+	if _, exists := redis["additionalProperties"]; exists {
+		t.Errorf("Expected the redis schema to stay open, got %v", redis)
+	}
+	expected := map[string]any{"type": "boolean", "description": "Enables the redis dependency"}
+	if enabled := redis["properties"].(map[string]any)["enabled"]; !reflect.DeepEqual(enabled, expected) {
+		t.Errorf("Expected redis.enabled %v, got %v", expected, enabled)
+	}
+	expected = map[string]any{"type": "string", "description": "Read by the templates"}
+	if enabled := properties["cache"].(map[string]any)["properties"].(map[string]any)["enabled"]; !reflect.DeepEqual(enabled, expected) {
+		t.Errorf("Expected cache.enabled left as it is, got %v", enabled)
+	}
+	expected = map[string]any{
+		"type":                 "object",
+		"properties":           map[string]any{"backend": map[string]any{"type": "boolean", "description": "Enables the dependencies tagged backend"}},
+		"additionalProperties": false,
+	}
+	if tags := properties["tags"]; !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected tags %v, got %v", expected, tags)
+	}
+}
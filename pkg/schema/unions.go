@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"sort"
+)
+
+// numberKeywords are the keywords applying to numbers, integers included
+var numberKeywords = []string{"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf"}
+
+// typeKeywords are the keywords applying to instances of a single type, which the members of a
+// union rendered as anyOf carry
+var typeKeywords = map[string][]string{
+	"object":  {"properties", "patternProperties", "additionalProperties", "required", "propertyNames", "minProperties", "maxProperties"},
+	"array":   {"items", "prefixItems", "minItems", "maxItems", "uniqueItems", "contains"},
+	"string":  {"minLength", "maxLength", "pattern", "format", "contentMediaType", "contentEncoding"},
+	"number":  numberKeywords,
+	"integer": numberKeywords,
+}
+
+// anyOfUnions renders the values of a schema accepting several types as an anyOf of one schema
+// per type, holding the keywords applying to that type, rather than a type list. Keywords
+// applying to any instance, such as description or default, stay on the value.
+func anyOfUnions(schema map[string]any) {
+	forEachSubschema(schema, "", func(child map[string]any, _ string, _ func(any)) {
+		anyOfUnions(child)
+	})
+
+	types, ok := schema["type"].([]string)
+	if !ok || len(types) < 2 {
+		return
+	}
+	sort.Strings(types)
+	members := make([]any, 0, len(types))
+	for _, memberType := range types {
+		member := map[string]any{"type": memberType}
+		for _, keyword := range typeKeywords[memberType] {
+			if value, exists := schema[keyword]; exists {
+				member[keyword] = value
+			}
+		}
+		members = append(members, member)
+	}
+	for _, memberType := range types {
+		for _, keyword := range typeKeywords[memberType] {
+			delete(schema, keyword)
+		}
+	}
+	delete(schema, "type")
+	schema["anyOf"] = members
+}
+
+// scalarTypes are the types a value Kubernetes takes in several forms, such as an integer or a
+// percentage, may have been inferred as from how the templates render it
+var scalarTypes = map[string]bool{
+	"integer": true,
+	"number":  true,
+	"string":  true,
+	"null":    true,
+}
+
+// addScalarAlternatives replaces the type of a value with an anyOf of the forms Kubernetes takes
+// it in, keeping null when the value accepts it. Values inferred as anything else, such as
+// objects, only share the name of such fields and are left as they are.
+func addScalarAlternatives(prop map[string]any, alternatives ...map[string]any) {
+	var types []string
+	switch valueType := prop["type"].(type) {
+	case string:
+		types = []string{valueType}
+	case []string:
+		types = valueType
+	}
+	nullable := false
+	for _, valueType := range types {
+		if !scalarTypes[valueType] {
+			return
+		}
+		nullable = nullable || valueType == "null"
+	}
+
+	delete(prop, "type")
+	members := make([]any, 0, len(alternatives)+1)
+	for _, alternative := range alternatives {
+		members = append(members, alternative)
+	}
+	if nullable {
+		members = append(members, map[string]any{"type": "null"})
+	}
+	prop["anyOf"] = members
+}
@@ -0,0 +1,97 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarshalYAML encodes a schema as YAML, for consumers such as CRD manifests or documentation
+// that embed schemas as YAML. Keywords are written in the order Marshal writes them in.
+func MarshalYAML(schema map[string]any) ([]byte, error) {
+	node, err := yamlSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(node); err != nil {
+		return nil, fmt.Errorf("encoding YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("encoding YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// yamlSchema converts a schema, or any other value found where a schema is expected, into a
+// YAML node
+func yamlSchema(node any) (*yaml.Node, error) {
+	object, ok := node.(map[string]any)
+	if !ok {
+		return yamlValue(node)
+	}
+	return yamlObject(object, orderedKeywords(object), func(key string, value any) (*yaml.Node, error) {
+		switch {
+		case schemaMapKeywords[key]:
+			return yamlSchemaMap(value)
+		case schemaListKeywords[key]:
+			return yamlSchemaList(value)
+		case subschemaKeywords[key]:
+			return yamlSchema(value)
+		}
+		return yamlValue(value)
+	})
+}
+
+// yamlSchemaMap converts a map from names to schemas, in name order
+func yamlSchemaMap(node any) (*yaml.Node, error) {
+	object, ok := node.(map[string]any)
+	if !ok {
+		return yamlValue(node)
+	}
+	return yamlObject(object, sortedKeys(object), func(_ string, value any) (*yaml.Node, error) {
+		return yamlSchema(value)
+	})
+}
+
+// yamlSchemaList converts a list of schemas
+func yamlSchemaList(node any) (*yaml.Node, error) {
+	list, ok := node.([]any)
+	if !ok {
+		return yamlValue(node)
+	}
+	sequence := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+	for _, value := range list {
+		item, err := yamlSchema(value)
+		if err != nil {
+			return nil, err
+		}
+		sequence.Content = append(sequence.Content, item)
+	}
+	return sequence, nil
+}
+
+// yamlObject converts the members of an object in the order of keys
+func yamlObject(object map[string]any, keys []string, convert func(key string, value any) (*yaml.Node, error)) (*yaml.Node, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, key := range keys {
+		value, err := convert(key, object[key])
+		if err != nil {
+			return nil, err
+		}
+		mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+	}
+	return mapping, nil
+}
+
+// yamlValue converts a value that is not a schema as yaml.Marshal does
+func yamlValue(value any) (*yaml.Node, error) {
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return nil, fmt.Errorf("encoding YAML: %w", err)
+	}
+	return &node, nil
+}
@@ -0,0 +1,61 @@
+package schema
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMarshalYAML(t *testing.T) {
+	schema := map[string]any{
+		"additionalProperties": false,
+		"type":                 "object",
+		"properties": map[string]any{
+			"replicas": map[string]any{"default": 1, "type": []any{"integer", "null"}},
+			"labels": map[string]any{
+				"type":              "object",
+				"patternProperties": map[string]any{"^.*$": map[string]any{"type": "string"}},
+			},
+			"mode": map[string]any{"enum": []any{"true", "no"}, "type": "string"},
+		},
+		"$schema": SchemaURI(Draft202012),
+	}
+
+	output, err := MarshalYAML(schema)
+	if err != nil {
+		t.Fatalf("Failed to encode schema: %v", err)
+	}
+	expected := `$schema: https://json-schema.org/draft/2020-12/schema
+type: object
+properties:
+  labels:
+    type: object
+    patternProperties:
+      ^.*$:
+        type: string
+  mode:
+    type: string
+    enum:
+      - "true"
+      - "no"
+  replicas:
+    type:
+      - integer
+      - "null"
+    default: 1
+additionalProperties: false
+`
+	if string(output) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, output)
+	}
+
+	// Strings that would read as other types stay strings
+	var decoded map[string]any
+	if err := yaml.Unmarshal(output, &decoded); err != nil {
+		t.Fatalf("Failed to decode schema: %v", err)
+	}
+	mode := decoded["properties"].(map[string]any)["mode"].(map[string]any)
+	if enum := mode["enum"].([]any); enum[0] != "true" || enum[1] != "no" {
+		t.Errorf("Expected string enum values, got %v", enum)
+	}
+}
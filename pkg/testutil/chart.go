@@ -0,0 +1,93 @@
+package testutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Chart builds a chart on disk for a single test. Files are written as they are added, so the
+// chart can be parsed at any point.
+type Chart struct {
+	t            testing.TB
+	dir          string
+	name         string
+	dependencies []string
+}
+
+// NewChart creates an empty chart named name in a temporary directory removed after the test
+func NewChart(t testing.TB, name string) *Chart {
+	t.Helper()
+	return newChart(t, filepath.Join(t.TempDir(), name), name)
+}
+
+// newChart creates an empty chart in dir
+func newChart(t testing.TB, dir, name string) *Chart {
+	t.Helper()
+	c := &Chart{t: t, dir: dir, name: name}
+	if err := os.MkdirAll(filepath.Join(dir, "templates"), 0755); err != nil {
+		t.Fatalf("Failed to create chart: %v", err)
+	}
+	c.writeMetadata()
+	return c
+}
+
+// Dir returns the chart directory
+func (c *Chart) Dir() string {
+	return c.dir
+}
+
+// Template adds a template file under templates/
+func (c *Chart) Template(name, content string) *Chart {
+	c.t.Helper()
+	return c.File(filepath.Join("templates", name), content)
+}
+
+// Helper adds a helper file defining named templates under templates/, e.g. _helpers.tpl
+func (c *Chart) Helper(name, content string) *Chart {
+	c.t.Helper()
+	return c.Template(name, content)
+}
+
+// Values writes the chart's values.yaml
+func (c *Chart) Values(content string) *Chart {
+	c.t.Helper()
+	return c.File("values.yaml", content)
+}
+
+// File writes an arbitrary file relative to the chart directory
+func (c *Chart) File(name, content string) *Chart {
+	c.t.Helper()
+	path := filepath.Join(c.dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		c.t.Fatalf("Failed to create %s: %v", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		c.t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return c
+}
+
+// Subchart adds a local dependency under charts/ and returns it for further building
+func (c *Chart) Subchart(name string) *Chart {
+	c.t.Helper()
+	c.dependencies = append(c.dependencies, name)
+	c.writeMetadata()
+	return newChart(c.t, filepath.Join(c.dir, "charts", name), name)
+}
+
+// writeMetadata writes Chart.yaml with the dependencies added so far
+func (c *Chart) writeMetadata() {
+	c.t.Helper()
+	var metadata strings.Builder
+	fmt.Fprintf(&metadata, "apiVersion: v2\nname: %s\nversion: 0.1.0\n", c.name)
+	if len(c.dependencies) > 0 {
+		metadata.WriteString("dependencies:\n")
+		for _, dependency := range c.dependencies {
+			fmt.Fprintf(&metadata, "- name: %s\n  version: 0.1.0\n", dependency)
+		}
+	}
+	c.File("Chart.yaml", metadata.String())
+}
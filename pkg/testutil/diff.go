@@ -0,0 +1,111 @@
+// Package testutil helps writing concise tests against parser output: diffing value path models,
+// comparing output with golden files and building throwaway charts.
+package testutil
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+// Diff compares value path models and describes every difference, one line per path and field.
+// Only the fields set in want are compared, so expectations can be written as sparsely as
+// {Type: "string"}; Path is never compared since it repeats the map key.
+func Diff(got, want map[string]*parser.ValuePath) []string {
+	var diffs []string
+	for _, path := range sortedKeys(want) {
+		actual, exists := got[path]
+		if !exists {
+			diffs = append(diffs, fmt.Sprintf("%s: missing", path))
+			continue
+		}
+		diffs = append(diffs, diffValuePath(path, actual, want[path])...)
+	}
+
+	for _, path := range sortedKeys(got) {
+		if _, expected := want[path]; !expected {
+			diffs = append(diffs, fmt.Sprintf("%s: unexpected (%s)", path, got[path].Type))
+		}
+	}
+
+	return diffs
+}
+
+// DiffTypes compares the paths and types of a model with a path to type map
+func DiffTypes(got map[string]*parser.ValuePath, want map[string]string) []string {
+	expected := make(map[string]*parser.ValuePath, len(want))
+	for path, valueType := range want {
+		expected[path] = &parser.ValuePath{Type: valueType}
+	}
+	return Diff(got, expected)
+}
+
+// AssertValues fails the test with every difference between the models
+func AssertValues(t testing.TB, got, want map[string]*parser.ValuePath) {
+	t.Helper()
+	for _, diff := range Diff(got, want) {
+		t.Error(diff)
+	}
+}
+
+// AssertTypes fails the test with every difference between the model and the expected types
+func AssertTypes(t testing.TB, got map[string]*parser.ValuePath, want map[string]string) {
+	t.Helper()
+	for _, diff := range DiffTypes(got, want) {
+		t.Error(diff)
+	}
+}
+
+// diffValuePath compares the fields set in want with got
+func diffValuePath(path string, got, want *parser.ValuePath) []string {
+	var diffs []string
+	field := func(name string, got, want any) {
+		diffs = append(diffs, fmt.Sprintf("%s: %s is %v, expected %v", path, name, got, want))
+	}
+
+	if want.Type != "" && got.Type != want.Type {
+		field("type", got.Type, want.Type)
+	}
+	if want.Types != nil && !reflect.DeepEqual(got.Types, want.Types) {
+		field("types", got.Types, want.Types)
+	}
+	if want.Required && !got.Required {
+		field("required", got.Required, want.Required)
+	}
+	if want.Default != nil && !reflect.DeepEqual(got.Default, want.Default) {
+		field("default", got.Default, want.Default)
+	}
+	if want.Sensitive && !got.Sensitive {
+		field("sensitive", got.Sensitive, want.Sensitive)
+	}
+	if want.Functions != nil && !reflect.DeepEqual(got.Functions, want.Functions) {
+		field("functions", got.Functions, want.Functions)
+	}
+	if want.Confidence != 0 && got.Confidence != want.Confidence {
+		field("confidence", got.Confidence, want.Confidence)
+	}
+	if want.Encoding != "" && got.Encoding != want.Encoding {
+		field("encoding", got.Encoding, want.Encoding)
+	}
+	if want.Decoded != nil && !reflect.DeepEqual(got.Decoded, want.Decoded) {
+		field("decoded", got.Decoded, want.Decoded)
+	}
+	if want.Sources != nil && !reflect.DeepEqual(got.Sources, want.Sources) {
+		field("sources", got.Sources, want.Sources)
+	}
+
+	return diffs
+}
+
+// sortedKeys returns the paths of a model in order
+func sortedKeys(values map[string]*parser.ValuePath) []string {
+	var paths []string
+	for path := range values {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
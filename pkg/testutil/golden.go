@@ -0,0 +1,49 @@
+package testutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// update rewrites golden files with the current output instead of comparing against them,
+// as in go test ./... -update
+var update = flag.Bool("update", false, "Rewrite golden files with the current output")
+
+// Golden compares output with the golden file at path, conventionally under testdata/.
+// Running the tests with -update writes the output to the file instead.
+func Golden(t testing.TB, path string, got []byte) {
+	t.Helper()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output differs from %s (run with -update to accept it)\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
+
+// GoldenJSON compares the indented JSON encoding of value with the golden file at path
+func GoldenJSON(t testing.TB, path string, value any) {
+	t.Helper()
+
+	got, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to encode JSON: %v", err)
+	}
+	Golden(t, path, append(got, '\n'))
+}
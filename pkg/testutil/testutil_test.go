@@ -0,0 +1,71 @@
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"helm-schema/pkg/parser"
+)
+
+func TestDiff(t *testing.T) {
+	got := map[string]*parser.ValuePath{
+		"image":     {Path: "image", Type: "object"},
+		"image.tag": {Path: "image.tag", Type: "string", Functions: []string{"quote"}},
+		"replicas":  {Path: "replicas", Type: "unknown"},
+	}
+	want := map[string]*parser.ValuePath{
+		"image":     {Type: "object"},
+		"image.tag": {Type: "integer", Functions: []string{"quote"}},
+		"debug":     {Type: "boolean"},
+	}
+
+	expected := []string{
+		"debug: missing",
+		"image.tag: type is string, expected integer",
+		"replicas: unexpected (unknown)",
+	}
+	if diffs := Diff(got, want); !reflect.DeepEqual(diffs, expected) {
+		t.Errorf("Unexpected diff:\n%v\nexpected:\n%v", diffs, expected)
+	}
+
+	if diffs := DiffTypes(got, map[string]string{"image": "object", "image.tag": "string", "replicas": "unknown"}); len(diffs) != 0 {
+		t.Errorf("Expected no differences, got %v", diffs)
+	}
+}
+
+func TestChartBuilder(t *testing.T) {
+	chart := NewChart(t, "parent").
+		Template("deployment.yaml", "image: {{ .Values.image.tag | quote }}\n").
+		Values("image:\n  tag: latest\n")
+	chart.Subchart("cache").
+		Template("configmap.yaml", "port: {{ .Values.port }}\n")
+
+	if _, err := os.Stat(filepath.Join(chart.Dir(), "values.yaml")); err != nil {
+		t.Errorf("values.yaml should be written: %v", err)
+	}
+
+	p := parser.New()
+	if err := p.ParseChartWithOptions(chart.Dir(), true); err != nil {
+		t.Fatalf("Failed to parse built chart: %v", err)
+	}
+
+	AssertTypes(t, p.GetAllValues(), map[string]string{
+		"image":      "object",
+		"image.tag":  "string",
+		"cache.port": "unknown",
+	})
+}
+
+func TestGolden(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "testdata", "schema.golden")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create testdata: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("{\n  \"type\": \"object\"\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write golden file: %v", err)
+	}
+
+	GoldenJSON(t, path, map[string]any{"type": "object"})
+}
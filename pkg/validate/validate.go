@@ -0,0 +1,196 @@
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+
+	"helm-schema/pkg/schema"
+)
+
+// schemaURL names the in-memory schema resource
+const schemaURL = "values.schema.json"
+
+// printer formats the messages of meta-schema violations
+var printer = message.NewPrinter(language.English)
+
+// Violation describes a single value failing the schema
+type Violation struct {
+	Path    string `json:"path"`    // Dotted location of the offending value, empty for the root
+	Pointer string `json:"pointer"` // JSON pointer to the offending value, such as /image/tag
+	Message string `json:"message"`
+}
+
+// String formats the violation as path: message
+func (v Violation) String() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Validator checks values against a compiled schema
+type Validator struct {
+	schema *jsonschema.Schema
+}
+
+// New compiles a generated schema for validation
+func New(schema map[string]any) (*Validator, error) {
+	doc, err := normalize(schema)
+	if err != nil {
+		return nil, fmt.Errorf("encoding schema: %w", err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(schemaURL, doc); err != nil {
+		return nil, fmt.Errorf("loading schema: %w", err)
+	}
+
+	compiled, err := compiler.Compile(schemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("compiling schema: %w", err)
+	}
+
+	return &Validator{schema: compiled}, nil
+}
+
+// CheckSchema returns where a generated schema breaks the meta-schema of the draft it declares,
+// such as an unknown type name or a keyword holding the wrong kind of value, ordered by path.
+// References are not followed, so schemas referencing remote documents are checked offline.
+func CheckSchema(generated map[string]any) ([]Violation, error) {
+	compiler := jsonschema.NewCompiler()
+	metaSchema, err := compiler.Compile(schema.SchemaURI(schema.DraftOf(generated)))
+	if err != nil {
+		return nil, fmt.Errorf("compiling meta-schema: %w", err)
+	}
+
+	doc, err := normalize(generated)
+	if err != nil {
+		return nil, fmt.Errorf("encoding schema: %w", err)
+	}
+	err = metaSchema.Validate(doc)
+	if err == nil {
+		return nil, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	// Meta-schemas nest their keywords deeply, the innermost failures tell what is wrong
+	var violations []Violation
+	seen := make(map[string]bool)
+	var collect func(*jsonschema.ValidationError)
+	collect = func(cause *jsonschema.ValidationError) {
+		if len(cause.Causes) > 0 {
+			for _, nested := range cause.Causes {
+				collect(nested)
+			}
+			return
+		}
+		path := strings.Join(cause.InstanceLocation, ".")
+		// Alternatives the location matches none of each fail, the first one is enough
+		if seen[path] {
+			return
+		}
+		seen[path] = true
+		violations = append(violations, Violation{
+			Path:    path,
+			Pointer: pointer(cause.InstanceLocation),
+			Message: cause.ErrorKind.LocalizedString(printer),
+		})
+	}
+	collect(validationErr)
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+	return violations, nil
+}
+
+// Validate returns the violations of values, ordered by path
+func (v *Validator) Validate(values map[string]any) ([]Violation, error) {
+	if values == nil {
+		values = map[string]any{}
+	}
+
+	instance, err := normalize(values)
+	if err != nil {
+		return nil, fmt.Errorf("encoding values: %w", err)
+	}
+
+	err = v.schema.Validate(instance)
+	if err == nil {
+		return nil, nil
+	}
+
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return nil, err
+	}
+
+	var violations []Violation
+	seen := make(map[Violation]bool)
+	for _, unit := range validationErr.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		violation := Violation{
+			Path:    instancePath(unit.InstanceLocation),
+			Pointer: unit.InstanceLocation,
+			Message: unit.Error.String(),
+		}
+		// Summary units ("validation failed") repeat their children
+		if strings.HasPrefix(violation.Message, "validation failed") || seen[violation] {
+			continue
+		}
+		seen[violation] = true
+		violations = append(violations, violation)
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		return violations[i].Path < violations[j].Path
+	})
+
+	return violations, nil
+}
+
+// normalize converts a Go value into the JSON representation the validator expects
+func normalize(value any) (any, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return jsonschema.UnmarshalJSON(bytes.NewReader(data))
+}
+
+// instancePath converts a JSON pointer such as /image/tag into image.tag
+func instancePath(pointer string) string {
+	pointer = strings.TrimPrefix(pointer, "/")
+	if pointer == "" {
+		return ""
+	}
+
+	parts := strings.Split(pointer, "/")
+	for i, part := range parts {
+		parts[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// pointer converts the tokens of an instance location into a JSON pointer
+func pointer(tokens []string) string {
+	var builder strings.Builder
+	for _, token := range tokens {
+		builder.WriteString("/")
+		builder.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(token))
+	}
+	return builder.String()
+}
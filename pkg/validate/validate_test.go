@@ -0,0 +1,116 @@
+package validate
+
+import (
+	"testing"
+
+	"helm-schema/pkg/parser"
+	"helm-schema/pkg/schema"
+)
+
+func TestValidate(t *testing.T) {
+	generated := schema.Generate(map[string]*parser.ValuePath{
+		"image":            {Path: "image", Type: "object"},
+		"image.repository": {Path: "image.repository", Type: "string"},
+		"image.tag":        {Path: "image.tag", Type: "unknown"},
+		"replicas":         {Path: "replicas", Type: "integer"},
+	}).ToMap()
+
+	validator, err := New(generated)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+
+	violations, err := validator.Validate(map[string]any{
+		"image":    map[string]any{"repository": "nginx", "tag": 1.25},
+		"replicas": 3,
+	})
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+	if len(violations) != 0 {
+		t.Errorf("Expected valid values, got %v", violations)
+	}
+
+	violations, err = validator.Validate(map[string]any{
+		"image":    map[string]any{"repository": 5},
+		"replicas": "three",
+		"removed":  true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+
+	paths := make(map[string]bool)
+	for _, violation := range violations {
+		paths[violation.Path] = true
+	}
+	for _, path := range []string{"", "image.repository", "replicas"} {
+		if !paths[path] {
+			t.Errorf("Expected a violation at %q, got %v", path, violations)
+		}
+	}
+}
+
+func TestViolationPointers(t *testing.T) {
+	generated := schema.Generate(map[string]*parser.ValuePath{
+		"annotations":                   {Path: "annotations", Type: "object"},
+		"annotations.example.com/owner": {Path: "annotations.example.com/owner", Type: "string"},
+		"replicas":                      {Path: "replicas", Type: "integer"},
+	}).ToMap()
+
+	validator, err := New(generated)
+	if err != nil {
+		t.Fatalf("Failed to compile schema: %v", err)
+	}
+
+	violations, err := validator.Validate(map[string]any{
+		"annotations": map[string]any{"example": map[string]any{"com/owner": 1}},
+		"replicas":    "three",
+	})
+	if err != nil {
+		t.Fatalf("Failed to validate: %v", err)
+	}
+
+	pointers := make(map[string]bool)
+	for _, violation := range violations {
+		pointers[violation.Pointer] = true
+	}
+	for _, pointer := range []string{"/annotations/example/com~1owner", "/replicas"} {
+		if !pointers[pointer] {
+			t.Errorf("Expected a violation at %q, got %v", pointer, violations)
+		}
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	for _, draft := range []string{schema.Draft202012, schema.Draft07} {
+		generated := schema.GenerateWithOptions(map[string]*parser.ValuePath{
+			"image.repository": {Path: "image.repository", Type: "string", Required: true},
+			"replicas":         {Path: "replicas", Type: "integer", Default: 1},
+			"maxSurge":         {Path: "maxSurge", Type: "unknown"},
+		}, schema.Options{Draft: draft}).ToMap()
+		violations, err := CheckSchema(generated)
+		if err != nil {
+			t.Fatalf("%s: failed to check schema: %v", draft, err)
+		}
+		if len(violations) != 0 {
+			t.Errorf("%s: expected a valid schema, got %v", draft, violations)
+		}
+	}
+
+	broken := schema.Generate(map[string]*parser.ValuePath{
+		"app.name": {Path: "app.name", Type: "primitive"},
+		"replicas": {Path: "replicas", Type: "integer", Constraints: map[string]any{"minimum": "one"}},
+	}).ToMap()
+	violations, err := CheckSchema(broken)
+	if err != nil {
+		t.Fatalf("Failed to check schema: %v", err)
+	}
+	paths := make(map[string]bool)
+	for _, violation := range violations {
+		paths[violation.Path] = true
+	}
+	if !paths["properties.app.properties.name.type"] || !paths["properties.replicas.minimum"] {
+		t.Errorf("Expected the unknown type and the string minimum reported, got %v", violations)
+	}
+}
@@ -0,0 +1,24 @@
+{{/* Renders a container from a component config passed as .cfg */}}
+{{- define "app.container" -}}
+image: {{ .cfg.image.repository }}:{{ .cfg.image.tag | default .root.Chart.AppVersion }}
+{{- if .cfg.resources }}
+resources:
+  {{- toYaml .cfg.resources | nindent 2 }}
+{{- end }}
+{{- include "app.env" (dict "env" .cfg.env "root" .root) }}
+{{- end }}
+
+{{/* Renders environment variables, reading shared settings through the root context */}}
+{{- define "app.env" -}}
+env:
+- name: LOG_LEVEL
+  value: {{ .root.Values.global.logLevel | quote }}
+{{- range .env }}
+- name: {{ .name }}
+{{- end }}
+{{- end }}
+
+{{/* Renders an image reference from an image config passed as the context */}}
+{{- define "app.image" -}}
+{{ .registry }}/{{ .repository }}
+{{- end }}